@@ -0,0 +1,222 @@
+package godfish
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+// BaselineWith records available forward migrations as applied, without
+// running them. It's meant for adopting this library on a database whose
+// schema already exists. The schema migrations table is created, and then
+// each forward migration up to and including the target version is recorded.
+// Nothing is recorded if any of those versions was already applied.
+//
+// # Relevant opts
+//
+//   - [WithTargetVersion]. This is required. The function will record
+//     migrations up to and including the target version.
+//     When passed in with a zero value, or omitted, then an error is returned.
+//   - See also [WithMigrationsTable], [WithGoMigrations] and [WithLockTimeout].
+func BaselineWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts ...Opter) error {
+	o, err := setOptions(opts...)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", msgPrefix, "BaselineWith", err)
+	}
+	if o.targetVersion == "" {
+		return fmt.Errorf("%s.%s: %w; a target version is required", msgPrefix, "BaselineWith", internal.ErrDataInvalid)
+	}
+
+	return baseline(ctx, driver, dirFS, o)
+}
+
+func baseline(ctx context.Context, d driver.Driver, dirFS fs.FS, o *options) (err error) {
+	ctx = o.logContext(ctx)
+	migrationsTable := cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName)
+	finish, err := o.scheme().ParseVersion(o.targetVersion)
+	if err != nil {
+		return fmt.Errorf("%w; parsing target version %q: %w", internal.ErrDataInvalid, o.targetVersion, err)
+	}
+
+	unlock, err := lockSchemaMigrations(ctx, d, migrationsTable, o.lockTimeout)
+	if err != nil {
+		return
+	}
+	defer func() { err = errors.Join(err, unlock()) }()
+
+	finder := migrationFinder{direction: internal.DirForward, dirFS: dirFS, goMigrations: o.goMigrations, scheme: o.scheme(), convention: o.convention(), recursive: o.recursive, files: o.parsedFiles, logger: o.log()}
+	availableByVersion, orderedVersions, err := finder.available()
+	if err != nil {
+		return fmt.Errorf("getting available migrations: %w", err)
+	}
+
+	applied, err := scanAppliedVersions(ctx, d, migrationsTable, o.scheme(), availableByVersion)
+	if errors.Is(err, driver.ErrSchemaMigrationsDoesNotExist) {
+		err = nil // The table is created before recording migrations.
+	} else if err != nil {
+		return
+	}
+	appliedVersions := make(map[int64]bool, len(applied))
+	for _, mig := range applied {
+		appliedVersions[mig.Version.Value()] = true
+	}
+
+	var migrations []*internal.Migration
+	var alreadyApplied []string
+	for _, version := range orderedVersions {
+		mig := availableByVersion[version]
+		if finish.Before(mig.Version) {
+			break
+		}
+		if appliedVersions[version] {
+			alreadyApplied = append(alreadyApplied, mig.Version.String())
+		}
+		migrations = append(migrations, mig)
+	}
+	if len(alreadyApplied) > 0 {
+		return fmt.Errorf(
+			"%w; cannot baseline, these versions are already recorded in %q: %s",
+			internal.ErrAlreadyApplied, migrationsTable, strings.Join(alreadyApplied, ", "),
+		)
+	}
+	if len(migrations) < 1 {
+		return fmt.Errorf("%w; no forward migrations at or before version %q", internal.ErrNotFound, finish.String())
+	}
+
+	return recordMigrations(ctx, d, dirFS, migrations, migrationsTable, true, latestBatch(applied)+1)
+}
+
+// MarkAppliedWith records one forward migration as applied, without running
+// it. It's a repair tool for the schema migrations table, such as after a
+// migration only partially succeeded and was then finished by hand. It's an
+// error if the migration is already recorded as applied.
+//
+// # Relevant opts
+//
+//   - [WithTargetVersion]. This is required. It's the version of the forward
+//     migration to record.
+//     When passed in with a zero value, or omitted, then an error is returned.
+//   - See also [WithMigrationsTable], [WithGoMigrations] and [WithLockTimeout].
+func MarkAppliedWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts ...Opter) error {
+	o, err := setOptions(opts...)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", msgPrefix, "MarkAppliedWith", err)
+	}
+	if o.targetVersion == "" {
+		return fmt.Errorf("%s.%s: %w; a target version is required", msgPrefix, "MarkAppliedWith", internal.ErrDataInvalid)
+	}
+
+	return mark(ctx, driver, dirFS, true, o)
+}
+
+// MarkUnappliedWith removes the record of one applied migration, without
+// running its reverse migration. It's a repair tool for the schema migrations
+// table, such as after a migration was undone by hand. It's an error if the
+// migration is not recorded as applied.
+//
+// # Relevant opts
+//
+//   - [WithTargetVersion]. This is required. It's the version of the forward
+//     migration whose record is removed.
+//     When passed in with a zero value, or omitted, then an error is returned.
+//   - See also [WithMigrationsTable], [WithGoMigrations] and [WithLockTimeout].
+func MarkUnappliedWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts ...Opter) error {
+	o, err := setOptions(opts...)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", msgPrefix, "MarkUnappliedWith", err)
+	}
+	if o.targetVersion == "" {
+		return fmt.Errorf("%s.%s: %w; a target version is required", msgPrefix, "MarkUnappliedWith", internal.ErrDataInvalid)
+	}
+
+	return mark(ctx, driver, dirFS, false, o)
+}
+
+// mark records the forward migration at the target version as applied, or
+// removes its record when applied is false.
+func mark(ctx context.Context, d driver.Driver, dirFS fs.FS, applied bool, o *options) (err error) {
+	ctx = o.logContext(ctx)
+	migrationsTable := cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName)
+
+	unlock, err := lockSchemaMigrations(ctx, d, migrationsTable, o.lockTimeout)
+	if err != nil {
+		return
+	}
+	defer func() { err = errors.Join(err, unlock()) }()
+
+	mig := findGoMigration(o.goMigrations, internal.DirForward, o.targetVersion)
+	if mig == nil {
+		if mig, err = findParseMigration(dirFS, internal.DirForward, o.targetVersion, o); err != nil {
+			return fmt.Errorf("trying to find, parse migration to mark: %w", err)
+		}
+	}
+
+	appliedMigrations, err := scanAppliedVersions(ctx, d, migrationsTable, o.scheme(), nil)
+	if errors.Is(err, driver.ErrSchemaMigrationsDoesNotExist) {
+		err = nil // Same as no applied migrations.
+	} else if err != nil {
+		return
+	}
+	isApplied := slices.ContainsFunc(appliedMigrations, func(m *internal.Migration) bool {
+		return m.Version.Value() == mig.Version.Value()
+	})
+	if applied && isApplied {
+		return fmt.Errorf("%w; version %q is already recorded in %q", internal.ErrAlreadyApplied, mig.Version.String(), migrationsTable)
+	} else if !applied && !isApplied {
+		return fmt.Errorf("%w; version %q is not recorded in %q", internal.ErrNotFound, mig.Version.String(), migrationsTable)
+	}
+
+	return recordMigrations(ctx, d, dirFS, []*internal.Migration{mig}, migrationsTable, applied, latestBatch(appliedMigrations)+1)
+}
+
+// recordMigrations updates the schema migrations table for each migration,
+// without running them. When forward is true, each one is recorded as applied.
+// Otherwise, the record of each one is removed. The batch is recorded along
+// with each applied migration. When d is a [driver.Transactor], all of the
+// updates are made within one transaction.
+func recordMigrations(ctx context.Context, d driver.Driver, dirFS fs.FS, migrations []*internal.Migration, migrationsTable string, forward bool, batch int64) (err error) {
+	msg := "recorded as applied, without running"
+	if !forward {
+		msg = "removed record of applied migration, without running"
+	}
+
+	record := func(ctx context.Context, d driver.Driver) error {
+		if err := d.CreateSchemaMigrationsTable(ctx, migrationsTable); err != nil {
+			return fmt.Errorf("creating schema migrations table: %w", err)
+		}
+		for _, mig := range migrations {
+			var checksum string
+			if forward && mig.Func == nil {
+				data, err := fs.ReadFile(dirFS, filepath.Clean(mig.Filename))
+				if err != nil {
+					return fmt.Errorf("%s: reading file to record checksum: %w", msgPrefix, err)
+				}
+				checksum = internal.Checksum(data)
+			}
+			meta := driver.Metadata{Checksum: checksum, Batch: batch}
+			err := updateSchemaMigrations(ctx, d, migrationsTable, forward, mig.Version.String(), mig.Label, meta)
+			if err != nil {
+				return fmt.Errorf("updating schema migrations table, version %q: %w", mig.Version.String(), err)
+			}
+			driver.Logger(ctx).Info(msg,
+				slog.String("version", mig.Version.String()),
+				slog.String("path_to_file", mig.DisplayName()),
+			)
+		}
+		return nil
+	}
+
+	if transactor, ok := d.(driver.Transactor); ok {
+		return transactor.WithinTransaction(ctx, record)
+	}
+	return record(ctx, d)
+}
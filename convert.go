@@ -0,0 +1,87 @@
+package godfish
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+// ConvertWith rewrites the migration files in srcFS, which are laid out for
+// one migration tool, into the layout of another tool in the directory at
+// dirpath. The formats are named by from and to, each one of "godfish",
+// "goose", "golang-migrate". The "godfish" format reads both forward and
+// reverse files, and single-file migrations, and writes forward and reverse
+// files. A goose file has both directions, separated by annotations. A
+// golang-migrate migration has an up file and a down file.
+//
+// The version and label of each migration are kept as is. It's an error if a
+// filename of the target format would not keep them, such as a version other
+// than an integer for goose or golang-migrate. Files already in dirpath are
+// not overwritten, a file with the same name is an error. Only the SQL is
+// converted, other directives of this library are left in the statements.
+// It writes a report that maps the files read to the files written.
+//
+// # Relevant opts
+//
+//   - [WithNamingConvention]. If passed in with a valid name, then the files
+//     of the "godfish" format are named by that convention.
+//     When passed in with any other value, then an error is returned.
+//   - [WithFormat]. Use "json" or "tsv" for the report. The default is "tsv".
+//   - [WithWriter]. If passed in with a non-zero value, then it will set the
+//     output writer for the report.
+//     When passed in with a zero value, then an error is returned.
+//     When this option is omitted, then it will write to standard output.
+//   - See also [WithVersionScheme].
+func ConvertWith(srcFS fs.FS, dirpath, from, to string, opts ...Opter) error {
+	o, err := setOptions(opts...)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", msgPrefix, "ConvertWith", err)
+	}
+	if err = convert(srcFS, dirpath, from, to, o); err != nil {
+		return fmt.Errorf("%s.%s: %w", msgPrefix, "ConvertWith", err)
+	}
+	return nil
+}
+
+func convert(srcFS fs.FS, dirpath, from, to string, o *options) (err error) {
+	w := cmp.Or[io.Writer](o.writer, os.Stdout)
+	if from == to {
+		return fmt.Errorf("%w; the formats to convert from and to are both %q", internal.ErrDataInvalid, from)
+	}
+	src, err := internal.LookupFileFormat(from, o.convention(), o.scheme())
+	if err != nil {
+		return
+	}
+	dst, err := internal.LookupFileFormat(to, o.convention(), o.scheme())
+	if err != nil {
+		return
+	}
+
+	migrations, err := src.Read(srcFS, o.scheme(), o.log())
+	if err != nil {
+		return fmt.Errorf("reading %s migrations: %w", src.Name(), err)
+	}
+	if err = os.MkdirAll(dirpath, 0755); err != nil {
+		return
+	}
+
+	results := make([]internal.ConvertResult, 0, len(migrations))
+	for _, mig := range migrations {
+		written, werr := dst.Write(dirpath, mig)
+		if werr != nil {
+			return fmt.Errorf("writing %s migration %q: %w", dst.Name(), mig.Version.String(), werr)
+		}
+		results = append(results, internal.ConvertResult{
+			Version: mig.Version.String(),
+			Label:   mig.Label,
+			From:    mig.Files,
+			To:      written,
+		})
+	}
+
+	return choosePrinter(o.format, w, o.log(), internal.NewConvertJSON, internal.NewConvertTSV).PrintConvert(results)
+}
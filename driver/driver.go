@@ -2,7 +2,10 @@
 // [Driver] for the godfish library.
 package driver
 
-import "context"
+import (
+	"context"
+	"log/slog"
+)
 
 // A Driver lets godfish talk to the database. Implementations are responsible
 // opening a connection, maintaining it and closing it after operations are
@@ -13,31 +16,42 @@ type Driver interface {
 	// AppliedVersions queries the schema migrations table for migration
 	// versions that have been executed against the database. If the schema
 	// migrations table does not exist, the returned error should be
-	// [ErrSchemaMigrationsDoesNotExist]. If the table exists, but it is missing some
-	// newer columns, then it should return [ErrSchemaMigrationsMissingColumns].
+	// [ErrSchemaMigrationsDoesNotExist]. If the table exists, but it is missing the
+	// label or executed_at columns, then it should return
+	// [ErrSchemaMigrationsMissingColumns].
 	AppliedVersions(ctx context.Context, migrationsTable string) (AppliedVersions, error)
 	// CreateSchemaMigrationsTable should create a table to record migration
-	// versions once they've been applied. The version should be a timestamp as
-	// a string, formatted as the TimeFormat variable in this package.
+	// versions once they've been applied. The version should be stored as a
+	// string. By default, it's a timestamp, but it may be a sequential or a
+	// dotted version, such as "0007" or "V1.2.3", depending on the version
+	// scheme of the migration files.
 	CreateSchemaMigrationsTable(ctx context.Context, migrationsTable string) error
 	// Execute runs the schema change and commits it to the database. The query
 	// parameter is a SQL string and may contain placeholders for the values in
 	// args. Input should be passed to conn so it could be sanitized, escaped.
+	// When it fails, it may return a [StatementError] to tell where.
 	Execute(ctx context.Context, query string, args ...any) error
 	// UpdateSchemaMigrations records a timestamped version of a migration that
 	// has been successfully applied by adding a new row to the schema
-	// migrations table.
+	// migrations table. When forward is false, then the row for the version is
+	// removed instead.
 	UpdateSchemaMigrations(ctx context.Context, migrationsTable string, forward bool, version, label string) error
 	// UpgradeSchemaMigrations adds new columns to the migrationsTable. The new
-	// columns are some extra metadata. Roughly, they should be:
+	// columns are some extra metadata. Only the columns that are missing should
+	// be added. Roughly, they should be:
 	//
 	// 	label VARCHAR (or equivalent) default ""
 	// 	executed_at INTEGER (or equivalent) default 0
+	//
+	// A [MetadataRecorder] should also add the columns for its [Metadata].
 	UpgradeSchemaMigrations(ctx context.Context, migrationsTable string) error
 }
 
 // AppliedVersions represents an iterative list of migrations that have been run
 // against the database and have been recorded in the schema migrations table.
+// Each row should be scanned in this order: migration_id, label, executed_at.
+// The rows of a [MetadataRecorder] also have the fields of [Metadata], see
+// [MetadataRecorder.RecordMigration].
 // It's enough to convert a *sql.Rows struct when implementing the Driver
 // interface since a *sql.Rows already satisfies this interface. See existing
 // Driver implementations in this project for examples.
@@ -46,3 +60,112 @@ type AppliedVersions interface {
 	Next() bool
 	Scan(dest ...any) error
 }
+
+// A Locker is a [Driver] that can prevent multiple processes from running
+// migrations against the same database at the same time. Implementing this
+// interface is optional. When a Driver is also a Locker, then godfish acquires
+// the lock before reading the schema migrations table and holds it until the
+// run is complete.
+type Locker interface {
+	// Lock acquires an exclusive lock, keyed by migrationsTable, that is
+	// visible to every process connected to the same database. It should block
+	// until the lock is acquired or until ctx is done, whichever is first.
+	Lock(ctx context.Context, migrationsTable string) error
+	// Unlock releases a lock previously acquired with Lock.
+	Unlock(ctx context.Context, migrationsTable string) error
+}
+
+// A MetadataRecorder is a [Driver] that records more about each applied
+// migration than its version and label. Implementing this interface is
+// optional. When a Driver is also a MetadataRecorder, then godfish records an
+// applied migration with RecordMigration rather than UpdateSchemaMigrations.
+// Otherwise, the [Metadata] of each migration is not recorded, so a migration
+// file cannot be checked for changes since it was applied, and a repeatable
+// migration cannot be run.
+type MetadataRecorder interface {
+	// RecordMigration is like UpdateSchemaMigrations with forward set to true,
+	// but it also records meta. Each row of AppliedVersions should then be
+	// scanned in this order: migration_id, label, executed_at, checksum,
+	// batch.
+	//
+	// A schema migrations table created before a column of meta existed may
+	// lack it until UpgradeSchemaMigrations adds it. Until then, the field
+	// should not be recorded, and AppliedVersions should read it as its zero
+	// value rather than returning [ErrSchemaMigrationsMissingColumns].
+	RecordMigration(ctx context.Context, migrationsTable, version, label string, meta Metadata) error
+}
+
+// Metadata is recorded along with an applied migration by a
+// [MetadataRecorder].
+type Metadata struct {
+	// Checksum is a hash of the migration file contents. It's empty for a
+	// migration without a file, such as a Go migration.
+	Checksum string
+	// Batch identifies the run that applied the migration. Every migration
+	// applied in the same run has the same Batch. It's 0 for a migration
+	// without a batch, such as one applied before it was recorded. A NULL
+	// batch should be read as 0 as well.
+	Batch int64
+}
+
+// A Transactor is a [Driver] that can run a migration and record it in the
+// schema migrations table as one atomic unit. Implementing this interface is
+// optional, and only makes sense for databases that support transactional DDL.
+// When a Driver is also a Transactor, then godfish executes the migration and
+// updates the schema migrations table within one transaction. Otherwise, each
+// of those operations is committed separately.
+type Transactor interface {
+	// WithinTransaction begins a transaction and calls fn with a Driver whose
+	// Execute, CreateSchemaMigrationsTable and UpdateSchemaMigrations methods
+	// run within that transaction. When the Driver is a [MetadataRecorder],
+	// then so is the one passed to fn. If fn returns nil, then the transaction
+	// should be committed. Otherwise, it should be rolled back and the error
+	// from fn should be returned.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context, tx Driver) error) error
+}
+
+// A Scripter is a [Driver] that can render the statements it would run to
+// maintain the schema migrations table, rather than running them. Implementing
+// this interface is optional. It's used to output the plan of a migration run
+// without changing the database. When a Driver is not a Scripter, then the
+// plan only has the contents of the migration files.
+type Scripter interface {
+	// CreateSchemaMigrationsTableScript returns the statement that
+	// CreateSchemaMigrationsTable would execute.
+	CreateSchemaMigrationsTableScript(migrationsTable string) (string, error)
+	// UpdateSchemaMigrationsScript returns the statement that
+	// UpdateSchemaMigrations would execute. When the Driver is also a
+	// [MetadataRecorder] and forward is true, then it's the statement that
+	// RecordMigration would execute instead. Values should be inlined as
+	// escaped literals, so the statement could be run as is.
+	UpdateSchemaMigrationsScript(migrationsTable string, forward bool, version, label, checksum string, batch int64) (string, error)
+}
+
+// A RawConnector is a [Driver] that exposes its underlying database
+// connection, so that migrations written as Go functions can use it directly.
+// Implementing this interface is optional. When a Driver is not a
+// RawConnector, then Go migrations receive the Driver itself.
+type RawConnector interface {
+	// RawConn returns the connection that migrations run on, such as a
+	// *sql.DB. When called on the Driver passed along by a [Transactor], it
+	// should return the transaction instead, such as a *sql.Tx.
+	RawConn() any
+}
+
+type loggerCtxKey struct{}
+
+// ContextWithLogger returns a copy of ctx with lgr, for a [Driver] to log
+// with. See [Logger].
+func ContextWithLogger(ctx context.Context, lgr *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey{}, lgr)
+}
+
+// Logger returns the logger put on ctx by [ContextWithLogger]. Otherwise, it's
+// the default logger. A Driver should log with it, so that the logger passed
+// to godfish is used instead of the default one.
+func Logger(ctx context.Context) *slog.Logger {
+	if lgr, ok := ctx.Value(loggerCtxKey{}).(*slog.Logger); ok && lgr != nil {
+		return lgr
+	}
+	return slog.Default()
+}
@@ -2,6 +2,7 @@ package driver_test
 
 import (
 	"database/sql"
+	"log/slog"
 	"testing"
 
 	"github.com/rafaelespinoza/godfish/driver"
@@ -15,3 +16,14 @@ func TestAppliedVersions(t *testing.T) {
 		t.Fatalf("expected %T to implement driver.AppliedVersions", thing)
 	}
 }
+
+func TestLogger(t *testing.T) {
+	if got := driver.Logger(t.Context()); got != slog.Default() {
+		t.Errorf("expected the default logger, got %v", got)
+	}
+
+	lgr := slog.New(slog.DiscardHandler)
+	if got := driver.Logger(driver.ContextWithLogger(t.Context(), lgr)); got != lgr {
+		t.Errorf("expected the logger on the context, got %v", got)
+	}
+}
@@ -9,3 +9,21 @@ var ErrSchemaMigrationsDoesNotExist = errors.New("schema migrations table does n
 // ErrSchemaMigrationsMissingColumns means the schema migrations table exists,
 // but is missing some extra metadata columns.
 var ErrSchemaMigrationsMissingColumns = errors.New("schema migrations table is missing columns")
+
+// A StatementError is returned by [Driver.Execute] to tell where in the query
+// something failed. Returning one is optional, it helps godfish to point at
+// the line and column of the migration file with the error.
+type StatementError struct {
+	// Statement is the index of the failed statement, when a driver runs the
+	// statements of the query one at a time. Otherwise, it's 0.
+	Statement int
+	// Offset is the byte offset of the failed statement within the query.
+	Offset int
+	// Position is the byte offset of the error within the query, when the
+	// database reports one. Otherwise, it's -1.
+	Position int
+	Err      error
+}
+
+func (e *StatementError) Error() string { return e.Err.Error() }
+func (e *StatementError) Unwrap() error { return e.Err }
@@ -3,9 +3,11 @@ package cassandra
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,9 +26,13 @@ const SampleDSN = `cassandra://server_host:9042/keyspace_name?timeout_ms=2000&co
 func NewDriver() *Driver { return &Driver{} }
 
 // Driver implements the [driver.Driver] interface for cassandra databases.
+// It does not implement [driver.Transactor] because cassandra has no
+// transactions spanning multiple statements.
 type Driver struct {
 	connection *gocql.Session
 	keyspace   string
+	// lease is the currently held lock, see [Driver.Lock].
+	lease *lease
 }
 
 func (d *Driver) Name() string { return "cassandra" }
@@ -54,42 +60,198 @@ func (d *Driver) Close() (err error) {
 		return
 	}
 	d.connection = nil
+	if l := d.lease; l != nil {
+		d.lease = nil
+		l.stop()
+		<-l.done
+	}
 	conn.Close()
 	return
 }
 
-var statementDelimiter = regexp.MustCompile(`;\s*\n`)
+const (
+	// lockLeaseTTL is how long a lock lease lasts unless it's renewed. It's
+	// renewed periodically while the lock is held, so a lease left behind by a
+	// crashed process expires on its own.
+	lockLeaseTTL = 60 * time.Second
+	// lockPollInterval is how long to wait in between attempts to acquire a lock.
+	lockPollInterval = time.Second
+	// lockID is the primary key of the single row in a lock table.
+	lockID = "godfish"
+)
 
-func (d *Driver) Execute(ctx context.Context, query string, args ...any) (err error) {
-	statements := statementDelimiter.Split(query, -1)
-	if len(statements) < 1 {
+// lease is a lock held with a lightweight transaction.
+type lease struct {
+	owner string
+	stop  context.CancelFunc
+	done  chan struct{}
+}
+
+// Lock acquires a lease by inserting a row, with a TTL, into a lock table with
+// a lightweight transaction. The lock table is named after migrationsTable
+// with a "_lock" suffix and is created if needed. While another process holds
+// the lease, it polls until the lease is released, expires or ctx is done.
+// The lease is renewed in the background until [Driver.Unlock] is called.
+func (d *Driver) Lock(ctx context.Context, migrationsTable string) (err error) {
+	lockTable, err := lockTableName(migrationsTable)
+	if err != nil {
 		return
 	}
-	for _, q := range statements {
-		if len(strings.TrimSpace(q)) < 1 {
-			continue
+	if d.lease != nil {
+		return errors.New(msgPrefix + "lock is already held")
+	}
+
+	q := `CREATE TABLE IF NOT EXISTS ` + lockTable + ` (lock_id TEXT PRIMARY KEY, owner TEXT)`
+	if err = d.connection.Query(q).WithContext(ctx).Exec(); err != nil {
+		return fmt.Errorf(msgPrefix+"creating lock table; %w", err)
+	}
+
+	owner := gocql.TimeUUID().String()
+	ttl := int(lockLeaseTTL.Seconds())
+	q = `INSERT INTO ` + lockTable + ` (lock_id, owner) VALUES (?, ?) IF NOT EXISTS USING TTL ?`
+	err = internal.PollLock(ctx, lockPollInterval, func(ctx context.Context) (bool, error) {
+		return d.connection.Query(q, lockID, owner, ttl).WithContext(ctx).MapScanCAS(make(map[string]any))
+	})
+	if err != nil {
+		return fmt.Errorf(msgPrefix+"acquiring lock; %w", err)
+	}
+
+	renewCtx, stop := context.WithCancel(context.WithoutCancel(ctx))
+	d.lease = &lease{owner: owner, stop: stop, done: make(chan struct{})}
+	go d.renewLease(renewCtx, lockTable, d.lease)
+	return
+}
+
+func (d *Driver) renewLease(ctx context.Context, lockTable string, l *lease) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(lockLeaseTTL / 3)
+	defer ticker.Stop()
+
+	ttl := int(lockLeaseTTL.Seconds())
+	q := `UPDATE ` + lockTable + ` USING TTL ? SET owner = ? WHERE lock_id = ? IF owner = ?`
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
-		err = d.connection.Query(q).WithContext(ctx).Exec()
+
+		applied, err := d.connection.Query(q, ttl, l.owner, lockID, l.owner).WithContext(ctx).MapScanCAS(make(map[string]any))
 		if err != nil {
+			driver.Logger(ctx).Warn(msgPrefix+"renewing lock lease", slog.Any("error", err))
+		} else if !applied {
+			driver.Logger(ctx).Error(msgPrefix+"lock lease was lost", slog.String("owner", l.owner))
 			return
 		}
 	}
+}
+
+// Unlock stops renewing the lease acquired by [Driver.Lock] and deletes it.
+func (d *Driver) Unlock(ctx context.Context, migrationsTable string) (err error) {
+	lockTable, err := lockTableName(migrationsTable)
+	if err != nil {
+		return
+	}
+	l := d.lease
+	if l == nil {
+		return errors.New(msgPrefix + "lock is not held")
+	}
+	d.lease = nil
+	l.stop()
+	<-l.done
+
+	q := `DELETE FROM ` + lockTable + ` WHERE lock_id = ? IF owner = ?`
+	applied, err := d.connection.Query(q, lockID, l.owner).WithContext(ctx).MapScanCAS(make(map[string]any))
+	if err != nil {
+		return fmt.Errorf(msgPrefix+"releasing lock; %w", err)
+	}
+	if !applied {
+		return fmt.Errorf(msgPrefix+"releasing lock; lease with owner %s was lost before release", l.owner)
+	}
+	return
+}
+
+func lockTableName(migrationsTable string) (string, error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return "", err
+	}
+	return cleanIdentifier(strings.ReplaceAll(cleanedTableName, quote, "") + "_lock")
+}
+
+// RawConn returns the connection, a *gocql.Session. See [driver.RawConnector].
+func (d *Driver) RawConn() any { return d.connection }
+
+var statementDelimiter = regexp.MustCompile(`;\s*\n`)
+
+func (d *Driver) Execute(ctx context.Context, query string, args ...any) (err error) {
+	for i, stmt := range internal.SplitStatements(query, statementDelimiter) {
+		err = d.connection.Query(stmt.Text).WithContext(ctx).Exec()
+		if err != nil {
+			return &driver.StatementError{Statement: i, Offset: stmt.Offset, Position: errorPosition(stmt, err), Err: err}
+		}
+	}
 	return nil
 }
 
+// lineColumn matches the start of the message of a syntax error, such as:
+// "line 1:0 no viable alternative at input 'SELEC'". The column starts at 0.
+var lineColumn = regexp.MustCompile(`^line (\d+):(\d+)`)
+
+// errorPosition finds the byte offset of a syntax error in the query that
+// stmt is from. It's -1 for other errors.
+func errorPosition(stmt internal.Statement, err error) int {
+	var rerr gocql.RequestError
+	if !errors.As(err, &rerr) || rerr.Code() != gocql.ErrCodeSyntax {
+		return -1
+	}
+	match := lineColumn.FindStringSubmatch(rerr.Message())
+	if match == nil {
+		return -1
+	}
+	line, lerr := strconv.Atoi(match[1])
+	column, cerr := strconv.Atoi(match[2])
+	if lerr != nil || cerr != nil {
+		return -1
+	}
+	if ind := internal.OffsetOfLine(stmt.Text, line, column+1); ind >= 0 {
+		return stmt.Offset + ind
+	}
+	return -1
+}
+
 func (d *Driver) CreateSchemaMigrationsTable(ctx context.Context, migrationsTable string) (err error) {
 	cleanedTableName, err := cleanIdentifier(migrationsTable)
 	if err != nil {
 		return
 	}
 
-	q := `CREATE TABLE IF NOT EXISTS ` + cleanedTableName + ` (
+	q := createSchemaMigrationsTableQuery(cleanedTableName)
+	err = d.connection.Query(q).WithContext(ctx).Exec()
+	return
+}
+
+// CreateSchemaMigrationsTableScript returns the statement that
+// [Driver.CreateSchemaMigrationsTable] would execute. See [driver.Scripter].
+func (d *Driver) CreateSchemaMigrationsTableScript(migrationsTable string) (string, error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return "", err
+	}
+	return createSchemaMigrationsTableQuery(cleanedTableName), nil
+}
+
+// createSchemaMigrationsTableQuery is the statement to create the schema
+// migrations table.
+func createSchemaMigrationsTableQuery(cleanedTableName string) string {
+	return `CREATE TABLE IF NOT EXISTS ` + cleanedTableName + ` (
 	migration_id TEXT PRIMARY KEY,
 	label TEXT,
-	executed_at BIGINT
+	executed_at BIGINT,
+	checksum TEXT,
+	batch BIGINT
 )`
-	err = d.connection.Query(q).WithContext(ctx).Exec()
-	return
 }
 
 func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (out driver.AppliedVersions, err error) {
@@ -101,22 +263,30 @@ func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (o
 	metadata, err := checkKeyspaceMetadata(ctx, d, cleanedTableName)
 	if err != nil {
 		return
-	} else if !metadata.hasTable {
+	} else if !metadata.HasTable {
 		err = driver.ErrSchemaMigrationsDoesNotExist
 		return
-	} else if !metadata.hasColLabel || !metadata.hasColExecutedAt {
+	} else if !metadata.HasColLabel || !metadata.HasColExecutedAt {
 		err = driver.ErrSchemaMigrationsMissingColumns
 		return
 	}
+	columns := "migration_id, label, executed_at"
+	if metadata.HasColChecksum {
+		columns += ", checksum"
+	}
+	if metadata.HasColBatch {
+		columns += ", batch"
+	}
 
-	q := `SELECT migration_id, label, executed_at FROM ` + cleanedTableName
+	q := `SELECT ` + columns + ` FROM ` + cleanedTableName
 	query := d.connection.Query(q).WithContext(ctx)
-	slog.Debug(msgPrefix+"(*driver).AppliedVersions query",
+	lgr := driver.Logger(ctx)
+	lgr.Debug(msgPrefix+"(*driver).AppliedVersions query",
 		slog.String("keyspace", query.Keyspace()),
 		slog.String("statement", query.Statement()),
 	)
 
-	av := execAllAscending(query)
+	av := execAllAscending(query, metadata, lgr)
 	if av.closingErr == nil && av.scanningErr == nil {
 		out = av
 		return
@@ -124,7 +294,7 @@ func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (o
 
 	// An error here is probably more serious, prioritize that one if it exists.
 	if av.closingErr != nil {
-		slog.Error(msgPrefix+"(*driver).AppliedVersions non-empty error(s) after executing query",
+		lgr.Error(msgPrefix+"(*driver).AppliedVersions non-empty error(s) after executing query",
 			slog.Any("closing_err", av.closingErr),
 			slog.Any("scanning_err", av.scanningErr), // just in case there's another lingering error...
 		)
@@ -132,7 +302,7 @@ func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (o
 		return
 	}
 
-	slog.Error(msgPrefix+"(*driver).AppliedVersions non-empty scanning error",
+	lgr.Error(msgPrefix+"(*driver).AppliedVersions non-empty scanning error",
 		slog.Any("scanning_err", av.scanningErr),
 		slog.String("type", fmt.Sprintf("%T", av.scanningErr)),
 	)
@@ -142,7 +312,7 @@ func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (o
 		return
 	}
 
-	slog.Error(msgPrefix+"(*driver).AppliedVersions more details on the same scanning error",
+	lgr.Error(msgPrefix+"(*driver).AppliedVersions more details on the same scanning error",
 		slog.String("type", fmt.Sprintf("%T", ierr)), slog.String("error", ierr.Error()),
 		slog.Int("code", ierr.Code()), slog.String("message", ierr.Message()),
 	)
@@ -169,6 +339,48 @@ func (d *Driver) UpdateSchemaMigrations(ctx context.Context, migrationsTable str
 	return
 }
 
+// RecordMigration is like [Driver.UpdateSchemaMigrations], but it also
+// records meta in the columns that the schema migrations table has. See
+// [driver.MetadataRecorder].
+func (d *Driver) RecordMigration(ctx context.Context, migrationsTable, version, label string, meta driver.Metadata) (err error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return
+	}
+
+	metadata, err := checkKeyspaceMetadata(ctx, d, cleanedTableName)
+	if err != nil {
+		return
+	}
+	now := time.Now().UTC()
+	columns, args := metadata.RecordedColumns(version, label, now.Unix(), meta)
+	placeholders := strings.Repeat(", ?", len(args))[2:]
+
+	q := `INSERT INTO ` + cleanedTableName + ` (` + strings.Join(columns, ", ") + `) VALUES (` + placeholders + `)`
+	err = d.connection.Query(q, args...).WithContext(ctx).Exec()
+	return
+}
+
+// UpdateSchemaMigrationsScript returns the statement that
+// [Driver.UpdateSchemaMigrations] or [Driver.RecordMigration] would execute on
+// an upgraded schema migrations table. See [driver.Scripter].
+func (d *Driver) UpdateSchemaMigrationsScript(migrationsTable string, forward bool, version, label, checksum string, batch int64) (string, error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return "", err
+	}
+
+	if !forward {
+		return `DELETE FROM ` + cleanedTableName + ` WHERE migration_id = ` + internal.QuoteLiteral(version), nil
+	}
+
+	now := time.Now().UTC()
+	return fmt.Sprintf(
+		`INSERT INTO %s (migration_id, label, executed_at, checksum, batch) VALUES (%s, %s, %d, %s, %d)`,
+		cleanedTableName, internal.QuoteLiteral(version), internal.QuoteLiteral(label), now.Unix(), internal.QuoteLiteral(checksum), batch,
+	), nil
+}
+
 func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable string) error {
 	cleanedTableName, err := cleanIdentifier(migrationsTable)
 	if err != nil {
@@ -179,9 +391,9 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 	// table within the same query. Add each column with its own statement and
 	// await for each node in the cluster to be in agreement.
 	type update struct{ columnName, query string }
-	updates := make([]update, 0, 2)
+	updates := make([]update, 0, 4)
 
-	lgr := slog.With(slog.String("keyspace", d.keyspace), slog.String("table_name", cleanedTableName))
+	lgr := driver.Logger(ctx).With(slog.String("keyspace", d.keyspace), slog.String("table_name", cleanedTableName))
 	startTime := time.Now()
 	const timeSinceLogKey = "time_since_start_ms"
 	defer func() { lgr.Info(msgPrefix+"done", makeDurationMSAttr(timeSinceLogKey, startTime)) }()
@@ -190,11 +402,11 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 	metadata, err := checkKeyspaceMetadata(ctx, d, cleanedTableName)
 	if err != nil {
 		return err
-	} else if !metadata.hasTable {
+	} else if !metadata.HasTable {
 		return driver.ErrSchemaMigrationsDoesNotExist
 	}
 	// Conditionally add the updates in case there's a need to retry 1 of them.
-	if metadata.hasColLabel {
+	if metadata.HasColLabel {
 		lgr.Debug(msgPrefix+"column appears to already exist, skipping", slog.String("column", "label"))
 	} else {
 		updates = append(
@@ -202,7 +414,7 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 			update{columnName: "label", query: `ALTER TABLE ` + cleanedTableName + ` ADD label TEXT`},
 		)
 	}
-	if metadata.hasColExecutedAt {
+	if metadata.HasColExecutedAt {
 		lgr.Debug(msgPrefix+"column appears to already exist, skipping", slog.String("column", "executed_at"))
 	} else {
 		updates = append(
@@ -210,6 +422,22 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 			update{columnName: "executed_at", query: `ALTER TABLE ` + cleanedTableName + ` ADD executed_at BIGINT`},
 		)
 	}
+	if metadata.HasColChecksum {
+		lgr.Debug(msgPrefix+"column appears to already exist, skipping", slog.String("column", "checksum"))
+	} else {
+		updates = append(
+			updates,
+			update{columnName: "checksum", query: `ALTER TABLE ` + cleanedTableName + ` ADD checksum TEXT`},
+		)
+	}
+	if metadata.HasColBatch {
+		lgr.Debug(msgPrefix+"column appears to already exist, skipping", slog.String("column", "batch"))
+	} else {
+		updates = append(
+			updates,
+			update{columnName: "batch", query: `ALTER TABLE ` + cleanedTableName + ` ADD batch BIGINT`},
+		)
+	}
 	lgr.Debug(msgPrefix+"updates prepared", slog.Int("num_updates", len(updates)))
 	for i, u := range updates {
 		ulgr := lgr.With(slog.Int("i", i), slog.String("column", u.columnName))
@@ -236,28 +464,24 @@ func makeDurationMSAttr(key string, startedAt time.Time) slog.Attr {
 	return slog.Int64(key, dur.Milliseconds())
 }
 
-type metadataResult struct {
-	hasTable         bool
-	hasColLabel      bool
-	hasColExecutedAt bool
-}
-
 // checkKeyspaceMetadata inspects the schema of the schema_migrations table
 // within the current keyspace.
-func checkKeyspaceMetadata(ctx context.Context, d *Driver, tableName string) (out metadataResult, err error) {
+func checkKeyspaceMetadata(ctx context.Context, d *Driver, tableName string) (out internal.SchemaMigrationsMetadata, err error) {
 	// Expect for the input tableName to have been treated by cleanIdentifier.
 	// It doesn't need to be quoted in this case because it's used as a regular
 	// query parameter in this function.
 	tableName = strings.ReplaceAll(tableName, quote, "")
 
-	lgr := slog.With(slog.String("driver", d.Name()), slog.String("keyspace", d.keyspace), slog.String("table_name", tableName))
+	lgr := driver.Logger(ctx).With(slog.String("driver", d.Name()), slog.String("keyspace", d.keyspace), slog.String("table_name", tableName))
 
 	defer func() {
 		lgr.Debug(msgPrefix+"checked keyspace metadata",
 			slog.Group("result",
-				slog.Bool("has_table", out.hasTable),
-				slog.Bool("has_col_label", out.hasColLabel),
-				slog.Bool("has_col_executed_at", out.hasColExecutedAt),
+				slog.Bool("has_table", out.HasTable),
+				slog.Bool("has_col_label", out.HasColLabel),
+				slog.Bool("has_col_executed_at", out.HasColExecutedAt),
+				slog.Bool("has_col_checksum", out.HasColChecksum),
+				slog.Bool("has_col_batch", out.HasColBatch),
 			),
 		)
 	}()
@@ -278,10 +502,10 @@ func checkKeyspaceMetadata(ctx context.Context, d *Driver, tableName string) (ou
 		}
 	}()
 	for tableScanner.Next() {
-		out.hasTable = true
+		out.HasTable = true
 	}
 
-	if !out.hasTable {
+	if !out.HasTable {
 		return
 	}
 
@@ -291,7 +515,7 @@ FROM system_schema.columns
 WHERE keyspace_name = ?
 	AND table_name = ?
 	AND column_name IN ?`
-	colArgs := []any{d.keyspace, tableName, []string{"label", "executed_at"}}
+	colArgs := []any{d.keyspace, tableName, internal.MetadataColumns}
 	lgr.Debug(
 		msgPrefix+"checking for column existence",
 		slog.String("query", columnsQuery), slog.Any("args", colArgs),
@@ -307,18 +531,13 @@ WHERE keyspace_name = ?
 		}
 	}()
 	for colScanner.Next() {
-		out.hasTable = true
+		out.HasTable = true
 		var t, c string
 		if err = colScanner.Scan(&t, &c); err != nil {
 			err = fmt.Errorf("scanning for columns; %w", err)
 			return
 		}
-		switch c {
-		case "label":
-			out.hasColLabel = true
-		case "executed_at":
-			out.hasColExecutedAt = true
-		}
+		out.SetColumn(c)
 	}
 
 	return
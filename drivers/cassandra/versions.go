@@ -6,12 +6,15 @@ import (
 	"sort"
 
 	"github.com/gocql/gocql"
+
+	"github.com/rafaelespinoza/godfish/drivers/internal"
 )
 
 // execAllAscending executes query, reads the entire results and then sorts the
 // results ascendingly. The output av will be non-nil, read its error fields to
-// check if an error was encountered.
-func execAllAscending(query *gocql.Query) *appliedVersions {
+// check if an error was encountered. Each version is logged with lgr. The
+// query selects the checksum and batch columns only when metadata has them.
+func execAllAscending(query *gocql.Query, metadata internal.SchemaMigrationsMetadata, lgr *slog.Logger) *appliedVersions {
 	scanner := query.Iter().Scanner()
 	av := appliedVersions{versions: make([]migration, 0)}
 
@@ -28,16 +31,24 @@ func execAllAscending(query *gocql.Query) *appliedVersions {
 	// Read it all up front so DB resources can be closed while also avoid nil
 	// access errors.
 	for scanner.Next() {
-		var version, label string
-		var executedAt int64
-		if err := scanner.Scan(&version, &label, &executedAt); err != nil {
+		var version, label, checksum string
+		var executedAt, batch int64
+		dest := []any{&version, &label, &executedAt}
+		if metadata.HasColChecksum {
+			dest = append(dest, &checksum)
+		}
+		if metadata.HasColBatch {
+			dest = append(dest, &batch)
+		}
+		if err := scanner.Scan(dest...); err != nil {
 			av.scanningErr = err
 			return &av
 		}
-		av.versions = append(av.versions, migration{version, label, executedAt})
-		slog.Debug(
+		av.versions = append(av.versions, migration{version, label, executedAt, checksum, batch})
+		lgr.Debug(
 			msgPrefix+"scanned version",
 			slog.String("version", version), slog.String("label", label), slog.Int64("executed_at", executedAt),
+			slog.Int64("batch", batch),
 		)
 	}
 
@@ -100,6 +111,24 @@ func (a *appliedVersions) Scan(dest ...any) error {
 		return fmt.Errorf("unexpected type (%T) for %q field", val, "executed_at")
 	}
 
+	if len(dest) < 5 {
+		return nil
+	}
+
+	switch val := dest[3].(type) {
+	case *string:
+		*val = curr.checksum
+	default:
+		return fmt.Errorf("unexpected type (%T) for %q field", val, "checksum")
+	}
+
+	switch val := dest[4].(type) {
+	case *int64:
+		*val = curr.batch
+	default:
+		return fmt.Errorf("unexpected type (%T) for %q field", val, "batch")
+	}
+
 	return nil
 }
 
@@ -107,4 +136,6 @@ type migration struct {
 	id         string
 	label      string
 	executedAt int64
+	checksum   string
+	batch      int64
 }
@@ -0,0 +1,38 @@
+package drivertest
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func testBaseline(t *testing.T, d driver.Driver, queries testdataQueries) {
+	const migrationsTable = internal.DefaultMigrationsTableName
+	dirFS := fstest.MapFS{
+		"forward-1234-alpha.sql": &fstest.MapFile{Data: []byte(queries.CreateFoos.Forward)},
+		"reverse-1234-alpha.sql": &fstest.MapFile{Data: []byte(queries.CreateFoos.Reverse)},
+	}
+	defer teardown(t, d, "", migrationsTable, "foos")
+
+	err := godfish.BaselineWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1234"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	appliedVersions := collectAppliedMigrations(t, d, migrationsTable)
+	testAppliedMigrations(t, appliedVersions, []string{"1234"})
+
+	// The migration should be recorded, but not executed. If it were executed,
+	// then creating the table here would fail.
+	if err = d.Execute(t.Context(), queries.CreateFoos.Forward); err != nil {
+		t.Fatalf("expected table to not exist after baseline; %v", err)
+	}
+
+	err = godfish.BaselineWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1234"))
+	if !errors.Is(err, internal.ErrAlreadyApplied) {
+		t.Errorf("expected error (%v) to be %v", err, internal.ErrAlreadyApplied)
+	}
+}
@@ -0,0 +1,47 @@
+package drivertest
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func testBatch(t *testing.T, d driver.Driver, queries testdataQueries) {
+	const migrationsTable = internal.DefaultMigrationsTableName
+	dirFS := fstest.MapFS{
+		"forward-1234-alpha.sql":   &fstest.MapFile{Data: []byte(queries.CreateFoos.Forward)},
+		"reverse-1234-alpha.sql":   &fstest.MapFile{Data: []byte(queries.CreateFoos.Reverse)},
+		"forward-2345-bravo.sql":   &fstest.MapFile{Data: []byte(queries.CreateBars.Forward)},
+		"reverse-2345-bravo.sql":   &fstest.MapFile{Data: []byte(queries.CreateBars.Reverse)},
+		"forward-3456-charlie.sql": &fstest.MapFile{Data: []byte(queries.AlterFoos.Forward)},
+		"reverse-3456-charlie.sql": &fstest.MapFile{Data: []byte(queries.AlterFoos.Reverse)},
+	}
+	defer teardown(t, d, "", migrationsTable, "foos", "bars")
+
+	if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithSteps(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := godfish.MigrateWith(t.Context(), d, dirFS); err != nil {
+		t.Fatal(err)
+	}
+	appliedVersions := collectAppliedMigrations(t, d, migrationsTable)
+	testAppliedMigrations(t, appliedVersions, []string{"1234", "2345", "3456"})
+	for i, expected := range []int64{1, 2, 2} {
+		if got := appliedVersions[i].Batch; got != expected {
+			t.Errorf("index %d; wrong batch; got %d, expected %d", i, got, expected)
+		}
+	}
+
+	if err := godfish.RollbackWith(t.Context(), d, dirFS, godfish.WithLastBatch()); err != nil {
+		t.Fatal(err)
+	}
+	testAppliedMigrations(t, collectAppliedMigrations(t, d, migrationsTable), []string{"1234"})
+
+	if err := godfish.RollbackWith(t.Context(), d, dirFS, godfish.WithBatch(1)); err != nil {
+		t.Fatal(err)
+	}
+	testAppliedMigrations(t, collectAppliedMigrations(t, d, migrationsTable), []string{})
+}
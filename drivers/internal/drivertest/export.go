@@ -0,0 +1,44 @@
+package drivertest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func testExport(t *testing.T, d driver.Driver, queries testdataQueries) {
+	if _, ok := d.(driver.Scripter); !ok {
+		t.Skipf("driver %s is not a driver.Scripter", d.Name())
+	}
+
+	const migrationsTable = internal.DefaultMigrationsTableName
+	dirFS := fstest.MapFS{
+		"forward-1234-alpha.sql": &fstest.MapFile{Data: []byte(queries.CreateFoos.Forward)},
+		"forward-2345-bravo.sql": &fstest.MapFile{Data: []byte(queries.CreateBars.Forward)},
+	}
+	defer teardown(t, d, "", migrationsTable, "foos", "bars")
+
+	if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1234")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := godfish.ExportWith(t.Context(), d, dirFS, godfish.WithWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "path_to_file: forward-1234-alpha.sql") {
+		t.Errorf("did not expect output to contain an applied migration\n%s", out)
+	}
+	if !strings.Contains(out, strings.TrimSpace(queries.CreateBars.Forward)) {
+		t.Errorf("expected output to contain the pending migration\n%s", out)
+	}
+
+	// Nothing should have been executed.
+	testAppliedMigrations(t, collectAppliedMigrations(t, d, migrationsTable), []string{"1234"})
+}
@@ -0,0 +1,68 @@
+package drivertest
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func testGoMigration(t *testing.T, d driver.Driver) {
+	if _, ok := d.(driver.RawConnector); !ok {
+		t.Skipf("driver %s is not a driver.RawConnector", d.Name())
+	}
+	const migrationsTable = internal.DefaultMigrationsTableName
+
+	var forwardConn, reverseConn any
+	gm := godfish.GoMigration{
+		Version: "1234",
+		Label:   "alpha",
+		Forward: func(_ context.Context, conn any) error {
+			forwardConn = conn
+			return nil
+		},
+		Reverse: func(_ context.Context, conn any) error {
+			reverseConn = conn
+			return nil
+		},
+	}
+	dirFS := fstest.MapFS{}
+	defer teardown(t, d, "", migrationsTable)
+
+	if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithGoMigrations(gm)); err != nil {
+		t.Fatal(err)
+	}
+	if forwardConn == nil {
+		t.Error("expected Forward func to receive a non-nil conn")
+	}
+	// A Go migration has no file, so its checksum is empty.
+	appliedVersions := collectAppliedMigrations(t, d, migrationsTable)
+	if len(appliedVersions) != 1 {
+		t.Fatalf("wrong number of applied migrations; got %d, expected %d", len(appliedVersions), 1)
+	}
+	act := appliedVersions[0]
+	if act.Version.String() != "1234" || act.Label != "alpha" {
+		t.Errorf("wrong migration; got version %q, label %q", act.Version.String(), act.Label)
+	}
+	if act.ExecutedAt.IsZero() {
+		t.Error("executed_at should be non-empty")
+	}
+	if act.Checksum != "" {
+		t.Errorf("checksum should be empty, got %q", act.Checksum)
+	}
+	if act.Batch < 1 {
+		t.Errorf("batch should be positive, got %d", act.Batch)
+	}
+
+	if err := godfish.RollbackWith(t.Context(), d, dirFS, godfish.WithGoMigrations(gm)); err != nil {
+		t.Fatal(err)
+	}
+	if reverseConn == nil {
+		t.Error("expected Reverse func to receive a non-nil conn")
+	}
+	appliedVersions = collectAppliedMigrations(t, d, migrationsTable)
+	testAppliedMigrations(t, appliedVersions, []string{})
+}
@@ -0,0 +1,60 @@
+package drivertest
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func testImportState(t *testing.T, d driver.Driver, queries testdataQueries) {
+	rc, ok := d.(driver.RawConnector)
+	if !ok {
+		t.Skipf("driver %s is not a driver.RawConnector", d.Name())
+	}
+	if _, ok = rc.RawConn().(*sql.DB); !ok {
+		t.Skipf("driver %s does not have a *sql.DB", d.Name())
+	}
+
+	const migrationsTable = internal.DefaultMigrationsTableName
+	const sourceTable = "dbmate_migrations"
+	dirFS := fstest.MapFS{
+		"forward-1234-alpha.sql":   &fstest.MapFile{Data: []byte(queries.CreateFoos.Forward)},
+		"forward-2345-bravo.sql":   &fstest.MapFile{Data: []byte(queries.CreateBars.Forward)},
+		"forward-3456-charlie.sql": &fstest.MapFile{Data: []byte(queries.AlterFoos.Forward)},
+	}
+	defer teardown(t, d, "", migrationsTable, sourceTable)
+
+	// Set up the table as if dbmate had applied some migrations.
+	for _, stmt := range []string{
+		"CREATE TABLE " + sourceTable + " (version VARCHAR(128) PRIMARY KEY)",
+		"INSERT INTO " + sourceTable + " (version) VALUES ('1234')",
+		"INSERT INTO " + sourceTable + " (version) VALUES ('2345')",
+		"INSERT INTO " + sourceTable + " (version) VALUES ('9999')",
+	} {
+		if err := d.Execute(t.Context(), stmt); err != nil {
+			t.Fatalf("executing statement (%q): %v", stmt, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := godfish.ImportStateWith(t.Context(), d, dirFS, "dbmate", godfish.WithSourceTable(sourceTable), godfish.WithWriter(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testAppliedMigrations(t, collectAppliedMigrations(t, d, migrationsTable), []string{"1234", "2345"})
+	if out := buf.String(); !strings.Contains(out, "unmatched") {
+		t.Errorf("expected output to report the unmatched version\n%s", out)
+	}
+
+	// It's safe to run again.
+	if err = godfish.ImportStateWith(t.Context(), d, dirFS, "dbmate", godfish.WithSourceTable(sourceTable), godfish.WithWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	testAppliedMigrations(t, collectAppliedMigrations(t, d, migrationsTable), []string{"1234", "2345"})
+}
@@ -0,0 +1,43 @@
+package drivertest
+
+import (
+	"cmp"
+	"testing"
+
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func testLocker(t *testing.T, d driver.Driver) {
+	locker, ok := d.(driver.Locker)
+	if !ok {
+		t.Skipf("driver %s is not a driver.Locker", d.Name())
+	}
+
+	t.Run("lock and unlock more than once", func(t *testing.T) {
+		for _, test := range okMigrationsTableTestCases {
+			t.Run(test.name, func(t *testing.T) {
+				table := cmp.Or(test.migrationsTable, internal.DefaultMigrationsTableName)
+				for i := range 2 {
+					if err := locker.Lock(t.Context(), table); err != nil {
+						t.Fatalf("attempt %d, Lock: %v", i, err)
+					}
+					if err := locker.Unlock(t.Context(), table); err != nil {
+						t.Fatalf("attempt %d, Unlock: %v", i, err)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("invalid migrations table", func(t *testing.T) {
+		for _, test := range invalidMigrationsTableTestCases {
+			t.Run(test.name, func(t *testing.T) {
+				err := locker.Lock(t.Context(), test.migrationsTable)
+				if !internal.IsInvalidDataError(err) {
+					t.Fatalf("expected error (%v) to be an invalid data error", err)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package drivertest
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func testMark(t *testing.T, d driver.Driver, queries testdataQueries) {
+	const migrationsTable = internal.DefaultMigrationsTableName
+	dirFS := fstest.MapFS{
+		"forward-1234-alpha.sql": &fstest.MapFile{Data: []byte(queries.CreateFoos.Forward)},
+		"reverse-1234-alpha.sql": &fstest.MapFile{Data: []byte(queries.CreateFoos.Reverse)},
+	}
+	defer teardown(t, d, "", migrationsTable)
+
+	err := godfish.MarkAppliedWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1234"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	appliedVersions := collectAppliedMigrations(t, d, migrationsTable)
+	testAppliedMigrations(t, appliedVersions, []string{"1234"})
+
+	err = godfish.MarkAppliedWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1234"))
+	if !errors.Is(err, internal.ErrAlreadyApplied) {
+		t.Errorf("expected error (%v) to be %v", err, internal.ErrAlreadyApplied)
+	}
+
+	err = godfish.MarkUnappliedWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1234"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	appliedVersions = collectAppliedMigrations(t, d, migrationsTable)
+	testAppliedMigrations(t, appliedVersions, []string{})
+
+	err = godfish.MarkUnappliedWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1234"))
+	if !errors.Is(err, internal.ErrNotFound) {
+		t.Errorf("expected error (%v) to be %v", err, internal.ErrNotFound)
+	}
+}
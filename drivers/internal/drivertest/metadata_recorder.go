@@ -0,0 +1,87 @@
+package drivertest
+
+import (
+	"testing"
+
+	"github.com/rafaelespinoza/godfish/driver"
+)
+
+func testMetadataRecorder(t *testing.T, d driver.Driver) {
+	recorder, ok := d.(driver.MetadataRecorder)
+	if !ok {
+		t.Fatalf("driver %s should be a driver.MetadataRecorder", d.Name())
+	}
+	const migrationsTable = "legacy_migrations"
+
+	// Set up a schema migrations table from before the checksum and batch
+	// columns existed.
+	q := `CREATE TABLE ` + migrationsTable + ` (migration_id VARCHAR(128) PRIMARY KEY NOT NULL, label VARCHAR(255), executed_at BIGINT)`
+	if d.Name() == "cassandra" {
+		q = `CREATE TABLE ` + migrationsTable + ` (migration_id VARCHAR PRIMARY KEY, label VARCHAR, executed_at BIGINT)`
+	}
+	if err := d.Execute(t.Context(), q); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := d.Execute(t.Context(), "DROP TABLE IF EXISTS "+migrationsTable); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	meta := driver.Metadata{Checksum: "abc123", Batch: 1}
+	if err := recorder.RecordMigration(t.Context(), migrationsTable, "1234", "alpha", meta); err != nil {
+		t.Fatalf("recording to a table without metadata columns; %v", err)
+	}
+	got := scanMetadata(t, d, migrationsTable)
+	if got["1234"] != (driver.Metadata{}) {
+		t.Errorf("without metadata columns, expected empty metadata; got %+v", got["1234"])
+	}
+
+	if err := d.UpgradeSchemaMigrations(t.Context(), migrationsTable); err != nil {
+		t.Fatal(err)
+	}
+	meta = driver.Metadata{Checksum: "def456", Batch: 2}
+	if err := recorder.RecordMigration(t.Context(), migrationsTable, "2345", "bravo", meta); err != nil {
+		t.Fatalf("recording to an upgraded table; %v", err)
+	}
+	got = scanMetadata(t, d, migrationsTable)
+	if got["1234"] != (driver.Metadata{}) {
+		t.Errorf("after upgrade, expected empty metadata for earlier migration; got %+v", got["1234"])
+	}
+	if got["2345"] != meta {
+		t.Errorf("after upgrade, wrong metadata; got %+v, expected %+v", got["2345"], meta)
+	}
+
+	// A NULL, such as from a row inserted by hand, means there is no metadata.
+	q = `INSERT INTO ` + migrationsTable + ` (migration_id, label, executed_at, checksum, batch) VALUES ('3456', 'charlie', 0, NULL, NULL)`
+	if err := d.Execute(t.Context(), q); err != nil {
+		t.Fatal(err)
+	}
+	got = scanMetadata(t, d, migrationsTable)
+	if got["3456"] != (driver.Metadata{}) {
+		t.Errorf("with NULL metadata, expected empty metadata; got %+v", got["3456"])
+	}
+}
+
+// scanMetadata reads the metadata of each applied migration, by version.
+func scanMetadata(t *testing.T, d driver.Driver, migrationsTable string) map[string]driver.Metadata {
+	t.Helper()
+
+	rows, err := d.AppliedVersions(t.Context(), migrationsTable)
+	if err != nil {
+		t.Fatalf("could not retrieve applied versions; %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make(map[string]driver.Metadata)
+	for rows.Next() {
+		var version, label string
+		var executedAt int64
+		var meta driver.Metadata
+		if err = rows.Scan(&version, &label, &executedAt, &meta.Checksum, &meta.Batch); err != nil {
+			t.Fatalf("could not scan applied versions; %v", err)
+		}
+		out[version] = meta
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package drivertest
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func testRepeatable(t *testing.T, d driver.Driver, queries testdataQueries) {
+	const migrationsTable = internal.DefaultMigrationsTableName
+	repeatableTable := internal.RepeatableTable(migrationsTable)
+	dirFS := fstest.MapFS{
+		"forward-1234-alpha.sql": &fstest.MapFile{Data: []byte(queries.CreateFoos.Forward)},
+		"reverse-1234-alpha.sql": &fstest.MapFile{Data: []byte(queries.CreateFoos.Reverse)},
+		"repeatable-bars.sql":    &fstest.MapFile{Data: []byte(queries.CreateBars.Forward)},
+	}
+	defer teardown(t, d, "", migrationsTable, "foos", "bars", repeatableTable)
+
+	if err := godfish.MigrateWith(t.Context(), d, dirFS); err != nil {
+		t.Fatal(err)
+	}
+	testAppliedMigrations(t, collectAppliedMigrations(t, d, migrationsTable), []string{"1234"})
+	testAppliedRepeatables(t, d, repeatableTable, map[string]string{"bars": internal.Checksum(dirFS["repeatable-bars.sql"].Data)})
+
+	// The contents are the same, so it should not run again. Otherwise, it
+	// would fail to create a table that already exists.
+	if err := godfish.MigrateWith(t.Context(), d, dirFS); err != nil {
+		t.Fatal(err)
+	}
+
+	dirFS["repeatable-bars.sql"] = &fstest.MapFile{Data: []byte(queries.CreateBars.Reverse)}
+	if err := godfish.MigrateWith(t.Context(), d, dirFS); err != nil {
+		t.Fatal(err)
+	}
+	testAppliedRepeatables(t, d, repeatableTable, map[string]string{"bars": internal.Checksum(dirFS["repeatable-bars.sql"].Data)})
+}
+
+// testAppliedRepeatables compares the checksum of each label recorded in the
+// repeatable migrations table.
+func testAppliedRepeatables(t *testing.T, d driver.Driver, repeatableTable string, expected map[string]string) {
+	t.Helper()
+
+	rows, err := d.AppliedVersions(t.Context(), repeatableTable)
+	if err != nil {
+		t.Fatalf("could not retrieve applied repeatables; %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	got := make(map[string]string)
+	for rows.Next() {
+		var id, label, checksum string
+		var executedAt, batch int64
+		if err = rows.Scan(&id, &label, &executedAt, &checksum, &batch); err != nil {
+			t.Fatalf("could not scan applied repeatables; %v", err)
+		}
+		if executedAt < 1 {
+			t.Errorf("executed_at for repeatable %q should be non-empty", id)
+		}
+		got[id] = checksum
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("wrong number of applied repeatables; got %d, expected %d", len(got), len(expected))
+	}
+	for label, checksum := range expected {
+		if got[label] != checksum {
+			t.Errorf("repeatable %q; wrong checksum; got %q, expected %q", label, got[label], checksum)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package drivertest
+
+import (
+	"cmp"
+	"testing"
+
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func testScripter(t *testing.T, d driver.Driver) {
+	scripter, ok := d.(driver.Scripter)
+	if !ok {
+		t.Skipf("driver %s is not a driver.Scripter", d.Name())
+	}
+
+	t.Run("statements can be executed", func(t *testing.T) {
+		for _, test := range okMigrationsTableTestCases {
+			t.Run(test.name, func(t *testing.T) {
+				migrationsTable := cmp.Or(test.migrationsTable, internal.DefaultMigrationsTableName)
+				defer teardown(t, d, "", migrationsTable)
+
+				stmt, err := scripter.CreateSchemaMigrationsTableScript(migrationsTable)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = d.Execute(t.Context(), stmt); err != nil {
+					t.Fatalf("executing statement (%q): %v", stmt, err)
+				}
+
+				// Include a quote in the label to check that values are escaped.
+				stmt, err = scripter.UpdateSchemaMigrationsScript(migrationsTable, true, "1234", "it's", "abc123", 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = d.Execute(t.Context(), stmt); err != nil {
+					t.Fatalf("executing statement (%q): %v", stmt, err)
+				}
+				appliedVersions := collectAppliedMigrations(t, d, migrationsTable)
+				testAppliedMigrations(t, appliedVersions, []string{"1234"})
+				if got := appliedVersions[0].Label; got != "it's" {
+					t.Errorf("wrong label; got %q, expected %q", got, "it's")
+				}
+
+				stmt, err = scripter.UpdateSchemaMigrationsScript(migrationsTable, false, "1234", "it's", "abc123", 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = d.Execute(t.Context(), stmt); err != nil {
+					t.Fatalf("executing statement (%q): %v", stmt, err)
+				}
+				appliedVersions = collectAppliedMigrations(t, d, migrationsTable)
+				testAppliedMigrations(t, appliedVersions, []string{})
+			})
+		}
+	})
+
+	t.Run("invalid migrations table", func(t *testing.T) {
+		for _, test := range invalidMigrationsTableTestCases {
+			t.Run(test.name, func(t *testing.T) {
+				_, err := scripter.CreateSchemaMigrationsTableScript(test.migrationsTable)
+				if !internal.IsInvalidDataError(err) {
+					t.Errorf("expected error (%v) to be an invalid data error", err)
+				}
+				_, err = scripter.UpdateSchemaMigrationsScript(test.migrationsTable, true, "1234", "alpha", "abc123", 1)
+				if !internal.IsInvalidDataError(err) {
+					t.Errorf("expected error (%v) to be an invalid data error", err)
+				}
+			})
+		}
+	})
+}
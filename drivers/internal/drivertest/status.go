@@ -0,0 +1,39 @@
+package drivertest
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func testStatus(t *testing.T, d driver.Driver, queries testdataQueries) {
+	const migrationsTable = internal.DefaultMigrationsTableName
+	dirFS := fstest.MapFS{
+		"forward-1234-alpha.sql": &fstest.MapFile{Data: []byte(queries.CreateFoos.Forward)},
+		"reverse-1234-alpha.sql": &fstest.MapFile{Data: []byte(queries.CreateFoos.Reverse)},
+		"forward-2345-bravo.sql": &fstest.MapFile{Data: []byte(queries.AlterFoos.Forward)},
+	}
+	defer teardown(t, d, "", migrationsTable, "foos")
+
+	err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1234"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := godfish.Status(t.Context(), d, dirFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("wrong number of statuses %d, %+v", len(got), got)
+	}
+	if alpha := got[0]; alpha.Version != "1234" || alpha.Label != "alpha" || !alpha.Applied || alpha.ExecutedAt.IsZero() || !alpha.Reversible {
+		t.Errorf("wrong status for applied migration %+v", alpha)
+	}
+	if bravo := got[1]; bravo.Version != "2345" || bravo.Filename != "forward-2345-bravo.sql" || bravo.Applied || bravo.Reversible {
+		t.Errorf("wrong status for pending migration %+v", bravo)
+	}
+}
@@ -0,0 +1,43 @@
+package drivertest
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func testTargets(t *testing.T, d driver.Driver, queries testdataQueries) {
+	const migrationsTable = internal.DefaultMigrationsTableName
+	dirFS := fstest.MapFS{
+		"forward-1234-alpha.sql":   &fstest.MapFile{Data: []byte(queries.CreateFoos.Forward)},
+		"reverse-1234-alpha.sql":   &fstest.MapFile{Data: []byte(queries.CreateFoos.Reverse)},
+		"forward-2345-bravo.sql":   &fstest.MapFile{Data: []byte(queries.CreateBars.Forward)},
+		"reverse-2345-bravo.sql":   &fstest.MapFile{Data: []byte(queries.CreateBars.Reverse)},
+		"forward-3456-charlie.sql": &fstest.MapFile{Data: []byte(queries.AlterFoos.Forward)},
+		"reverse-3456-charlie.sql": &fstest.MapFile{Data: []byte(queries.AlterFoos.Reverse)},
+	}
+	defer teardown(t, d, "", migrationsTable, "foos", "bars")
+
+	if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithSteps(1)); err != nil {
+		t.Fatal(err)
+	}
+	testAppliedMigrations(t, collectAppliedMigrations(t, d, migrationsTable), []string{"1234"})
+
+	if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithTargetLabel("charlie")); err != nil {
+		t.Fatal(err)
+	}
+	testAppliedMigrations(t, collectAppliedMigrations(t, d, migrationsTable), []string{"1234", "2345", "3456"})
+
+	if err := godfish.RollbackWith(t.Context(), d, dirFS, godfish.WithSteps(1)); err != nil {
+		t.Fatal(err)
+	}
+	testAppliedMigrations(t, collectAppliedMigrations(t, d, migrationsTable), []string{"1234", "2345"})
+
+	if err := godfish.RollbackWith(t.Context(), d, dirFS, godfish.WithTargetLabel("alpha")); err != nil {
+		t.Fatal(err)
+	}
+	testAppliedMigrations(t, collectAppliedMigrations(t, d, migrationsTable), []string{})
+}
@@ -49,7 +49,20 @@ func RunDriverTests(t *testing.T, driver DriverConnector) {
 	t.Run("ApplyMigration", func(t *testing.T) { testApplyMigration(t, driver, q) })
 	t.Run("UpdateSchemaMigrations", func(t *testing.T) { testUpdateSchemaMigrations(t, driver) })
 	t.Run("UpgradeSchemaMigrations", func(t *testing.T) { testUpgradeSchemaMigrations(t, driver, q) })
+	t.Run("MetadataRecorder", func(t *testing.T) { testMetadataRecorder(t, driver) })
 	t.Run("Context", func(t *testing.T) { testContext(t, driver) })
+	t.Run("Locker", func(t *testing.T) { testLocker(t, driver) })
+	t.Run("Transactor", func(t *testing.T) { testTransactor(t, driver, q) })
+	t.Run("Scripter", func(t *testing.T) { testScripter(t, driver) })
+	t.Run("GoMigration", func(t *testing.T) { testGoMigration(t, driver) })
+	t.Run("Baseline", func(t *testing.T) { testBaseline(t, driver, q) })
+	t.Run("Mark", func(t *testing.T) { testMark(t, driver, q) })
+	t.Run("Targets", func(t *testing.T) { testTargets(t, driver, q) })
+	t.Run("Batch", func(t *testing.T) { testBatch(t, driver, q) })
+	t.Run("Repeatable", func(t *testing.T) { testRepeatable(t, driver, q) })
+	t.Run("Export", func(t *testing.T) { testExport(t, driver, q) })
+	t.Run("ImportState", func(t *testing.T) { testImportState(t, driver, q) })
+	t.Run("Status", func(t *testing.T) { testStatus(t, driver, q) })
 }
 
 // testdataQueries are named DB testdataQueries to use in the tests.
@@ -223,7 +236,7 @@ func generateMigrationFiles(t *testing.T, pathToTestDir string, stubs []testDriv
 		}
 
 		fwd, rev := stub.indirectives.forward, stub.indirectives.reverse
-		params, err := internal.NewMigrationParams(strconv.Itoa(i), reversible, pathToTestDir, fwd.Label, rev.Label, ".sql")
+		params, err := internal.NewMigrationParams(strconv.Itoa(i), reversible, pathToTestDir, fwd.Label, rev.Label, ".sql", nil, nil)
 		if err != nil {
 			t.Fatalf("error in generateMigrationFiles, stubs[%d] failure from NewMigrationParams: %v", i, err)
 		}
@@ -301,9 +314,9 @@ func collectAppliedMigrations(t *testing.T, driver driver.Driver, migrationsTabl
 
 	for appliedVersions.Next() {
 		// pass in the same types to Scan that are passed in the library's scanAppliedVersions function
-		var version, label string
-		var executedAt int64
-		if err = appliedVersions.Scan(&version, &label, &executedAt); err != nil {
+		var version, label, checksum string
+		var executedAt, batch int64
+		if err = appliedVersions.Scan(&version, &label, &executedAt, &checksum, &batch); err != nil {
 			t.Fatalf("could not scan applied versions; %v", err)
 		}
 
@@ -312,6 +325,8 @@ func collectAppliedMigrations(t *testing.T, driver driver.Driver, migrationsTabl
 			Label:       label,
 			Version:     formattedTime(version),
 			ExecutedAt:  time.Unix(executedAt, 0),
+			Checksum:    checksum,
+			Batch:       batch,
 		})
 	}
 
@@ -345,6 +360,12 @@ func testAppliedMigrations(t *testing.T, actual []internal.Migration, expectedVe
 		if act.ExecutedAt.IsZero() {
 			t.Errorf("index %d; executed_at for migration %q should be non-empty", i, version)
 		}
+		if act.Checksum == "" {
+			t.Errorf("index %d; checksum for migration %q should be non-empty", i, version)
+		}
+		if act.Batch < 1 {
+			t.Errorf("index %d; batch for migration %q should be positive", i, version)
+		}
 	}
 }
 
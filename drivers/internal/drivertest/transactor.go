@@ -0,0 +1,69 @@
+package drivertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func testTransactor(t *testing.T, d driver.Driver, queries testdataQueries) {
+	transactor, ok := d.(driver.Transactor)
+	if !ok {
+		t.Skipf("driver %s is not a driver.Transactor", d.Name())
+	}
+	const migrationsTable = internal.DefaultMigrationsTableName
+
+	// executeAndRecord does the same work as a migration within fn.
+	executeAndRecord := func(ctx context.Context, tx driver.Driver) error {
+		if err := tx.Execute(ctx, queries.CreateFoos.Forward); err != nil {
+			return err
+		}
+		if err := tx.CreateSchemaMigrationsTable(ctx, migrationsTable); err != nil {
+			return err
+		}
+		recorder, ok := tx.(driver.MetadataRecorder)
+		if !ok {
+			return fmt.Errorf("expected %T to be a driver.MetadataRecorder", tx)
+		}
+		return recorder.RecordMigration(ctx, migrationsTable, "1234", "alpha", driver.Metadata{Checksum: "abc123", Batch: 1})
+	}
+
+	t.Run("commits when fn succeeds", func(t *testing.T) {
+		err := transactor.WithinTransaction(t.Context(), executeAndRecord)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer teardown(t, d, "", migrationsTable, "foos")
+
+		appliedVersions := collectAppliedMigrations(t, d, migrationsTable)
+		testAppliedMigrations(t, appliedVersions, []string{"1234"})
+	})
+
+	t.Run("rolls back when fn fails", func(t *testing.T) {
+		oof := errors.New("oof")
+		err := transactor.WithinTransaction(t.Context(), func(ctx context.Context, tx driver.Driver) error {
+			if err := executeAndRecord(ctx, tx); err != nil {
+				return err
+			}
+			return oof
+		})
+		if !errors.Is(err, oof) {
+			t.Fatalf("expected error (%v) to be %v", err, oof)
+		}
+
+		appliedVersions := collectAppliedMigrations(t, d, migrationsTable)
+		testAppliedMigrations(t, appliedVersions, []string{})
+
+		// If the table was not rolled back, then creating it again would fail.
+		if err = d.Execute(t.Context(), queries.CreateFoos.Forward); err != nil {
+			t.Fatalf("expected table to not exist after rollback; %v", err)
+		}
+		if err = d.Execute(t.Context(), queries.CreateFoos.Reverse); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
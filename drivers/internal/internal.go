@@ -68,3 +68,10 @@ type invalidDataError struct{}
 func (e invalidDataError) Error() string { return "data invalid" }
 
 func (e invalidDataError) Invalid() bool { return true }
+
+// QuoteLiteral wraps s in single quotes, doubling any single quotes within, so
+// it can be inlined into a SQL statement as a string literal. It is meant for
+// [Driver] implementations that render statements rather than execute them.
+func QuoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
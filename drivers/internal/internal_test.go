@@ -101,3 +101,18 @@ func TestCleanNamespacedIdentifier(t *testing.T) {
 		})
 	}
 }
+
+func TestQuoteLiteral(t *testing.T) {
+	tests := []struct{ input, expected string }{
+		{input: "", expected: `''`},
+		{input: "alpha", expected: `'alpha'`},
+		{input: "it's", expected: `'it''s'`},
+		{input: "'; DROP TABLE foos; --", expected: `'''; DROP TABLE foos; --'`},
+	}
+
+	for _, test := range tests {
+		if got := internal.QuoteLiteral(test.input); got != test.expected {
+			t.Errorf("wrong output for input %q; got %q, expected %q", test.input, got, test.expected)
+		}
+	}
+}
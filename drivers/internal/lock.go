@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+)
+
+// LockKey derives a numeric key from a DB identifier, such as the name of the
+// schema migrations table. It is meant for [Driver] implementations whose
+// locking primitives are keyed by integers rather than by names.
+func LockKey(identifier string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(identifier)) // a hash.Hash never returns an error here.
+
+	// #nosec G115 -- the key only needs to be stable, not positive.
+	return int64(h.Sum64())
+}
+
+// PollLock calls tryLock until it reports that the lock was acquired, until it
+// returns an error, or until ctx is done. It waits for interval in between
+// attempts. It is meant for [Driver] implementations whose database does not
+// have a blocking lock primitive.
+func PollLock(ctx context.Context, interval time.Duration, tryLock func(ctx context.Context) (bool, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		acquired, err := tryLock(ctx)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
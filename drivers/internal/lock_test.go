@@ -0,0 +1,59 @@
+package internal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rafaelespinoza/godfish/drivers/internal"
+)
+
+func TestLockKey(t *testing.T) {
+	a, b := internal.LockKey(`"schema_migrations"`), internal.LockKey(`"schema_migrations"`)
+	if a != b {
+		t.Errorf("expected same input to produce same key; got %d and %d", a, b)
+	}
+	if c := internal.LockKey(`"custom"`); c == a {
+		t.Errorf("expected different input to produce different key; got %d for both", c)
+	}
+}
+
+func TestPollLock(t *testing.T) {
+	const interval = time.Millisecond
+
+	t.Run("acquired after some attempts", func(t *testing.T) {
+		var attempts int
+		err := internal.PollLock(t.Context(), interval, func(context.Context) (bool, error) {
+			attempts++
+			return attempts == 3, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attempts != 3 {
+			t.Errorf("wrong number of attempts; got %d, expected %d", attempts, 3)
+		}
+	})
+
+	t.Run("error from tryLock", func(t *testing.T) {
+		oof := errors.New("oof")
+		err := internal.PollLock(t.Context(), interval, func(context.Context) (bool, error) {
+			return false, oof
+		})
+		if !errors.Is(err, oof) {
+			t.Errorf("expected error (%v) to be %v", err, oof)
+		}
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 10*interval)
+		defer cancel()
+		err := internal.PollLock(ctx, interval, func(context.Context) (bool, error) {
+			return false, nil
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected error (%v) to be %v", err, context.DeadlineExceeded)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package internal
+
+import "github.com/rafaelespinoza/godfish/driver"
+
+// MetadataColumns are the columns of the schema migrations table, besides
+// migration_id, which were added in later versions of godfish.
+var MetadataColumns = []string{"label", "executed_at", "checksum", "batch"}
+
+// SchemaMigrationsMetadata is the shape of the schema migrations table. It is
+// meant for [Driver] implementations, which fill it in by inspecting the table,
+// to tell whether or not the table needs an upgrade, and which columns to read
+// and write in the meantime.
+type SchemaMigrationsMetadata struct {
+	HasTable         bool
+	HasColLabel      bool
+	HasColExecutedAt bool
+	HasColChecksum   bool
+	HasColBatch      bool
+}
+
+// SetColumn marks the column, name, as present. A name that is not one of the
+// [MetadataColumns] is ignored.
+func (m *SchemaMigrationsMetadata) SetColumn(name string) {
+	switch name {
+	case "label":
+		m.HasColLabel = true
+	case "executed_at":
+		m.HasColExecutedAt = true
+	case "checksum":
+		m.HasColChecksum = true
+	case "batch":
+		m.HasColBatch = true
+	}
+}
+
+// ColumnDefinitions are the database-specific definitions of the
+// [MetadataColumns], such as the clause to add each one to a table.
+type ColumnDefinitions struct {
+	Label, ExecutedAt, Checksum, Batch string
+}
+
+// MissingColumns returns, in order, the definitions in defs of each column
+// that the schema migrations table does not have yet.
+func (m SchemaMigrationsMetadata) MissingColumns(defs ColumnDefinitions) []string {
+	out := make([]string, 0, len(MetadataColumns))
+	if !m.HasColLabel {
+		out = append(out, defs.Label)
+	}
+	if !m.HasColExecutedAt {
+		out = append(out, defs.ExecutedAt)
+	}
+	if !m.HasColChecksum {
+		out = append(out, defs.Checksum)
+	}
+	if !m.HasColBatch {
+		out = append(out, defs.Batch)
+	}
+	return out
+}
+
+// SelectColumns returns the columns to select for each row of
+// [driver.AppliedVersions]. The columns of a [driver.Metadata] that the table
+// does not have yet are selected as their zero values, and so are NULLs.
+func (m SchemaMigrationsMetadata) SelectColumns() string {
+	checksum, batch := `''`, `0`
+	if m.HasColChecksum {
+		checksum = `COALESCE(checksum, '')`
+	}
+	if m.HasColBatch {
+		batch = `COALESCE(batch, 0)`
+	}
+	return `migration_id, label, executed_at, ` + checksum + `, ` + batch
+}
+
+// RecordedColumns returns the columns to insert for an applied migration, and
+// their values. The columns of meta are only included when the table has them.
+func (m SchemaMigrationsMetadata) RecordedColumns(version, label string, executedAt int64, meta driver.Metadata) ([]string, []any) {
+	columns := []string{"migration_id", "label", "executed_at"}
+	args := []any{version, label, executedAt}
+	if m.HasColChecksum {
+		columns = append(columns, "checksum")
+		args = append(args, meta.Checksum)
+	}
+	if m.HasColBatch {
+		columns = append(columns, "batch")
+		args = append(args, meta.Batch)
+	}
+	return columns, args
+}
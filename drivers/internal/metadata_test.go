@@ -0,0 +1,56 @@
+package internal_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/drivers/internal"
+)
+
+func TestSchemaMigrationsMetadata(t *testing.T) {
+	defs := internal.ColumnDefinitions{Label: "l", ExecutedAt: "e", Checksum: "c", Batch: "b"}
+	meta := driver.Metadata{Checksum: "abc123", Batch: 2}
+
+	t.Run("not upgraded", func(t *testing.T) {
+		m := internal.SchemaMigrationsMetadata{HasTable: true}
+		for _, name := range []string{"label", "executed_at", "migration_id"} {
+			m.SetColumn(name)
+		}
+
+		if got, exp := m.MissingColumns(defs), []string{"c", "b"}; !slices.Equal(got, exp) {
+			t.Errorf("wrong missing columns; got %q, expected %q", got, exp)
+		}
+		if got, exp := m.SelectColumns(), `migration_id, label, executed_at, '', 0`; got != exp {
+			t.Errorf("wrong select columns; got %q, expected %q", got, exp)
+		}
+		columns, args := m.RecordedColumns("1234", "alpha", 1, meta)
+		if exp := []string{"migration_id", "label", "executed_at"}; !slices.Equal(columns, exp) {
+			t.Errorf("wrong recorded columns; got %q, expected %q", columns, exp)
+		}
+		if exp := []any{"1234", "alpha", int64(1)}; !slices.Equal(args, exp) {
+			t.Errorf("wrong recorded args; got %v, expected %v", args, exp)
+		}
+	})
+
+	t.Run("upgraded", func(t *testing.T) {
+		m := internal.SchemaMigrationsMetadata{HasTable: true}
+		for _, name := range internal.MetadataColumns {
+			m.SetColumn(name)
+		}
+
+		if got := m.MissingColumns(defs); len(got) > 0 {
+			t.Errorf("expected no missing columns; got %q", got)
+		}
+		if got, exp := m.SelectColumns(), `migration_id, label, executed_at, COALESCE(checksum, ''), COALESCE(batch, 0)`; got != exp {
+			t.Errorf("wrong select columns; got %q, expected %q", got, exp)
+		}
+		columns, args := m.RecordedColumns("1234", "alpha", 1, meta)
+		if exp := []string{"migration_id", "label", "executed_at", "checksum", "batch"}; !slices.Equal(columns, exp) {
+			t.Errorf("wrong recorded columns; got %q, expected %q", columns, exp)
+		}
+		if exp := []any{"1234", "alpha", int64(1), "abc123", int64(2)}; !slices.Equal(args, exp) {
+			t.Errorf("wrong recorded args; got %v, expected %v", args, exp)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// A Statement is one statement of a query, for a driver that runs them one at
+// a time.
+type Statement struct {
+	Text   string
+	Offset int // byte offset of Text in the query.
+}
+
+// SplitStatements breaks up query into statements at each match of
+// delimiter. Blank statements are left out.
+func SplitStatements(query string, delimiter *regexp.Regexp) (out []Statement) {
+	var start int
+	for _, loc := range append(delimiter.FindAllStringIndex(query, -1), []int{len(query), len(query)}) {
+		if text := query[start:loc[0]]; strings.TrimSpace(text) != "" {
+			out = append(out, Statement{Text: text, Offset: start})
+		}
+		start = loc[1]
+	}
+	return
+}
+
+// OffsetOfRune converts pos, a position in characters starting at 1, such as
+// one reported by Postgres, into a byte offset of query. It's -1 when pos is
+// out of range.
+func OffsetOfRune(query string, pos int) int {
+	if pos < 1 {
+		return -1
+	}
+	var n int
+	for offset := range query {
+		if n++; n == pos {
+			return offset
+		}
+	}
+	return -1
+}
+
+// OffsetOfLine converts a line and a column, both starting at 1, into a byte
+// offset of query. The column is in characters. When column is 0, then it's
+// the first non-blank character of the line. It's -1 when line is out of
+// range. A column past the end of the line is the end of the line.
+func OffsetOfLine(query string, line, column int) int {
+	if line < 1 || column < 0 {
+		return -1
+	}
+	var start int
+	for range line - 1 {
+		ind := strings.IndexByte(query[start:], '\n')
+		if ind < 0 {
+			return -1
+		}
+		start += ind + 1
+	}
+	text := query[start:]
+	if end := strings.IndexByte(text, '\n'); end >= 0 {
+		text = text[:end]
+	}
+
+	if column == 0 {
+		if ind := strings.IndexFunc(text, func(r rune) bool { return !unicode.IsSpace(r) }); ind >= 0 {
+			return start + ind
+		}
+		return start
+	}
+	var n int
+	for offset := range text {
+		if n++; n == column {
+			return start + offset
+		}
+	}
+	return start + len(text)
+}
@@ -0,0 +1,63 @@
+package internal_test
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/rafaelespinoza/godfish/drivers/internal"
+)
+
+func TestSplitStatements(t *testing.T) {
+	delimiter := regexp.MustCompile(`;\s*\n`)
+	got := internal.SplitStatements("CREATE TABLE foos (id int);\n\n;\nSELECT 1;  \nSELECT 2", delimiter)
+	exp := []internal.Statement{
+		{Text: "CREATE TABLE foos (id int)", Offset: 0},
+		{Text: "SELECT 1", Offset: 31},
+		{Text: "SELECT 2", Offset: 43},
+	}
+	if !slices.Equal(got, exp) {
+		t.Errorf("wrong statements\ngot:      %q\nexpected: %q", got, exp)
+	}
+}
+
+func TestOffsetOfRune(t *testing.T) {
+	const query = "SELECT 'é';\nSELEC 1;"
+	tests := []struct {
+		pos, exp int
+	}{
+		{pos: 1, exp: 0},
+		{pos: 10, exp: 10},
+		{pos: 11, exp: 11},
+		{pos: 13, exp: 13},
+		{pos: 0, exp: -1},
+		{pos: 100, exp: -1},
+	}
+	for _, test := range tests {
+		if got := internal.OffsetOfRune(query, test.pos); got != test.exp {
+			t.Errorf("pos %d; got %d, expected %d", test.pos, got, test.exp)
+		}
+	}
+}
+
+func TestOffsetOfLine(t *testing.T) {
+	const query = "CREATE TABLE foos (id int);\n\n  SELEC 'é', 1;\n"
+	tests := []struct {
+		line, column, exp int
+	}{
+		{line: 1, column: 1, exp: 0},
+		{line: 1, column: 8, exp: 7},
+		{line: 2, column: 0, exp: 28},
+		{line: 3, column: 0, exp: 31},
+		{line: 3, column: 12, exp: 41},
+		{line: 3, column: 100, exp: 45},
+		{line: 4, column: 0, exp: 46},
+		{line: 5, column: 0, exp: -1},
+		{line: 0, column: 1, exp: -1},
+	}
+	for _, test := range tests {
+		if got := internal.OffsetOfLine(query, test.line, test.column); got != test.exp {
+			t.Errorf("line %d, column %d; got %d, expected %d", test.line, test.column, got, test.exp)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Execer is the subset of methods shared by a *sql.DB and a *sql.Tx that
+// [Driver] implementations need to make changes, and to inspect the schema
+// migrations table before making them. It lets the same code path run with or
+// without a transaction.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// WithinTransaction begins a transaction on db and calls fn with it. When fn
+// returns nil, the transaction is committed. Otherwise, it's rolled back and
+// the error from fn is returned, along with any error from the rollback. It is
+// meant for [Driver] implementations that also implement a Transactor.
+func WithinTransaction(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction; %w", err)
+	}
+
+	if ferr := fn(tx); ferr != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			return fmt.Errorf("%w; rolling back transaction also failed; %w", ferr, rerr)
+		}
+		return ferr
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction; %w", err)
+	}
+	return nil
+}
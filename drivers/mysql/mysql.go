@@ -4,16 +4,19 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/rafaelespinoza/godfish/driver"
 	"github.com/rafaelespinoza/godfish/drivers/internal"
 
-	_ "github.com/go-sql-driver/mysql" // register driver with database/sql
+	"github.com/go-sql-driver/mysql"
 )
 
 const msgPrefix = "mysql: "
@@ -25,8 +28,12 @@ const SampleDSN = `username:password@tcp(server_host)/db_name?param1=value&param
 func NewDriver() *Driver { return &Driver{} }
 
 // Driver implements the [driver.Driver] interface for mysql databases.
+// It does not implement [driver.Transactor] because DDL statements in mysql
+// cause an implicit commit, so a migration could not be rolled back anyway.
 type Driver struct {
 	connection *sql.DB
+	// lockConn holds the session that owns the named lock, see [Driver.Lock].
+	lockConn *sql.Conn
 }
 
 func (d *Driver) Name() string { return "mysql" }
@@ -48,17 +55,84 @@ func (d *Driver) Close() (err error) {
 		return
 	}
 	d.connection = nil
+	if lockConn := d.lockConn; lockConn != nil {
+		d.lockConn = nil
+		_ = lockConn.Close()
+	}
 	err = conn.Close()
 	return
 }
 
+// Lock acquires a named lock with GET_LOCK. The name is derived from
+// migrationsTable. Named locks belong to the session that acquired them, so a
+// dedicated connection is held until [Driver.Unlock]. If ctx has a deadline,
+// then it's used as the GET_LOCK timeout, otherwise it waits indefinitely.
+func (d *Driver) Lock(ctx context.Context, migrationsTable string) (err error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return
+	}
+	if d.lockConn != nil {
+		return errors.New(msgPrefix + "lock is already held")
+	}
+
+	timeoutSeconds := -1
+	if deadline, ok := ctx.Deadline(); ok {
+		timeoutSeconds = int(math.Ceil(time.Until(deadline).Seconds()))
+		timeoutSeconds = max(timeoutSeconds, 0)
+	}
+
+	conn, err := d.connection.Conn(ctx)
+	if err != nil {
+		return
+	}
+	var result sql.NullInt64
+	err = conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, lockName(cleanedTableName), timeoutSeconds).Scan(&result)
+	if err == nil && (!result.Valid || result.Int64 != 1) {
+		err = fmt.Errorf("timed out or got an error, result: %v; %w", result, context.DeadlineExceeded)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf(msgPrefix+"acquiring named lock; %w", err)
+	}
+	d.lockConn = conn
+	return
+}
+
+// Unlock releases the named lock acquired by [Driver.Lock].
+func (d *Driver) Unlock(ctx context.Context, migrationsTable string) (err error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return
+	}
+	conn := d.lockConn
+	if conn == nil {
+		return errors.New(msgPrefix + "lock is not held")
+	}
+	d.lockConn = nil
+
+	_, err = conn.ExecContext(ctx, `SELECT RELEASE_LOCK(?)`, lockName(cleanedTableName))
+	if err != nil {
+		err = fmt.Errorf(msgPrefix+"releasing named lock; %w", err)
+	}
+	return errors.Join(err, conn.Close())
+}
+
+// lockName formats a name for GET_LOCK, which must not exceed 64 characters.
+func lockName(cleanedTableName string) string {
+	return fmt.Sprintf("godfish_%x", internal.LockKey(cleanedTableName))
+}
+
+// RawConn returns the connection, a *sql.DB. See [driver.RawConnector].
+func (d *Driver) RawConn() any { return d.connection }
+
 var statementDelimiter = regexp.MustCompile(`;\s*\n`)
 
 func (d *Driver) Execute(ctx context.Context, query string, args ...any) (err error) {
 	// Attempt to support migrations with 1 or more statements. AFAIK, the
 	// standard library does not support executing multiple statements at once.
 	// As a workaround, break them up and apply them.
-	statements := statementDelimiter.Split(query, -1)
+	statements := internal.SplitStatements(query, statementDelimiter)
 	if len(statements) < 1 {
 		return
 	}
@@ -66,12 +140,10 @@ func (d *Driver) Execute(ctx context.Context, query string, args ...any) (err er
 	if err != nil {
 		return
 	}
-	for _, q := range statements {
-		if len(strings.TrimSpace(q)) < 1 {
-			continue
-		}
-		_, err = tx.ExecContext(ctx, q)
+	for i, stmt := range statements {
+		_, err = tx.ExecContext(ctx, stmt.Text)
 		if err != nil {
+			err = &driver.StatementError{Statement: i, Offset: stmt.Offset, Position: errorPosition(stmt, err), Err: err}
 			if rerr := tx.Rollback(); rerr != nil {
 				return fmt.Errorf("%w; %v", err, rerr)
 			}
@@ -81,20 +153,67 @@ func (d *Driver) Execute(ctx context.Context, query string, args ...any) (err er
 	return tx.Commit()
 }
 
+// nearText matches the end of the message of a syntax error, such as:
+// "... for the right syntax to use near 'SELEC 1' at line 1". The text is
+// where the error is, but it may be cut short.
+var nearText = regexp.MustCompile(`(?s)near '(.*)' at line (\d+)$`)
+
+// errorPosition finds the byte offset of a syntax error in the query that
+// stmt is from. It's -1 for other errors.
+func errorPosition(stmt internal.Statement, err error) int {
+	var merr *mysql.MySQLError
+	if !errors.As(err, &merr) {
+		return -1
+	}
+	match := nearText.FindStringSubmatch(merr.Message)
+	if match == nil {
+		return -1
+	}
+	if ind := strings.Index(stmt.Text, match[1]); match[1] != "" && ind >= 0 {
+		return stmt.Offset + ind
+	}
+	line, err := strconv.Atoi(match[2])
+	if err != nil {
+		return -1
+	}
+	if ind := internal.OffsetOfLine(stmt.Text, line, 0); ind >= 0 {
+		return stmt.Offset + ind
+	}
+	return -1
+}
+
 func (d *Driver) CreateSchemaMigrationsTable(ctx context.Context, migrationsTable string) (err error) {
 	cleanedTableName, err := cleanIdentifier(migrationsTable)
 	if err != nil {
 		return
 	}
 
+	q := createSchemaMigrationsTableQuery(cleanedTableName)
+	_, err = d.connection.ExecContext(ctx, q)
+	return
+}
+
+// CreateSchemaMigrationsTableScript returns the statement that
+// [Driver.CreateSchemaMigrationsTable] would execute. See [driver.Scripter].
+func (d *Driver) CreateSchemaMigrationsTableScript(migrationsTable string) (string, error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return "", err
+	}
+	return createSchemaMigrationsTableQuery(cleanedTableName), nil
+}
+
+// createSchemaMigrationsTableQuery is the statement to create the schema
+// migrations table.
+func createSchemaMigrationsTableQuery(cleanedTableName string) string {
 	// #nosec G202 -- table name was sanitized
-	q := `CREATE TABLE IF NOT EXISTS ` + cleanedTableName + ` (
+	return `CREATE TABLE IF NOT EXISTS ` + cleanedTableName + ` (
 	migration_id VARCHAR(128) PRIMARY KEY NOT NULL,
 	label VARCHAR(255) DEFAULT '',
-	executed_at BIGINT DEFAULT 0
+	executed_at BIGINT DEFAULT 0,
+	checksum VARCHAR(64) DEFAULT '',
+	batch BIGINT DEFAULT 0
 )`
-	_, err = d.connection.ExecContext(ctx, q)
-	return
 }
 
 func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (out driver.AppliedVersions, err error) {
@@ -106,16 +225,16 @@ func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (o
 	metadata, err := checkSchemaMigrationMetadata(ctx, d, cleanedTableName)
 	if err != nil {
 		return
-	} else if !metadata.hasTable {
+	} else if !metadata.HasTable {
 		err = driver.ErrSchemaMigrationsDoesNotExist
 		return
-	} else if !metadata.hasColLabel || !metadata.hasColExecutedAt {
+	} else if !metadata.HasColLabel || !metadata.HasColExecutedAt {
 		err = driver.ErrSchemaMigrationsMissingColumns
 		return
 	}
 
 	// #nosec G202 -- table name was sanitized
-	q := `SELECT migration_id, label, executed_at FROM ` + cleanedTableName + ` ORDER BY migration_id ASC`
+	q := `SELECT ` + metadata.SelectColumns() + ` FROM ` + cleanedTableName + ` ORDER BY migration_id ASC`
 	rows, err := d.connection.QueryContext(ctx, q)
 	out = driver.AppliedVersions(rows)
 	return
@@ -142,6 +261,51 @@ func (d *Driver) UpdateSchemaMigrations(ctx context.Context, migrationsTable str
 	return
 }
 
+// RecordMigration is like [Driver.UpdateSchemaMigrations], but it also
+// records meta in the columns that the schema migrations table has. See
+// [driver.MetadataRecorder].
+func (d *Driver) RecordMigration(ctx context.Context, migrationsTable, version, label string, meta driver.Metadata) (err error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return
+	}
+
+	metadata, err := checkSchemaMigrationMetadata(ctx, d, cleanedTableName)
+	if err != nil {
+		return
+	}
+	now := time.Now().UTC()
+	columns, args := metadata.RecordedColumns(version, label, now.Unix(), meta)
+	placeholders := strings.Repeat(", ?", len(args))[2:]
+
+	// #nosec G202 -- table name was sanitized
+	q := `INSERT INTO ` + cleanedTableName + ` (` + strings.Join(columns, ", ") + `) VALUES (` + placeholders + `)`
+	_, err = d.connection.ExecContext(ctx, q, args...)
+	return
+}
+
+// UpdateSchemaMigrationsScript returns the statement that
+// [Driver.UpdateSchemaMigrations] or [Driver.RecordMigration] would execute on
+// an upgraded schema migrations table. See [driver.Scripter].
+func (d *Driver) UpdateSchemaMigrationsScript(migrationsTable string, forward bool, version, label, checksum string, batch int64) (string, error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return "", err
+	}
+
+	if !forward {
+		// #nosec G202 -- table name was sanitized, values are quoted
+		return `DELETE FROM ` + cleanedTableName + ` WHERE migration_id = ` + quoteLiteral(version), nil
+	}
+
+	now := time.Now().UTC()
+	// #nosec G201 -- table name was sanitized, values are quoted
+	return fmt.Sprintf(
+		`INSERT INTO %s (migration_id, label, executed_at, checksum, batch) VALUES (%s, %s, %d, %s, %d)`,
+		cleanedTableName, quoteLiteral(version), quoteLiteral(label), now.Unix(), quoteLiteral(checksum), batch,
+	), nil
+}
+
 func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable string) error {
 	cleanedTableName, err := cleanIdentifier(migrationsTable)
 	if err != nil {
@@ -149,10 +313,24 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 	}
 	const errMsgPrefix = msgPrefix + "upgrading schema migrations table"
 
+	metadata, err := checkSchemaMigrationMetadata(ctx, d, cleanedTableName)
+	if err != nil {
+		return fmt.Errorf(errMsgPrefix+", checking metadata; %w", err)
+	} else if !metadata.HasTable {
+		return driver.ErrSchemaMigrationsDoesNotExist
+	}
+	columns := metadata.MissingColumns(internal.ColumnDefinitions{
+		Label:      `ADD COLUMN label VARCHAR(255) DEFAULT ''`,
+		ExecutedAt: `ADD COLUMN executed_at BIGINT DEFAULT 0`,
+		Checksum:   `ADD COLUMN checksum VARCHAR(64) DEFAULT ''`,
+		Batch:      `ADD COLUMN batch BIGINT DEFAULT 0`,
+	})
+	if len(columns) < 1 {
+		return nil
+	}
+
 	// #nosec G202 -- table name was sanitized
-	q := `ALTER TABLE ` + cleanedTableName + `
-	ADD COLUMN label VARCHAR(255) DEFAULT '',
-	ADD COLUMN executed_at BIGINT DEFAULT 0`
+	q := `ALTER TABLE ` + cleanedTableName + "\n\t" + strings.Join(columns, ",\n\t")
 
 	if _, err = d.connection.ExecContext(ctx, q); err != nil {
 		err = fmt.Errorf(errMsgPrefix+", exec failed; %w", err)
@@ -161,30 +339,24 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 	return err
 }
 
-type metadataResult struct {
-	hasTable         bool
-	hasColLabel      bool
-	hasColExecutedAt bool
-}
-
-func checkSchemaMigrationMetadata(ctx context.Context, d *Driver, tableName string) (out metadataResult, err error) {
+func checkSchemaMigrationMetadata(ctx context.Context, d *Driver, tableName string) (out internal.SchemaMigrationsMetadata, err error) {
 	// Expect for the input tableName to have been treated by cleanIdentifier.
 	// It doesn't need to be quoted in this case because it's used as a regular
 	// query parameter in this function.
 	tableName = strings.ReplaceAll(tableName, quote, "")
 
-	lgr := slog.With("driver", d.Name(), slog.String("table_name", tableName))
+	lgr := driver.Logger(ctx).With("driver", d.Name(), slog.String("table_name", tableName))
 
 	const query = `
 SELECT t.table_name, c.column_name
 FROM information_schema.tables t LEFT JOIN information_schema.columns c
 	ON  t.table_schema = c.table_schema
 	AND t.table_name = c.table_name
-	AND c.column_name IN (?, ?)
+	AND c.column_name IN (?, ?, ?, ?)
 WHERE t.table_schema = DATABASE()
 	AND t.table_name = ?
 `
-	args := []any{"label", "executed_at", tableName}
+	args := []any{"label", "executed_at", "checksum", "batch", tableName}
 	lgr.Debug(
 		msgPrefix+"checking for table, column existence",
 		slog.String("query", query), slog.Any("args", args),
@@ -200,15 +372,10 @@ WHERE t.table_schema = DATABASE()
 			return
 		}
 
-		out.hasTable = table.Valid
+		out.HasTable = table.Valid
 
 		if column.Valid {
-			switch column.String {
-			case "label":
-				out.hasColLabel = true
-			case "executed_at":
-				out.hasColExecutedAt = true
-			}
+			out.SetColumn(column.String)
 		}
 	}
 
@@ -217,6 +384,12 @@ WHERE t.table_schema = DATABASE()
 
 const quote = "`"
 
+// quoteLiteral is like [internal.QuoteLiteral], but also escapes backslashes,
+// which mysql treats as an escape character within string literals by default.
+func quoteLiteral(s string) string {
+	return internal.QuoteLiteral(strings.ReplaceAll(s, `\`, `\\`))
+}
+
 func quotePart(part string) string { return quote + part + quote }
 
 func cleanIdentifier(input string) (string, error) {
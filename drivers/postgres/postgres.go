@@ -4,8 +4,10 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,6 +28,10 @@ func NewDriver() *Driver { return &Driver{} }
 // Driver implements the [driver.Driver] interface for postgres databases.
 type Driver struct {
 	connection *sql.DB
+	// lockConn holds the session that owns the advisory lock, see [Driver.Lock].
+	lockConn *sql.Conn
+	// tx is set on the Driver passed along by [Driver.WithinTransaction].
+	tx *sql.Tx
 }
 
 func (d *Driver) Name() string { return "postgres" }
@@ -47,12 +53,99 @@ func (d *Driver) Close() (err error) {
 		return
 	}
 	d.connection = nil
+	if lockConn := d.lockConn; lockConn != nil {
+		d.lockConn = nil
+		_ = lockConn.Close()
+	}
 	err = conn.Close()
 	return
 }
 
+// Lock acquires a session-level advisory lock with pg_advisory_lock. The lock
+// key is derived from migrationsTable. Advisory locks belong to the session
+// that acquired them, so a dedicated connection is held until [Driver.Unlock].
+func (d *Driver) Lock(ctx context.Context, migrationsTable string) (err error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return
+	}
+	if d.lockConn != nil {
+		return errors.New(msgPrefix + "lock is already held")
+	}
+
+	conn, err := d.connection.Conn(ctx)
+	if err != nil {
+		return
+	}
+	if _, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, internal.LockKey(cleanedTableName)); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf(msgPrefix+"acquiring advisory lock; %w", err)
+	}
+	d.lockConn = conn
+	return
+}
+
+// Unlock releases the advisory lock acquired by [Driver.Lock].
+func (d *Driver) Unlock(ctx context.Context, migrationsTable string) (err error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return
+	}
+	conn := d.lockConn
+	if conn == nil {
+		return errors.New(msgPrefix + "lock is not held")
+	}
+	d.lockConn = nil
+
+	_, err = conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, internal.LockKey(cleanedTableName))
+	if err != nil {
+		err = fmt.Errorf(msgPrefix+"releasing advisory lock; %w", err)
+	}
+	return errors.Join(err, conn.Close())
+}
+
+// WithinTransaction runs fn with a copy of the Driver whose changes are made
+// within one transaction. See [driver.Transactor].
+func (d *Driver) WithinTransaction(ctx context.Context, fn func(ctx context.Context, tx driver.Driver) error) error {
+	if d.tx != nil {
+		return errors.New(msgPrefix + "transaction already in progress")
+	}
+	err := internal.WithinTransaction(ctx, d.connection, func(tx *sql.Tx) error {
+		return fn(ctx, &Driver{connection: d.connection, tx: tx})
+	})
+	if err != nil {
+		err = fmt.Errorf(msgPrefix+"%w", err)
+	}
+	return err
+}
+
+// execer returns the transaction in progress, if there is one. Otherwise it
+// returns the connection.
+func (d *Driver) execer() internal.Execer {
+	if d.tx != nil {
+		return d.tx
+	}
+	return d.connection
+}
+
+// RawConn returns the transaction in progress, a *sql.Tx, if there is one.
+// Otherwise it returns the connection, a *sql.DB. See [driver.RawConnector].
+func (d *Driver) RawConn() any {
+	if d.tx != nil {
+		return d.tx
+	}
+	return d.connection
+}
+
 func (d *Driver) Execute(ctx context.Context, query string, args ...any) (err error) {
-	_, err = d.connection.ExecContext(ctx, query)
+	_, err = d.execer().ExecContext(ctx, query)
+	var perr *pq.Error
+	if errors.As(err, &perr) && perr.Position != "" {
+		// The position is in characters, starting at 1.
+		if pos, aerr := strconv.Atoi(perr.Position); aerr == nil {
+			err = &driver.StatementError{Position: internal.OffsetOfRune(query, pos), Err: err}
+		}
+	}
 	return
 }
 
@@ -62,14 +155,32 @@ func (d *Driver) CreateSchemaMigrationsTable(ctx context.Context, migrationsTabl
 		return
 	}
 
+	q := createSchemaMigrationsTableQuery(cleanedTableName)
+	_, err = d.execer().ExecContext(ctx, q)
+	return
+}
+
+// CreateSchemaMigrationsTableScript returns the statement that
+// [Driver.CreateSchemaMigrationsTable] would execute. See [driver.Scripter].
+func (d *Driver) CreateSchemaMigrationsTableScript(migrationsTable string) (string, error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return "", err
+	}
+	return createSchemaMigrationsTableQuery(cleanedTableName), nil
+}
+
+// createSchemaMigrationsTableQuery is the statement to create the schema
+// migrations table.
+func createSchemaMigrationsTableQuery(cleanedTableName string) string {
 	// #nosec G202 -- table name was sanitized
-	q := `CREATE TABLE IF NOT EXISTS ` + cleanedTableName + ` (
+	return `CREATE TABLE IF NOT EXISTS ` + cleanedTableName + ` (
 	migration_id VARCHAR(128) PRIMARY KEY NOT NULL,
 	label VARCHAR(255) DEFAULT '',
-	executed_at BIGINT DEFAULT 0
+	executed_at BIGINT DEFAULT 0,
+	checksum VARCHAR(64) DEFAULT '',
+	batch BIGINT DEFAULT 0
 )`
-	_, err = d.connection.ExecContext(ctx, q)
-	return
 }
 
 func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (out driver.AppliedVersions, err error) {
@@ -81,16 +192,16 @@ func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (o
 	metadata, err := checkSchemaMigrationMetadata(ctx, d, cleanedTableName)
 	if err != nil {
 		return
-	} else if !metadata.hasTable {
+	} else if !metadata.HasTable {
 		err = driver.ErrSchemaMigrationsDoesNotExist
 		return
-	} else if !metadata.hasColLabel || !metadata.hasColExecutedAt {
+	} else if !metadata.HasColLabel || !metadata.HasColExecutedAt {
 		err = driver.ErrSchemaMigrationsMissingColumns
 		return
 	}
 
 	// #nosec G202 -- table name was sanitized
-	q := `SELECT migration_id, label, executed_at FROM ` + cleanedTableName + ` ORDER BY migration_id ASC`
+	q := `SELECT ` + metadata.SelectColumns() + ` FROM ` + cleanedTableName + ` ORDER BY migration_id ASC`
 	rows, err := d.connection.QueryContext(ctx, q)
 	out = driver.AppliedVersions(rows)
 	return
@@ -102,7 +213,7 @@ func (d *Driver) UpdateSchemaMigrations(ctx context.Context, migrationsTable str
 		return
 	}
 
-	conn := d.connection
+	conn := d.execer()
 	if !forward {
 		// #nosec G202 -- table name was sanitized
 		q := `DELETE FROM ` + cleanedTableName + ` WHERE migration_id = $1 RETURNING migration_id`
@@ -117,6 +228,54 @@ func (d *Driver) UpdateSchemaMigrations(ctx context.Context, migrationsTable str
 	return
 }
 
+// RecordMigration is like [Driver.UpdateSchemaMigrations], but it also
+// records meta in the columns that the schema migrations table has. See
+// [driver.MetadataRecorder].
+func (d *Driver) RecordMigration(ctx context.Context, migrationsTable, version, label string, meta driver.Metadata) (err error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return
+	}
+
+	metadata, err := checkSchemaMigrationMetadata(ctx, d, cleanedTableName)
+	if err != nil {
+		return
+	}
+	now := time.Now().UTC()
+	columns, args := metadata.RecordedColumns(version, label, now.Unix(), meta)
+	placeholders := make([]string, len(args))
+	for i := range args {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	// #nosec G202 -- table name was sanitized
+	q := `INSERT INTO ` + cleanedTableName + ` (` + strings.Join(columns, ", ") + `) VALUES (` + strings.Join(placeholders, ", ") + `) RETURNING migration_id`
+	_, err = d.execer().ExecContext(ctx, q, args...)
+	return
+}
+
+// UpdateSchemaMigrationsScript returns the statement that
+// [Driver.UpdateSchemaMigrations] or [Driver.RecordMigration] would execute on
+// an upgraded schema migrations table. See [driver.Scripter].
+func (d *Driver) UpdateSchemaMigrationsScript(migrationsTable string, forward bool, version, label, checksum string, batch int64) (string, error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return "", err
+	}
+
+	if !forward {
+		// #nosec G202 -- table name was sanitized, values are quoted
+		return `DELETE FROM ` + cleanedTableName + ` WHERE migration_id = ` + pq.QuoteLiteral(version), nil
+	}
+
+	now := time.Now().UTC()
+	// #nosec G201 -- table name was sanitized, values are quoted
+	return fmt.Sprintf(
+		`INSERT INTO %s (migration_id, label, executed_at, checksum, batch) VALUES (%s, %s, %d, %s, %d)`,
+		cleanedTableName, pq.QuoteLiteral(version), pq.QuoteLiteral(label), now.Unix(), pq.QuoteLiteral(checksum), batch,
+	), nil
+}
+
 func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable string) error {
 	cleanedTableName, err := cleanIdentifier(migrationsTable)
 	if err != nil {
@@ -124,15 +283,29 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 	}
 	const errMsgPrefix = msgPrefix + "upgrading schema migrations table"
 
+	metadata, err := checkSchemaMigrationMetadata(ctx, d, cleanedTableName)
+	if err != nil {
+		return fmt.Errorf(errMsgPrefix+", checking metadata; %w", err)
+	} else if !metadata.HasTable {
+		return driver.ErrSchemaMigrationsDoesNotExist
+	}
+	columns := metadata.MissingColumns(internal.ColumnDefinitions{
+		Label:      `ADD COLUMN label VARCHAR(255) DEFAULT ''`,
+		ExecutedAt: `ADD COLUMN executed_at BIGINT DEFAULT 0`,
+		Checksum:   `ADD COLUMN checksum VARCHAR(64) DEFAULT ''`,
+		Batch:      `ADD COLUMN batch BIGINT DEFAULT 0`,
+	})
+	if len(columns) < 1 {
+		return nil
+	}
+
 	tx, terr := d.connection.BeginTx(ctx, nil)
 	if terr != nil {
 		return fmt.Errorf(errMsgPrefix+", beginning transaction; %w", terr)
 	}
 
 	// #nosec G202 -- table name was sanitized
-	q := `ALTER TABLE ` + cleanedTableName + `
-	ADD COLUMN label VARCHAR(255) DEFAULT '',
-	ADD COLUMN executed_at BIGINT DEFAULT 0`
+	q := `ALTER TABLE ` + cleanedTableName + "\n\t" + strings.Join(columns, ",\n\t")
 	_, xerr := tx.ExecContext(ctx, q)
 	if xerr != nil {
 		if rerr := tx.Rollback(); rerr != nil {
@@ -148,35 +321,29 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 	return cerr
 }
 
-type metadataResult struct {
-	hasTable         bool
-	hasColLabel      bool
-	hasColExecutedAt bool
-}
-
-func checkSchemaMigrationMetadata(ctx context.Context, d *Driver, tableName string) (out metadataResult, err error) {
+func checkSchemaMigrationMetadata(ctx context.Context, d *Driver, tableName string) (out internal.SchemaMigrationsMetadata, err error) {
 	// Expect for the input tableName to have been treated by cleanIdentifier.
 	// It doesn't need to be quoted in this case because it's used as a regular
 	// query parameter in this function.
 	tableName = strings.ReplaceAll(tableName, `"`, "")
 
-	lgr := slog.With("driver", d.Name(), slog.String("table_name", tableName))
+	lgr := driver.Logger(ctx).With("driver", d.Name(), slog.String("table_name", tableName))
 
 	const query = `
 SELECT t.table_name, c.column_name
 FROM information_schema.tables t LEFT JOIN information_schema.columns c
     ON  t.table_schema = c.table_schema
     AND t.table_name = c.table_name
-    AND c.column_name IN ($1, $2)
+    AND c.column_name IN ($1, $2, $3, $4)
 WHERE t.table_catalog = current_database()
-	AND t.table_name = $3
+	AND t.table_name = $5
 `
-	args := []any{"label", "executed_at", tableName}
+	args := []any{"label", "executed_at", "checksum", "batch", tableName}
 	lgr.Debug(
 		msgPrefix+"checking for table, column existence",
 		slog.String("query", query), slog.Any("args", args),
 	)
-	rows, err := d.connection.QueryContext(ctx, query, args...)
+	rows, err := d.execer().QueryContext(ctx, query, args...)
 	if err != nil {
 		return
 	}
@@ -187,15 +354,10 @@ WHERE t.table_catalog = current_database()
 			return
 		}
 
-		out.hasTable = table.Valid
+		out.HasTable = table.Valid
 
 		if column.Valid {
-			switch column.String {
-			case "label":
-				out.hasColLabel = true
-			case "executed_at":
-				out.hasColExecutedAt = true
-			}
+			out.SetColumn(column.String)
 		}
 	}
 
@@ -4,6 +4,7 @@ package sqlite3
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -12,7 +13,8 @@ import (
 	"github.com/rafaelespinoza/godfish/driver"
 	"github.com/rafaelespinoza/godfish/drivers/internal"
 
-	_ "modernc.org/sqlite" // register driver with database/sql
+	"modernc.org/sqlite" // also registers the driver with database/sql
+	sqlitelib "modernc.org/sqlite/lib"
 )
 
 const msgPrefix = "sqlite3: "
@@ -26,6 +28,10 @@ func NewDriver() *Driver { return &Driver{} }
 // Driver implements the [driver.Driver] interface for sqlite3 databases.
 type Driver struct {
 	connection *sql.DB
+	// lock is held by [Driver.Lock] until [Driver.Unlock].
+	lock *lockFile
+	// tx is set on the Driver passed along by [Driver.WithinTransaction].
+	tx *sql.Tx
 }
 
 func (d *Driver) Name() string { return "sqlite3" }
@@ -51,12 +57,140 @@ func (d *Driver) Close() (err error) {
 		return
 	}
 	d.connection = nil
+	if l := d.lock; l != nil {
+		d.lock = nil
+		_ = l.release(context.Background())
+	}
 	err = conn.Close()
 	return
 }
 
+// lockPollInterval is how long to wait in between attempts to acquire a lock.
+const lockPollInterval = 250 * time.Millisecond
+
+// lockFile is a database file, next to the main database file, which is kept
+// in a write transaction while the lock is held. Its fields are nil when the
+// main database is in memory.
+type lockFile struct {
+	db   *sql.DB
+	conn *sql.Conn
+}
+
+// Lock acquires a lock on a file next to the database file, named after both
+// of it and migrationsTable, such as "app.db-schema_migrations.lock". The lock
+// is a write transaction on that file, held on a dedicated connection until
+// [Driver.Unlock]. While another process holds the lock, it polls until the
+// lock is released or ctx is done. The operating system releases the lock of
+// a process that exits, so a crashed process does not leave it behind. An
+// in-memory database is private to the process, so there is nothing to lock.
+func (d *Driver) Lock(ctx context.Context, migrationsTable string) (err error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return
+	}
+	if d.lock != nil {
+		return errors.New(msgPrefix + "lock is already held")
+	}
+
+	var dbFile string
+	q := `SELECT file FROM pragma_database_list WHERE name = 'main'`
+	if err = d.connection.QueryRowContext(ctx, q).Scan(&dbFile); err != nil {
+		return fmt.Errorf(msgPrefix+"finding database file; %w", err)
+	}
+	if dbFile == "" {
+		d.lock = &lockFile{}
+		return
+	}
+
+	db, err := sql.Open("sqlite", dbFile+"-"+strings.ReplaceAll(cleanedTableName, quote, "")+".lock")
+	if err != nil {
+		return fmt.Errorf(msgPrefix+"opening lock file; %w", err)
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return errors.Join(fmt.Errorf(msgPrefix+"opening lock file; %w", err), db.Close())
+	}
+	err = internal.PollLock(ctx, lockPollInterval, func(ctx context.Context) (bool, error) {
+		_, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`)
+		if isBusy(err) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		return errors.Join(fmt.Errorf(msgPrefix+"acquiring lock; %w", err), conn.Close(), db.Close())
+	}
+	d.lock = &lockFile{db: db, conn: conn}
+	return
+}
+
+// Unlock releases the lock acquired by [Driver.Lock].
+func (d *Driver) Unlock(ctx context.Context, migrationsTable string) (err error) {
+	if _, err = cleanIdentifier(migrationsTable); err != nil {
+		return
+	}
+	l := d.lock
+	if l == nil {
+		return errors.New(msgPrefix + "lock is not held")
+	}
+	d.lock = nil
+
+	if err = l.release(ctx); err != nil {
+		err = fmt.Errorf(msgPrefix+"releasing lock; %w", err)
+	}
+	return
+}
+
+func (l *lockFile) release(ctx context.Context) error {
+	if l.db == nil {
+		return nil
+	}
+	_, err := l.conn.ExecContext(ctx, `ROLLBACK`)
+	return errors.Join(err, l.conn.Close(), l.db.Close())
+}
+
+// isBusy reports whether or not err means that the database file is locked by
+// another connection.
+func isBusy(err error) bool {
+	var serr *sqlite.Error
+	return errors.As(err, &serr) && serr.Code()&0xff == sqlitelib.SQLITE_BUSY
+}
+
+// WithinTransaction runs fn with a copy of the Driver whose changes are made
+// within one transaction. See [driver.Transactor].
+func (d *Driver) WithinTransaction(ctx context.Context, fn func(ctx context.Context, tx driver.Driver) error) error {
+	if d.tx != nil {
+		return errors.New(msgPrefix + "transaction already in progress")
+	}
+	err := internal.WithinTransaction(ctx, d.connection, func(tx *sql.Tx) error {
+		return fn(ctx, &Driver{connection: d.connection, tx: tx})
+	})
+	if err != nil {
+		err = fmt.Errorf(msgPrefix+"%w", err)
+	}
+	return err
+}
+
+// execer returns the transaction in progress, if there is one. Otherwise it
+// returns the connection.
+func (d *Driver) execer() internal.Execer {
+	if d.tx != nil {
+		return d.tx
+	}
+	return d.connection
+}
+
+// RawConn returns the transaction in progress, a *sql.Tx, if there is one.
+// Otherwise it returns the connection, a *sql.DB. See [driver.RawConnector].
+func (d *Driver) RawConn() any {
+	if d.tx != nil {
+		return d.tx
+	}
+	return d.connection
+}
+
 func (d *Driver) Execute(ctx context.Context, query string, args ...any) (err error) {
-	_, err = d.connection.ExecContext(ctx, query)
+	_, err = d.execer().ExecContext(ctx, query)
 	return
 }
 
@@ -66,14 +200,32 @@ func (d *Driver) CreateSchemaMigrationsTable(ctx context.Context, migrationsTabl
 		return
 	}
 
+	q := createSchemaMigrationsTableQuery(cleanedTableName)
+	_, err = d.execer().ExecContext(ctx, q)
+	return
+}
+
+// CreateSchemaMigrationsTableScript returns the statement that
+// [Driver.CreateSchemaMigrationsTable] would execute. See [driver.Scripter].
+func (d *Driver) CreateSchemaMigrationsTableScript(migrationsTable string) (string, error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return "", err
+	}
+	return createSchemaMigrationsTableQuery(cleanedTableName), nil
+}
+
+// createSchemaMigrationsTableQuery is the statement to create the schema
+// migrations table.
+func createSchemaMigrationsTableQuery(cleanedTableName string) string {
 	// #nosec G202 -- table name was sanitized
-	q := `CREATE TABLE IF NOT EXISTS ` + cleanedTableName + ` (
+	return `CREATE TABLE IF NOT EXISTS ` + cleanedTableName + ` (
 	migration_id VARCHAR(128) PRIMARY KEY NOT NULL,
 	label VARCHAR(255) DEFAULT '',
-	executed_at BIGINT DEFAULT 0
+	executed_at BIGINT DEFAULT 0,
+	checksum VARCHAR(64) DEFAULT '',
+	batch BIGINT DEFAULT 0
 )`
-	_, err = d.connection.ExecContext(ctx, q)
-	return
 }
 
 func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (out driver.AppliedVersions, err error) {
@@ -85,16 +237,16 @@ func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (o
 	metadata, err := checkSchemaMigrationMetadata(ctx, d, cleanedTableName)
 	if err != nil {
 		return
-	} else if !metadata.hasTable {
+	} else if !metadata.HasTable {
 		err = driver.ErrSchemaMigrationsDoesNotExist
 		return
-	} else if !metadata.hasColLabel || !metadata.hasColExecutedAt {
+	} else if !metadata.HasColLabel || !metadata.HasColExecutedAt {
 		err = driver.ErrSchemaMigrationsMissingColumns
 		return
 	}
 
 	// #nosec G202 -- table name was sanitized
-	q := `SELECT migration_id, label, executed_at FROM ` + cleanedTableName + ` ORDER BY migration_id ASC`
+	q := `SELECT ` + metadata.SelectColumns() + ` FROM ` + cleanedTableName + ` ORDER BY migration_id ASC`
 	rows, err := d.connection.QueryContext(ctx, q)
 	out = driver.AppliedVersions(rows)
 	return
@@ -106,7 +258,7 @@ func (d *Driver) UpdateSchemaMigrations(ctx context.Context, migrationsTable str
 		return
 	}
 
-	conn := d.connection
+	conn := d.execer()
 	if !forward {
 		// #nosec G202 -- table name was sanitized
 		q := `DELETE FROM ` + cleanedTableName + ` WHERE migration_id = $1`
@@ -121,6 +273,54 @@ func (d *Driver) UpdateSchemaMigrations(ctx context.Context, migrationsTable str
 	return
 }
 
+// RecordMigration is like [Driver.UpdateSchemaMigrations], but it also
+// records meta in the columns that the schema migrations table has. See
+// [driver.MetadataRecorder].
+func (d *Driver) RecordMigration(ctx context.Context, migrationsTable, version, label string, meta driver.Metadata) (err error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return
+	}
+
+	metadata, err := checkSchemaMigrationMetadata(ctx, d, cleanedTableName)
+	if err != nil {
+		return
+	}
+	now := time.Now().UTC()
+	columns, args := metadata.RecordedColumns(version, label, now.Unix(), meta)
+	placeholders := make([]string, len(args))
+	for i := range args {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	// #nosec G202 -- table name was sanitized
+	q := `INSERT INTO ` + cleanedTableName + ` (` + strings.Join(columns, ", ") + `) VALUES (` + strings.Join(placeholders, ", ") + `)`
+	_, err = d.execer().ExecContext(ctx, q, args...)
+	return
+}
+
+// UpdateSchemaMigrationsScript returns the statement that
+// [Driver.UpdateSchemaMigrations] or [Driver.RecordMigration] would execute on
+// an upgraded schema migrations table. See [driver.Scripter].
+func (d *Driver) UpdateSchemaMigrationsScript(migrationsTable string, forward bool, version, label, checksum string, batch int64) (string, error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return "", err
+	}
+
+	if !forward {
+		// #nosec G202 -- table name was sanitized, values are quoted
+		return `DELETE FROM ` + cleanedTableName + ` WHERE migration_id = ` + internal.QuoteLiteral(version), nil
+	}
+
+	now := time.Now().UTC()
+	// #nosec G201 -- table name was sanitized, values are quoted
+	return fmt.Sprintf(
+		`INSERT INTO %s (migration_id, label, executed_at, checksum, batch) VALUES (%s, %s, %d, %s, %d)`,
+		cleanedTableName, internal.QuoteLiteral(version), internal.QuoteLiteral(label), now.Unix(), internal.QuoteLiteral(checksum), batch,
+	), nil
+}
+
 func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable string) error {
 	cleanedTableName, err := cleanIdentifier(migrationsTable)
 	if err != nil {
@@ -128,17 +328,28 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 	}
 	const errMsgPrefix = msgPrefix + "upgrading schema migrations table"
 
+	metadata, err := checkSchemaMigrationMetadata(ctx, d, cleanedTableName)
+	if err != nil {
+		return fmt.Errorf(errMsgPrefix+", checking metadata; %w", err)
+	} else if !metadata.HasTable {
+		return driver.ErrSchemaMigrationsDoesNotExist
+	}
+	querySuffixes := metadata.MissingColumns(internal.ColumnDefinitions{
+		Label:      `ADD COLUMN label VARCHAR(255) DEFAULT ''`,
+		ExecutedAt: `ADD COLUMN executed_at BIGINT DEFAULT 0`,
+		Checksum:   `ADD COLUMN checksum VARCHAR(64) DEFAULT ''`,
+		Batch:      `ADD COLUMN batch BIGINT DEFAULT 0`,
+	})
+	if len(querySuffixes) < 1 {
+		return nil
+	}
+
 	tx, terr := d.connection.BeginTx(ctx, nil)
 	if terr != nil {
 		return fmt.Errorf(errMsgPrefix+", beginning transaction; %w", terr)
 	}
 
 	// sqlite3 can do transaction DDL, but each column must be added in its own query.
-
-	querySuffixes := []string{
-		`ADD COLUMN label VARCHAR(255) DEFAULT ''`,
-		`ADD COLUMN executed_at BIGINT DEFAULT 0`,
-	}
 	for _, qs := range querySuffixes {
 		// #nosec G202 -- table name was sanitized
 		q := `ALTER TABLE ` + cleanedTableName + ` ` + qs
@@ -158,35 +369,30 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 	return cerr
 }
 
-type metadataResult struct {
-	hasTable         bool
-	hasColLabel      bool
-	hasColExecutedAt bool
-}
-
-// checkSchemaMigrationMetadata inspects the shape of tableName to see if it has two
-// columns: label and executed_at. These results inform the tool about the need
-// to upgrade the schema migrations table.
-func checkSchemaMigrationMetadata(ctx context.Context, d *Driver, tableName string) (out metadataResult, err error) {
+// checkSchemaMigrationMetadata inspects the shape of tableName to see if it has
+// the columns: label, executed_at, checksum and batch. These results inform the
+// tool about the need to upgrade the schema migrations table, and about which
+// columns to record.
+func checkSchemaMigrationMetadata(ctx context.Context, d *Driver, tableName string) (out internal.SchemaMigrationsMetadata, err error) {
 	// Expect for the input tableName to have been treated by cleanIdentifier.
 	// It doesn't need to be quoted in this case because it's used as a regular
 	// query parameter in this function.
 	tableName = strings.ReplaceAll(tableName, quote, "")
 
-	lgr := slog.With("driver", d.Name(), slog.String("table_name", tableName))
+	lgr := driver.Logger(ctx).With("driver", d.Name(), slog.String("table_name", tableName))
 
 	const query = `
 SELECT m.name AS table_name, p.name AS column_name
 FROM sqlite_master m LEFT JOIN pragma_table_info(m.name) p
-    ON p.name IN (?, ?)
+    ON p.name IN (?, ?, ?, ?)
 WHERE m.type = 'table'
   AND m.name = ?`
-	args := []any{"label", "executed_at", tableName}
+	args := []any{"label", "executed_at", "checksum", "batch", tableName}
 	lgr.Debug(
 		msgPrefix+"checking for table, column existence",
 		slog.String("query", query), slog.Any("args", args),
 	)
-	rows, err := d.connection.QueryContext(ctx, query, args...)
+	rows, err := d.execer().QueryContext(ctx, query, args...)
 	if err != nil {
 		return
 	}
@@ -197,15 +403,10 @@ WHERE m.type = 'table'
 			return
 		}
 
-		out.hasTable = table.Valid
+		out.HasTable = table.Valid
 
 		if column.Valid {
-			switch column.String {
-			case "label":
-				out.hasColLabel = true
-			case "executed_at":
-				out.hasColExecutedAt = true
-			}
+			out.SetColumn(column.String)
 		}
 	}
 
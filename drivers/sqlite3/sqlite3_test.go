@@ -1,12 +1,64 @@
 package sqlite3_test
 
 import (
+	"context"
+	"errors"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/rafaelespinoza/godfish/drivers/internal/drivertest"
 	"github.com/rafaelespinoza/godfish/drivers/sqlite3"
+	"github.com/rafaelespinoza/godfish/internal"
 )
 
 func Test(t *testing.T) {
 	drivertest.RunDriverTests(t, sqlite3.NewDriver())
 }
+
+func TestLock(t *testing.T) {
+	dsn := os.Getenv(internal.DSNKey)
+	if dsn == "" {
+		t.Fatalf("define env var %q for these tests", internal.DSNKey)
+	}
+	const table = "lock_test_migrations"
+
+	connect := func(t *testing.T) *sqlite3.Driver {
+		t.Helper()
+		d := sqlite3.NewDriver()
+		if err := d.Connect(dsn); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = d.Close() })
+		return d
+	}
+	crashed, other := connect(t), connect(t)
+
+	if err := crashed.Lock(t.Context(), table); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("held lock", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+		defer cancel()
+		if err := other.Lock(ctx, table); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected error (%v) to be %v", err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("lock of a closed connection", func(t *testing.T) {
+		// Close without unlocking, as if the process crashed.
+		if err := crashed.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+		defer cancel()
+		if err := other.Lock(ctx, table); err != nil {
+			t.Fatalf("expected to acquire the lock, got %v", err)
+		}
+		if err := other.Unlock(t.Context(), table); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -4,6 +4,7 @@ package sqlserver
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -12,7 +13,7 @@ import (
 	"github.com/rafaelespinoza/godfish/driver"
 	"github.com/rafaelespinoza/godfish/drivers/internal"
 
-	_ "github.com/microsoft/go-mssqldb" // register driver with database/sql
+	mssql "github.com/microsoft/go-mssqldb" // also registers driver with database/sql
 )
 
 const msgPrefix = "sqlserver: "
@@ -26,6 +27,10 @@ func NewDriver() *Driver { return &Driver{} }
 // Driver implements the [driver.Driver] interface for Microsoft SQL Server.
 type Driver struct {
 	connection *sql.DB
+	// lockConn holds the session that owns the application lock, see [Driver.Lock].
+	lockConn *sql.Conn
+	// tx is set on the Driver passed along by [Driver.WithinTransaction].
+	tx *sql.Tx
 }
 
 func (d *Driver) Name() string { return "sqlserver" }
@@ -53,12 +58,118 @@ func (d *Driver) Close() (err error) {
 		return
 	}
 	d.connection = nil
+	if lockConn := d.lockConn; lockConn != nil {
+		d.lockConn = nil
+		_ = lockConn.Close()
+	}
 	err = conn.Close()
 	return
 }
 
+// Lock acquires an exclusive application lock with sp_getapplock. The resource
+// name is derived from migrationsTable. The lock is owned by the session, so a
+// dedicated connection is held until [Driver.Unlock]. If ctx has a deadline,
+// then it's used as the lock timeout, otherwise it waits indefinitely.
+func (d *Driver) Lock(ctx context.Context, migrationsTable string) (err error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return
+	}
+	if d.lockConn != nil {
+		return errors.New(msgPrefix + "lock is already held")
+	}
+
+	timeoutMS := int64(-1)
+	if deadline, ok := ctx.Deadline(); ok {
+		timeoutMS = max(time.Until(deadline).Milliseconds(), 0)
+	}
+
+	conn, err := d.connection.Conn(ctx)
+	if err != nil {
+		return
+	}
+
+	const q = `DECLARE @result INT;
+EXEC @result = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = @p2;
+SELECT @result;`
+	var result int
+	err = conn.QueryRowContext(ctx, q, lockResource(cleanedTableName), timeoutMS).Scan(&result)
+	if err == nil && result < 0 {
+		// See sp_getapplock documentation for meanings of negative values.
+		err = fmt.Errorf("sp_getapplock result: %d; %w", result, context.DeadlineExceeded)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf(msgPrefix+"acquiring application lock; %w", err)
+	}
+	d.lockConn = conn
+	return
+}
+
+// Unlock releases the application lock acquired by [Driver.Lock].
+func (d *Driver) Unlock(ctx context.Context, migrationsTable string) (err error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return
+	}
+	conn := d.lockConn
+	if conn == nil {
+		return errors.New(msgPrefix + "lock is not held")
+	}
+	d.lockConn = nil
+
+	const q = `EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'`
+	if _, err = conn.ExecContext(ctx, q, lockResource(cleanedTableName)); err != nil {
+		err = fmt.Errorf(msgPrefix+"releasing application lock; %w", err)
+	}
+	return errors.Join(err, conn.Close())
+}
+
+// lockResource formats a resource name for sp_getapplock.
+func lockResource(cleanedTableName string) string {
+	return "godfish:" + unquoteCleanedTablename(cleanedTableName)
+}
+
+// WithinTransaction runs fn with a copy of the Driver whose changes are made
+// within one transaction. See [driver.Transactor].
+func (d *Driver) WithinTransaction(ctx context.Context, fn func(ctx context.Context, tx driver.Driver) error) error {
+	if d.tx != nil {
+		return errors.New(msgPrefix + "transaction already in progress")
+	}
+	err := internal.WithinTransaction(ctx, d.connection, func(tx *sql.Tx) error {
+		return fn(ctx, &Driver{connection: d.connection, tx: tx})
+	})
+	if err != nil {
+		err = fmt.Errorf(msgPrefix+"%w", err)
+	}
+	return err
+}
+
+// execer returns the transaction in progress, if there is one. Otherwise it
+// returns the connection.
+func (d *Driver) execer() internal.Execer {
+	if d.tx != nil {
+		return d.tx
+	}
+	return d.connection
+}
+
+// RawConn returns the transaction in progress, a *sql.Tx, if there is one.
+// Otherwise it returns the connection, a *sql.DB. See [driver.RawConnector].
+func (d *Driver) RawConn() any {
+	if d.tx != nil {
+		return d.tx
+	}
+	return d.connection
+}
+
 func (d *Driver) Execute(ctx context.Context, query string, args ...any) (err error) {
-	_, err = d.connection.ExecContext(ctx, query)
+	_, err = d.execer().ExecContext(ctx, query)
+	var merr mssql.Error
+	if errors.As(err, &merr) && merr.LineNo > 0 {
+		// Only the line is reported, so point at the start of it.
+		err = &driver.StatementError{Position: internal.OffsetOfLine(query, int(merr.LineNo), 0), Err: err}
+	}
 	return
 }
 
@@ -68,16 +179,34 @@ func (d *Driver) CreateSchemaMigrationsTable(ctx context.Context, migrationsTabl
 		return
 	}
 
+	q := createSchemaMigrationsTableQuery(cleanedTableName, "@p1")
+	_, err = d.execer().ExecContext(ctx, q, cleanedTableName)
+	return
+}
+
+// CreateSchemaMigrationsTableScript returns the statement that
+// [Driver.CreateSchemaMigrationsTable] would execute. See [driver.Scripter].
+func (d *Driver) CreateSchemaMigrationsTableScript(migrationsTable string) (string, error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return "", err
+	}
+	return createSchemaMigrationsTableQuery(cleanedTableName, internal.QuoteLiteral(cleanedTableName)), nil
+}
+
+// createSchemaMigrationsTableQuery is the statement to create the schema
+// migrations table. The objectID is either a query parameter placeholder or a
+// quoted literal of the table name.
+func createSchemaMigrationsTableQuery(cleanedTableName, objectID string) string {
 	// #nosec G202 -- table name was sanitized
-	q := `IF OBJECT_ID(@p1, 'U') IS NULL
+	return `IF OBJECT_ID(` + objectID + `, 'U') IS NULL
 	CREATE TABLE ` + cleanedTableName + ` (
 	migration_id VARCHAR(128) PRIMARY KEY NOT NULL,
 	label VARCHAR(255) DEFAULT '',
-	executed_at BIGINT DEFAULT 0
+	executed_at BIGINT DEFAULT 0,
+	checksum VARCHAR(64) DEFAULT '',
+	batch BIGINT DEFAULT 0
 )`
-
-	_, err = d.connection.ExecContext(ctx, q, cleanedTableName)
-	return
 }
 
 func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (out driver.AppliedVersions, err error) {
@@ -89,16 +218,16 @@ func (d *Driver) AppliedVersions(ctx context.Context, migrationsTable string) (o
 	metadata, err := checkSchemaMigrationMetadata(ctx, d, cleanedTableName)
 	if err != nil {
 		return
-	} else if !metadata.hasTable {
+	} else if !metadata.HasTable {
 		err = driver.ErrSchemaMigrationsDoesNotExist
 		return
-	} else if !metadata.hasColLabel || !metadata.hasColExecutedAt {
+	} else if !metadata.HasColLabel || !metadata.HasColExecutedAt {
 		err = driver.ErrSchemaMigrationsMissingColumns
 		return
 	}
 
 	// #nosec G202 -- table name was sanitized
-	q := `SELECT migration_id, label, executed_at FROM ` + cleanedTableName + ` ORDER BY migration_id ASC`
+	q := `SELECT ` + metadata.SelectColumns() + ` FROM ` + cleanedTableName + ` ORDER BY migration_id ASC`
 	rows, err := d.connection.QueryContext(ctx, q)
 	out = driver.AppliedVersions(rows)
 	return
@@ -110,7 +239,7 @@ func (d *Driver) UpdateSchemaMigrations(ctx context.Context, migrationsTable str
 		return
 	}
 
-	conn := d.connection
+	conn := d.execer()
 	if !forward {
 		// #nosec G202 -- table name was sanitized
 		q := `DELETE FROM ` + cleanedTableName + ` WHERE migration_id = @p1`
@@ -125,6 +254,54 @@ func (d *Driver) UpdateSchemaMigrations(ctx context.Context, migrationsTable str
 	return
 }
 
+// RecordMigration is like [Driver.UpdateSchemaMigrations], but it also
+// records meta in the columns that the schema migrations table has. See
+// [driver.MetadataRecorder].
+func (d *Driver) RecordMigration(ctx context.Context, migrationsTable, version, label string, meta driver.Metadata) (err error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return
+	}
+
+	metadata, err := checkSchemaMigrationMetadata(ctx, d, cleanedTableName)
+	if err != nil {
+		return
+	}
+	now := time.Now().UTC()
+	columns, args := metadata.RecordedColumns(version, label, now.Unix(), meta)
+	placeholders := make([]string, len(args))
+	for i := range args {
+		placeholders[i] = fmt.Sprintf("@p%d", i+1)
+	}
+
+	// #nosec G202 -- table name was sanitized
+	q := `INSERT INTO ` + cleanedTableName + ` (` + strings.Join(columns, ", ") + `) VALUES (` + strings.Join(placeholders, ", ") + `)`
+	_, err = d.execer().ExecContext(ctx, q, args...)
+	return
+}
+
+// UpdateSchemaMigrationsScript returns the statement that
+// [Driver.UpdateSchemaMigrations] or [Driver.RecordMigration] would execute on
+// an upgraded schema migrations table. See [driver.Scripter].
+func (d *Driver) UpdateSchemaMigrationsScript(migrationsTable string, forward bool, version, label, checksum string, batch int64) (string, error) {
+	cleanedTableName, err := cleanIdentifier(migrationsTable)
+	if err != nil {
+		return "", err
+	}
+
+	if !forward {
+		// #nosec G202 -- table name was sanitized, values are quoted
+		return `DELETE FROM ` + cleanedTableName + ` WHERE migration_id = ` + internal.QuoteLiteral(version), nil
+	}
+
+	now := time.Now().UTC()
+	// #nosec G201 -- table name was sanitized, values are quoted
+	return fmt.Sprintf(
+		`INSERT INTO %s (migration_id, label, executed_at, checksum, batch) VALUES (%s, %s, %d, %s, %d)`,
+		cleanedTableName, internal.QuoteLiteral(version), internal.QuoteLiteral(label), now.Unix(), internal.QuoteLiteral(checksum), batch,
+	), nil
+}
+
 func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable string) error {
 	cleanedTableName, err := cleanIdentifier(migrationsTable)
 	if err != nil {
@@ -132,9 +309,11 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 	}
 	const errMsgPrefix = msgPrefix + "upgrading schema migrations table"
 
-	tx, terr := d.connection.BeginTx(ctx, nil)
-	if terr != nil {
-		return fmt.Errorf(errMsgPrefix+", beginning transaction; %w", terr)
+	metadata, err := checkSchemaMigrationMetadata(ctx, d, cleanedTableName)
+	if err != nil {
+		return fmt.Errorf(errMsgPrefix+", checking metadata; %w", err)
+	} else if !metadata.HasTable {
+		return driver.ErrSchemaMigrationsDoesNotExist
 	}
 
 	// In order to let existing data have a default value of '' or 0, add some named constraints.
@@ -145,16 +324,29 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 	// enough to not have to use sql.NullString or sql.NullInt64. And the intent of
 	// that is to insulate the cassandra driver from having to know about database/sql.
 	constraintPrefix := `DF_` + unquoteCleanedTablename(cleanedTableName)
-	labelConstraint := constraintPrefix + `_label`
-	executedAtConstraint := constraintPrefix + `_executed_at`
+	columns := metadata.MissingColumns(internal.ColumnDefinitions{
+		Label: `label VARCHAR(255) NULL
+			CONSTRAINT ` + constraintPrefix + `_label DEFAULT '' WITH VALUES`,
+		ExecutedAt: `executed_at BIGINT NULL
+			CONSTRAINT ` + constraintPrefix + `_executed_at DEFAULT 0 WITH VALUES`,
+		Checksum: `checksum VARCHAR(64) NULL
+			CONSTRAINT ` + constraintPrefix + `_checksum DEFAULT '' WITH VALUES`,
+		Batch: `batch BIGINT NULL
+			CONSTRAINT ` + constraintPrefix + `_batch DEFAULT 0 WITH VALUES`,
+	})
+	if len(columns) < 1 {
+		return nil
+	}
+
+	tx, terr := d.connection.BeginTx(ctx, nil)
+	if terr != nil {
+		return fmt.Errorf(errMsgPrefix+", beginning transaction; %w", terr)
+	}
 
 	// #nosec G202 -- table name was sanitized
 	q := `ALTER TABLE ` + cleanedTableName + `
 	ADD
-		label VARCHAR(255) NULL
-			CONSTRAINT ` + labelConstraint + ` DEFAULT '' WITH VALUES,
-		executed_at BIGINT NULL
-			CONSTRAINT ` + executedAtConstraint + ` DEFAULT 0 WITH VALUES;`
+		` + strings.Join(columns, ",\n\t\t") + `;`
 	_, xerr := tx.ExecContext(ctx, q)
 	if xerr != nil {
 		if rerr := tx.Rollback(); rerr != nil {
@@ -170,34 +362,28 @@ func (d *Driver) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 	return cerr
 }
 
-type metadataResult struct {
-	hasTable         bool
-	hasColLabel      bool
-	hasColExecutedAt bool
-}
-
-func checkSchemaMigrationMetadata(ctx context.Context, d *Driver, tableName string) (out metadataResult, err error) {
+func checkSchemaMigrationMetadata(ctx context.Context, d *Driver, tableName string) (out internal.SchemaMigrationsMetadata, err error) {
 	// Expect for the input tableName to have been treated by cleanIdentifier.
 	// It doesn't need to be quoted in this case because it's used as a regular
 	// query parameter in this function.
 	tableName = unquoteCleanedTablename(tableName)
 
-	lgr := slog.With("driver", d.Name(), slog.String("table_name", tableName))
+	lgr := driver.Logger(ctx).With("driver", d.Name(), slog.String("table_name", tableName))
 
 	const query = `
 SELECT t.table_name, c.column_name
 FROM information_schema.tables t LEFT JOIN information_schema.columns c
     ON  t.table_name = c.table_name
-    AND c.column_name IN (@p1, @p2)
+    AND c.column_name IN (@p1, @p2, @p3, @p4)
 WHERE t.table_catalog = DB_NAME()
-	AND t.table_name = @p3
+	AND t.table_name = @p5
 `
-	args := []any{"label", "executed_at", tableName}
+	args := []any{"label", "executed_at", "checksum", "batch", tableName}
 	lgr.Debug(
 		msgPrefix+"checking for table, column existence",
 		slog.String("query", query), slog.Any("args", args),
 	)
-	rows, err := d.connection.QueryContext(ctx, query, args...)
+	rows, err := d.execer().QueryContext(ctx, query, args...)
 	if err != nil {
 		return
 	}
@@ -208,15 +394,10 @@ WHERE t.table_catalog = DB_NAME()
 			return
 		}
 
-		out.hasTable = table.Valid
+		out.HasTable = table.Valid
 
 		if column.Valid {
-			switch column.String {
-			case "label":
-				out.hasColLabel = true
-			case "executed_at":
-				out.hasColExecutedAt = true
-			}
+			out.SetColumn(column.String)
 		}
 	}
 
@@ -0,0 +1,94 @@
+package godfish
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+// A MigrationError is returned when a migration fails to execute. Get one
+// from the error of a function such as [MigrateWith] with [errors.As]. When
+// the driver tells where the error is, see [driver.StatementError], then the
+// Line and Column locate it in the migration file.
+type MigrationError struct {
+	// Version is empty for a repeatable migration.
+	Version  string
+	Label    string
+	Filename string
+	// Statement is the index of the failed statement, when the driver runs
+	// the statements of a migration one at a time. Otherwise, it's 0.
+	Statement int
+	// Line and Column start at 1, and the Column is in characters. Both are 0
+	// when the location of the error is unknown.
+	Line   int
+	Column int
+	// Source is the text of the Line.
+	Source string
+	Err    error
+}
+
+func (e *MigrationError) Error() string {
+	msg := fmt.Sprintf("%s; path_to_file: %s", internal.ErrExecutingMigration, e.Filename)
+	if e.Line > 0 {
+		msg += fmt.Sprintf("; line %d, column %d", e.Line, e.Column)
+	}
+	return msg + "; " + e.Err.Error()
+}
+
+func (e *MigrationError) Unwrap() []error { return []error{internal.ErrExecutingMigration, e.Err} }
+
+// Snippet shows the line with the error, and a caret under the column:
+//
+//	12 | SELEC * FROM foos;
+//	   | ^
+//
+// It's empty when the location of the error is unknown.
+func (e *MigrationError) Snippet() string {
+	if e.Line < 1 {
+		return ""
+	}
+
+	// Keep the tabs, so that the caret lines up with the column.
+	var pad strings.Builder
+	for i, r := range []rune(e.Source) {
+		if i >= e.Column-1 {
+			break
+		}
+		if r == '\t' {
+			pad.WriteRune(r)
+		} else {
+			pad.WriteByte(' ')
+		}
+	}
+	gutter := strconv.Itoa(e.Line)
+	return fmt.Sprintf("%s | %s\n%s | %s^", gutter, e.Source, strings.Repeat(" ", len(gutter)), pad.String())
+}
+
+// newMigrationError makes a MigrationError for err, which happened when
+// executing query. If err is a [driver.StatementError], then the error is
+// located in query, at its Position or else at the start of the statement.
+func newMigrationError(version, label, filename string, query []byte, err error) *MigrationError {
+	out := MigrationError{Version: version, Label: label, Filename: filename, Err: err}
+
+	var serr *driver.StatementError
+	if !errors.As(err, &serr) {
+		return &out
+	}
+	out.Statement = serr.Statement
+
+	pos := serr.Position
+	if pos < 0 && serr.Offset >= 0 && serr.Offset <= len(query) {
+		stmt := query[serr.Offset:]
+		pos = serr.Offset + len(stmt) - len(bytes.TrimLeftFunc(stmt, unicode.IsSpace))
+	}
+	if pos >= 0 && pos <= len(query) {
+		out.Line, out.Column, out.Source = internal.LineColumn(query, pos)
+	}
+	return &out
+}
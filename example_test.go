@@ -223,3 +223,60 @@ func ExampleApplyRollbackWith() {
 		// Handle error
 	}
 }
+
+// Decide what to do based on the state of the migrations.
+func ExampleStatus() {
+	ctx := context.Background()
+
+	// driver can be one of the drivers in this project, see drivers/.
+	driver := postgres.NewDriver()
+	if err := driver.Connect(postgres.SampleDSN); err != nil {
+		fmt.Println("connecting to DB", err)
+		return
+	}
+	defer func() { _ = driver.Close() }()
+
+	// migrationsDir is an fs.FS directory with the migrations files.
+	migrationsDir := os.DirFS("path/to/migration/files")
+
+	statuses, err := godfish.Status(ctx, driver, migrationsDir)
+	if err != nil {
+		// Handle error
+	}
+
+	for _, status := range statuses {
+		if !status.Applied {
+			fmt.Println("pending migration", status.Version, status.Label)
+		}
+	}
+}
+
+// Configure a Migrator once, and reuse it.
+func ExampleMigrator() {
+	ctx := context.Background()
+
+	// driver can be one of the drivers in this project, see drivers/.
+	driver := mysql.NewDriver()
+	if err := driver.Connect(mysql.SampleDSN); err != nil {
+		fmt.Println("connecting to DB", err)
+		return
+	}
+	defer func() { _ = driver.Close() }()
+
+	// migrationsDir is an fs.FS directory with the migrations files.
+	migrationsDir := os.DirFS("path/to/migration/files")
+
+	migrator, err := godfish.NewMigrator(driver, migrationsDir, godfish.WithMigrationsTable("migration_versions"))
+	if err != nil {
+		// Handle error
+	}
+
+	if err = migrator.Migrate(ctx); err != nil {
+		// Handle error
+	}
+
+	// Options passed to a method only apply to that call.
+	if err = migrator.Rollback(ctx, godfish.WithSteps(2)); err != nil {
+		// Handle error
+	}
+}
@@ -1,6 +1,40 @@
 // Package godfish is a database migration library.
 // It's built to serve the command line tool, but could be used for more
 // customized situations, such as embedding migrations into a binary.
+//
+// Migration files are read from an [fs.FS], see [WithRecursive] and [MergeFS].
+// Their filenames follow [WithNamingConvention], with versions in the scheme
+// of [WithVersionScheme].
+//
+// # Directives
+//
+// A migration file may adjust how it's run with line comments at the top of
+// the file, before any statements, in the form "-- godfish:<name> [value]":
+//
+//   - no-transaction: run outside of a transaction, even if the driver is a
+//     [driver.Transactor], such as for CREATE INDEX CONCURRENTLY on postgres.
+//   - timeout: a duration, such as "10m", to limit how long the migration runs.
+//   - env: a comma-separated list of environments, see [WithEnvironment].
+//
+// An unknown directive is ignored, with a warning. A known directive with an
+// invalid value is an error.
+//
+// # Single-file migrations
+//
+// A migration file named in the forward direction may hold both directions,
+// in sections after "-- godfish:up" and "-- godfish:down" marker comments.
+// Directives before the up marker apply to both directions. The checksum of
+// the migration is of the whole file.
+//
+// # Repeatable migrations
+//
+// A repeatable migration has no version, such as a file with a
+// "CREATE OR REPLACE VIEW" statement. It's named "repeatable-${label}", or
+// "R__${label}" in the flyway convention. After [MigrateWith] applies every
+// available forward migration, it runs each repeatable migration, ordered by
+// filename, whose checksum differs from the one of its last run. Runs are
+// recorded in the table named after the schema migrations table with a
+// "_repeatable" suffix. The driver must be a [driver.MetadataRecorder].
 package godfish
 
 import (
@@ -12,6 +46,7 @@ import (
 	"io"
 	"io/fs"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"slices"
@@ -40,8 +75,12 @@ const msgPrefix = "godfish"
 //   - forward, migrate, up
 //   - reverse, rollback, down
 //
-// The version is autogenerated. Filenames created with this tool will be a UTC
-// timestamp of the creation. The form is YYYYMMDDHHmmss.
+// The version is autogenerated. By default, it's a UTC timestamp of the
+// creation. The form is YYYYMMDDHHmmss. With another version scheme, it's the
+// next version after the latest migration file at dirpath.
+//
+// With another naming convention, the filename is laid out in that convention
+// instead, and the direction labels are up to the convention.
 //
 // # Relevant opts
 //
@@ -62,13 +101,24 @@ const msgPrefix = "godfish"
 //     the filename extension.
 //     When this option is omitted, then this function will use the default,
 //     which is ".sql".
+//   - [WithVersionScheme]. If passed in with a valid name, then it will set
+//     how the version is generated.
+//     When passed in with any other value, then an error is returned.
+//     When this option is omitted, then the version is a timestamp.
+//   - [WithNamingConvention]. If passed in with a valid name, then it will set
+//     how the filename is laid out.
+//     When passed in with any other value, then an error is returned.
+//     When this option is omitted, then the default layout is used.
+//   - [WithSingleFile]. If passed in, then it will generate one file in the
+//     forward direction, with an up section, and a down section when
+//     reversible is true. See the package documentation for the layout.
 func CreateMigrationFilesWith(migrationName string, reversible bool, dirpath string, opts ...Opter) (err error) {
 	o, err := setOptions(opts...)
 	if err != nil {
 		return fmt.Errorf("%s.%s: %w", msgPrefix, "CreateMigrationFilesWith", err)
 	}
 
-	return createMigrationFiles(migrationName, reversible, dirpath, o.forwardLabel, o.reverseLabel, o.filenameExt)
+	return createMigrationFiles(migrationName, reversible, dirpath, o.forwardLabel, o.reverseLabel, o.filenameExt, o.scheme(), o.convention(), o.singleFile, o.log())
 }
 
 // CreateMigrationFiles takes care of setting up a new DB migration by
@@ -82,21 +132,84 @@ func CreateMigrationFilesWith(migrationName string, reversible bool, dirpath str
 // New code should use [CreateMigrationFilesWith].
 // Current code is encouraged to adjust as well.
 func CreateMigrationFiles(migrationName string, reversible bool, dirpath, fwdlabel, revlabel string) (err error) {
-	return createMigrationFiles(migrationName, reversible, dirpath, fwdlabel, revlabel, ".sql")
+	return createMigrationFiles(migrationName, reversible, dirpath, fwdlabel, revlabel, ".sql", internal.TimestampScheme, internal.DefaultConvention, false, slog.Default())
 }
 
-func createMigrationFiles(migrationName string, reversible bool, dirpath, fwdlabel, revlabel, ext string) error {
+func createMigrationFiles(migrationName string, reversible bool, dirpath, fwdlabel, revlabel, ext string, scheme internal.VersionScheme, convention internal.NamingConvention, singleFile bool, lgr *slog.Logger) error {
 	ext = cmp.Or(ext, ".sql")
 
-	params, err := internal.NewMigrationParams(migrationName, reversible, dirpath, fwdlabel, revlabel, ext)
+	params, err := internal.NewMigrationParams(migrationName, reversible, dirpath, fwdlabel, revlabel, ext, scheme, convention)
 	if err != nil {
 		return err
 	}
+	params.SingleFile, params.Logger = singleFile, lgr
 
 	return params.GenerateFiles()
 }
 
+// A GoMigration is a migration written as Go code rather than in a file. It
+// could be used for changes that cannot be expressed in the database's query
+// language, such as transforming data with application code. Go migrations are
+// registered with [WithGoMigrations] and are ordered by Version along with the
+// migration files. The Reverse func is optional; without it, the migration is
+// passed over when rolling back, just like a forward migration file without a
+// corresponding reverse migration file.
+type GoMigration struct {
+	Version string
+	Label   string
+	Forward GoMigrationFunc
+	Reverse GoMigrationFunc
+}
+
+// GoMigrationFunc is the body of a [GoMigration]. When the driver is a
+// [driver.RawConnector], then conn is its underlying connection. For drivers
+// based on database/sql, that is a *sql.DB, or a *sql.Tx if the migration runs
+// within a transaction. Otherwise, conn is the [driver.Driver] itself.
+type GoMigrationFunc func(ctx context.Context, conn any) error
+
+// toMigrations validates g and converts it to a migration for each direction,
+// with a version in the scheme. The reverse Migration is nil if there is no
+// Reverse func.
+func (g GoMigration) toMigrations(scheme internal.VersionScheme) (forward, reverse *internal.Migration, err error) {
+	if g.Forward == nil {
+		err = fmt.Errorf("%w; Forward func is required, version %q", internal.ErrDataInvalid, g.Version)
+		return
+	}
+	if g.Label == "" {
+		err = fmt.Errorf("%w; Label is required, version %q", internal.ErrDataInvalid, g.Version)
+		return
+	}
+	version, err := scheme.ParseVersion(g.Version)
+	if err != nil {
+		err = fmt.Errorf("%w; parsing version %q: %w", internal.ErrDataInvalid, g.Version, err)
+		return
+	}
+
+	forward = &internal.Migration{
+		Indirection: internal.Indirection{Value: internal.DirForward, Label: internal.ForwardDirections[0]},
+		Label:       g.Label,
+		Version:     version,
+		Func:        g.Forward,
+	}
+	if g.Reverse != nil {
+		reverse = &internal.Migration{
+			Indirection: internal.Indirection{Value: internal.DirReverse, Label: internal.ReverseDirections[0]},
+			Label:       g.Label,
+			Version:     version,
+			Func:        g.Reverse,
+		}
+	}
+	return
+}
+
 // MigrateWith applies one or more available migrations in the forward direction.
+// Each migration applied in the same call is recorded with the same batch, so
+// they could be rolled back together with [WithBatch] or [WithLastBatch].
+// When no target is passed in, then pending repeatable migrations are run
+// afterwards. See the package documentation on repeatable migrations.
+//
+// Only one of [WithTargetVersion], [WithSteps], [WithTargetLabel] may be
+// passed in to target migrations. Otherwise, an error is returned.
 //
 // # Relevant opts
 //
@@ -105,22 +218,30 @@ func createMigrationFiles(migrationName string, reversible bool, dirpath, fwdlab
 //     When passed in with a zero value, then an error is returned.
 //     When this option is omitted, then this function will apply all available
 //     migrations.
-//   - [WithMigrationsTable]. If passed in with a non-zero value, then this
-//     function will override the default value of "schema_migrations".
+//   - [WithSteps]. If passed in with a positive value, then this function
+//     will apply at most that many migrations.
+//     When passed in with a non-positive value, then an error is returned.
+//   - [WithTargetLabel]. If passed in with a non-zero value, then this
+//     function will apply migrations up to and including the first one with
+//     that label. It's an error if no migration to apply has that label.
 //     When passed in with a zero value, then an error is returned.
-//     When this option is omitted, then this function will use the default.
-//     This DB table will be automatically created unless it already exists.
+//   - See also [WithMigrationsTable], [WithGoMigrations], [WithEnvironment],
+//     [WithLockTimeout], [WithDryRun], [WithWriter] and [WithReport].
 func MigrateWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts ...Opter) error {
 	o, err := setOptions(opts...)
 	if err != nil {
 		return fmt.Errorf("%s.%s: %w", msgPrefix, "MigrateWith", err)
 	}
 
-	return migrateOrRollback(ctx, driver, dirFS, true, o.targetVersion, o.migrationsTable)
+	return migrateOrRollback(ctx, driver, dirFS, true, o)
 }
 
 // RollbackWith applies one or more available migrations in the reverse direction.
 //
+// Only one of [WithTargetVersion], [WithSteps], [WithTargetLabel], [WithSince],
+// [WithBatch], [WithLastBatch] may be passed in to target migrations.
+// Otherwise, an error is returned.
+//
 // # Relevant opts
 //
 //   - [WithTargetVersion]. If passed in with a non-zero value, then this
@@ -128,18 +249,33 @@ func MigrateWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts ..
 //     When passed in with a zero value, then an error is returned.
 //     When this option is omitted, then this function will apply all
 //     available rollback migrations.
-//   - [WithMigrationsTable]. If passed in with a non-zero value, then this
-//     function will override the default value of "schema_migrations".
+//   - [WithSteps]. If passed in with a positive value, then this function
+//     will rollback at most that many migrations.
+//     When passed in with a non-positive value, then an error is returned.
+//   - [WithTargetLabel]. If passed in with a non-zero value, then this
+//     function will rollback down to and including the most recent migration
+//     with that label. It's an error if no migration to rollback has that label.
 //     When passed in with a zero value, then an error is returned.
-//     When this option is omitted, then this function will use the default.
-//     This DB table will be automatically created unless it already exists.
+//   - [WithSince]. If passed in with a non-zero value, then this function
+//     will rollback each migration that was executed after that time.
+//     When passed in with a zero value, then an error is returned.
+//   - [WithBatch]. If passed in with a positive value, then this function
+//     will rollback each migration that was applied in that batch. It's an
+//     error if no migration to rollback is in that batch, or if a migration
+//     in that batch has no reverse migration.
+//     When passed in with a non-positive value, then an error is returned.
+//   - [WithLastBatch]. If passed in, then this function will rollback each
+//     migration that was applied in the most recent batch. Like [WithBatch],
+//     it's an error if a migration in that batch has no reverse migration.
+//   - See also [WithMigrationsTable], [WithGoMigrations], [WithEnvironment],
+//     [WithLockTimeout], [WithDryRun], [WithWriter] and [WithReport].
 func RollbackWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts ...Opter) error {
 	o, err := setOptions(opts...)
 	if err != nil {
 		return fmt.Errorf("%s.%s: %w", msgPrefix, "RollbackWith", err)
 	}
 
-	return migrateOrRollback(ctx, driver, dirFS, false, o.targetVersion, o.migrationsTable)
+	return migrateOrRollback(ctx, driver, dirFS, false, o)
 }
 
 // Migrate executes all migrations at the directory dirFS in the specified
@@ -158,11 +294,16 @@ func RollbackWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts .
 // of the migration(s) to apply.
 // Current code is encouraged to adjust as well.
 func Migrate(ctx context.Context, driver driver.Driver, dirFS fs.FS, forward bool, finishAtVersion string, migrationsTable string) (err error) {
-	return migrateOrRollback(ctx, driver, dirFS, forward, finishAtVersion, migrationsTable)
+	return migrateOrRollback(ctx, driver, dirFS, forward, &options{targetVersion: finishAtVersion, migrationsTable: migrationsTable})
 }
 
-func migrateOrRollback(ctx context.Context, driver driver.Driver, dirFS fs.FS, forward bool, finishAtVersion string, migrationsTable string) (err error) {
-	migrationsTable = cmp.Or(migrationsTable, internal.DefaultMigrationsTableName)
+func migrateOrRollback(ctx context.Context, driver driver.Driver, dirFS fs.FS, forward bool, o *options) (err error) {
+	ctx = o.logContext(ctx)
+	if err = validateTargets(o, forward); err != nil {
+		return
+	}
+	migrationsTable := cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName)
+	finishAtVersion := o.targetVersion
 	var migrations []*internal.Migration
 	direction := internal.DirReverse
 	if forward {
@@ -179,14 +320,40 @@ func migrateOrRollback(ctx context.Context, driver driver.Driver, dirFS fs.FS, f
 		direction:       direction,
 		dirFS:           dirFS,
 		finishAtVersion: finishAtVersion,
+		goMigrations:    o.goMigrations,
+		scheme:          o.scheme(),
+		convention:      o.convention(),
+		recursive:       o.recursive,
+		files:           o.parsedFiles,
+		logger:          o.log(),
+		steps:           o.steps,
+		targetLabel:     o.targetLabel,
+		since:           o.since,
+		batch:           o.batch,
+		lastBatch:       o.lastBatch,
 	}
+
+	if !o.dryRun && !o.export {
+		unlock, lerr := lockSchemaMigrations(ctx, driver, migrationsTable, o.lockTimeout)
+		if lerr != nil {
+			return lerr
+		}
+		defer func() { err = errors.Join(err, unlock()) }()
+	}
+
 	if migrations, err = finder.query(ctx, driver, migrationsTable); err != nil {
 		return
 	}
+	if forward {
+		// Every migration applied in this run is recorded as one batch.
+		for _, mig := range migrations {
+			mig.Batch = finder.latestBatch + 1
+		}
+	}
 
-	// Before executing any migrations, ensure there is a known file for each one.
+	// Before executing any migrations, ensure there is a known file or Go func for each one.
 	for _, mig := range migrations {
-		if mig.Filename == "" {
+		if mig.Filename == "" && mig.Func == nil {
 			return fmt.Errorf(
 				"migration with direction (%q) version (%q) label (%q) does not seem to have a Filename",
 				mig.Indirection.Label, mig.Version.String(), mig.Label,
@@ -194,12 +361,67 @@ func migrateOrRollback(ctx context.Context, driver driver.Driver, dirFS fs.FS, f
 		}
 	}
 
-	for _, mig := range migrations {
-		if err = runMigration(ctx, driver, dirFS, mig, migrationsTable); err != nil {
+	// Repeatable migrations go after all of the versioned forward migrations,
+	// so they are left alone when only some of those are targeted.
+	var repeatables []*internal.Repeatable
+	if forward && o.targetVersion == "" && o.steps < 1 && o.targetLabel == "" {
+		if repeatables, err = findRepeatables(ctx, driver, dirFS, migrationsTable, o); err != nil {
 			return
 		}
+		for _, rep := range repeatables {
+			rep.Batch = finder.latestBatch + 1
+		}
 	}
-	return
+
+	if o.dryRun || o.export {
+		return writePlan(driver, dirFS, migrations, repeatables, o)
+	}
+
+	pending := make([]MigrationEvent, 0, len(migrations)+len(repeatables))
+	for _, mig := range migrations {
+		pending = append(pending, migrationEvent(mig))
+	}
+	for _, rep := range repeatables {
+		pending = append(pending, repeatableEvent(rep))
+	}
+
+	return observeRun(ctx, o, direction, pending, func() error {
+		for _, mig := range migrations {
+			if err := runMigration(ctx, driver, dirFS, mig, o); err != nil {
+				return err
+			}
+		}
+		for _, rep := range repeatables {
+			if err := runRepeatable(ctx, driver, dirFS, rep, o); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// validateTargets checks that at most one way of targeting migrations is set.
+// The since and batch options only apply to a rollback.
+func validateTargets(o *options, forward bool) error {
+	var numTargets int
+	for _, isSet := range []bool{o.targetVersion != "", o.steps > 0, o.targetLabel != "", !o.since.IsZero(), o.batch > 0, o.lastBatch} {
+		if isSet {
+			numTargets++
+		}
+	}
+	if numTargets > 1 {
+		return fmt.Errorf(
+			"%s: %w; use only one of WithTargetVersion, WithSteps, WithTargetLabel, WithSince, WithBatch, WithLastBatch",
+			msgPrefix, internal.ErrDataInvalid,
+		)
+	}
+	if forward && !o.since.IsZero() {
+		return fmt.Errorf("%s: %w; WithSince only applies to a rollback", msgPrefix, internal.ErrDataInvalid)
+	}
+	if forward && (o.batch > 0 || o.lastBatch) {
+		return fmt.Errorf("%s: %w; WithBatch, WithLastBatch only apply to a rollback", msgPrefix, internal.ErrDataInvalid)
+	}
+	return nil
 }
 
 // ApplyMigrationWith runs one forward migration at the directory dirFS with
@@ -217,18 +439,16 @@ func migrateOrRollback(ctx context.Context, driver driver.Driver, dirFS fs.FS, f
 //     When passed in with a zero value, then an error is returned.
 //     When this option is omitted, then this function will apply the closest
 //     available forward migration.
-//   - [WithMigrationsTable]. If passed in with a non-zero value, then this
-//     function will override the default value of "schema_migrations".
-//     When passed in with a zero value, then an error is returned.
-//     When this option is omitted, then this function will use the default.
-//     This DB table will be automatically created unless it already exists.
+//   - See also [WithMigrationsTable], [WithGoMigrations], [WithEnvironment],
+//     [WithLockTimeout], [WithDryRun], [WithWriter] and [WithReport].
 func ApplyMigrationWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts ...Opter) error {
 	o, err := setOptions(opts...)
 	if err != nil {
 		return fmt.Errorf("%s.%s: %w", msgPrefix, "ApplyMigrationWith", err)
 	}
 
-	return applyMigration(ctx, driver, dirFS, true, o.targetVersion, o.migrationsTable)
+	_, err = applyMigration(ctx, driver, dirFS, true, o)
+	return err
 }
 
 // ApplyRollbackWith runs one rollback migration at the directory dirFS with
@@ -246,18 +466,16 @@ func ApplyMigrationWith(ctx context.Context, driver driver.Driver, dirFS fs.FS,
 //     When passed in with a zero value, then an error is returned.
 //     When this option is omitted, then this function will apply the closest
 //     available rollback migration.
-//   - [WithMigrationsTable]. If passed in with a non-zero value, then this
-//     function will override the default value of "schema_migrations".
-//     When passed in with a zero value, then an error is returned.
-//     When this option is omitted, then this function will use the default.
-//     This DB table will be automatically created unless it already exists.
+//   - See also [WithMigrationsTable], [WithGoMigrations], [WithEnvironment],
+//     [WithLockTimeout], [WithDryRun], [WithWriter] and [WithReport].
 func ApplyRollbackWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts ...Opter) error {
 	o, err := setOptions(opts...)
 	if err != nil {
 		return fmt.Errorf("%s.%s: %w", msgPrefix, "ApplyRollbackWith", err)
 	}
 
-	return applyMigration(ctx, driver, dirFS, false, o.targetVersion, o.migrationsTable)
+	_, err = applyMigration(ctx, driver, dirFS, false, o)
+	return err
 }
 
 // ApplyMigration runs a migration at the directory dirFS with the specified
@@ -275,22 +493,45 @@ func ApplyRollbackWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, o
 // the direction of the migration to apply.
 // Current code is encouraged to adjust as well.
 func ApplyMigration(ctx context.Context, driver driver.Driver, dirFS fs.FS, forward bool, version, migrationsTable string) (err error) {
-	return applyMigration(ctx, driver, dirFS, forward, version, migrationsTable)
+	_, err = applyMigration(ctx, driver, dirFS, forward, &options{targetVersion: version, migrationsTable: migrationsTable})
+	return
 }
 
-func applyMigration(ctx context.Context, driver driver.Driver, dirFS fs.FS, forward bool, version, migrationsTable string) error {
-	migrationsTable = cmp.Or(migrationsTable, internal.DefaultMigrationsTableName)
+// applyMigration runs one migration and returns it.
+func applyMigration(ctx context.Context, driver driver.Driver, dirFS fs.FS, forward bool, o *options) (mig *internal.Migration, err error) {
+	ctx = o.logContext(ctx)
+	if !o.dryRun {
+		unlock, lerr := lockSchemaMigrations(ctx, driver, cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName), o.lockTimeout)
+		if lerr != nil {
+			return nil, lerr
+		}
+		defer func() { err = errors.Join(err, unlock()) }()
+	}
+
+	return applyMigrationLocked(ctx, driver, dirFS, forward, o)
+}
+
+// applyMigrationLocked is like applyMigration, but the caller is responsible
+// for the lock on the schema migrations table.
+func applyMigrationLocked(ctx context.Context, driver driver.Driver, dirFS fs.FS, forward bool, o *options) (mig *internal.Migration, err error) {
+	migrationsTable := cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName)
+	version := o.targetVersion
 
 	direction := internal.DirReverse
 	if forward {
 		direction = internal.DirForward
 	}
 
-	var mig *internal.Migration
 	if version != "" {
-		var err error
-		if mig, err = findParseMigration(dirFS, direction, version); err != nil {
-			return fmt.Errorf("trying to find, parse migration to apply: %w", err)
+		if mig = findGoMigration(o.goMigrations, direction, version); mig != nil {
+			o.log().Debug("found Go migration", slog.String("version", version))
+		} else if mig, err = findParseMigration(dirFS, direction, version, o); err != nil {
+			return nil, fmt.Errorf("trying to find, parse migration to apply: %w", err)
+		}
+		if forward {
+			if mig.Batch, err = nextBatch(ctx, driver, migrationsTable, o.scheme()); err != nil {
+				return nil, err
+			}
 		}
 	} else {
 		// attempt to find the next version to apply in the direction
@@ -302,76 +543,614 @@ func applyMigration(ctx context.Context, driver driver.Driver, dirFS fs.FS, forw
 			direction:       direction,
 			dirFS:           dirFS,
 			finishAtVersion: limit,
+			goMigrations:    o.goMigrations,
+			scheme:          o.scheme(),
+			convention:      o.convention(),
+			recursive:       o.recursive,
+			files:           o.parsedFiles,
+			logger:          o.log(),
 		}
 		toApply, ierr := finder.query(ctx, driver, migrationsTable)
 		if ierr != nil {
-			return fmt.Errorf("specified no version; error attempting to find one; %w", ierr)
+			return nil, fmt.Errorf("specified no version; error attempting to find one; %w", ierr)
 		}
 		if len(toApply) < 1 {
-			return fmt.Errorf("version %w", internal.ErrNotFound)
+			return nil, fmt.Errorf("version %w", internal.ErrNotFound)
 		}
 		// There may be more than 1 migration that could be applied. However, we're
 		// only interested in the nearest migration in the said direction.
 		mig = toApply[0]
+		if forward {
+			mig.Batch = finder.latestBatch + 1
+		}
 	}
 
 	if mig == nil {
-		return fmt.Errorf("trying to apply migration, but it's empty, forward=%t version=%s", forward, version)
+		return nil, fmt.Errorf("trying to apply migration, but it's empty, forward=%t version=%s", forward, version)
 	}
 
-	if err := runMigration(ctx, driver, dirFS, mig, migrationsTable); err != nil {
-		return fmt.Errorf("running migration with filename %q: %w", mig.Filename, err)
+	if o.dryRun {
+		err = writePlan(driver, dirFS, []*internal.Migration{mig}, nil, o)
+		return
 	}
-	return nil
+
+	err = observeRun(ctx, o, direction, []MigrationEvent{migrationEvent(mig)}, func() error { return runMigration(ctx, driver, dirFS, mig, o) })
+	if err != nil {
+		return nil, fmt.Errorf("running migration with filename %q: %w", mig.DisplayName(), err)
+	}
+	return
+}
+
+// RemigrateWith runs the last applied migration in the reverse direction and
+// then runs the same migration in the forward direction. This could be useful
+// for development.
+//
+// # Relevant opts
+//
+//   - [WithTargetVersion]. If passed in with a non-zero value, then this
+//     function will remigrate the targeted migration rather than the last one.
+//     When passed in with a zero value, then an error is returned.
+//     When this option is omitted, then this function will remigrate the
+//     closest available rollback migration.
+//   - See also [WithMigrationsTable], [WithGoMigrations], [WithEnvironment],
+//     [WithLockTimeout], [WithDryRun], [WithWriter] and [WithReport].
+func RemigrateWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts ...Opter) error {
+	o, err := setOptions(opts...)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", msgPrefix, "RemigrateWith", err)
+	}
+
+	return remigrate(ctx, driver, dirFS, o)
+}
+
+func remigrate(ctx context.Context, driver driver.Driver, dirFS fs.FS, o *options) (err error) {
+	ctx = o.logContext(ctx)
+	// Hold the lock for both steps, so that no other process can run a
+	// migration in between them.
+	if !o.dryRun {
+		unlock, lerr := lockSchemaMigrations(ctx, driver, cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName), o.lockTimeout)
+		if lerr != nil {
+			return lerr
+		}
+		defer func() { err = errors.Join(err, unlock()) }()
+	}
+
+	rolledBack, err := applyMigrationLocked(ctx, driver, dirFS, false, o)
+	if err != nil {
+		return err
+	}
+
+	// Target the same version, in case it isn't the next one to apply. That
+	// could happen on a dry run, where the rollback did not really happen.
+	forwardOpts := *o
+	forwardOpts.targetVersion = rolledBack.Version.String()
+	_, err = applyMigrationLocked(ctx, driver, dirFS, true, &forwardOpts)
+	return err
+}
+
+// lockSchemaMigrations acquires a lock on migrationsTable when d implements
+// [driver.Locker], so that concurrent runs from other processes wait their
+// turn. The returned func releases the lock and should be called once the run
+// is done. When d is not a Locker, then acquiring and releasing are no-ops.
+func lockSchemaMigrations(ctx context.Context, d driver.Driver, migrationsTable string, timeout time.Duration) (unlock func() error, err error) {
+	locker, ok := d.(driver.Locker)
+	if !ok {
+		unlock = func() error { return nil }
+		return
+	}
+
+	lockCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		lockCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	lgr := driver.Logger(ctx).With(slog.String("migrations_table", migrationsTable))
+	startTime := time.Now()
+	lgr.Debug("acquiring lock ...")
+	if err = locker.Lock(lockCtx, migrationsTable); err != nil {
+		err = fmt.Errorf("%s: acquiring lock: %w", msgPrefix, err)
+		return
+	}
+	lgr.Debug("acquired lock", makeDurationMSAttr(startTime))
+
+	unlock = func() error {
+		// The lock should be released even if ctx was canceled during the run.
+		if uerr := locker.Unlock(context.WithoutCancel(ctx), migrationsTable); uerr != nil {
+			return fmt.Errorf("%s: releasing lock: %w", msgPrefix, uerr)
+		}
+		lgr.Debug("released lock", makeDurationMSAttr(startTime))
+		return nil
+	}
+	return
 }
 
 // runMigration executes a migration against the database. The input, pathToFile
-// should be relative to the current working directory.
-func runMigration(ctx context.Context, driver driver.Driver, dir fs.FS, mig *internal.Migration, migrationsTable string) (err error) {
-	if mig.Filename == "" {
+// should be relative to the current working directory. When the driver is a
+// [driver.Transactor], then the migration and the update to the schema
+// migrations table are committed together.
+func runMigration(ctx context.Context, d driver.Driver, dir fs.FS, mig *internal.Migration, o *options) (err error) {
+	if mig.Filename == "" && mig.Func == nil {
 		return fmt.Errorf(
 			"migration (direction=%q, version=%s, label=%s) was not assigned a filename",
 			mig.Indirection.Label, mig.Version.String(), mig.Label,
 		)
 	}
-	var data []byte
-	if data, err = fs.ReadFile(dir, filepath.Clean(mig.Filename)); err != nil {
-		err = fmt.Errorf("%s: reading file in prep for running migration: %w", msgPrefix, err)
-		return
+	migrationsTable := cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName)
+	var data, file []byte
+	var checksum string
+	if mig.Func == nil {
+		if data, err = fs.ReadFile(dir, filepath.Clean(mig.Filename)); err != nil {
+			err = fmt.Errorf("%s: reading file in prep for running migration: %w", msgPrefix, err)
+			return
+		}
+		checksum = internal.Checksum(data)
+		if section, ok := internal.Section(data, mig.Indirection.Value); ok {
+			file, data = data, section
+		}
+		if mig.Directives, err = internal.ParseDirectives(data); err != nil {
+			err = fmt.Errorf("%s: parsing directives of file %q: %w", msgPrefix, mig.Filename, err)
+			return
+		}
 	}
-	gerund := "migrating"
-	if mig.Indirection.Value == internal.DirReverse {
-		gerund = "rolling back"
+	lgr := o.log().With(slog.String("path_to_file", mig.DisplayName()), slog.String("version", mig.Version.String()))
+	warnUnknownDirectives(lgr, mig.Directives)
+	if !mig.Directives.MatchesEnv(o.environment) {
+		lgr.Info("skipping, environment does not match",
+			slog.String("environment", o.environment), slog.Any("directive_envs", mig.Directives.Envs),
+		)
+		event := migrationEvent(mig)
+		event.Skipped = true
+		o.observe().MigrationFinished(ctx, event)
+		return
 	}
 
-	lgr := slog.With(slog.String("path_to_file", mig.Filename), slog.String("version", mig.Version.String()))
-	lgr.Info(gerund + " ...")
+	obs := o.observe()
+	event := migrationEvent(mig)
+	obs.MigrationStarted(ctx, event)
 	startTime := time.Now()
+	defer func() {
+		event.Duration, event.Err = time.Since(startTime), err
+		obs.MigrationFinished(ctx, event)
+	}()
 
-	if err = driver.Execute(ctx, string(data)); err != nil {
-		err = fmt.Errorf("%w; path_to_file: %s; %w", internal.ErrExecutingMigration, mig.Filename, err)
-		lgr.Error("executing migration", slog.Any("error", err), makeDurationMSAttr(startTime))
-		return
+	if mig.Directives.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, mig.Directives.Timeout)
+		defer cancel()
 	}
-	if err = driver.CreateSchemaMigrationsTable(ctx, migrationsTable); err != nil {
-		lgr.Error("creating schema migrations table", slog.Any("error", err), makeDurationMSAttr(startTime))
-		return
+
+	if transactor, ok := d.(driver.Transactor); ok && !mig.Directives.NoTransaction {
+		lgr.Debug("running within transaction")
+		err = transactor.WithinTransaction(ctx, func(ctx context.Context, tx driver.Driver) error {
+			return executeAndRecord(ctx, tx, mig, data, checksum, migrationsTable)
+		})
+	} else {
+		err = executeAndRecord(ctx, d, mig, data, checksum, migrationsTable)
+	}
+
+	// The error was located in a section of a single-file migration.
+	var merr *MigrationError
+	if file != nil && errors.As(err, &merr) && merr.Line > 0 {
+		merr.Line = internal.SectionLine(file, mig.Indirection.Value, merr.Line)
+	}
+	return
+}
+
+// warnUnknownDirectives logs the directives of a migration that are ignored,
+// because they are not recognized.
+func warnUnknownDirectives(lgr *slog.Logger, directives internal.Directives) {
+	if len(directives.Unknown) > 0 {
+		lgr.Warn("ignoring unknown directives", slog.Any("directives", directives.Unknown))
 	}
-	err = driver.UpdateSchemaMigrations(
+}
+
+// executeAndRecord runs the migration contents, data, or its Go func, and then
+// updates the schema migrations table to reflect it. The checksum is of the
+// whole migration file, which is more than data for a single-file migration.
+func executeAndRecord(ctx context.Context, d driver.Driver, mig *internal.Migration, data []byte, checksum, migrationsTable string) (err error) {
+	if mig.Func != nil {
+		err = mig.Func(ctx, rawConn(d))
+	} else {
+		err = d.Execute(ctx, string(data))
+	}
+	if err != nil {
+		return newMigrationError(mig.Version.String(), mig.Label, mig.DisplayName(), data, err)
+	}
+	if err = d.CreateSchemaMigrationsTable(ctx, migrationsTable); err != nil {
+		return fmt.Errorf("creating schema migrations table: %w", err)
+	}
+	err = updateSchemaMigrations(
 		ctx,
+		d,
 		migrationsTable,
 		mig.Indirection.Value == internal.DirForward,
 		mig.Version.String(),
 		mig.Label,
+		driver.Metadata{Checksum: checksum, Batch: mig.Batch},
 	)
 	if err != nil {
-		lgr.Error("updating schema migrations table", slog.Any("error", err), makeDurationMSAttr(startTime))
+		err = fmt.Errorf("updating schema migrations table: %w", err)
+	}
+	return
+}
+
+// updateSchemaMigrations adds or removes the row for version in the schema
+// migrations table. When adding it and d is a [driver.MetadataRecorder], then
+// meta is recorded along with it. Otherwise, meta is dropped.
+func updateSchemaMigrations(ctx context.Context, d driver.Driver, migrationsTable string, forward bool, version, label string, meta driver.Metadata) error {
+	if recorder, ok := d.(driver.MetadataRecorder); ok && forward {
+		return recorder.RecordMigration(ctx, migrationsTable, version, label, meta)
+	}
+	return d.UpdateSchemaMigrations(ctx, migrationsTable, forward, version, label)
+}
+
+// findRepeatables loads the repeatable migrations in dirFS that are pending,
+// ordered by filename. Whether or not one is pending depends on its last run
+// recorded in the repeatable migrations table, see [internal.RepeatableTable].
+func findRepeatables(ctx context.Context, d driver.Driver, dirFS fs.FS, migrationsTable string, o *options) ([]*internal.Repeatable, error) {
+	names, err := internal.ListFiles(dirFS, o.recursive, o.log())
+	if err != nil {
+		return nil, fmt.Errorf("reading directory entries: %w", err)
+	}
+
+	var repeatables []*internal.Repeatable
+	byLabel := make(map[string]*internal.Repeatable)
+	for _, name := range names {
+		label, perr := o.convention().ParseRepeatable(internal.Filename(name))
+		if perr != nil {
+			continue
+		}
+		if existing, found := byLabel[label]; found {
+			return nil, fmt.Errorf(
+				"%w; repeatable migrations %q and %q have the same label",
+				internal.ErrDataInvalid, existing.Filename, name,
+			)
+		}
+
+		data, rerr := fs.ReadFile(dirFS, name)
+		if rerr != nil {
+			return nil, fmt.Errorf("reading repeatable migration file: %w", rerr)
+		}
+		rep := internal.Repeatable{Label: label, Filename: name, Checksum: internal.Checksum(data)}
+		if rep.Directives, err = internal.ParseDirectives(data); err != nil {
+			return nil, fmt.Errorf("parsing directives of file %q: %w", name, err)
+		}
+		byLabel[label] = &rep
+		repeatables = append(repeatables, &rep)
+	}
+	if len(repeatables) < 1 {
+		return nil, nil
+	}
+	if _, ok := d.(driver.MetadataRecorder); !ok {
+		return nil, fmt.Errorf(
+			"%w; repeatable migrations need a driver that records checksums, see driver.MetadataRecorder",
+			errors.ErrUnsupported,
+		)
+	}
+
+	rows, err := d.AppliedVersions(ctx, internal.RepeatableTable(migrationsTable))
+	if errors.Is(err, driver.ErrSchemaMigrationsDoesNotExist) {
+		return repeatables, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading repeatable migrations table: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			o.log().Warn("closing rows from func findRepeatables", slog.Any("error", cerr))
+		}
+	}()
+	for rows.Next() {
+		row, err := scanAppliedRow(d, rows)
+		if err != nil {
+			return nil, err
+		}
+		rep, found := byLabel[row.version]
+		if !found {
+			continue
+		}
+		rep.Applied = true
+		rep.AppliedChecksum = row.meta.Checksum
+		if row.executedAt > 0 {
+			rep.ExecutedAt = time.Unix(row.executedAt, 0).UTC()
+		}
+	}
+
+	pending := slices.DeleteFunc(repeatables, func(rep *internal.Repeatable) bool { return !rep.Pending() })
+	return pending, nil
+}
+
+// runRepeatable executes a repeatable migration against the database, and then
+// records its checksum in the repeatable migrations table, replacing the
+// record of any previous run. When the driver is a [driver.Transactor], then
+// both are committed together.
+func runRepeatable(ctx context.Context, d driver.Driver, dir fs.FS, rep *internal.Repeatable, o *options) (err error) {
+	table := internal.RepeatableTable(cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName))
+	data, err := fs.ReadFile(dir, filepath.Clean(rep.Filename))
+	if err != nil {
+		return fmt.Errorf("%s: reading file in prep for running repeatable migration: %w", msgPrefix, err)
+	}
+
+	lgr := o.log().With(slog.String("path_to_file", rep.Filename), slog.String("label", rep.Label))
+	warnUnknownDirectives(lgr, rep.Directives)
+	if !rep.Directives.MatchesEnv(o.environment) {
+		lgr.Info("skipping, environment does not match",
+			slog.String("environment", o.environment), slog.Any("directive_envs", rep.Directives.Envs),
+		)
+		event := repeatableEvent(rep)
+		event.Skipped = true
+		o.observe().MigrationFinished(ctx, event)
+		return
+	}
+
+	obs := o.observe()
+	event := repeatableEvent(rep)
+	obs.MigrationStarted(ctx, event)
+	startTime := time.Now()
+	defer func() {
+		event.Duration, event.Err = time.Since(startTime), err
+		obs.MigrationFinished(ctx, event)
+	}()
+
+	if rep.Directives.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rep.Directives.Timeout)
+		defer cancel()
+	}
+
+	executeAndRecord := func(ctx context.Context, d driver.Driver) error {
+		if err := d.Execute(ctx, string(data)); err != nil {
+			return newMigrationError("", rep.Label, rep.Filename, data, err)
+		}
+		if err := d.CreateSchemaMigrationsTable(ctx, table); err != nil {
+			return fmt.Errorf("creating repeatable migrations table: %w", err)
+		}
+		if rep.Applied {
+			if err := d.UpdateSchemaMigrations(ctx, table, false, rep.Label, rep.Label); err != nil {
+				return fmt.Errorf("removing previous run from repeatable migrations table: %w", err)
+			}
+		}
+		meta := driver.Metadata{Checksum: rep.Checksum, Batch: rep.Batch}
+		if err := updateSchemaMigrations(ctx, d, table, true, rep.Label, rep.Label, meta); err != nil {
+			return fmt.Errorf("updating repeatable migrations table: %w", err)
+		}
+		return nil
+	}
+
+	if transactor, ok := d.(driver.Transactor); ok && !rep.Directives.NoTransaction {
+		lgr.Debug("running within transaction")
+		err = transactor.WithinTransaction(ctx, executeAndRecord)
 	} else {
-		lgr.Info("ok", makeDurationMSAttr(startTime))
+		err = executeAndRecord(ctx, d)
 	}
 	return
 }
 
+// rawConn returns the connection to pass along to a Go migration.
+func rawConn(d driver.Driver) any {
+	if rc, ok := d.(driver.RawConnector); ok {
+		return rc.RawConn()
+	}
+	return d
+}
+
+// ExportWith writes every pending forward migration, in order, as one SQL
+// script to run offline, such as by a DBA with their own tooling. Nothing is
+// executed, the driver only reads the schema migrations table to find the
+// pending migrations. The script creates the schema migrations table unless it
+// already exists, and records each migration in it after its statements, so
+// that the migrations are shown as applied once the script has run.
+//
+// The driver must be a [driver.Scripter]. It's an error to export a Go
+// migration, since its statements cannot be rendered. A migration file with
+// an env directive that does not match [WithEnvironment] is left out.
+// Pending repeatable migrations are exported after the versioned ones, unless
+// a target is passed in.
+//
+// Only one of [WithTargetVersion], [WithSteps], [WithTargetLabel] may be
+// passed in to target migrations. Otherwise, an error is returned.
+//
+// # Relevant opts
+//
+//   - [WithTargetVersion]. If passed in with a non-zero value, then this
+//     function will export up to and including the target migration.
+//     When passed in with a zero value, then an error is returned.
+//     When this option is omitted, then this function will export all pending
+//     migrations.
+//   - [WithSteps]. If passed in with a positive value, then this function
+//     will export at most that many migrations.
+//     When passed in with a non-positive value, then an error is returned.
+//   - [WithTargetLabel]. If passed in with a non-zero value, then this
+//     function will export migrations up to and including the first one with
+//     that label.
+//     When passed in with a zero value, then an error is returned.
+//   - [WithMigrationsTable]. If passed in with a non-zero value, then this
+//     function will override the default value of "schema_migrations".
+//     When passed in with a zero value, then an error is returned.
+//   - [WithEnvironment]. If passed in with a non-zero value, then a
+//     migration file with an env directive is only exported when one of its
+//     environments matches.
+//     When passed in with a zero value, then an error is returned.
+//   - [WithWriter]. If passed in with a non-zero value, then it will set the
+//     output writer for the script.
+//     When passed in with a zero value, then an error is returned.
+//     When this option is omitted, then it will write to standard output.
+func ExportWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts ...Opter) error {
+	o, err := setOptions(opts...)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", msgPrefix, "ExportWith", err)
+	}
+	o.export = true
+
+	return migrateOrRollback(ctx, driver, dirFS, true, o)
+}
+
+// writePlan outputs, as annotated SQL, the statements that would run for each
+// migration in migrations. Nothing is executed. When the driver is not a
+// [driver.Scripter], then only the contents of the migration files are shown.
+// When exporting, see [ExportWith], then the output is a script to run as is.
+func writePlan(d driver.Driver, dir fs.FS, migrations []*internal.Migration, repeatables []*internal.Repeatable, o *options) error {
+	migrationsTable := cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName)
+	scripter, isScripter := d.(driver.Scripter)
+	_, isTransactor := d.(driver.Transactor)
+	if o.export {
+		if !isScripter {
+			return fmt.Errorf("%s: exporting migrations: driver %q cannot output statements for the schema migrations table", msgPrefix, d.Name())
+		}
+		// The script is run by other tooling, which may or may not use a
+		// transaction, so don't claim that it does.
+		isTransactor = false
+		for _, mig := range migrations {
+			if mig.Func != nil {
+				return fmt.Errorf("%s: exporting migrations: Go migration %q cannot be exported", msgPrefix, mig.DisplayName())
+			}
+		}
+	}
+	script := internal.NewScriptWriter(cmp.Or[io.Writer](o.writer, os.Stdout))
+
+	header := "godfish dry run, nothing was executed"
+	if o.export {
+		header = "godfish export, run this script to apply the pending migrations"
+	}
+	script.Comment(
+		header,
+		"driver: "+d.Name(),
+		"migrations_table: "+migrationsTable,
+		fmt.Sprintf("migrations: %d", len(migrations)),
+	)
+	if len(repeatables) > 0 {
+		script.Comment(fmt.Sprintf("repeatable migrations: %d", len(repeatables)))
+	}
+	if !isScripter {
+		script.Comment("this driver cannot output statements for the schema migrations table")
+	}
+	script.Newline()
+
+	if o.export {
+		// Create the tables once, rather than along with each migration.
+		tables := []string{migrationsTable}
+		if len(repeatables) > 0 {
+			tables = append(tables, internal.RepeatableTable(migrationsTable))
+		}
+		for _, table := range tables {
+			stmt, err := scripter.CreateSchemaMigrationsTableScript(table)
+			if err != nil {
+				return fmt.Errorf("%s: exporting migrations: %w", msgPrefix, err)
+			}
+			script.Statement(stmt)
+		}
+	}
+
+	for i, mig := range migrations {
+		script.Comment(
+			fmt.Sprintf("[%d/%d] direction: %s, version: %s, label: %s", i+1, len(migrations), mig.Indirection.Value, mig.Version.String(), mig.Label),
+			"path_to_file: "+mig.DisplayName(),
+		)
+
+		var data []byte
+		var checksum string
+		var directives internal.Directives
+		if mig.Func == nil {
+			var err error
+			if data, err = fs.ReadFile(dir, filepath.Clean(mig.Filename)); err != nil {
+				return fmt.Errorf("%s: reading file in prep for writing plan: %w", msgPrefix, err)
+			}
+			checksum = internal.Checksum(data)
+			if section, ok := internal.Section(data, mig.Indirection.Value); ok {
+				data = section
+			}
+			if directives, err = internal.ParseDirectives(data); err != nil {
+				return fmt.Errorf("%s: parsing directives of file %q: %w", msgPrefix, mig.Filename, err)
+			}
+		}
+		if val := directives.String(); val != "" {
+			script.Comment("directives: " + val)
+		}
+		if !directives.MatchesEnv(o.environment) {
+			script.Comment(fmt.Sprintf("skipped, environment %q does not match", o.environment))
+			script.Newline()
+			continue
+		}
+		if isTransactor && !directives.NoTransaction {
+			script.Comment("runs within a transaction along with the schema migrations table statements")
+		}
+
+		if mig.Func != nil {
+			script.Comment("this is a Go migration, its statements cannot be shown")
+		} else {
+			script.Statement(string(data))
+		}
+
+		if !isScripter {
+			continue
+		}
+		if !o.export {
+			stmt, err := scripter.CreateSchemaMigrationsTableScript(migrationsTable)
+			if err != nil {
+				return fmt.Errorf("%s: writing plan: %w", msgPrefix, err)
+			}
+			script.Statement(stmt)
+		}
+		forward := mig.Indirection.Value == internal.DirForward
+		stmt, err := scripter.UpdateSchemaMigrationsScript(migrationsTable, forward, mig.Version.String(), mig.Label, checksum, mig.Batch)
+		if err != nil {
+			return fmt.Errorf("%s: writing plan: %w", msgPrefix, err)
+		}
+		script.Statement(stmt)
+	}
+
+	repeatableTable := internal.RepeatableTable(migrationsTable)
+	for i, rep := range repeatables {
+		script.Comment(
+			fmt.Sprintf("[%d/%d] repeatable, label: %s", i+1, len(repeatables), rep.Label),
+			"path_to_file: "+rep.Filename,
+		)
+		if val := rep.Directives.String(); val != "" {
+			script.Comment("directives: " + val)
+		}
+		if !rep.Directives.MatchesEnv(o.environment) {
+			script.Comment(fmt.Sprintf("skipped, environment %q does not match", o.environment))
+			script.Newline()
+			continue
+		}
+		if isTransactor && !rep.Directives.NoTransaction {
+			script.Comment("runs within a transaction along with the repeatable migrations table statements")
+		}
+		data, err := fs.ReadFile(dir, filepath.Clean(rep.Filename))
+		if err != nil {
+			return fmt.Errorf("%s: reading file in prep for writing plan: %w", msgPrefix, err)
+		}
+		script.Statement(string(data))
+
+		if !isScripter {
+			continue
+		}
+		var stmt string
+		if !o.export {
+			if stmt, err = scripter.CreateSchemaMigrationsTableScript(repeatableTable); err != nil {
+				return fmt.Errorf("%s: writing plan: %w", msgPrefix, err)
+			}
+			script.Statement(stmt)
+		}
+		if rep.Applied {
+			if stmt, err = scripter.UpdateSchemaMigrationsScript(repeatableTable, false, rep.Label, rep.Label, "", 0); err != nil {
+				return fmt.Errorf("%s: writing plan: %w", msgPrefix, err)
+			}
+			script.Statement(stmt)
+		}
+		if stmt, err = scripter.UpdateSchemaMigrationsScript(repeatableTable, true, rep.Label, rep.Label, rep.Checksum, rep.Batch); err != nil {
+			return fmt.Errorf("%s: writing plan: %w", msgPrefix, err)
+		}
+		script.Statement(stmt)
+	}
+
+	if err := script.Err(); err != nil {
+		return fmt.Errorf("%s: writing plan: %w", msgPrefix, err)
+	}
+	return nil
+}
+
 // makeDurationMSAttr calculates how much time, in milliseconds, has transpired
 // since startedAt and returns a slog.KindInt64 attr with the key duration_ms.
 func makeDurationMSAttr(startedAt time.Time) slog.Attr {
@@ -385,16 +1164,24 @@ func makeDurationMSAttr(startedAt time.Time) slog.Attr {
 //
 // (seems to look better in a terminal emulator)
 //
-//	i	version	applied	executed_at		label	filename
-//	0	1234	true	2026-06-21 15:04:05	alpha	forward-1234-alpha.sql
-//	1	2345	true	2026-07-01 03:40:50	bravo	forward-2345-bravo.sql
-//	2	3456	false	-			charlie	forward-3456-charlie.sql
+//	i	version	applied	executed_at		batch	label	filename			directives
+//	0	1234	true	2026-06-21 15:04:05	1	alpha	forward-1234-alpha.sql		-
+//	1	2345	true	2026-07-01 03:40:50	2	bravo	forward-2345-bravo.sql		-
+//	2	3456	false	-			-	charlie	forward-3456-charlie.sql	timeout 10m0s
 //
 // # Example of json format
 //
-//	{"i":0,"version":"1234","applied":true,"executed_at":"2026-06-21 15:04:05","label":"alpha","filename":"forward-1234-alpha.sql"}
-//	{"i":1,"version":"2345","applied":true,"executed_at":"2026-07-01 03:40:50","label":"bravo","filename":"forward-2345-bravo.sql"}
-//	{"i":2,"version":"3456","applied":false,"executed_at":"","label":"charlie","filename":"forward-3456-charlie.sql"}
+//	{"i":0,"version":"1234","applied":true,"executed_at":"2026-06-21 15:04:05","batch":1,"label":"alpha","filename":"forward-1234-alpha.sql","directives":""}
+//	{"i":1,"version":"2345","applied":true,"executed_at":"2026-07-01 03:40:50","batch":2,"label":"bravo","filename":"forward-2345-bravo.sql","directives":""}
+//	{"i":2,"version":"3456","applied":false,"executed_at":"","batch":0,"label":"charlie","filename":"forward-3456-charlie.sql","directives":"timeout 10m0s"}
+//
+// The batch column identifies the run that applied each migration. It's empty
+// for migrations that were applied before batches were recorded.
+//
+// The directives column shows the directives found in the header comments of
+// each migration file. See the package documentation for more.
+//
+// To get the same information as values instead, use [Status].
 //
 // # Relevant opts
 //   - [WithWriter]. If passed in with a non-zero value, then it will set the
@@ -410,12 +1197,15 @@ func makeDurationMSAttr(startedAt time.Time) slog.Attr {
 //     When passed in with a zero value, then an error is returned.
 //     When this option is omitted, then this function will use the default.
 //     This DB table will be automatically created unless it already exists.
+//   - [WithGoMigrations]. If passed in with a non-zero value, then the Go
+//     migrations are considered along with the migration files.
+//     When passed in with a zero value, then an error is returned.
 func InfoWith(ctx context.Context, driver driver.Driver, directory fs.FS, opts ...Opter) error {
 	o, err := setOptions(opts...)
 	if err != nil {
 		return fmt.Errorf("%s.%s: %w", msgPrefix, "InfoWith", err)
 	}
-	return info(ctx, driver, directory, true, "", o.writer, o.format, o.migrationsTable)
+	return info(ctx, driver, directory, true, "", o)
 }
 
 // Info writes status of migrations to w in formats json or tsv.
@@ -430,13 +1220,14 @@ func InfoWith(ctx context.Context, driver driver.Driver, directory fs.FS, opts .
 // New code should use [InfoWith].
 // Current code is encouraged to adjust as well.
 func Info(ctx context.Context, driver driver.Driver, directory fs.FS, forward bool, finishAtVersion string, w io.Writer, format string, migrationsTable string) (err error) {
-	return info(ctx, driver, directory, forward, finishAtVersion, w, format, migrationsTable)
+	return info(ctx, driver, directory, forward, finishAtVersion, &options{writer: w, format: format, migrationsTable: migrationsTable})
 }
 
-func info(ctx context.Context, driver driver.Driver, directory fs.FS, forward bool, finishAtVersion string, w io.Writer, format string, migrationsTable string) (err error) {
-	w = cmp.Or[io.Writer](w, os.Stdout)
-	format = cmp.Or(format, "tsv")
-	migrationsTable = cmp.Or(migrationsTable, internal.DefaultMigrationsTableName)
+func info(ctx context.Context, driver driver.Driver, directory fs.FS, forward bool, finishAtVersion string, o *options) (err error) {
+	ctx = o.logContext(ctx)
+	w := cmp.Or[io.Writer](o.writer, os.Stdout)
+	format := cmp.Or(o.format, "tsv")
+	migrationsTable := cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName)
 
 	direction := internal.DirReverse
 	if forward {
@@ -447,30 +1238,47 @@ func info(ctx context.Context, driver driver.Driver, directory fs.FS, forward bo
 		direction:       direction,
 		dirFS:           directory,
 		finishAtVersion: finishAtVersion,
-		infoPrinter:     choosePrinter(format, w),
+		infoPrinter:     choosePrinter(format, w, o.log(), internal.NewJSON, internal.NewTSV),
+		goMigrations:    o.goMigrations,
+		scheme:          o.scheme(),
+		convention:      o.convention(),
+		recursive:       o.recursive,
+		files:           o.parsedFiles,
+		logger:          o.log(),
+		readDirectives:  true,
 	}
 	_, err = finder.query(ctx, driver, migrationsTable)
 	return
 }
 
-func choosePrinter(format string, w io.Writer) (out internal.InfoPrinter) {
+// choosePrinter constructs a printer for the format, with newJSON for "json",
+// or with newTSV otherwise. An unknown format is logged as it defaults to tsv.
+func choosePrinter[P any](format string, w io.Writer, lgr *slog.Logger, newJSON, newTSV func(io.Writer, *slog.Logger) P) P {
 	if format == "json" {
-		out = internal.NewJSON(w)
-		return
+		return newJSON(w, lgr)
 	}
 
 	if format != "tsv" && format != "" {
-		slog.Warn("unknown format, defaulting to tsv", slog.String("format", format))
+		lgr.Warn("unknown format, defaulting to tsv", slog.String("format", format))
 	}
-	out = internal.NewTSV(w)
-	return
+	return newTSV(w, lgr)
 }
 
 // Init creates a configuration file at pathToFile unless it already exists.
-func Init(pathToFile string) (err error) {
+// It's like [InitWith] without options.
+func Init(pathToFile string) error { return InitWith(pathToFile) }
+
+// InitWith creates a configuration file at pathToFile unless it already
+// exists. The only relevant option is [WithLogger].
+func InitWith(pathToFile string, opts ...Opter) (err error) {
+	o, err := setOptions(opts...)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", msgPrefix, "InitWith", err)
+	}
+
 	_, err = os.Stat(pathToFile)
 	if err == nil {
-		slog.Info("config file already present", slog.String("path_to_file", pathToFile))
+		o.log().Info("config file already present", slog.String("path_to_file", pathToFile))
 		return nil
 	}
 	if !os.IsNotExist(err) {
@@ -488,65 +1296,65 @@ func Init(pathToFile string) (err error) {
 	)
 }
 
-func findParseMigration(fsys fs.FS, direction internal.Direction, version string) (*internal.Migration, error) {
-	basename, err := findUniqueByPrefix(fsys, direction, version)
+// MergeFS combines several directories of migration files, such as those
+// opened with [os.DirFS], into one, so they may be passed in as the dirFS to
+// the other functions. It's an error to read a directory from the result, in
+// which the same file is in more than one of the combined directories.
+func MergeFS(fsyss ...fs.FS) fs.FS { return internal.MergeFS(fsyss...) }
+
+func findParseMigration(fsys fs.FS, direction internal.Direction, version string, o *options) (*internal.Migration, error) {
+	mig, err := findUniqueByPrefix(fsys, direction, version, o)
 	if err != nil {
 		return nil, fmt.Errorf("attempting to find migration file by prefix: %w", err)
 	}
-	basename = filepath.Clean(basename)
-	mig, err := internal.ParseMigration(internal.Filename(basename))
-	if err != nil {
-		return nil, fmt.Errorf("parsing migration metadata with filename %q: %w", basename, err)
-	}
-	mig.Filename = basename
 	return mig, nil
 }
 
-// findUniqueByPrefix returns the relative path of the single file matching prefix.
-// It errors early if 0 or > 1 matches are found.
-func findUniqueByPrefix(fsys fs.FS, direction internal.Direction, version string) (string, error) {
-	entries, err := fs.ReadDir(fsys, ".")
+// findUniqueByPrefix returns the single migration in the direction, whose
+// version starts with the version prefix. It errors early if 0 or > 1 matches
+// are found.
+func findUniqueByPrefix(fsys fs.FS, direction internal.Direction, version string, o *options) (*internal.Migration, error) {
+	names, err := internal.ListFiles(fsys, o.recursive, o.log())
 	if err != nil {
-		return "", fmt.Errorf("reading directory entries: %w", err)
-	}
-
-	var match string
-	var found bool
-	var directionNames []string
-	switch direction {
-	case internal.DirForward:
-		directionNames = internal.ForwardDirections
-	case internal.DirReverse:
-		directionNames = internal.ReverseDirections
+		return nil, fmt.Errorf("reading directory entries: %w", err)
 	}
 
-	for _, entry := range entries {
-		basename := entry.Name()
-		if entry.IsDir() {
-			slog.Info("searching for available migrations and found directory, skipping", slog.String("path", basename))
+	var match *internal.Migration
+	for _, name := range names {
+		mig, perr := o.convention().ParseMigration(internal.Filename(name), o.scheme())
+		if perr != nil || !strings.HasPrefix(mig.Version.String(), version) {
 			continue
 		}
-
-		for _, alias := range directionNames {
-			filenamePrefix := alias + "-" + version
-			if strings.HasPrefix(basename, filenamePrefix) {
-				if found {
-					return "", fmt.Errorf(
-						"%s: too many migration files with matching direction-version, %q",
-						msgPrefix, filenamePrefix,
-					)
-				}
-				match = basename
-				found = true
+		if dir := mig.Indirection.Value; dir != direction {
+			// A single-file migration is named in the forward direction, but
+			// may also be a reverse migration.
+			if dir != internal.DirForward {
+				continue
 			}
+			data, rerr := fs.ReadFile(fsys, name)
+			if rerr != nil {
+				return nil, fmt.Errorf("reading file for sections: %w", rerr)
+			}
+			if _, ok := internal.Section(data, direction); !ok {
+				continue
+			}
+			mig.Indirection.Value = direction
+		}
+		if match != nil {
+			return nil, fmt.Errorf(
+				"%s: too many migration files with matching direction-version, %s %q",
+				msgPrefix, direction, version,
+			)
 		}
+		mig.Filename = name
+		match = mig
 	}
 
-	if !found {
-		return "", fmt.Errorf("version %w", internal.ErrNotFound)
+	if match == nil {
+		return nil, fmt.Errorf("version %w", internal.ErrNotFound)
 	}
 
-	return filepath.Clean(match), nil
+	return match, nil
 }
 
 // migrationFinder is a collection of named parameters to use when searching
@@ -556,11 +1364,33 @@ type migrationFinder struct {
 	dirFS           fs.FS
 	finishAtVersion string
 	infoPrinter     internal.InfoPrinter
+	goMigrations    []*internal.Migration
+	scheme          internal.VersionScheme
+	convention      internal.NamingConvention
+	// files is where a Migrator caches the parsed migration files. It's nil
+	// otherwise.
+	files  *parsedFiles
+	logger *slog.Logger
+	// recursive is whether or not to search the subdirectories of dirFS.
+	recursive bool
+	// readDirectives is whether or not to read each available migration file
+	// and parse its Directives.
+	readDirectives bool
+	// steps, targetLabel, since further limit the migrations to apply, see
+	// the limit method.
+	steps       int
+	targetLabel string
+	since       time.Time
+	batch       int64
+	lastBatch   bool
+	// latestBatch is the greatest batch of the applied migrations. It's set
+	// by the query method.
+	latestBatch int64
 }
 
 // query returns a list of Migrations to apply.
 func (m *migrationFinder) query(ctx context.Context, d driver.Driver, migrationsTable string) (out []*internal.Migration, err error) {
-	lgr := slog.With(slog.String("func", "(*migrationFinder).query"))
+	lgr := m.logger.With(slog.String("func", "(*migrationFinder).query"))
 
 	availableByVersion, orderedAvailableVersions, err := m.available()
 	if err != nil {
@@ -575,11 +1405,11 @@ func (m *migrationFinder) query(ctx context.Context, d driver.Driver, migrations
 		slog.Any("available", internal.Migrations(available)),
 	)
 
-	applied, err := scanAppliedVersions(ctx, d, migrationsTable, availableByVersion)
+	applied, err := scanAppliedVersions(ctx, d, migrationsTable, m.scheme, availableByVersion)
 	if errors.Is(err, driver.ErrSchemaMigrationsDoesNotExist) {
 		// The next invocation of CreateSchemaMigrationsTable should fix this.
 		// We can continue with zero value for now.
-		slog.Info(
+		m.logger.Info(
 			"no migrations applied yet, continuing...",
 			slog.Any("message", err), slog.String("migrations_table", migrationsTable),
 		)
@@ -590,6 +1420,7 @@ func (m *migrationFinder) query(ctx context.Context, d driver.Driver, migrations
 		slog.Int("count", len(applied)),
 		slog.Any("applied", internal.Migrations(applied)),
 	)
+	m.latestBatch = latestBatch(applied)
 
 	defer func() {
 		if m.infoPrinter == nil {
@@ -597,7 +1428,7 @@ func (m *migrationFinder) query(ctx context.Context, d driver.Driver, migrations
 		}
 
 		if perr := printMigrations(m.infoPrinter, applied, out); perr != nil {
-			slog.Error("printing migrations", slog.Any("error", perr))
+			m.logger.Error("printing migrations", slog.Any("error", perr))
 		}
 	}()
 
@@ -626,7 +1457,7 @@ func (m *migrationFinder) query(ctx context.Context, d driver.Driver, migrations
 		m.finishAtVersion = toApply[0].Version.String()
 	}
 	var finish internal.Version
-	if finish, err = internal.ParseVersion(m.finishAtVersion); err != nil {
+	if finish, err = m.parseFinish(); err != nil {
 		return
 	}
 	lgr.Debug("about to collect migrations to apply in a loop",
@@ -656,51 +1487,137 @@ func (m *migrationFinder) query(ctx context.Context, d driver.Driver, migrations
 	return
 }
 
+// parseFinish parses m.finishAtVersion with the version scheme. The limits,
+// MinVersion and MaxVersion, are bounds of every scheme.
+func (m *migrationFinder) parseFinish() (internal.Version, error) {
+	switch m.finishAtVersion {
+	case internal.MinVersion:
+		return internal.FirstVersion, nil
+	case internal.MaxVersion:
+		return internal.LastVersion, nil
+	}
+	return m.scheme.ParseVersion(m.finishAtVersion)
+}
+
 // available loads Migrations in the m.dirFS with a direction matching
 // m.direction. It returns them in a map where the key is the migration
 // version and value is the *Migration, an ordered slice of migration versions.
 // and if non-empty, an error. Entries in the fs.FS that are files, and have
 // a basename in a form: "${direction}-${version}-${label}", are parsed into
-// migration values. The migration file's basename is also added here.
+// migration values. The migration file's basename is also added here. A
+// single-file migration with a section for m.direction is included too.
 func (m *migrationFinder) available() (map[int64]*internal.Migration, []int64, error) {
-	dirEntries, err := fs.ReadDir(m.dirFS, ".")
+	key := parsedFilesKey{m.direction, m.scheme, m.convention, m.recursive, m.readDirectives}
+	files, err := m.files.get(key, m.parseFiles)
 	if err != nil {
-		return nil, nil, fmt.Errorf("reading directory entries: %w", err)
-	}
-	if m.direction != internal.DirForward {
-		slices.Reverse(dirEntries)
+		return nil, nil, err
 	}
 
-	migrations := make(map[int64]*internal.Migration, len(dirEntries))
-	orderedVersions := make([]int64, 0, len(dirEntries))
+	migrations := make(map[int64]*internal.Migration, len(files)+len(m.goMigrations))
+	orderedVersions := make([]int64, 0, len(files)+len(m.goMigrations))
 
-	for _, dirEntry := range dirEntries {
-		name := dirEntry.Name()
-		if dirEntry.IsDir() {
-			slog.Info("searching for available migrations and found directory, skipping", slog.String("path", name))
-			continue
+	for _, mig := range files {
+		version := mig.Version.Value()
+		if existing, found := migrations[version]; found {
+			return nil, nil, fmt.Errorf(
+				"%w; migration files %q and %q have the same version",
+				internal.ErrDataInvalid, existing.Filename, mig.Filename,
+			)
 		}
+		migrations[version] = mig
+		orderedVersions = append(orderedVersions, version)
+	}
 
-		mig, ierr := internal.ParseMigration(internal.Filename(name))
-		if internal.IsInvalidDataError(ierr) {
-			slog.Warn("parsing migration filename, skipping over this one", slog.String("filename", name), slog.String("error", ierr.Error()))
-			continue
-		} else if ierr != nil {
-			return nil, nil, ierr
-		}
-		dir := mig.Indirection.Value
-		if dir != m.direction {
+	for _, mig := range m.goMigrations {
+		if mig.Indirection.Value != m.direction {
 			continue
 		}
-		mig.Filename = name
 		version := mig.Version.Value()
+		if existing, found := migrations[version]; found {
+			return nil, nil, fmt.Errorf(
+				"%w; Go migration with version %q has the same version as file %q",
+				internal.ErrDataInvalid, mig.Version.String(), existing.Filename,
+			)
+		}
 		migrations[version] = mig
 		orderedVersions = append(orderedVersions, version)
 	}
+	slices.Sort(orderedVersions)
+	if m.direction != internal.DirForward {
+		slices.Reverse(orderedVersions)
+	}
 
 	return migrations, slices.Clip(orderedVersions), nil
 }
 
+// parseFiles lists the migration files in m.dirFS with a direction matching
+// m.direction, in the order they are listed.
+func (m *migrationFinder) parseFiles() (out []*internal.Migration, err error) {
+	names, err := internal.ListFiles(m.dirFS, m.recursive, m.logger)
+	if err != nil {
+		return nil, fmt.Errorf("reading directory entries: %w", err)
+	}
+	if m.direction != internal.DirForward {
+		slices.Reverse(names)
+	}
+
+	out = make([]*internal.Migration, 0, len(names))
+	for _, name := range names {
+		mig, ierr := m.convention.ParseMigration(internal.Filename(name), m.scheme)
+		if _, rerr := m.convention.ParseRepeatable(internal.Filename(name)); ierr != nil && rerr == nil {
+			// Repeatable migrations are found separately, see findRepeatables.
+			continue
+		} else if internal.IsInvalidDataError(ierr) {
+			m.logger.Warn("parsing migration filename, skipping over this one", slog.String("filename", name), slog.String("error", ierr.Error()))
+			continue
+		} else if ierr != nil {
+			return nil, ierr
+		}
+		var data []byte
+		if dir := mig.Indirection.Value; dir != m.direction {
+			// A single-file migration is named in the forward direction, but
+			// may also be a reverse migration.
+			if dir != internal.DirForward {
+				continue
+			}
+			if data, err = fs.ReadFile(m.dirFS, name); err != nil {
+				return nil, fmt.Errorf("reading file for sections: %w", err)
+			}
+			if _, ok := internal.Section(data, m.direction); !ok {
+				continue
+			}
+			mig.Indirection.Value = m.direction
+		}
+		mig.Filename = name
+		if m.readDirectives {
+			if data == nil {
+				if data, err = fs.ReadFile(m.dirFS, name); err != nil {
+					return nil, fmt.Errorf("reading file for directives: %w", err)
+				}
+			}
+			if section, ok := internal.Section(data, m.direction); ok {
+				data = section
+			}
+			if mig.Directives, err = internal.ParseDirectives(data); err != nil {
+				return nil, fmt.Errorf("parsing directives of file %q: %w", name, err)
+			}
+		}
+		out = append(out, mig)
+	}
+	return out, nil
+}
+
+// findGoMigration returns the Go migration with the direction and version, or
+// nil if there is no such migration.
+func findGoMigration(goMigrations []*internal.Migration, direction internal.Direction, version string) *internal.Migration {
+	for _, mig := range goMigrations {
+		if mig.Indirection.Value == direction && mig.Version.String() == version {
+			return mig
+		}
+	}
+	return nil
+}
+
 // scanAppliedVersions reads the DB for info on already-applied migrations.
 // A side effect of this operation is a signal of whether or not the
 // migrationsTable needs to be "upgraded". That is, if the driver detects
@@ -719,43 +1636,46 @@ func (m *migrationFinder) available() (map[int64]*internal.Migration, []int64, e
 // pretty important when you want to read the file later.
 // But if you only you want to check if the DB table needs to be upgraded, then
 // it's ok to pass an empty map.
-func scanAppliedVersions(ctx context.Context, d driver.Driver, migrationsTable string, availableByVersion map[int64]*internal.Migration) (out []*internal.Migration, err error) {
+func scanAppliedVersions(ctx context.Context, d driver.Driver, migrationsTable string, scheme internal.VersionScheme, availableByVersion map[int64]*internal.Migration) (out []*internal.Migration, err error) {
 	var rows driver.AppliedVersions
 	if rows, err = d.AppliedVersions(ctx, migrationsTable); err != nil {
 		return
 	}
 	defer func() {
 		if cerr := rows.Close(); cerr != nil {
-			slog.Warn("closing rows from func scanAppliedVersions", slog.Any("error", cerr))
+			driver.Logger(ctx).Warn("closing rows from func scanAppliedVersions", slog.Any("error", cerr))
 		}
 	}()
 	for rows.Next() {
-		var version, label string
-		var executedAt int64
-		if err = rows.Scan(&version, &label, &executedAt); err != nil {
+		var row appliedRow
+		if row, err = scanAppliedRow(d, rows); err != nil {
 			return
 		}
 
-		ver, verr := internal.ParseVersion(version)
+		ver, verr := scheme.ParseVersion(row.version)
 		if verr != nil {
-			err = fmt.Errorf("%w; while scanning applied versions, parsing version (%v) from DB: %w", internal.ErrDataInvalid, version, verr)
+			err = fmt.Errorf("%w; while scanning applied versions, parsing version (%v) from DB: %w", internal.ErrDataInvalid, row.version, verr)
 			return
 		}
 		var executedAtTime time.Time
-		if executedAt > 0 {
-			executedAtTime = time.Unix(executedAt, 0).UTC()
+		if row.executedAt > 0 {
+			executedAtTime = time.Unix(row.executedAt, 0).UTC()
 		}
 		mig := internal.Migration{
 			Indirection: internal.Indirection{Value: internal.DirForward},
-			Label:       label,
+			Label:       row.label,
 			Version:     ver,
 			ExecutedAt:  executedAtTime,
 			Applied:     true,
+			Checksum:    row.meta.Checksum,
+			Batch:       row.meta.Batch,
 		}
 
 		if availableByVersion != nil {
 			if knownAvailableMigration, found := availableByVersion[ver.Value()]; found {
 				mig.Filename = knownAvailableMigration.Filename
+				mig.Func = knownAvailableMigration.Func
+				mig.Directives = knownAvailableMigration.Directives
 				// If this data was originally inserted before the label column was present,
 				// then it would be empty in the DB. Attempt to reconstruct the Label field
 				// based on a matching filename.
@@ -771,6 +1691,46 @@ func scanAppliedVersions(ctx context.Context, d driver.Driver, migrationsTable s
 	return
 }
 
+// appliedRow is a row of the schema migrations table.
+type appliedRow struct {
+	version    string
+	label      string
+	executedAt int64
+	meta       driver.Metadata
+}
+
+// scanAppliedRow scans the current row of rows, which came from d. Only the
+// rows of a [driver.MetadataRecorder] have the fields of a [driver.Metadata].
+func scanAppliedRow(d driver.Driver, rows driver.AppliedVersions) (out appliedRow, err error) {
+	dest := []any{&out.version, &out.label, &out.executedAt}
+	if _, ok := d.(driver.MetadataRecorder); ok {
+		dest = append(dest, &out.meta.Checksum, &out.meta.Batch)
+	}
+	err = rows.Scan(dest...)
+	return
+}
+
+// latestBatch returns the greatest batch of the applied migrations, or 0 if
+// there are none.
+func latestBatch(applied []*internal.Migration) (out int64) {
+	for _, mig := range applied {
+		out = max(out, mig.Batch)
+	}
+	return
+}
+
+// nextBatch returns the batch to record for the migrations applied in a new
+// run. It's one more than the greatest batch recorded so far.
+func nextBatch(ctx context.Context, d driver.Driver, migrationsTable string, scheme internal.VersionScheme) (int64, error) {
+	applied, err := scanAppliedVersions(ctx, d, migrationsTable, scheme, nil)
+	if errors.Is(err, driver.ErrSchemaMigrationsDoesNotExist) {
+		return 1, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("scanning applied migrations for batch: %w", err)
+	}
+	return latestBatch(applied) + 1, nil
+}
+
 // filter compares lists of applied and available migrations, then selects a
 // list of migrations to apply.
 func (m *migrationFinder) filter(applied, available []*internal.Migration) (out []*internal.Migration, err error) {
@@ -815,7 +1775,7 @@ func (m *migrationFinder) filter(applied, available []*internal.Migration) (out
 					Value: internal.DirReverse,
 					Label: "reverse", // need to have something here, it gets restored later.
 				}
-				mut, err = newMigration(mig.Version.String(), indirection, mig.Label)
+				mut, err = newMigration(mig.Version.String(), indirection, mig.Label, m.scheme)
 				if err != nil {
 					return
 				}
@@ -829,7 +1789,7 @@ func (m *migrationFinder) filter(applied, available []*internal.Migration) (out
 					// "${direction}-${version}-${label}", then this won't work.
 					if mig.Indirection.Label == fwd {
 						indirection.Label = internal.ReverseDirections[i]
-						mut, err = newMigration(mig.Version.String(), indirection, mig.Label)
+						mut, err = newMigration(mig.Version.String(), indirection, mig.Label, m.scheme)
 						if err != nil {
 							return
 						}
@@ -844,6 +1804,10 @@ func (m *migrationFinder) filter(applied, available []*internal.Migration) (out
 					return
 				}
 				mut.Filename = mig.Filename
+				mut.Func = mig.Func
+				mut.Directives = mig.Directives
+				mut.ExecutedAt = mig.ExecutedAt
+				mut.Batch = mig.Batch
 				out = append(out, mut)
 			}
 		}
@@ -857,12 +1821,75 @@ func (m *migrationFinder) filter(applied, available []*internal.Migration) (out
 			return out[j].Version.Before(out[i].Version)
 		})
 	}
-	return
+	// In reverse, the applied migrations without a reverse migration are
+	// left over. A rollback by batch should not silently skip them.
+	noReverse := slices.Collect(maps.Values(uniqueToApplied))
+	if m.direction == internal.DirForward {
+		noReverse = nil
+	}
+	sort.Slice(noReverse, func(i, j int) bool {
+		return noReverse[i].Version.Before(noReverse[j].Version)
+	})
+	return m.limit(out, noReverse)
+}
+
+// limit narrows down toApply, which is sorted in the order to apply them. When
+// targetLabel is set, it keeps the migrations up to and including the first
+// one with that label. When steps is set, it keeps that many. When since is
+// set, it keeps the ones that were executed after that time. When batch or
+// lastBatch is set, it keeps the ones that were applied in that batch. Then,
+// it's an error if any of noReverse, the applied migrations without a reverse
+// migration, was also applied in that batch.
+func (m *migrationFinder) limit(toApply, noReverse []*internal.Migration) ([]*internal.Migration, error) {
+	if m.targetLabel != "" {
+		i := slices.IndexFunc(toApply, func(mig *internal.Migration) bool { return mig.Label == m.targetLabel })
+		if i < 0 {
+			return nil, fmt.Errorf(
+				"%w; no %s migration to apply with label %q",
+				internal.ErrNotFound, m.direction, m.targetLabel,
+			)
+		}
+		toApply = toApply[:i+1]
+	}
+	if m.steps > 0 && m.steps < len(toApply) {
+		toApply = toApply[:m.steps]
+	}
+	if !m.since.IsZero() {
+		toApply = slices.DeleteFunc(toApply, func(mig *internal.Migration) bool {
+			return !mig.ExecutedAt.After(m.since)
+		})
+	}
+	if (m.batch > 0 || m.lastBatch) && len(toApply)+len(noReverse) > 0 {
+		batch := m.batch
+		if m.lastBatch {
+			if batch = max(latestBatch(toApply), latestBatch(noReverse)); batch < 1 {
+				return nil, fmt.Errorf("%w; no %s migration to apply has a batch", internal.ErrNotFound, m.direction)
+			}
+		}
+		toApply = slices.DeleteFunc(toApply, func(mig *internal.Migration) bool { return mig.Batch != batch })
+
+		var missing []string
+		for _, mig := range noReverse {
+			if mig.Batch == batch {
+				missing = append(missing, mig.Version.String())
+			}
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf(
+				"%w; no %s migration for versions %q, applied in batch %d",
+				internal.ErrNotFound, m.direction, missing, batch,
+			)
+		}
+		if len(toApply) < 1 {
+			return nil, fmt.Errorf("%w; no %s migration to apply with batch %d", internal.ErrNotFound, m.direction, batch)
+		}
+	}
+	return toApply, nil
 }
 
-func newMigration(version string, ind internal.Indirection, label string) (out *internal.Migration, err error) {
+func newMigration(version string, ind internal.Indirection, label string, scheme internal.VersionScheme) (out *internal.Migration, err error) {
 	fn := internal.MakeFilename(version, ind, label)
-	out, err = internal.ParseMigration(fn)
+	out, err = internal.ParseMigrationWith(fn, scheme)
 	return
 }
 
@@ -881,17 +1908,13 @@ func printMigrations(p internal.InfoPrinter, applied, toApply []*internal.Migrat
 //
 // # Relevant opts
 //
-//   - [WithMigrationsTable]. If passed in with a non-zero value, then this
-//     function will override the default value of "schema_migrations".
-//     When passed in with a zero value, then an error is returned.
-//     When this option is omitted, then this function will use the default.
-//     This DB table will be automatically created unless it already exists.
+//   - [WithMigrationsTable].
 func UpgradeSchemaMigrationsWith(ctx context.Context, driver driver.Driver, opts ...Opter) error {
 	o, err := setOptions(opts...)
 	if err != nil {
 		return fmt.Errorf("%s.%s: %w", msgPrefix, "UpgradeSchemaMigrationsWith", err)
 	}
-	return UpgradeSchemaMigrations(ctx, driver, o.migrationsTable)
+	return upgradeSchemaMigrations(o.logContext(ctx), driver, o.migrationsTable, o.scheme())
 }
 
 // UpgradeSchemaMigrations may alter an existing schema migrations table,
@@ -905,20 +1928,20 @@ func UpgradeSchemaMigrationsWith(ctx context.Context, driver driver.Driver, opts
 // New code should use [UpgradeSchemaMigrationsWith].
 // Current code is encouraged to adjust as well.
 func UpgradeSchemaMigrations(ctx context.Context, driver driver.Driver, migrationsTable string) (err error) {
-	return upgradeSchemaMigrations(ctx, driver, migrationsTable)
+	return upgradeSchemaMigrations(ctx, driver, migrationsTable, internal.TimestampScheme)
 }
 
-func upgradeSchemaMigrations(ctx context.Context, d driver.Driver, migrationsTable string) (err error) {
+func upgradeSchemaMigrations(ctx context.Context, d driver.Driver, migrationsTable string, scheme internal.VersionScheme) (err error) {
 	migrationsTable = cmp.Or(migrationsTable, internal.DefaultMigrationsTableName)
 
-	lgr := slog.With(slog.String("migrations_table", migrationsTable))
+	lgr := driver.Logger(ctx).With(slog.String("migrations_table", migrationsTable))
 
 	// Check if table exists but needs an upgrade
 	//
 	// Keep the map empty here b/c we're not actually interested in the contents
 	// at this time. We want to test if the DB table can be read at all w/o error.
 	var dummyMigrationMap map[int64]*internal.Migration
-	if _, err = scanAppliedVersions(ctx, d, migrationsTable, dummyMigrationMap); err != nil {
+	if _, err = scanAppliedVersions(ctx, d, migrationsTable, scheme, dummyMigrationMap); err != nil {
 		lgr.Debug("from UpgradeSchemaMigrations", slog.Any("error", err))
 		if errors.Is(err, driver.ErrSchemaMigrationsDoesNotExist) {
 			err = fmt.Errorf("%w; cannot upgrade if it does not exist yet", err)
@@ -5,13 +5,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/rafaelespinoza/godfish"
 	"github.com/rafaelespinoza/godfish/driver"
@@ -343,6 +348,26 @@ func TestMigrateWith(t *testing.T) {
 				name: "WithMigrationsTable empty string",
 				opt:  godfish.WithMigrationsTable(""),
 			},
+			{
+				name: "WithLockTimeout zero value",
+				opt:  godfish.WithLockTimeout(0),
+			},
+			{
+				name: "WithWriter nil",
+				opt:  godfish.WithWriter(nil),
+			},
+			{
+				name: "WithGoMigrations no migrations",
+				opt:  godfish.WithGoMigrations(),
+			},
+			{
+				name: "WithSteps zero value",
+				opt:  godfish.WithSteps(0),
+			},
+			{
+				name: "WithTargetLabel empty string",
+				opt:  godfish.WithTargetLabel(""),
+			},
 		}
 
 		for _, test := range tests {
@@ -383,6 +408,30 @@ func TestRollbackWith(t *testing.T) {
 				name: "WithMigrationsTable empty string",
 				opt:  godfish.WithMigrationsTable(""),
 			},
+			{
+				name: "WithLockTimeout zero value",
+				opt:  godfish.WithLockTimeout(0),
+			},
+			{
+				name: "WithWriter nil",
+				opt:  godfish.WithWriter(nil),
+			},
+			{
+				name: "WithSteps zero value",
+				opt:  godfish.WithSteps(0),
+			},
+			{
+				name: "WithTargetLabel empty string",
+				opt:  godfish.WithTargetLabel(""),
+			},
+			{
+				name: "WithSince zero value",
+				opt:  godfish.WithSince(time.Time{}),
+			},
+			{
+				name: "WithBatch zero value",
+				opt:  godfish.WithBatch(0),
+			},
 		}
 
 		for _, test := range tests {
@@ -404,685 +453,3793 @@ func TestRollbackWith(t *testing.T) {
 	})
 }
 
-func TestApplyMigration(t *testing.T) {
+func TestTargets(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each applied migration was executed one day after the previous one.
+	startedAt := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	makeApplied := func(versions ...string) func(context.Context, string) (driver.AppliedVersions, error) {
+		return func(context.Context, string) (driver.AppliedVersions, error) {
+			migs := makeMigrations(t, versions...)
+			for i := range migs {
+				migs[i].ExecutedAt = startedAt.AddDate(0, 0, i+1)
+			}
+			return stub.NewAppliedVersions(migs...), nil
+		}
+	}
+
 	tests := []struct {
-		name string
-		up   compat.MigrateFunc
-		down compat.RollbackFunc
+		name        string
+		fn          func(context.Context, driver.Driver, fs.FS, ...godfish.Opter) error
+		applied     []string
+		opts        []godfish.Opter
+		expVersions []string
+		expErr      error
 	}{
 		{
-			name: "Deprecated APIs",
-			up: func(ctx context.Context, d driver.Driver, fsys fs.FS, v, tbl string) error {
-				return godfish.ApplyMigration(ctx, d, fsys, true, v, tbl)
-			},
-			down: func(ctx context.Context, d driver.Driver, fsys fs.FS, v, tbl string) error {
-				return godfish.ApplyMigration(ctx, d, fsys, false, v, tbl)
-			},
+			name:        "migrate steps",
+			fn:          godfish.MigrateWith,
+			opts:        []godfish.Opter{godfish.WithSteps(2)},
+			expVersions: []string{"1234", "2345"},
 		},
 		{
-			name: "Replacement APIs",
-			up: func(ctx context.Context, d driver.Driver, fsys fs.FS, v, tbl string) error {
-				opts := compat.MakeMigrationOpts(compat.MigrationOptParams{
-					TargetVersion:   v,
-					MigrationsTable: tbl,
-				})
-				return godfish.ApplyMigrationWith(ctx, d, fsys, opts...)
-			},
-			down: func(ctx context.Context, d driver.Driver, fsys fs.FS, v, tbl string) error {
-				opts := compat.MakeMigrationOpts(compat.MigrationOptParams{
-					TargetVersion:   v,
-					MigrationsTable: tbl,
-				})
-				return godfish.ApplyRollbackWith(ctx, d, fsys, opts...)
-			},
+			name:        "migrate steps more than available",
+			fn:          godfish.MigrateWith,
+			applied:     []string{"1234"},
+			opts:        []godfish.Opter{godfish.WithSteps(5)},
+			expVersions: []string{"2345", "3456"},
+		},
+		{
+			name:        "migrate label",
+			fn:          godfish.MigrateWith,
+			opts:        []godfish.Opter{godfish.WithTargetLabel("bravo")},
+			expVersions: []string{"1234", "2345"},
+		},
+		{
+			name:        "rollback steps",
+			fn:          godfish.RollbackWith,
+			applied:     []string{"1234", "2345", "3456"},
+			opts:        []godfish.Opter{godfish.WithSteps(2)},
+			expVersions: []string{"3456", "2345"},
+		},
+		{
+			name:        "rollback label",
+			fn:          godfish.RollbackWith,
+			applied:     []string{"1234", "2345", "3456"},
+			opts:        []godfish.Opter{godfish.WithTargetLabel("bravo")},
+			expVersions: []string{"3456", "2345"},
+		},
+		{
+			name:        "rollback since",
+			fn:          godfish.RollbackWith,
+			applied:     []string{"1234", "2345", "3456"},
+			opts:        []godfish.Opter{godfish.WithSince(startedAt.AddDate(0, 0, 1))},
+			expVersions: []string{"3456", "2345"},
+		},
+		{
+			name:    "rollback since, nothing newer",
+			fn:      godfish.RollbackWith,
+			applied: []string{"1234", "2345", "3456"},
+			opts:    []godfish.Opter{godfish.WithSince(startedAt.AddDate(0, 0, 3))},
+		},
+		{
+			name:    "error - label not found",
+			fn:      godfish.MigrateWith,
+			applied: []string{"1234"},
+			opts:    []godfish.Opter{godfish.WithTargetLabel("alpha")},
+			expErr:  internal.ErrNotFound,
+		},
+		{
+			name:   "error - more than one target",
+			fn:     godfish.MigrateWith,
+			opts:   []godfish.Opter{godfish.WithSteps(1), godfish.WithTargetVersion("2345")},
+			expErr: internal.ErrDataInvalid,
+		},
+		{
+			name:   "error - since on migrate",
+			fn:     godfish.MigrateWith,
+			opts:   []godfish.Opter{godfish.WithSince(startedAt)},
+			expErr: internal.ErrDataInvalid,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			testApplyMigration(t, test.up, test.down)
+			var gotVersions []string
+			d := &stub.Double{
+				AppliedVersionsFn:        makeApplied(test.applied...),
+				ExecuteFn:                makeExecuteFn(nil),
+				CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+				UpdateSchemaMigrationsFn: func(_ context.Context, _ string, _ bool, version, _ string) error {
+					gotVersions = append(gotVersions, version)
+					return nil
+				},
+			}
+
+			err := test.fn(t.Context(), d, dirFS, test.opts...)
+			if test.expErr != nil {
+				if !errors.Is(err, test.expErr) {
+					t.Fatalf("expected error (%v) to be %v", err, test.expErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(gotVersions, test.expVersions) {
+				t.Errorf("wrong versions\ngot:      %q\nexpected: %q", gotVersions, test.expVersions)
+			}
 		})
 	}
 }
 
-func testApplyMigration(t *testing.T, up compat.MigrateFunc, down compat.RollbackFunc) {
-	testUpDown(t, up, down)
-
-	okFS, err := fs.Sub(testdata.Migrations, "default")
+func TestBatches(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	t.Run("version empty, not found", func(t *testing.T) {
-		driver := stub.Double{
-			AppliedVersionsFn: makeScanApplied(t),
+	// makeApplied sets up applied migrations, where batches[i] is the batch of
+	// the migration with versions[i].
+	makeApplied := func(versions []string, batches []int64) func(context.Context, string) (driver.AppliedVersions, error) {
+		return func(context.Context, string) (driver.AppliedVersions, error) {
+			migs := makeMigrations(t, versions...)
+			for i := range migs {
+				migs[i].Batch = batches[i]
+			}
+			return stub.NewAppliedVersions(migs...), nil
 		}
-		fsys := fstest.MapFS{}
-		err := up(t.Context(), &driver, fsys, "", "")
-		if err == nil {
-			t.Fatal("expected an error but got nil")
+	}
+
+	tests := []struct {
+		name       string
+		fn         func(context.Context, driver.Driver, fs.FS, ...godfish.Opter) error
+		applied    []string
+		batches    []int64
+		opts       []godfish.Opter
+		expUpdates []string
+		expErr     error
+	}{
+		{
+			name:       "migrate records one batch for the run",
+			fn:         godfish.MigrateWith,
+			applied:    []string{"1234"},
+			batches:    []int64{4},
+			expUpdates: []string{"2345-5", "3456-5"},
+		},
+		{
+			name:       "migrate first batch",
+			fn:         godfish.MigrateWith,
+			opts:       []godfish.Opter{godfish.WithSteps(1)},
+			expUpdates: []string{"1234-1"},
+		},
+		{
+			name:       "apply migration records next batch",
+			fn:         godfish.ApplyMigrationWith,
+			applied:    []string{"1234"},
+			batches:    []int64{1},
+			opts:       []godfish.Opter{godfish.WithTargetVersion("3456")},
+			expUpdates: []string{"3456-2"},
+		},
+		{
+			name:       "apply migration without target version records next batch",
+			fn:         godfish.ApplyMigrationWith,
+			applied:    []string{"1234"},
+			batches:    []int64{1},
+			expUpdates: []string{"2345-2"},
+		},
+		{
+			name:       "mark applied records next batch",
+			fn:         godfish.MarkAppliedWith,
+			applied:    []string{"1234"},
+			batches:    []int64{3},
+			opts:       []godfish.Opter{godfish.WithTargetVersion("2345")},
+			expUpdates: []string{"2345-4"},
+		},
+		{
+			name:       "rollback last batch",
+			fn:         godfish.RollbackWith,
+			applied:    []string{"1234", "2345", "3456"},
+			batches:    []int64{1, 2, 2},
+			opts:       []godfish.Opter{godfish.WithLastBatch()},
+			expUpdates: []string{"3456", "2345"},
+		},
+		{
+			name:       "rollback batch",
+			fn:         godfish.RollbackWith,
+			applied:    []string{"1234", "2345", "3456"},
+			batches:    []int64{1, 2, 2},
+			opts:       []godfish.Opter{godfish.WithBatch(1)},
+			expUpdates: []string{"1234"},
+		},
+		{
+			name: "rollback last batch, nothing applied",
+			fn:   godfish.RollbackWith,
+			opts: []godfish.Opter{godfish.WithLastBatch()},
+		},
+		{
+			name:    "error - batch not found",
+			fn:      godfish.RollbackWith,
+			applied: []string{"1234", "2345"},
+			batches: []int64{1, 2},
+			opts:    []godfish.Opter{godfish.WithBatch(3)},
+			expErr:  internal.ErrNotFound,
+		},
+		{
+			name:    "error - batch has a migration without a reverse",
+			fn:      godfish.RollbackWith,
+			applied: []string{"1234", "2345", "4567"},
+			batches: []int64{1, 2, 2},
+			opts:    []godfish.Opter{godfish.WithBatch(2)},
+			expErr:  internal.ErrNotFound,
+		},
+		{
+			name:    "error - last batch has a migration without a reverse",
+			fn:      godfish.RollbackWith,
+			applied: []string{"1234", "2345", "4567"},
+			batches: []int64{1, 1, 2},
+			opts:    []godfish.Opter{godfish.WithLastBatch()},
+			expErr:  internal.ErrNotFound,
+		},
+		{
+			name:    "error - last batch, applied without a batch",
+			fn:      godfish.RollbackWith,
+			applied: []string{"1234", "2345"},
+			batches: []int64{0, 0},
+			opts:    []godfish.Opter{godfish.WithLastBatch()},
+			expErr:  internal.ErrNotFound,
+		},
+		{
+			name:   "error - batch on migrate",
+			fn:     godfish.MigrateWith,
+			opts:   []godfish.Opter{godfish.WithLastBatch()},
+			expErr: internal.ErrDataInvalid,
+		},
+		{
+			name:   "error - more than one target",
+			fn:     godfish.RollbackWith,
+			opts:   []godfish.Opter{godfish.WithBatch(1), godfish.WithSteps(1)},
+			expErr: internal.ErrDataInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotUpdates []string
+			d := &stub.Recorder{
+				Double: stub.Double{
+					AppliedVersionsFn:        makeApplied(test.applied, test.batches),
+					ExecuteFn:                makeExecuteFn(nil),
+					CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+					UpdateSchemaMigrationsFn: func(_ context.Context, _ string, _ bool, version, _ string) error {
+						gotUpdates = append(gotUpdates, version)
+						return nil
+					},
+				},
+				RecordMigrationFn: func(_ context.Context, _, version, _ string, meta driver.Metadata) error {
+					gotUpdates = append(gotUpdates, fmt.Sprintf("%s-%d", version, meta.Batch))
+					return nil
+				},
+			}
+
+			err := test.fn(t.Context(), d, dirFS, test.opts...)
+			if test.expErr != nil {
+				if !errors.Is(err, test.expErr) {
+					t.Fatalf("expected error (%v) to be %v", err, test.expErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(gotUpdates, test.expUpdates) {
+				t.Errorf("wrong updates\ngot:      %q\nexpected: %q", gotUpdates, test.expUpdates)
+			}
+		})
+	}
+}
+
+func TestVersionSchemes(t *testing.T) {
+	makeFS := func(versions ...string) fstest.MapFS {
+		out := make(fstest.MapFS)
+		for i, v := range versions {
+			label := string(rune('a' + i))
+			out["forward-"+v+"-"+label+".sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE " + label + " (id int);")}
+			out["reverse-"+v+"-"+label+".sql"] = &fstest.MapFile{Data: []byte("DROP TABLE " + label + ";")}
 		}
-		t.Log(err)
-		if !errors.Is(err, internal.ErrNotFound) {
-			t.Errorf("expected for error (%v) to be %v", err, internal.ErrNotFound)
+		return out
+	}
+	makeApplied := func(t *testing.T, scheme internal.VersionScheme, versions ...string) func(context.Context, string) (driver.AppliedVersions, error) {
+		return func(context.Context, string) (driver.AppliedVersions, error) {
+			migs := make([]internal.Migration, len(versions))
+			for i, v := range versions {
+				version, err := scheme.ParseVersion(v)
+				if err != nil {
+					t.Fatal(err)
+				}
+				migs[i] = internal.Migration{Version: version, Batch: 1}
+			}
+			return stub.NewAppliedVersions(migs...), nil
 		}
-	})
+	}
 
-	t.Run("version specified, not found", func(t *testing.T) {
-		driver := stub.Double{}
-		err := up(t.Context(), &driver, okFS, "1111", "")
-		if err == nil {
-			t.Fatal("expected an error but got nil")
+	tests := []struct {
+		name        string
+		fn          func(context.Context, driver.Driver, fs.FS, ...godfish.Opter) error
+		scheme      internal.VersionScheme
+		dirFS       fs.FS
+		applied     []string
+		opts        []godfish.Opter
+		expVersions []string
+	}{
+		{
+			name:        "sequential migrate",
+			fn:          godfish.MigrateWith,
+			scheme:      internal.SequentialScheme,
+			dirFS:       makeFS("10", "9", "0002"),
+			opts:        []godfish.Opter{godfish.WithVersionScheme("sequential")},
+			expVersions: []string{"0002", "9", "10"},
+		},
+		{
+			name:        "sequential migrate to version",
+			fn:          godfish.MigrateWith,
+			scheme:      internal.SequentialScheme,
+			dirFS:       makeFS("10", "9", "0002"),
+			applied:     []string{"0002"},
+			opts:        []godfish.Opter{godfish.WithVersionScheme("sequential"), godfish.WithTargetVersion("9")},
+			expVersions: []string{"9"},
+		},
+		{
+			name:        "sequential rollback",
+			fn:          godfish.RollbackWith,
+			scheme:      internal.SequentialScheme,
+			dirFS:       makeFS("10", "9", "0002"),
+			applied:     []string{"0002", "9", "10"},
+			opts:        []godfish.Opter{godfish.WithVersionScheme("sequential"), godfish.WithSteps(2)},
+			expVersions: []string{"10", "9"},
+		},
+		{
+			name:        "dotted migrate",
+			fn:          godfish.MigrateWith,
+			scheme:      internal.DottedScheme,
+			dirFS:       makeFS("V2", "V1.10", "V1.2"),
+			opts:        []godfish.Opter{godfish.WithVersionScheme("dotted")},
+			expVersions: []string{"V1.2", "V1.10", "V2"},
+		},
+		{
+			name:        "dotted migrate to version",
+			fn:          godfish.MigrateWith,
+			scheme:      internal.DottedScheme,
+			dirFS:       makeFS("V2", "V1.10", "V1.2"),
+			opts:        []godfish.Opter{godfish.WithVersionScheme("dotted"), godfish.WithTargetVersion("V1.10")},
+			expVersions: []string{"V1.2", "V1.10"},
+		},
+		{
+			name:        "dotted rollback",
+			fn:          godfish.RollbackWith,
+			scheme:      internal.DottedScheme,
+			dirFS:       makeFS("V2", "V1.10", "V1.2"),
+			applied:     []string{"V1.2", "V1.10", "V2"},
+			opts:        []godfish.Opter{godfish.WithVersionScheme("dotted")},
+			expVersions: []string{"V2", "V1.10", "V1.2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotVersions []string
+			d := &stub.Double{
+				AppliedVersionsFn:        makeApplied(t, test.scheme, test.applied...),
+				ExecuteFn:                makeExecuteFn(nil),
+				CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+				UpdateSchemaMigrationsFn: func(_ context.Context, _ string, _ bool, version, _ string) error {
+					gotVersions = append(gotVersions, version)
+					return nil
+				},
+			}
+
+			if err := test.fn(t.Context(), d, test.dirFS, test.opts...); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(gotVersions, test.expVersions) {
+				t.Errorf("wrong versions\ngot:      %q\nexpected: %q", gotVersions, test.expVersions)
+			}
+		})
+	}
+
+	t.Run("create migration files", func(t *testing.T) {
+		tests := []struct {
+			scheme   string
+			existing []string
+			exp      string
+		}{
+			{scheme: "sequential", exp: "forward-0001-alfa.sql"},
+			{scheme: "sequential", existing: []string{"forward-0041-x.sql", "forward-0007-y.sql"}, exp: "forward-0042-alfa.sql"},
+			{scheme: "dotted", exp: "forward-V1-alfa.sql"},
+			{scheme: "dotted", existing: []string{"forward-V1.2.3-x.sql", "forward-V1.10-y.sql"}, exp: "forward-V1.11-alfa.sql"},
 		}
-		t.Log(err)
-		if !errors.Is(err, internal.ErrNotFound) {
-			t.Errorf("expected for error (%v) to be %v", err, internal.ErrNotFound)
+
+		for _, test := range tests {
+			t.Run(test.scheme, func(t *testing.T) {
+				dir := t.TempDir()
+				for _, name := range test.existing {
+					if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				err := godfish.CreateMigrationFilesWith("alfa", false, dir, godfish.WithVersionScheme(test.scheme))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err = os.Stat(filepath.Join(dir, test.exp)); err != nil {
+					t.Error(err)
+				}
+			})
 		}
 	})
 
-	t.Run("version specified, found", func(t *testing.T) {
-		var numCallsToAppliedVersions int
-		driver := stub.Double{
-			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
-				defer func() { numCallsToAppliedVersions++ }()
-				var migs []internal.Migration
-				switch numCallsToAppliedVersions {
-				case 0:
-					migs = makeMigrations(t)
-				case 1:
-					migs = makeMigrations(t, "1234")
-				default:
-					t.Fatal("too many calls to AppliedVersions")
-				}
-				return stub.NewAppliedVersions(migs...), nil
-			},
+	t.Run("Go migrations", func(t *testing.T) {
+		var gotVersions []string
+		d := &stub.Double{
+			AppliedVersionsFn:        makeApplied(t, internal.SequentialScheme),
 			ExecuteFn:                makeExecuteFn(nil),
 			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
-			UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: func(_ context.Context, _ string, _ bool, version, _ string) error {
+				gotVersions = append(gotVersions, version)
+				return nil
+			},
 		}
-		err := up(t.Context(), &driver, okFS, "1234", "")
+		gm := godfish.GoMigration{Version: "0003", Label: "gopher", Forward: func(context.Context, any) error { return nil }}
+
+		// The Go migration is registered before the scheme is set.
+		err := godfish.MigrateWith(
+			t.Context(), d, makeFS("0002", "0010"),
+			godfish.WithGoMigrations(gm), godfish.WithVersionScheme("sequential"),
+		)
 		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
+			t.Fatal(err)
 		}
-		err = down(t.Context(), &driver, okFS, "1234", "")
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
+		if exp := []string{"0002", "0003", "0010"}; !slices.Equal(gotVersions, exp) {
+			t.Errorf("wrong versions\ngot:      %q\nexpected: %q", gotVersions, exp)
 		}
 	})
 
-	t.Run("error too many migration files with same version", func(t *testing.T) {
-		driver := makeNoCallDriver(t)
-		badFS := fstest.MapFS{
-			"forward-1234-a": &fstest.MapFile{Mode: 0x640},
-			"forward-1234-b": &fstest.MapFile{Mode: 0x640},
-		}
-		err := up(t.Context(), driver, badFS, "1234", "")
-		if err == nil {
-			t.Fatal("expected an error but got nil")
-		}
-		t.Log(err)
-		const looseExpectation = "too many migration files with matching"
-		if m := err.Error(); !strings.Contains(m, looseExpectation) {
-			t.Errorf("expected for error (%v) to contain %q", err, looseExpectation)
+	t.Run("error - unknown scheme", func(t *testing.T) {
+		err := godfish.MigrateWith(t.Context(), makeNoCallDriver(t), makeFS(), godfish.WithVersionScheme("semver"))
+		if !errors.Is(err, internal.ErrDataInvalid) {
+			t.Fatalf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
 		}
 	})
+}
 
-	t.Run("schema migrations table does not exist", func(t *testing.T) {
-		// Check that when the table does not exist, in the happy path, the
-		// "database" will handle the error by creating the table and updating it.
-		var updateCalls int
-		driver := &stub.Double{
-			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
-				return nil, driver.ErrSchemaMigrationsDoesNotExist
-			},
+func TestNamingConventions(t *testing.T) {
+	dirFS := fstest.MapFS{
+		"V0002__a.sql":   &fstest.MapFile{Data: []byte("CREATE TABLE a (id int);")},
+		"U0002__a.sql":   &fstest.MapFile{Data: []byte("DROP TABLE a;")},
+		"V0010__b.sql":   &fstest.MapFile{Data: []byte("CREATE TABLE b (id int);")},
+		"U0010__b.sql":   &fstest.MapFile{Data: []byte("DROP TABLE b;")},
+		"V9__c.sql":      &fstest.MapFile{Data: []byte("CREATE TABLE c (id int);")},
+		"README.md":      &fstest.MapFile{Data: []byte("not a migration")},
+		"forward-1-.sql": &fstest.MapFile{Data: []byte("not in the convention")},
+	}
+	opts := []godfish.Opter{godfish.WithVersionScheme("sequential"), godfish.WithNamingConvention("flyway")}
+
+	t.Run("migrate", func(t *testing.T) {
+		var gotVersions []string
+		d := &stub.Double{
+			AppliedVersionsFn:        func(context.Context, string) (driver.AppliedVersions, error) { return stub.NewAppliedVersions(), nil },
 			ExecuteFn:                makeExecuteFn(nil),
 			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
-			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
-				updateCalls++
+			UpdateSchemaMigrationsFn: func(_ context.Context, _ string, _ bool, version, _ string) error {
+				gotVersions = append(gotVersions, version)
 				return nil
 			},
 		}
-		err := up(t.Context(), driver, okFS, "2345", "test")
-		if err != nil {
+
+		if err := godfish.MigrateWith(t.Context(), d, dirFS, opts...); err != nil {
 			t.Fatal(err)
 		}
-		if expNumCalls := 1; updateCalls != expNumCalls {
-			t.Errorf("number of calls to UpdateSchemaMigrations; got %d, expected %d", updateCalls, expNumCalls)
+		if exp := []string{"0002", "9", "0010"}; !slices.Equal(gotVersions, exp) {
+			t.Errorf("wrong versions\ngot:      %q\nexpected: %q", gotVersions, exp)
 		}
 	})
 
-	t.Run("rollback - error no migrations found", func(t *testing.T) {
-		var calledExec, calledUpdate bool
-		driver := &stub.Double{
-			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
-				return stub.NewAppliedVersions(), nil
-			},
-			ExecuteFn: func(ctx context.Context, q string, a ...any) error {
-				calledExec = true
-				return nil
-			},
+	t.Run("apply by version", func(t *testing.T) {
+		var gotQueries []string
+		d := &stub.Double{
+			AppliedVersionsFn:        func(context.Context, string) (driver.AppliedVersions, error) { return stub.NewAppliedVersions(), nil },
 			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
-			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
-				calledUpdate = true
+			ExecuteFn: func(_ context.Context, query string, _ ...any) error {
+				gotQueries = append(gotQueries, query)
 				return nil
 			},
+			UpdateSchemaMigrationsFn: func(context.Context, string, bool, string, string) error { return nil },
 		}
-		err := down(t.Context(), driver, okFS, "", "test")
-		expErr := internal.ErrNotFound
-		if !errors.Is(err, expErr) {
-			t.Fatalf("expected error (%v) to be %v", err, expErr)
+
+		err := godfish.ApplyMigrationWith(t.Context(), d, dirFS, append(opts, godfish.WithTargetVersion("0010"))...)
+		if err != nil {
+			t.Fatal(err)
 		}
-		if !strings.Contains(err.Error(), "version") {
-			t.Errorf("expected error (%v) to contain %q", err.Error(), "version")
+		if !slices.Contains(gotQueries, "CREATE TABLE b (id int);") {
+			t.Errorf("expected to run the migration; got queries %q", gotQueries)
 		}
-		if calledExec {
-			t.Errorf("did not expect to call Execute")
+	})
+
+	t.Run("create migration files", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "V0007__x.sql"), nil, 0600); err != nil {
+			t.Fatal(err)
 		}
-		if calledUpdate {
-			t.Errorf("did not expect to call UpdateSchemaMigrations")
+
+		if err := godfish.CreateMigrationFilesWith("alfa", true, dir, opts...); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"V0008__alfa.sql", "U0008__alfa.sql"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+				t.Error(err)
+			}
 		}
 	})
 
-	t.Run("handles alternate filename extensions", func(t *testing.T) {
-		var appliedVersionsCalls, executeCalls int
-		var createSchemaMigrationsCalls, updateSchemaMigrationsCall int
-		driver := stub.Double{
-			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
-				appliedVersionsCalls++
-				switch appliedVersionsCalls {
-				case 1:
-					return makeScanApplied(t)(ctx, migrationsTable)
-				case 2:
-					return makeScanApplied(t, "1234")(ctx, migrationsTable)
-				default:
-					t.Fatalf("unexpected number of calls to AppliedVersions; got %d, expected [1,2]", appliedVersionsCalls)
-					return nil, nil
+	t.Run("error - unknown convention", func(t *testing.T) {
+		err := godfish.MigrateWith(t.Context(), makeNoCallDriver(t), dirFS, godfish.WithNamingConvention("rails"))
+		if !errors.Is(err, internal.ErrDataInvalid) {
+			t.Fatalf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
+		}
+	})
+}
+
+func TestSingleFileMigrations(t *testing.T) {
+	dirFS := fstest.MapFS{
+		"forward-1234-alpha.sql": &fstest.MapFile{Data: []byte(`-- godfish:up
+CREATE TABLE foos (id int);
+
+-- godfish:down
+DROP TABLE foos;
+`)},
+		"forward-2345-bravo.sql": &fstest.MapFile{Data: []byte("CREATE TABLE bars (id int);")},
+		"reverse-2345-bravo.sql": &fstest.MapFile{Data: []byte("DROP TABLE bars;")},
+		"forward-3456-charlie.sql": &fstest.MapFile{Data: []byte(`-- godfish:up
+CREATE TABLE quxes (id int);
+`)},
+	}
+	makeDriver := func(applied []string, gotQueries *[]string) *stub.Double {
+		return &stub.Double{
+			AppliedVersionsFn: func(context.Context, string) (driver.AppliedVersions, error) {
+				migs := make([]internal.Migration, len(applied))
+				for i, v := range applied {
+					version, err := internal.ParseVersion(v)
+					if err != nil {
+						t.Fatal(err)
+					}
+					migs[i] = internal.Migration{Version: version, Batch: 1}
 				}
+				return stub.NewAppliedVersions(migs...), nil
 			},
-			ExecuteFn: func(ctx context.Context, q string, a ...any) error {
-				executeCalls++
-				return nil
-			},
-			CreateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string) error {
-				createSchemaMigrationsCalls++
-				return nil
-			},
-			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
-				updateSchemaMigrationsCall++
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			ExecuteFn: func(_ context.Context, query string, _ ...any) error {
+				*gotQueries = append(*gotQueries, strings.TrimSpace(query))
 				return nil
 			},
+			UpdateSchemaMigrationsFn: func(context.Context, string, bool, string, string) error { return nil },
 		}
-		dirFS := stub.FS{
-			FS: fstest.MapFS{
-				"forward-1234-a.cql": &fstest.MapFile{Mode: 0x600},
-				"forward-2345-b.cql": &fstest.MapFile{Mode: 0x600},
-				"forward-3456-c.cql": &fstest.MapFile{Mode: 0x600},
-				"reverse-1234-a.cql": &fstest.MapFile{Mode: 0x600},
-				"reverse-2345-b.cql": &fstest.MapFile{Mode: 0x600},
-				"reverse-3456-c.cql": &fstest.MapFile{Mode: 0x600},
-			},
-		}
+	}
 
-		if err := up(t.Context(), &driver, dirFS, "", ""); err != nil {
+	t.Run("migrate", func(t *testing.T) {
+		var gotQueries []string
+		if err := godfish.MigrateWith(t.Context(), makeDriver(nil, &gotQueries), dirFS); err != nil {
 			t.Fatal(err)
 		}
-		const expNumCallsAfterUp = 1
-		if got := executeCalls; got != expNumCallsAfterUp {
-			t.Errorf("wrong number of calls to Execute; got %d, expected %d", got, expNumCallsAfterUp)
+		exp := []string{"CREATE TABLE foos (id int);", "CREATE TABLE bars (id int);", "CREATE TABLE quxes (id int);"}
+		if !slices.Equal(gotQueries, exp) {
+			t.Errorf("wrong queries\ngot:      %q\nexpected: %q", gotQueries, exp)
 		}
-		if got := createSchemaMigrationsCalls; got != expNumCallsAfterUp {
-			t.Errorf("wrong number of calls to CreateSchemaMigrations; got %d, expected %d", got, expNumCallsAfterUp)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		var gotQueries []string
+		d := makeDriver([]string{"1234", "2345", "3456"}, &gotQueries)
+		if err := godfish.RollbackWith(t.Context(), d, dirFS, godfish.WithSteps(3)); err != nil {
+			t.Fatal(err)
 		}
-		if got := updateSchemaMigrationsCall; got != expNumCallsAfterUp {
-			t.Errorf("wrong number of calls to UpdateSchemaMigrations; got %d, expected %d", got, expNumCallsAfterUp)
+		// The single file without a down section is passed over.
+		exp := []string{"DROP TABLE bars;", "DROP TABLE foos;"}
+		if !slices.Equal(gotQueries, exp) {
+			t.Errorf("wrong queries\ngot:      %q\nexpected: %q", gotQueries, exp)
 		}
+	})
 
-		if err := down(t.Context(), &driver, dirFS, "", ""); err != nil {
+	t.Run("apply rollback by version", func(t *testing.T) {
+		var gotQueries []string
+		d := makeDriver([]string{"1234"}, &gotQueries)
+		if err := godfish.ApplyRollbackWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1234")); err != nil {
 			t.Fatal(err)
 		}
-		const expNumCallsAfterDown = 2
-		if got := executeCalls; got != expNumCallsAfterDown {
-			t.Errorf("wrong number of calls to Execute; got %d, expected %d", got, expNumCallsAfterDown)
+		if exp := []string{"DROP TABLE foos;"}; !slices.Equal(gotQueries, exp) {
+			t.Errorf("wrong queries\ngot:      %q\nexpected: %q", gotQueries, exp)
 		}
-		if got := createSchemaMigrationsCalls; got != expNumCallsAfterDown {
-			t.Errorf("wrong number of calls to CreateSchemaMigrations; got %d, expected %d", got, expNumCallsAfterDown)
+	})
+
+	t.Run("checksum is of the whole file", func(t *testing.T) {
+		var gotChecksum string
+		var gotQueries []string
+		d := &stub.Recorder{
+			Double: *makeDriver(nil, &gotQueries),
+			RecordMigrationFn: func(_ context.Context, _, version, _ string, meta driver.Metadata) error {
+				if version == "1234" {
+					gotChecksum = meta.Checksum
+				}
+				return nil
+			},
 		}
-		if got := updateSchemaMigrationsCall; got != expNumCallsAfterDown {
-			t.Errorf("wrong number of calls to UpdateSchemaMigrations; got %d, expected %d", got, expNumCallsAfterDown)
+		if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1234")); err != nil {
+			t.Fatal(err)
+		}
+		if exp := internal.Checksum(dirFS["forward-1234-alpha.sql"].Data); gotChecksum != exp {
+			t.Errorf("wrong checksum; got %q, expected %q", gotChecksum, exp)
+		}
+	})
+
+	t.Run("create migration files", func(t *testing.T) {
+		for _, reversible := range []bool{true, false} {
+			dir := t.TempDir()
+			err := godfish.CreateMigrationFilesWith("alfa", reversible, dir, godfish.WithVersionScheme("sequential"), godfish.WithSingleFile())
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Name() != "forward-0001-alfa.sql" {
+				t.Fatalf("reversible=%t; expected only one forward file, got %v", reversible, entries)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(data); got != internal.SingleFileTemplate(reversible) {
+				t.Errorf("reversible=%t; wrong contents %q", reversible, got)
+			}
 		}
 	})
 }
 
-// testUpDown is for testing ApplyMigration, ApplyMigrationWith,
-// ApplyRollbackWith, Migrate, MigrateWith, RollbackWith.
-func testUpDown(t *testing.T, up compat.MigrateFunc, down compat.RollbackFunc) {
-	okFS, err := fs.Sub(testdata.Migrations, "default")
-	if err != nil {
-		t.Fatal(err)
+// labelVersion stands in for the migration_id of a row in the repeatable
+// migrations table, which is the label rather than a version.
+type labelVersion string
+
+func (v labelVersion) Before(u internal.Version) bool { return string(v) < u.String() }
+func (v labelVersion) String() string                 { return string(v) }
+func (v labelVersion) Value() int64                   { return 0 }
+
+func TestRepeatableMigrations(t *testing.T) {
+	const (
+		repeatableTable = internal.DefaultMigrationsTableName + "_repeatable"
+		viewV1          = "CREATE OR REPLACE VIEW foos_view AS SELECT id FROM foos;"
+		viewV2          = "CREATE OR REPLACE VIEW foos_view AS SELECT id, name FROM foos;"
+		funcV1          = "CREATE OR REPLACE FUNCTION foos_count() RETURNS int AS 'SELECT count(*) FROM foos' LANGUAGE SQL;"
+	)
+	dirFS := fstest.MapFS{
+		"forward-1234-alpha.sql":       &fstest.MapFile{Data: []byte("CREATE TABLE foos (id int);")},
+		"reverse-1234-alpha.sql":       &fstest.MapFile{Data: []byte("DROP TABLE foos;")},
+		"repeatable-foos_count.sql":    &fstest.MapFile{Data: []byte(funcV1)},
+		"repeatable-foos_view.sql":     &fstest.MapFile{Data: []byte(viewV2)},
+		"forward-2345-bravo.sql":       &fstest.MapFile{Data: []byte("ALTER TABLE foos ADD COLUMN name text;")},
+		"repeatable-.sql":              &fstest.MapFile{Data: []byte("not a repeatable, it has no label")},
+		"subdir/repeatable-nested.sql": &fstest.MapFile{Data: []byte("not in the top directory")},
 	}
 
-	t.Run("all the way up and down", func(t *testing.T) {
-		driver := stub.Double{
-			AppliedVersionsFn:        makeScanApplied(t, "1234"),
-			ExecuteFn:                makeExecuteFn(nil),
-			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
-			UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
+	type update struct {
+		table    string
+		forward  bool
+		id       string
+		checksum string
+	}
+	makeDriver := func(appliedVersions []string, appliedRepeatables map[string]string, gotQueries *[]string, gotUpdates *[]update) *stub.Recorder {
+		return &stub.Recorder{
+			Double: stub.Double{
+				NameFn: func() string { return "stub" },
+				AppliedVersionsFn: func(_ context.Context, table string) (driver.AppliedVersions, error) {
+					var migs []internal.Migration
+					if table == repeatableTable {
+						if appliedRepeatables == nil {
+							return nil, driver.ErrSchemaMigrationsDoesNotExist
+						}
+						for _, label := range slices.Sorted(maps.Keys(appliedRepeatables)) {
+							migs = append(migs, internal.Migration{Version: labelVersion(label), Label: label, Checksum: appliedRepeatables[label]})
+						}
+						return stub.NewAppliedVersions(migs...), nil
+					}
+					for _, v := range appliedVersions {
+						version, err := internal.ParseVersion(v)
+						if err != nil {
+							t.Fatal(err)
+						}
+						migs = append(migs, internal.Migration{Version: version, Batch: 1})
+					}
+					return stub.NewAppliedVersions(migs...), nil
+				},
+				CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+				ExecuteFn: func(_ context.Context, query string, _ ...any) error {
+					*gotQueries = append(*gotQueries, query)
+					return nil
+				},
+				UpdateSchemaMigrationsFn: func(_ context.Context, table string, forward bool, version, _ string) error {
+					*gotUpdates = append(*gotUpdates, update{table, forward, version, ""})
+					return nil
+				},
+			},
+			RecordMigrationFn: func(_ context.Context, table, version, _ string, meta driver.Metadata) error {
+				*gotUpdates = append(*gotUpdates, update{table, true, version, meta.Checksum})
+				return nil
+			},
 		}
-		var err error
-		if err = up(t.Context(), &driver, okFS, "", ""); err != nil {
+	}
+	checksumOf := func(s string) string { return internal.Checksum([]byte(s)) }
+
+	t.Run("first run", func(t *testing.T) {
+		var gotQueries []string
+		var gotUpdates []update
+		d := makeDriver(nil, nil, &gotQueries, &gotUpdates)
+		if err := godfish.MigrateWith(t.Context(), d, dirFS); err != nil {
 			t.Fatal(err)
 		}
 
-		if err = down(t.Context(), &driver, okFS, "", ""); err != nil {
-			t.Fatal(err)
+		expQueries := []string{"CREATE TABLE foos (id int);", "ALTER TABLE foos ADD COLUMN name text;", funcV1, viewV2}
+		if !slices.Equal(gotQueries, expQueries) {
+			t.Errorf("wrong queries\ngot:      %q\nexpected: %q", gotQueries, expQueries)
+		}
+		expUpdates := []update{
+			{internal.DefaultMigrationsTableName, true, "1234", checksumOf("CREATE TABLE foos (id int);")},
+			{internal.DefaultMigrationsTableName, true, "2345", checksumOf("ALTER TABLE foos ADD COLUMN name text;")},
+			{repeatableTable, true, "foos_count", checksumOf(funcV1)},
+			{repeatableTable, true, "foos_view", checksumOf(viewV2)},
+		}
+		if !slices.Equal(gotUpdates, expUpdates) {
+			t.Errorf("wrong updates\ngot:      %v\nexpected: %v", gotUpdates, expUpdates)
 		}
 	})
 
-	t.Run("bad version", func(t *testing.T) {
-		driver := stub.Double{
-			AppliedVersionsFn:        makeScanApplied(t, "1234", "2345", "3456"),
-			ExecuteFn:                makeExecuteFn(nil),
-			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
-			UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
+	t.Run("only changed ones run again", func(t *testing.T) {
+		var gotQueries []string
+		var gotUpdates []update
+		applied := map[string]string{"foos_count": checksumOf(funcV1), "foos_view": checksumOf(viewV1)}
+		d := makeDriver([]string{"1234", "2345"}, applied, &gotQueries, &gotUpdates)
+		if err := godfish.MigrateWith(t.Context(), d, dirFS); err != nil {
+			t.Fatal(err)
 		}
-		err := up(t.Context(), &driver, okFS, "bad", "")
-		if err == nil {
-			t.Fatal("expected error")
+
+		if expQueries := []string{viewV2}; !slices.Equal(gotQueries, expQueries) {
+			t.Errorf("wrong queries\ngot:      %q\nexpected: %q", gotQueries, expQueries)
 		}
-		t.Log(err)
-		if m := err.Error(); !strings.Contains(m, "version") {
-			t.Errorf("expected for error (%v) to mention %q", m, "version")
+		expUpdates := []update{
+			{repeatableTable, false, "foos_view", ""},
+			{repeatableTable, true, "foos_view", checksumOf(viewV2)},
+		}
+		if !slices.Equal(gotUpdates, expUpdates) {
+			t.Errorf("wrong updates\ngot:      %v\nexpected: %v", gotUpdates, expUpdates)
 		}
 	})
 
-	t.Run("error reading from FS directory", func(t *testing.T) {
-		driver := makeNoCallDriver(t)
-		badFS := stub.FS{
-			FS: fstest.MapFS{"forward-1234-no_read_permissions": &fstest.MapFile{Mode: 0x000}},
-			ReadDirFn: func(n string) ([]fs.DirEntry, error) {
-				return nil, errors.New("I've never seen that file in my life")
-			},
+	t.Run("not run with a target", func(t *testing.T) {
+		var gotQueries []string
+		var gotUpdates []update
+		d := makeDriver(nil, nil, &gotQueries, &gotUpdates)
+		if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithSteps(2)); err != nil {
+			t.Fatal(err)
 		}
-		err := up(t.Context(), driver, &badFS, "1234", "")
-		if err == nil {
-			t.Fatal("expected an error but got nil")
+		for _, upd := range gotUpdates {
+			if upd.table == repeatableTable {
+				t.Errorf("did not expect a repeatable migration to run, got update %v", upd)
+			}
 		}
-		t.Log(err)
-		const looseExpectation = "reading directory entries"
-		if m := err.Error(); !strings.Contains(m, looseExpectation) {
-			t.Errorf("expected for error (%v) to contain %q", err, looseExpectation)
+	})
+
+	t.Run("not run on rollback", func(t *testing.T) {
+		var gotQueries []string
+		var gotUpdates []update
+		d := makeDriver([]string{"1234"}, map[string]string{}, &gotQueries, &gotUpdates)
+		if err := godfish.RollbackWith(t.Context(), d, dirFS); err != nil {
+			t.Fatal(err)
+		}
+		if expQueries := []string{"DROP TABLE foos;"}; !slices.Equal(gotQueries, expQueries) {
+			t.Errorf("wrong queries\ngot:      %q\nexpected: %q", gotQueries, expQueries)
 		}
 	})
 
-	t.Run("error reading from found file", func(t *testing.T) {
-		driver := makeNoCallDriver(t)
-		// The only allowed Driver method in this case is AppliedVersions.
-		// Check that an error reading the file stops before calling Execute, and
-		// the other methods.
-		driver.AppliedVersionsFn = makeScanApplied(t, "1234")
+	t.Run("dry run", func(t *testing.T) {
+		var gotQueries []string
+		var gotUpdates []update
+		var buf bytes.Buffer
+		d := makeDriver([]string{"1234", "2345"}, nil, &gotQueries, &gotUpdates)
+		if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithDryRun(), godfish.WithWriter(&buf)); err != nil {
+			t.Fatal(err)
+		}
+		if len(gotQueries) > 0 || len(gotUpdates) > 0 {
+			t.Errorf("expected nothing to run; got queries %q, updates %v", gotQueries, gotUpdates)
+		}
+		for _, exp := range []string{"-- repeatable migrations: 2", "-- [2/2] repeatable, label: foos_view", viewV2} {
+			if !strings.Contains(buf.String(), exp) {
+				t.Errorf("expected output to contain %q\n%s", exp, buf.String())
+			}
+		}
+	})
 
-		badFS := stub.FS{
-			FS: fstest.MapFS{
-				"forward-1234-a": &fstest.MapFile{Mode: 0x600},
-				"forward-2345-b": &fstest.MapFile{Mode: 0x600},
-			},
-			ReadFileFn: func(name string) ([]byte, error) {
-				if name != "forward-2345-b" {
-					t.Fatalf("read unexpected file %q, should read %q", name, "forward-2345-b")
-				}
-				return nil, errors.New("OOF")
-			},
+	t.Run("error - same label", func(t *testing.T) {
+		var gotQueries []string
+		var gotUpdates []update
+		dirFS := fstest.MapFS{
+			"repeatable-foos_view.sql":  &fstest.MapFile{Data: []byte(viewV1)},
+			"repeatable-foos_view.psql": &fstest.MapFile{Data: []byte(viewV2)},
 		}
-		err := up(t.Context(), driver, &badFS, "2345", "")
-		if err == nil {
-			t.Fatal("expected an error but got nil")
+		err := godfish.MigrateWith(t.Context(), makeDriver(nil, nil, &gotQueries, &gotUpdates), dirFS)
+		if !errors.Is(err, internal.ErrDataInvalid) {
+			t.Fatalf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
 		}
-		t.Log(err)
-		const looseExpectation = "reading file"
-		if m := err.Error(); !strings.Contains(m, looseExpectation) {
-			t.Errorf("expected for error (%v) to contain %q", err, looseExpectation)
+		if len(gotQueries) > 0 {
+			t.Errorf("expected nothing to run; got queries %q", gotQueries)
 		}
 	})
 
-	t.Run("other error scanning for migrations", func(t *testing.T) {
-		oof := errors.New("oof")
-		var calledUpdate bool
-		driver := &stub.Double{
-			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
-				return nil, oof
-			},
+	t.Run("error - driver does not record checksums", func(t *testing.T) {
+		var gotQueries []string
+		var gotUpdates []update
+		d := makeDriver(nil, nil, &gotQueries, &gotUpdates)
+		err := godfish.MigrateWith(t.Context(), &d.Double, dirFS)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Fatalf("expected error (%v) to be %v", err, errors.ErrUnsupported)
+		}
+		if len(gotQueries) > 0 || len(gotUpdates) > 0 {
+			t.Errorf("expected nothing to run; got queries %q, updates %v", gotQueries, gotUpdates)
+		}
+	})
+}
+
+func TestMigrationSources(t *testing.T) {
+	migrate := func(t *testing.T, dirFS fs.FS, opts ...godfish.Opter) ([]string, error) {
+		t.Helper()
+		var gotVersions []string
+		d := &stub.Double{
+			AppliedVersionsFn:        func(context.Context, string) (driver.AppliedVersions, error) { return stub.NewAppliedVersions(), nil },
 			ExecuteFn:                makeExecuteFn(nil),
 			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
-			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
-				calledUpdate = true
+			UpdateSchemaMigrationsFn: func(_ context.Context, _ string, _ bool, version, _ string) error {
+				gotVersions = append(gotVersions, version)
 				return nil
 			},
 		}
+		err := godfish.MigrateWith(t.Context(), d, dirFS, opts...)
+		return gotVersions, err
+	}
 
-		// TODO: also add test that passes a non-zero version
-		err := up(t.Context(), driver, okFS, "", "test")
-		if !errors.Is(err, oof) {
-			t.Errorf("expected error (%v) to be %v", err, oof)
+	t.Run("recursive", func(t *testing.T) {
+		dirFS := fstest.MapFS{
+			"forward-20240101000000-a.sql":      &fstest.MapFile{Data: []byte("CREATE TABLE a (id int);")},
+			"2024/forward-20240301000000-c.sql": &fstest.MapFile{Data: []byte("CREATE TABLE c (id int);")},
+			"2025/forward-20250101000000-d.sql": &fstest.MapFile{Data: []byte("CREATE TABLE d (id int);")},
+			"2023/forward-20230101000000-b.sql": &fstest.MapFile{Data: []byte("CREATE TABLE b (id int);")},
 		}
-		if calledUpdate {
-			t.Errorf("did not expect to call UpdateSchemaMigrations")
+
+		gotVersions, err := migrate(t, dirFS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := []string{"20240101000000"}; !slices.Equal(gotVersions, exp) {
+			t.Errorf("wrong versions without recursion\ngot:      %q\nexpected: %q", gotVersions, exp)
 		}
-	})
 
-	t.Run("error executing migration", func(t *testing.T) {
-		var calledUpdateFn bool
-		driver := &stub.Double{
-			AppliedVersionsFn: makeScanApplied(t, "1234"),
-			ExecuteFn:         makeExecuteFn(errors.New("OOF")),
-			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
-				calledUpdateFn = true
+		gotVersions, err = migrate(t, dirFS, godfish.WithRecursive())
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := []string{"20230101000000", "20240101000000", "20240301000000", "20250101000000"}
+		if !slices.Equal(gotVersions, exp) {
+			t.Errorf("wrong versions\ngot:      %q\nexpected: %q", gotVersions, exp)
+		}
+
+		var gotQueries []string
+		d := &stub.Double{
+			AppliedVersionsFn:        func(context.Context, string) (driver.AppliedVersions, error) { return stub.NewAppliedVersions(), nil },
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			ExecuteFn: func(_ context.Context, query string, _ ...any) error {
+				gotQueries = append(gotQueries, query)
 				return nil
 			},
+			UpdateSchemaMigrationsFn: func(context.Context, string, bool, string, string) error { return nil },
 		}
-		err := up(t.Context(), driver, okFS, "2345", "test")
-		expErr := internal.ErrExecutingMigration
-		if !errors.Is(err, expErr) {
-			t.Errorf("expected error (%v) to be %v", err, expErr)
+		err = godfish.ApplyMigrationWith(t.Context(), d, dirFS, godfish.WithRecursive(), godfish.WithTargetVersion("2025"))
+		if err != nil {
+			t.Fatal(err)
 		}
-		if calledUpdateFn {
-			t.Errorf("did not expect to call the update method")
+		if !slices.Contains(gotQueries, "CREATE TABLE d (id int);") {
+			t.Errorf("expected to run the migration; got queries %q", gotQueries)
 		}
 	})
 
-	t.Run("error creating migrations table", func(t *testing.T) {
+	t.Run("several directories", func(t *testing.T) {
+		dirFS := godfish.MergeFS(
+			fstest.MapFS{
+				"forward-20240101000000-a.sql": &fstest.MapFile{Data: []byte("CREATE TABLE a (id int);")},
+				"forward-20240301000000-c.sql": &fstest.MapFile{Data: []byte("CREATE TABLE c (id int);")},
+			},
+			fstest.MapFS{
+				"forward-20240201000000-b.sql": &fstest.MapFile{Data: []byte("CREATE TABLE b (id int);")},
+			},
+		)
+
+		gotVersions, err := migrate(t, dirFS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := []string{"20240101000000", "20240201000000", "20240301000000"}; !slices.Equal(gotVersions, exp) {
+			t.Errorf("wrong versions\ngot:      %q\nexpected: %q", gotVersions, exp)
+		}
+	})
+
+	t.Run("error - duplicate version", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			dirFS fs.FS
+			opts  []godfish.Opter
+		}{
+			{
+				name: "in subdirectories",
+				dirFS: fstest.MapFS{
+					"2024/forward-20240101000000-a.sql": &fstest.MapFile{Data: []byte("CREATE TABLE a (id int);")},
+					"2025/forward-20240101000000-b.sql": &fstest.MapFile{Data: []byte("CREATE TABLE b (id int);")},
+				},
+				opts: []godfish.Opter{godfish.WithRecursive()},
+			},
+			{
+				name: "in several directories",
+				dirFS: godfish.MergeFS(
+					fstest.MapFS{"forward-20240101000000-a.sql": &fstest.MapFile{Data: []byte("CREATE TABLE a (id int);")}},
+					fstest.MapFS{"forward-20240101000000-b.sql": &fstest.MapFile{Data: []byte("CREATE TABLE b (id int);")}},
+				),
+			},
+			{
+				name: "same file in several directories",
+				dirFS: godfish.MergeFS(
+					fstest.MapFS{"forward-20240101000000-a.sql": &fstest.MapFile{Data: []byte("CREATE TABLE a (id int);")}},
+					fstest.MapFS{"forward-20240101000000-a.sql": &fstest.MapFile{Data: []byte("CREATE TABLE a (id int);")}},
+				),
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				gotVersions, err := migrate(t, test.dirFS, test.opts...)
+				if !errors.Is(err, internal.ErrDataInvalid) {
+					t.Fatalf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
+				}
+				if len(gotVersions) > 0 {
+					t.Errorf("expected nothing to run; got versions %q", gotVersions)
+				}
+			})
+		}
+	})
+}
+
+func TestLocker(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type callRecorder struct {
+		calls []string
+	}
+	makeLocker := func(t *testing.T, rec *callRecorder, lockErr, unlockErr, execErr error) *stub.Locker {
+		t.Helper()
+		return &stub.Locker{
+			Double: stub.Double{
+				AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
+					rec.calls = append(rec.calls, "AppliedVersions")
+					return makeScanApplied(t, "1234")(ctx, migrationsTable)
+				},
+				ExecuteFn: func(context.Context, string, ...any) error {
+					rec.calls = append(rec.calls, "Execute")
+					return execErr
+				},
+				CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+				UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
+			},
+			LockFn: func(ctx context.Context, migrationsTable string) error {
+				rec.calls = append(rec.calls, "Lock")
+				return lockErr
+			},
+			UnlockFn: func(ctx context.Context, migrationsTable string) error {
+				rec.calls = append(rec.calls, "Unlock")
+				return unlockErr
+			},
+		}
+	}
+
+	funcs := []struct {
+		name string
+		fn   func(context.Context, driver.Driver, fs.FS, ...godfish.Opter) error
+	}{
+		{name: "MigrateWith", fn: godfish.MigrateWith},
+		{name: "RollbackWith", fn: godfish.RollbackWith},
+		{name: "ApplyMigrationWith", fn: godfish.ApplyMigrationWith},
+		{name: "ApplyRollbackWith", fn: godfish.ApplyRollbackWith},
+		{name: "RemigrateWith", fn: godfish.RemigrateWith},
+	}
+
+	for _, f := range funcs {
+		t.Run(f.name, func(t *testing.T) {
+			t.Run("lock is held for the whole run", func(t *testing.T) {
+				var rec callRecorder
+				d := makeLocker(t, &rec, nil, nil, nil)
+				if err := f.fn(t.Context(), d, dirFS); err != nil {
+					t.Fatal(err)
+				}
+				if len(rec.calls) < 3 {
+					t.Fatalf("too few calls; got %q", rec.calls)
+				}
+				if first := rec.calls[0]; first != "Lock" {
+					t.Errorf("wrong first call; got %q, expected %q", first, "Lock")
+				}
+				if last := rec.calls[len(rec.calls)-1]; last != "Unlock" {
+					t.Errorf("wrong last call; got %q, expected %q", last, "Unlock")
+				}
+				if inner := rec.calls[1 : len(rec.calls)-1]; slices.Contains(inner, "Lock") || slices.Contains(inner, "Unlock") {
+					t.Errorf("expected only 1 call each to Lock and Unlock; got %q", rec.calls)
+				}
+			})
+
+			t.Run("error acquiring lock", func(t *testing.T) {
+				var rec callRecorder
+				oof := errors.New("oof")
+				d := makeLocker(t, &rec, oof, nil, nil)
+				err := f.fn(t.Context(), d, dirFS)
+				if !errors.Is(err, oof) {
+					t.Errorf("expected error (%v) to be %v", err, oof)
+				}
+				if len(rec.calls) != 1 {
+					t.Errorf("expected only 1 call to Lock; got %q", rec.calls)
+				}
+			})
+
+			t.Run("error releasing lock", func(t *testing.T) {
+				var rec callRecorder
+				oof := errors.New("oof")
+				d := makeLocker(t, &rec, nil, oof, nil)
+				err := f.fn(t.Context(), d, dirFS)
+				if !errors.Is(err, oof) {
+					t.Errorf("expected error (%v) to be %v", err, oof)
+				}
+			})
+
+			t.Run("lock is released after failed migration", func(t *testing.T) {
+				var rec callRecorder
+				d := makeLocker(t, &rec, nil, nil, errors.New("oof"))
+				err := f.fn(t.Context(), d, dirFS)
+				if !errors.Is(err, internal.ErrExecutingMigration) {
+					t.Errorf("expected error (%v) to be %v", err, internal.ErrExecutingMigration)
+				}
+				if last := rec.calls[len(rec.calls)-1]; last != "Unlock" {
+					t.Errorf("wrong last call; got %q, expected %q", last, "Unlock")
+				}
+			})
+		})
+	}
+
+	t.Run("WithLockTimeout sets a deadline on the lock", func(t *testing.T) {
+		var rec callRecorder
+		d := makeLocker(t, &rec, nil, nil, nil)
+		var hasDeadline bool
+		d.LockFn = func(ctx context.Context, migrationsTable string) error {
+			_, hasDeadline = ctx.Deadline()
+			return nil
+		}
+		if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithLockTimeout(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		if !hasDeadline {
+			t.Error("expected context passed to Lock to have a deadline")
+		}
+	})
+}
+
+func TestTransactor(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// makeTransactor sets up a Transactor whose own Execute, CreateSchemaMigrationsTable,
+	// UpdateSchemaMigrations methods should not be called. Those should be called
+	// on the tx Driver instead.
+	makeTransactor := func(t *testing.T, tx *stub.Double, txErr *error) *stub.Transactor {
+		t.Helper()
+		return &stub.Transactor{
+			Double: stub.Double{
+				AppliedVersionsFn: makeScanApplied(t, "1234"),
+			},
+			WithinTransactionFn: func(ctx context.Context, fn func(context.Context, driver.Driver) error) error {
+				*txErr = fn(ctx, tx)
+				return *txErr
+			},
+		}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var executeCalls, updateCalls int
+		tx := &stub.Double{
+			ExecuteFn: func(context.Context, string, ...any) error {
+				executeCalls++
+				return nil
+			},
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: func(context.Context, string, bool, string, string) error {
+				updateCalls++
+				return nil
+			},
+		}
+		var txErr error
+		d := makeTransactor(t, tx, &txErr)
+		if err := godfish.MigrateWith(t.Context(), d, dirFS); err != nil {
+			t.Fatal(err)
+		}
+		if executeCalls != 2 {
+			t.Errorf("wrong number of calls to Execute; got %d, expected %d", executeCalls, 2)
+		}
+		if updateCalls != 2 {
+			t.Errorf("wrong number of calls to UpdateSchemaMigrations; got %d, expected %d", updateCalls, 2)
+		}
+	})
+
+	t.Run("error updating schema migrations is passed to transaction", func(t *testing.T) {
 		oof := errors.New("oof")
-		var calledUpdateFn bool
+		tx := &stub.Double{
+			ExecuteFn:                makeExecuteFn(nil),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(oof),
+		}
+		var txErr error
+		d := makeTransactor(t, tx, &txErr)
+		err := godfish.ApplyMigrationWith(t.Context(), d, dirFS)
+		if !errors.Is(err, oof) {
+			t.Errorf("expected error (%v) to be %v", err, oof)
+		}
+		if !errors.Is(txErr, oof) {
+			t.Errorf("expected transaction error (%v) to be %v", txErr, oof)
+		}
+	})
+}
+
+func TestRemigrateWith(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("error - non-zero value required", func(t *testing.T) {
+		driver := makeNoCallDriver(t)
+		err := godfish.RemigrateWith(t.Context(), driver, dirFS, godfish.WithMigrationsTable(""))
+		if err == nil {
+			t.Fatal("expected error but got nil")
+		}
+		if m := err.Error(); !strings.Contains(m, "zero value") {
+			t.Errorf("expected for error message (%q) to contain %q", m, "zero value")
+		}
+	})
+
+	t.Run("rolls back and then migrates the same version", func(t *testing.T) {
+		var updates []string
 		driver := &stub.Double{
-			AppliedVersionsFn:        makeScanApplied(t),
+			AppliedVersionsFn:        makeScanApplied(t, "1234", "2345"),
 			ExecuteFn:                makeExecuteFn(nil),
-			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(oof),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
 			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
-				calledUpdateFn = true
+				if forward {
+					updates = append(updates, "forward-"+version)
+				} else {
+					updates = append(updates, "reverse-"+version)
+				}
 				return nil
 			},
 		}
-		err := up(t.Context(), driver, okFS, "2345", "test")
-		if !errors.Is(err, oof) {
-			t.Errorf("expected error (%v) to be %v", err, oof)
+		if err := godfish.RemigrateWith(t.Context(), driver, dirFS); err != nil {
+			t.Fatal(err)
 		}
-		if calledUpdateFn {
-			t.Errorf("did not expect to call the update method")
+		expected := []string{"reverse-2345", "forward-2345"}
+		if !slices.Equal(updates, expected) {
+			t.Errorf("wrong updates; got %q, expected %q", updates, expected)
 		}
 	})
+}
 
-	t.Run("error updating migrations table", func(t *testing.T) {
-		oof := errors.New("oof")
-		driver := &stub.Double{
-			AppliedVersionsFn:        makeScanApplied(t, "1234"),
+func TestGoMigrations(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// makeDriver sets up a Driver that records the direction and version of
+	// each call to UpdateSchemaMigrations.
+	makeDriver := func(t *testing.T, updates *[]string, appliedVersions ...string) *stub.Double {
+		t.Helper()
+		return &stub.Double{
+			AppliedVersionsFn:        makeScanApplied(t, appliedVersions...),
 			ExecuteFn:                makeExecuteFn(nil),
 			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
 			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
-				return oof
+				if forward {
+					*updates = append(*updates, "forward-"+version+"-"+label)
+				} else {
+					*updates = append(*updates, "reverse-"+version+"-"+label)
+				}
+				return nil
 			},
 		}
-		err := up(t.Context(), driver, okFS, "2345", "test")
-		if !errors.Is(err, oof) {
-			t.Errorf("expected error (%v) to be %v", err, oof)
+	}
+
+	t.Run("migrate in order with migration files", func(t *testing.T) {
+		var updates []string
+		d := makeDriver(t, &updates)
+		var gotConn any
+		gm := godfish.GoMigration{
+			Version: "2000",
+			Label:   "gopher",
+			Forward: func(ctx context.Context, conn any) error {
+				gotConn = conn
+				return nil
+			},
+		}
+		if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithGoMigrations(gm)); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"forward-1234-alpha", "forward-2000-gopher", "forward-2345-bravo", "forward-3456-charlie"}
+		if !slices.Equal(updates, expected) {
+			t.Errorf("wrong updates; got %q, expected %q", updates, expected)
+		}
+		// The stub driver is not a RawConnector, so it should receive the Driver.
+		if gotConn != d {
+			t.Errorf("wrong conn; got %#v, expected %#v", gotConn, d)
 		}
 	})
 
-	t.Run("skips over directories", func(t *testing.T) {
-		var numExecCalls int
-		driver := &stub.Double{
-			AppliedVersionsFn: makeScanApplied(t, "1234"),
-			ExecuteFn: func(ctx context.Context, q string, a ...any) error {
-				numExecCalls++
+	t.Run("rollback calls Reverse func", func(t *testing.T) {
+		var updates []string
+		d := makeDriver(t, &updates, "1234", "2000")
+		var reverseCalls int
+		gm := godfish.GoMigration{
+			Version: "2000",
+			Label:   "gopher",
+			Forward: func(context.Context, any) error { return nil },
+			Reverse: func(context.Context, any) error {
+				reverseCalls++
 				return nil
 			},
-			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
-			UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
 		}
-		dirFS := fstest.MapFS{
-			// this "directory" is named like a migrations file, but should be ignored.
-			"forward-2345-directory": &fstest.MapFile{Mode: fs.ModeDir},
-			"forward-1234-a.sql":     &fstest.MapFile{Mode: 0x640},
-			"forward-2345-b.sql":     &fstest.MapFile{Mode: 0x640},
+		if err := godfish.RollbackWith(t.Context(), d, dirFS, godfish.WithGoMigrations(gm)); err != nil {
+			t.Fatal(err)
 		}
-		err := up(t.Context(), driver, dirFS, "2345", "")
+		if reverseCalls != 1 {
+			t.Errorf("wrong number of calls to Reverse; got %d, expected %d", reverseCalls, 1)
+		}
+		expected := []string{"reverse-2000-gopher", "reverse-1234-alpha"}
+		if !slices.Equal(updates, expected) {
+			t.Errorf("wrong updates; got %q, expected %q", updates, expected)
+		}
+	})
+
+	t.Run("rollback without Reverse func skips it", func(t *testing.T) {
+		var updates []string
+		d := makeDriver(t, &updates, "1234", "2000")
+		gm := godfish.GoMigration{Version: "2000", Label: "gopher", Forward: func(context.Context, any) error { return nil }}
+		if err := godfish.RollbackWith(t.Context(), d, dirFS, godfish.WithGoMigrations(gm)); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"reverse-1234-alpha"}
+		if !slices.Equal(updates, expected) {
+			t.Errorf("wrong updates; got %q, expected %q", updates, expected)
+		}
+	})
+
+	t.Run("apply a specific version", func(t *testing.T) {
+		var updates []string
+		d := makeDriver(t, &updates, "1234")
+		gm := godfish.GoMigration{Version: "2000", Label: "gopher", Forward: func(context.Context, any) error { return nil }}
+		err := godfish.ApplyMigrationWith(t.Context(), d, dirFS, godfish.WithGoMigrations(gm), godfish.WithTargetVersion("2000"))
 		if err != nil {
 			t.Fatal(err)
 		}
-		const expNumCalls = 1
-		if numExecCalls != expNumCalls {
-			t.Errorf("wrong number of method calls; got %d, expected %d", numExecCalls, expNumCalls)
+		expected := []string{"forward-2000-gopher"}
+		if !slices.Equal(updates, expected) {
+			t.Errorf("wrong updates; got %q, expected %q", updates, expected)
+		}
+	})
+
+	t.Run("error from func is returned", func(t *testing.T) {
+		var updates []string
+		d := makeDriver(t, &updates)
+		oof := errors.New("oof")
+		gm := godfish.GoMigration{Version: "2000", Label: "gopher", Forward: func(context.Context, any) error { return oof }}
+		err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithGoMigrations(gm))
+		if !errors.Is(err, oof) {
+			t.Errorf("expected error (%v) to be %v", err, oof)
+		}
+		if !errors.Is(err, internal.ErrExecutingMigration) {
+			t.Errorf("expected error (%v) to be %v", err, internal.ErrExecutingMigration)
+		}
+		expected := []string{"forward-1234-alpha"}
+		if !slices.Equal(updates, expected) {
+			t.Errorf("wrong updates; got %q, expected %q", updates, expected)
+		}
+	})
+
+	t.Run("error - same version as a migration file", func(t *testing.T) {
+		var updates []string
+		d := makeDriver(t, &updates)
+		gm := godfish.GoMigration{Version: "2345", Label: "gopher", Forward: func(context.Context, any) error { return nil }}
+		err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithGoMigrations(gm))
+		if !errors.Is(err, internal.ErrDataInvalid) {
+			t.Errorf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
+		}
+		if len(updates) > 0 {
+			t.Errorf("expected no updates; got %q", updates)
+		}
+	})
+
+	t.Run("info shows a marker in place of filename", func(t *testing.T) {
+		var updates []string
+		d := makeDriver(t, &updates, "1234", "2000")
+		gm := godfish.GoMigration{Version: "2000", Label: "gopher", Forward: func(context.Context, any) error { return nil }}
+		var buf bytes.Buffer
+		err := godfish.InfoWith(t.Context(), d, dirFS, godfish.WithGoMigrations(gm), godfish.WithWriter(&buf), godfish.WithFormat("tsv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), internal.GoFuncMarker) {
+			t.Errorf("expected output to contain %q\n%s", internal.GoFuncMarker, buf.String())
+		}
+	})
+
+	t.Run("verify skips checksum", func(t *testing.T) {
+		var updates []string
+		d := makeDriver(t, &updates, "2000")
+		gm := godfish.GoMigration{Version: "2000", Label: "gopher", Forward: func(context.Context, any) error { return nil }}
+		var buf bytes.Buffer
+		err := godfish.VerifyWith(t.Context(), d, dirFS, godfish.WithGoMigrations(gm), godfish.WithWriter(&buf), godfish.WithFormat("json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got struct {
+			Version string `json:"version"`
+			Status  string `json:"status"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Version != "2000" || got.Status != string(internal.ChecksumSkipped) {
+			t.Errorf("wrong result; got %+v", got)
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		noop := func(context.Context, any) error { return nil }
+		tests := []struct {
+			name       string
+			migrations []godfish.GoMigration
+			expErr     error
+		}{
+			{name: "empty"},
+			{
+				name:       "bad version",
+				migrations: []godfish.GoMigration{{Version: "abc", Label: "gopher", Forward: noop}},
+				expErr:     internal.ErrDataInvalid,
+			},
+			{
+				name:       "empty label",
+				migrations: []godfish.GoMigration{{Version: "2000", Forward: noop}},
+				expErr:     internal.ErrDataInvalid,
+			},
+			{
+				name:       "nil Forward func",
+				migrations: []godfish.GoMigration{{Version: "2000", Label: "gopher", Reverse: noop}},
+				expErr:     internal.ErrDataInvalid,
+			},
+			{
+				name: "duplicate version",
+				migrations: []godfish.GoMigration{
+					{Version: "2000", Label: "gopher", Forward: noop},
+					{Version: "2000", Label: "other", Forward: noop},
+				},
+				expErr: internal.ErrDataInvalid,
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				driver := makeNoCallDriver(t)
+				err := godfish.MigrateWith(t.Context(), driver, dirFS, godfish.WithGoMigrations(test.migrations...))
+				if err == nil {
+					t.Fatal("expected error but got nil")
+				}
+				if test.expErr != nil && !errors.Is(err, test.expErr) {
+					t.Errorf("expected error (%v) to be %v", err, test.expErr)
+				}
+			})
 		}
 	})
 }
 
-func TestApplyMigrationWith(t *testing.T) {
-	t.Run("error - non-zero value required", func(t *testing.T) {
-		dirFS, err := fs.Sub(testdata.Migrations, "default")
+func TestDirectives(t *testing.T) {
+	const (
+		plain    = "CREATE TABLE foos (id int);"
+		noTx     = "-- godfish:no-transaction\n-- godfish:timeout 1m\nCREATE INDEX foos_id ON foos (id);"
+		prodOnly = "-- godfish:env prod\nINSERT INTO foos (id) VALUES (1);"
+	)
+	dirFS := fstest.MapFS{
+		"forward-1000-plain.sql":     &fstest.MapFile{Data: []byte(plain)},
+		"forward-2000-no_tx.sql":     &fstest.MapFile{Data: []byte(noTx)},
+		"forward-3000-prod_only.sql": &fstest.MapFile{Data: []byte(prodOnly)},
+	}
+
+	// makeTransactor sets up a Transactor that records each executed query,
+	// prefixed with "tx" if it's within a transaction, or "direct" otherwise.
+	// It also records whether or not the query's context had a deadline.
+	makeTransactor := func(t *testing.T, executed *[]string, deadlines map[string]bool) *stub.Transactor {
+		t.Helper()
+		makeExecuteFn := func(prefix string) func(context.Context, string, ...any) error {
+			return func(ctx context.Context, query string, _ ...any) error {
+				*executed = append(*executed, prefix+": "+query)
+				_, deadlines[query] = ctx.Deadline()
+				return nil
+			}
+		}
+		tx := &stub.Double{
+			ExecuteFn:                makeExecuteFn("tx"),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
+		}
+		return &stub.Transactor{
+			Double: stub.Double{
+				NameFn:                   func() string { return "stub" },
+				AppliedVersionsFn:        makeScanApplied(t),
+				ExecuteFn:                makeExecuteFn("direct"),
+				CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+				UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
+			},
+			WithinTransactionFn: func(ctx context.Context, fn func(context.Context, driver.Driver) error) error {
+				return fn(ctx, tx)
+			},
+		}
+	}
+
+	t.Run("no-transaction, timeout, env skipped", func(t *testing.T) {
+		var executed []string
+		deadlines := make(map[string]bool)
+		d := makeTransactor(t, &executed, deadlines)
+		obs := &recordingObserver{}
+		var report godfish.Report
+		err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithEnvironment("dev"), godfish.WithObserver(obs), godfish.WithReport(&report))
 		if err != nil {
 			t.Fatal(err)
 		}
+		expected := []string{"tx: " + plain, "direct: " + noTx}
+		if !slices.Equal(executed, expected) {
+			t.Errorf("wrong executed queries\ngot:      %q\nexpected: %q", executed, expected)
+		}
+		if deadlines[plain] {
+			t.Error("expected no deadline on a migration without a timeout directive")
+		}
+		if !deadlines[noTx] {
+			t.Error("expected a deadline on a migration with a timeout directive")
+		}
+
+		if got := obs.events[len(obs.events)-2]; got != "migration finished forward 3000 prod_only forward-3000-prod_only.sql" {
+			t.Errorf("expected a finished event for the skipped migration; got %q", got)
+		}
+		if got := obs.migrations[len(obs.migrations)-1]; !got.Skipped {
+			t.Errorf("expected skipped migration event; got %+v", got)
+		}
+		outcomes := make([]godfish.Outcome, len(report.Migrations))
+		for i, mig := range report.Migrations {
+			outcomes[i] = mig.Outcome
+		}
+		if exp := []godfish.Outcome{godfish.OutcomeApplied, godfish.OutcomeApplied, godfish.OutcomeSkipped}; !slices.Equal(outcomes, exp) {
+			t.Errorf("wrong outcomes; got %q, expected %q", outcomes, exp)
+		}
+	})
+
+	t.Run("no environment configured", func(t *testing.T) {
+		var executed []string
+		d := makeTransactor(t, &executed, make(map[string]bool))
+		if err := godfish.MigrateWith(t.Context(), d, dirFS); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"tx: " + plain, "direct: " + noTx, "tx: " + prodOnly}
+		if !slices.Equal(executed, expected) {
+			t.Errorf("wrong executed queries\ngot:      %q\nexpected: %q", executed, expected)
+		}
+	})
+
+	t.Run("env matches", func(t *testing.T) {
+		var executed []string
+		d := makeTransactor(t, &executed, make(map[string]bool))
+		if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithEnvironment("prod")); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"tx: " + plain, "direct: " + noTx, "tx: " + prodOnly}
+		if !slices.Equal(executed, expected) {
+			t.Errorf("wrong executed queries\ngot:      %q\nexpected: %q", executed, expected)
+		}
+	})
+
+	t.Run("info shows directives", func(t *testing.T) {
+		var executed []string
+		d := makeTransactor(t, &executed, make(map[string]bool))
+		var buf bytes.Buffer
+		if err := godfish.InfoWith(t.Context(), d, dirFS, godfish.WithWriter(&buf), godfish.WithFormat("json")); err != nil {
+			t.Fatal(err)
+		}
+
+		type result struct {
+			Version    string `json:"version"`
+			Directives string `json:"directives"`
+		}
+		expected := []result{
+			{"1000", ""},
+			{"2000", "no-transaction; timeout 1m0s"},
+			{"3000", "env prod"},
+		}
+		var got []result
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var r result
+			if err := dec.Decode(&r); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, r)
+		}
+		if !slices.Equal(got, expected) {
+			t.Errorf("wrong output\ngot:      %+v\nexpected: %+v", got, expected)
+		}
+		if len(executed) > 0 {
+			t.Errorf("expected nothing to be executed; got %q", executed)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		var executed []string
+		d := makeTransactor(t, &executed, make(map[string]bool))
+		var buf bytes.Buffer
+		if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithDryRun(), godfish.WithWriter(&buf), godfish.WithEnvironment("dev")); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, exp := range []string{"-- directives: no-transaction; timeout 1m0s", "-- directives: env prod", `-- skipped, environment "dev" does not match`} {
+			if !strings.Contains(out, exp) {
+				t.Errorf("expected output to contain %q\n%s", exp, out)
+			}
+		}
+		if strings.Contains(out, prodOnly[strings.Index(prodOnly, "INSERT"):]) {
+			t.Errorf("expected output to omit statements of a skipped migration\n%s", out)
+		}
+		if got := strings.Count(out, "runs within a transaction"); got != 1 {
+			t.Errorf("wrong number of transaction notes; got %d, expected %d", got, 1)
+		}
+	})
+
+	t.Run("unknown directive is ignored", func(t *testing.T) {
+		var executed []string
+		d := makeTransactor(t, &executed, make(map[string]bool))
+		unknownFS := fstest.MapFS{
+			"forward-1000-bogus.sql": &fstest.MapFile{Data: []byte("-- godfish:bogus\nSELECT 1;")},
+		}
+		var buf bytes.Buffer
+		lgr := slog.New(slog.NewTextHandler(&buf, nil))
+		if err := godfish.MigrateWith(t.Context(), d, unknownFS, godfish.WithLogger(lgr)); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"tx: -- godfish:bogus\nSELECT 1;"}
+		if !slices.Equal(executed, expected) {
+			t.Errorf("wrong executed queries\ngot:      %q\nexpected: %q", executed, expected)
+		}
+		if got := buf.String(); !strings.Contains(got, "ignoring unknown directives") {
+			t.Errorf("expected a warning about the unknown directive\n%s", got)
+		}
+	})
+
+	t.Run("error - invalid directive", func(t *testing.T) {
+		var executed []string
+		d := makeTransactor(t, &executed, make(map[string]bool))
+		invalidFS := fstest.MapFS{
+			"forward-1000-bogus.sql": &fstest.MapFile{Data: []byte("-- godfish:timeout bogus\nSELECT 1;")},
+		}
+		err := godfish.MigrateWith(t.Context(), d, invalidFS)
+		if !errors.Is(err, internal.ErrDataInvalid) {
+			t.Errorf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
+		}
+		if len(executed) > 0 {
+			t.Errorf("expected nothing to be executed; got %q", executed)
+		}
+	})
+
+	t.Run("error - non-zero value required", func(t *testing.T) {
+		driver := makeNoCallDriver(t)
+		err := godfish.MigrateWith(t.Context(), driver, dirFS, godfish.WithEnvironment(""))
+		if err == nil {
+			t.Fatal("expected error but got nil")
+		}
+		if m := err.Error(); !strings.Contains(m, "zero value") {
+			t.Errorf("expected for error message (%q) to contain %q", m, "zero value")
+		}
+	})
+}
+
+func TestDryRun(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readFile := func(t *testing.T, filename string) string {
+		t.Helper()
+		data, err := fs.ReadFile(dirFS, filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(data))
+	}
+
+	// makeDriver sets up a Driver that may only read the schema migrations
+	// table. Calling any other method fails the test.
+	makeDriver := func(t *testing.T) *stub.Double {
+		t.Helper()
+		d := makeNoCallDriver(t)
+		d.NameFn = func() string { return "test" }
+		d.AppliedVersionsFn = makeScanApplied(t, "1234", "2345")
+		return d
+	}
+
+	tests := []struct {
+		name          string
+		fn            func(context.Context, driver.Driver, fs.FS, ...godfish.Opter) error
+		expFiles      []string
+		expStatements []string
+	}{
+		{
+			name:          "MigrateWith",
+			fn:            godfish.MigrateWith,
+			expFiles:      []string{"forward-3456-charlie.sql"},
+			expStatements: []string{"UPDATE schema_migrations forward 3456 charlie"},
+		},
+		{
+			name:          "RollbackWith",
+			fn:            godfish.RollbackWith,
+			expFiles:      []string{"reverse-2345-bravo.sql", "reverse-1234-alpha.sql"},
+			expStatements: []string{"UPDATE schema_migrations reverse 2345 bravo", "UPDATE schema_migrations reverse 1234 alpha"},
+		},
+		{
+			name:          "ApplyMigrationWith",
+			fn:            godfish.ApplyMigrationWith,
+			expFiles:      []string{"forward-3456-charlie.sql"},
+			expStatements: []string{"UPDATE schema_migrations forward 3456 charlie"},
+		},
+		{
+			name:          "ApplyRollbackWith",
+			fn:            godfish.ApplyRollbackWith,
+			expFiles:      []string{"reverse-2345-bravo.sql"},
+			expStatements: []string{"UPDATE schema_migrations reverse 2345 bravo"},
+		},
+		{
+			name:          "RemigrateWith",
+			fn:            godfish.RemigrateWith,
+			expFiles:      []string{"reverse-2345-bravo.sql", "forward-2345-bravo.sql"},
+			expStatements: []string{"UPDATE schema_migrations reverse 2345 bravo", "UPDATE schema_migrations forward 2345 bravo"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Run("driver is not a Scripter", func(t *testing.T) {
+				var buf bytes.Buffer
+				err := test.fn(t.Context(), makeDriver(t), dirFS, godfish.WithDryRun(), godfish.WithWriter(&buf))
+				if err != nil {
+					t.Fatal(err)
+				}
+				out := buf.String()
+				for _, filename := range test.expFiles {
+					if !strings.Contains(out, "path_to_file: "+filename) {
+						t.Errorf("expected output to mention file %q\n%s", filename, out)
+					}
+					if contents := readFile(t, filename); !strings.Contains(out, contents) {
+						t.Errorf("expected output to contain contents of file %q\n%s", filename, out)
+					}
+				}
+				if strings.Contains(out, "UPDATE schema_migrations") {
+					t.Errorf("did not expect output to contain schema migrations statements\n%s", out)
+				}
+			})
+
+			t.Run("driver is a Scripter", func(t *testing.T) {
+				d := &stub.Scripter{
+					Double: *makeDriver(t),
+					CreateSchemaMigrationsTableScriptFn: func(migrationsTable string) (string, error) {
+						return "CREATE " + migrationsTable, nil
+					},
+					UpdateSchemaMigrationsScriptFn: func(migrationsTable string, forward bool, version, label, checksum string, batch int64) (string, error) {
+						if checksum == "" {
+							t.Error("expected a non-empty checksum")
+						}
+						direction := "reverse"
+						if forward {
+							direction = "forward"
+						}
+						return strings.Join([]string{"UPDATE", migrationsTable, direction, version, label}, " "), nil
+					},
+				}
+
+				var buf bytes.Buffer
+				err := test.fn(t.Context(), d, dirFS, godfish.WithDryRun(), godfish.WithWriter(&buf))
+				if err != nil {
+					t.Fatal(err)
+				}
+				out := buf.String()
+				if !strings.Contains(out, "CREATE schema_migrations;") {
+					t.Errorf("expected output to contain statement to create schema migrations table\n%s", out)
+				}
+				var prevIndex int
+				for i, stmt := range test.expStatements {
+					ind := strings.Index(out, stmt+";")
+					if ind < 0 {
+						t.Errorf("expected output to contain statement %q\n%s", stmt, out)
+					} else if ind < prevIndex {
+						t.Errorf("statement %d (%q) is out of order\n%s", i, stmt, out)
+					}
+					prevIndex = ind
+				}
+			})
+		})
+	}
+
+	t.Run("does not acquire lock", func(t *testing.T) {
+		d := &stub.Locker{Double: *makeDriver(t)}
+		if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithDryRun(), godfish.WithWriter(io.Discard)); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestExportWith(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// makeDriver sets up a Scripter that may only read the schema migrations
+	// table. Calling any other method of the Driver fails the test.
+	makeDriver := func(t *testing.T) *stub.Scripter {
+		t.Helper()
+		d := makeNoCallDriver(t)
+		d.NameFn = func() string { return "test" }
+		d.AppliedVersionsFn = makeScanApplied(t, "1234")
+		return &stub.Scripter{
+			Double: *d,
+			CreateSchemaMigrationsTableScriptFn: func(migrationsTable string) (string, error) {
+				return "CREATE " + migrationsTable, nil
+			},
+			UpdateSchemaMigrationsScriptFn: func(migrationsTable string, forward bool, version, label, checksum string, batch int64) (string, error) {
+				if !forward {
+					t.Errorf("expected only forward statements, version %q", version)
+				}
+				return fmt.Sprintf("INSERT %s %s %s %d", migrationsTable, version, label, batch), nil
+			},
+		}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := godfish.ExportWith(t.Context(), makeDriver(t), dirFS, godfish.WithWriter(&buf)); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		if got := strings.Count(out, "CREATE schema_migrations;"); got != 1 {
+			t.Errorf("expected the schema migrations table to be created once, got %d\n%s", got, out)
+		}
+		if strings.Contains(out, "dry run") {
+			t.Errorf("did not expect output to be labeled as a dry run\n%s", out)
+		}
+		if strings.Contains(out, "forward-1234-alpha.sql") {
+			t.Errorf("did not expect output to contain an applied migration\n%s", out)
+		}
+
+		expInOrder := []string{
+			"CREATE schema_migrations;",
+			"path_to_file: forward-2345-bravo.sql",
+			"INSERT schema_migrations 2345 bravo 1;",
+			"path_to_file: forward-3456-charlie.sql",
+			"INSERT schema_migrations 3456 charlie 1;",
+		}
+		var prevIndex int
+		for i, exp := range expInOrder {
+			ind := strings.Index(out, exp)
+			if ind < 0 {
+				t.Errorf("expected output to contain %q\n%s", exp, out)
+			} else if ind < prevIndex {
+				t.Errorf("item %d (%q) is out of order\n%s", i, exp, out)
+			}
+			prevIndex = ind
+		}
+	})
+
+	t.Run("target", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := godfish.ExportWith(t.Context(), makeDriver(t), dirFS, godfish.WithWriter(&buf), godfish.WithSteps(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out := buf.String(); !strings.Contains(out, "2345 bravo") || strings.Contains(out, "3456 charlie") {
+			t.Errorf("expected output to contain only the next migration\n%s", out)
+		}
+	})
+
+	t.Run("error - driver is not a Scripter", func(t *testing.T) {
+		var buf bytes.Buffer
+		d := makeDriver(t)
+		err := godfish.ExportWith(t.Context(), &d.Double, dirFS, godfish.WithWriter(&buf))
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+		if buf.Len() > 0 {
+			t.Errorf("expected empty output\n%s", buf.String())
+		}
+	})
+
+	t.Run("error - Go migration", func(t *testing.T) {
+		var buf bytes.Buffer
+		gm := godfish.GoMigration{Version: "5678", Label: "gopher", Forward: func(context.Context, any) error { return nil }}
+		err := godfish.ExportWith(t.Context(), makeDriver(t), dirFS, godfish.WithWriter(&buf), godfish.WithGoMigrations(gm))
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+		if buf.Len() > 0 {
+			t.Errorf("expected empty output\n%s", buf.String())
+		}
+	})
+}
+
+func TestApplyMigration(t *testing.T) {
+	tests := []struct {
+		name string
+		up   compat.MigrateFunc
+		down compat.RollbackFunc
+	}{
+		{
+			name: "Deprecated APIs",
+			up: func(ctx context.Context, d driver.Driver, fsys fs.FS, v, tbl string) error {
+				return godfish.ApplyMigration(ctx, d, fsys, true, v, tbl)
+			},
+			down: func(ctx context.Context, d driver.Driver, fsys fs.FS, v, tbl string) error {
+				return godfish.ApplyMigration(ctx, d, fsys, false, v, tbl)
+			},
+		},
+		{
+			name: "Replacement APIs",
+			up: func(ctx context.Context, d driver.Driver, fsys fs.FS, v, tbl string) error {
+				opts := compat.MakeMigrationOpts(compat.MigrationOptParams{
+					TargetVersion:   v,
+					MigrationsTable: tbl,
+				})
+				return godfish.ApplyMigrationWith(ctx, d, fsys, opts...)
+			},
+			down: func(ctx context.Context, d driver.Driver, fsys fs.FS, v, tbl string) error {
+				opts := compat.MakeMigrationOpts(compat.MigrationOptParams{
+					TargetVersion:   v,
+					MigrationsTable: tbl,
+				})
+				return godfish.ApplyRollbackWith(ctx, d, fsys, opts...)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testApplyMigration(t, test.up, test.down)
+		})
+	}
+}
+
+func testApplyMigration(t *testing.T, up compat.MigrateFunc, down compat.RollbackFunc) {
+	testUpDown(t, up, down)
+
+	okFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("version empty, not found", func(t *testing.T) {
+		driver := stub.Double{
+			AppliedVersionsFn: makeScanApplied(t),
+		}
+		fsys := fstest.MapFS{}
+		err := up(t.Context(), &driver, fsys, "", "")
+		if err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+		t.Log(err)
+		if !errors.Is(err, internal.ErrNotFound) {
+			t.Errorf("expected for error (%v) to be %v", err, internal.ErrNotFound)
+		}
+	})
+
+	t.Run("version specified, not found", func(t *testing.T) {
+		driver := stub.Double{}
+		err := up(t.Context(), &driver, okFS, "1111", "")
+		if err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+		t.Log(err)
+		if !errors.Is(err, internal.ErrNotFound) {
+			t.Errorf("expected for error (%v) to be %v", err, internal.ErrNotFound)
+		}
+	})
+
+	t.Run("version specified, found", func(t *testing.T) {
+		var numCallsToAppliedVersions int
+		driver := stub.Double{
+			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
+				defer func() { numCallsToAppliedVersions++ }()
+				var migs []internal.Migration
+				switch numCallsToAppliedVersions {
+				case 0:
+					migs = makeMigrations(t)
+				case 1:
+					migs = makeMigrations(t, "1234")
+				default:
+					t.Fatal("too many calls to AppliedVersions")
+				}
+				return stub.NewAppliedVersions(migs...), nil
+			},
+			ExecuteFn:                makeExecuteFn(nil),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
+		}
+		err := up(t.Context(), &driver, okFS, "1234", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err = down(t.Context(), &driver, okFS, "1234", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("error too many migration files with same version", func(t *testing.T) {
+		driver := makeNoCallDriver(t)
+		badFS := fstest.MapFS{
+			"forward-1234-a": &fstest.MapFile{Mode: 0x640},
+			"forward-1234-b": &fstest.MapFile{Mode: 0x640},
+		}
+		err := up(t.Context(), driver, badFS, "1234", "")
+		if err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+		t.Log(err)
+		const looseExpectation = "too many migration files with matching"
+		if m := err.Error(); !strings.Contains(m, looseExpectation) {
+			t.Errorf("expected for error (%v) to contain %q", err, looseExpectation)
+		}
+	})
+
+	t.Run("schema migrations table does not exist", func(t *testing.T) {
+		// Check that when the table does not exist, in the happy path, the
+		// "database" will handle the error by creating the table and updating it.
+		var updateCalls int
+		driver := &stub.Double{
+			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
+				return nil, driver.ErrSchemaMigrationsDoesNotExist
+			},
+			ExecuteFn:                makeExecuteFn(nil),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
+				updateCalls++
+				return nil
+			},
+		}
+		err := up(t.Context(), driver, okFS, "2345", "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expNumCalls := 1; updateCalls != expNumCalls {
+			t.Errorf("number of calls to UpdateSchemaMigrations; got %d, expected %d", updateCalls, expNumCalls)
+		}
+	})
+
+	t.Run("records checksum of file contents", func(t *testing.T) {
+		data, err := fs.ReadFile(okFS, "forward-2345-bravo.sql")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expChecksum := internal.Checksum(data)
+
+		var gotChecksum string
+		driver := &stub.Recorder{
+			Double: stub.Double{
+				AppliedVersionsFn:        makeScanApplied(t, "1234"),
+				ExecuteFn:                makeExecuteFn(nil),
+				CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			},
+			RecordMigrationFn: func(ctx context.Context, migrationsTable, version, label string, meta driver.Metadata) error {
+				gotChecksum = meta.Checksum
+				return nil
+			},
+		}
+		if err = up(t.Context(), driver, okFS, "2345", "test"); err != nil {
+			t.Fatal(err)
+		}
+		if gotChecksum != expChecksum {
+			t.Errorf("wrong checksum; got %q, expected %q", gotChecksum, expChecksum)
+		}
+	})
+
+	t.Run("rollback - error no migrations found", func(t *testing.T) {
+		var calledExec, calledUpdate bool
+		driver := &stub.Double{
+			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
+				return stub.NewAppliedVersions(), nil
+			},
+			ExecuteFn: func(ctx context.Context, q string, a ...any) error {
+				calledExec = true
+				return nil
+			},
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
+				calledUpdate = true
+				return nil
+			},
+		}
+		err := down(t.Context(), driver, okFS, "", "test")
+		expErr := internal.ErrNotFound
+		if !errors.Is(err, expErr) {
+			t.Fatalf("expected error (%v) to be %v", err, expErr)
+		}
+		if !strings.Contains(err.Error(), "version") {
+			t.Errorf("expected error (%v) to contain %q", err.Error(), "version")
+		}
+		if calledExec {
+			t.Errorf("did not expect to call Execute")
+		}
+		if calledUpdate {
+			t.Errorf("did not expect to call UpdateSchemaMigrations")
+		}
+	})
+
+	t.Run("handles alternate filename extensions", func(t *testing.T) {
+		var appliedVersionsCalls, executeCalls int
+		var createSchemaMigrationsCalls, updateSchemaMigrationsCall int
+		driver := stub.Double{
+			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
+				appliedVersionsCalls++
+				switch appliedVersionsCalls {
+				case 1:
+					return makeScanApplied(t)(ctx, migrationsTable)
+				case 2:
+					return makeScanApplied(t, "1234")(ctx, migrationsTable)
+				default:
+					t.Fatalf("unexpected number of calls to AppliedVersions; got %d, expected [1,2]", appliedVersionsCalls)
+					return nil, nil
+				}
+			},
+			ExecuteFn: func(ctx context.Context, q string, a ...any) error {
+				executeCalls++
+				return nil
+			},
+			CreateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string) error {
+				createSchemaMigrationsCalls++
+				return nil
+			},
+			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
+				updateSchemaMigrationsCall++
+				return nil
+			},
+		}
+		dirFS := stub.FS{
+			FS: fstest.MapFS{
+				"forward-1234-a.cql": &fstest.MapFile{Mode: 0x600},
+				"forward-2345-b.cql": &fstest.MapFile{Mode: 0x600},
+				"forward-3456-c.cql": &fstest.MapFile{Mode: 0x600},
+				"reverse-1234-a.cql": &fstest.MapFile{Mode: 0x600},
+				"reverse-2345-b.cql": &fstest.MapFile{Mode: 0x600},
+				"reverse-3456-c.cql": &fstest.MapFile{Mode: 0x600},
+			},
+		}
+
+		if err := up(t.Context(), &driver, dirFS, "", ""); err != nil {
+			t.Fatal(err)
+		}
+		const expNumCallsAfterUp = 1
+		if got := executeCalls; got != expNumCallsAfterUp {
+			t.Errorf("wrong number of calls to Execute; got %d, expected %d", got, expNumCallsAfterUp)
+		}
+		if got := createSchemaMigrationsCalls; got != expNumCallsAfterUp {
+			t.Errorf("wrong number of calls to CreateSchemaMigrations; got %d, expected %d", got, expNumCallsAfterUp)
+		}
+		if got := updateSchemaMigrationsCall; got != expNumCallsAfterUp {
+			t.Errorf("wrong number of calls to UpdateSchemaMigrations; got %d, expected %d", got, expNumCallsAfterUp)
+		}
+
+		if err := down(t.Context(), &driver, dirFS, "", ""); err != nil {
+			t.Fatal(err)
+		}
+		const expNumCallsAfterDown = 2
+		if got := executeCalls; got != expNumCallsAfterDown {
+			t.Errorf("wrong number of calls to Execute; got %d, expected %d", got, expNumCallsAfterDown)
+		}
+		if got := createSchemaMigrationsCalls; got != expNumCallsAfterDown {
+			t.Errorf("wrong number of calls to CreateSchemaMigrations; got %d, expected %d", got, expNumCallsAfterDown)
+		}
+		if got := updateSchemaMigrationsCall; got != expNumCallsAfterDown {
+			t.Errorf("wrong number of calls to UpdateSchemaMigrations; got %d, expected %d", got, expNumCallsAfterDown)
+		}
+	})
+}
+
+// testUpDown is for testing ApplyMigration, ApplyMigrationWith,
+// ApplyRollbackWith, Migrate, MigrateWith, RollbackWith.
+func testUpDown(t *testing.T, up compat.MigrateFunc, down compat.RollbackFunc) {
+	okFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("all the way up and down", func(t *testing.T) {
+		driver := stub.Double{
+			AppliedVersionsFn:        makeScanApplied(t, "1234"),
+			ExecuteFn:                makeExecuteFn(nil),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
+		}
+		var err error
+		if err = up(t.Context(), &driver, okFS, "", ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = down(t.Context(), &driver, okFS, "", ""); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("bad version", func(t *testing.T) {
+		driver := stub.Double{
+			AppliedVersionsFn:        makeScanApplied(t, "1234", "2345", "3456"),
+			ExecuteFn:                makeExecuteFn(nil),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
+		}
+		err := up(t.Context(), &driver, okFS, "bad", "")
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		t.Log(err)
+		if m := err.Error(); !strings.Contains(m, "version") {
+			t.Errorf("expected for error (%v) to mention %q", m, "version")
+		}
+	})
+
+	t.Run("error reading from FS directory", func(t *testing.T) {
+		driver := makeNoCallDriver(t)
+		badFS := stub.FS{
+			FS: fstest.MapFS{"forward-1234-no_read_permissions": &fstest.MapFile{Mode: 0x000}},
+			ReadDirFn: func(n string) ([]fs.DirEntry, error) {
+				return nil, errors.New("I've never seen that file in my life")
+			},
+		}
+		err := up(t.Context(), driver, &badFS, "1234", "")
+		if err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+		t.Log(err)
+		const looseExpectation = "reading directory entries"
+		if m := err.Error(); !strings.Contains(m, looseExpectation) {
+			t.Errorf("expected for error (%v) to contain %q", err, looseExpectation)
+		}
+	})
+
+	t.Run("error reading from found file", func(t *testing.T) {
+		driver := makeNoCallDriver(t)
+		// The only allowed Driver method in this case is AppliedVersions.
+		// Check that an error reading the file stops before calling Execute, and
+		// the other methods.
+		driver.AppliedVersionsFn = makeScanApplied(t, "1234")
+
+		badFS := stub.FS{
+			FS: fstest.MapFS{
+				"forward-1234-a": &fstest.MapFile{Mode: 0x600},
+				"forward-2345-b": &fstest.MapFile{Mode: 0x600},
+			},
+			ReadFileFn: func(name string) ([]byte, error) {
+				if name != "forward-2345-b" {
+					t.Fatalf("read unexpected file %q, should read %q", name, "forward-2345-b")
+				}
+				return nil, errors.New("OOF")
+			},
+		}
+		err := up(t.Context(), driver, &badFS, "2345", "")
+		if err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+		t.Log(err)
+		const looseExpectation = "reading file"
+		if m := err.Error(); !strings.Contains(m, looseExpectation) {
+			t.Errorf("expected for error (%v) to contain %q", err, looseExpectation)
+		}
+	})
+
+	t.Run("other error scanning for migrations", func(t *testing.T) {
+		oof := errors.New("oof")
+		var calledUpdate bool
+		driver := &stub.Double{
+			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
+				return nil, oof
+			},
+			ExecuteFn:                makeExecuteFn(nil),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
+				calledUpdate = true
+				return nil
+			},
+		}
+
+		// TODO: also add test that passes a non-zero version
+		err := up(t.Context(), driver, okFS, "", "test")
+		if !errors.Is(err, oof) {
+			t.Errorf("expected error (%v) to be %v", err, oof)
+		}
+		if calledUpdate {
+			t.Errorf("did not expect to call UpdateSchemaMigrations")
+		}
+	})
+
+	t.Run("error executing migration", func(t *testing.T) {
+		var calledUpdateFn bool
+		driver := &stub.Double{
+			AppliedVersionsFn: makeScanApplied(t, "1234"),
+			ExecuteFn:         makeExecuteFn(errors.New("OOF")),
+			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
+				calledUpdateFn = true
+				return nil
+			},
+		}
+		err := up(t.Context(), driver, okFS, "2345", "test")
+		expErr := internal.ErrExecutingMigration
+		if !errors.Is(err, expErr) {
+			t.Errorf("expected error (%v) to be %v", err, expErr)
+		}
+		if calledUpdateFn {
+			t.Errorf("did not expect to call the update method")
+		}
+	})
+
+	t.Run("error creating migrations table", func(t *testing.T) {
+		oof := errors.New("oof")
+		var calledUpdateFn bool
+		driver := &stub.Double{
+			AppliedVersionsFn:        makeScanApplied(t),
+			ExecuteFn:                makeExecuteFn(nil),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(oof),
+			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
+				calledUpdateFn = true
+				return nil
+			},
+		}
+		err := up(t.Context(), driver, okFS, "2345", "test")
+		if !errors.Is(err, oof) {
+			t.Errorf("expected error (%v) to be %v", err, oof)
+		}
+		if calledUpdateFn {
+			t.Errorf("did not expect to call the update method")
+		}
+	})
+
+	t.Run("error updating migrations table", func(t *testing.T) {
+		oof := errors.New("oof")
+		driver := &stub.Double{
+			AppliedVersionsFn:        makeScanApplied(t, "1234"),
+			ExecuteFn:                makeExecuteFn(nil),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
+				return oof
+			},
+		}
+		err := up(t.Context(), driver, okFS, "2345", "test")
+		if !errors.Is(err, oof) {
+			t.Errorf("expected error (%v) to be %v", err, oof)
+		}
+	})
+
+	t.Run("skips over directories", func(t *testing.T) {
+		var numExecCalls int
+		driver := &stub.Double{
+			AppliedVersionsFn: makeScanApplied(t, "1234"),
+			ExecuteFn: func(ctx context.Context, q string, a ...any) error {
+				numExecCalls++
+				return nil
+			},
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
+		}
+		dirFS := fstest.MapFS{
+			// this "directory" is named like a migrations file, but should be ignored.
+			"forward-2345-directory": &fstest.MapFile{Mode: fs.ModeDir},
+			"forward-1234-a.sql":     &fstest.MapFile{Mode: 0x640},
+			"forward-2345-b.sql":     &fstest.MapFile{Mode: 0x640},
+		}
+		err := up(t.Context(), driver, dirFS, "2345", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		const expNumCalls = 1
+		if numExecCalls != expNumCalls {
+			t.Errorf("wrong number of method calls; got %d, expected %d", numExecCalls, expNumCalls)
+		}
+	})
+}
+
+func TestApplyMigrationWith(t *testing.T) {
+	t.Run("error - non-zero value required", func(t *testing.T) {
+		dirFS, err := fs.Sub(testdata.Migrations, "default")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name string
+			opt  godfish.Opter
+		}{
+			{
+				name: "WithTargetVersion empty string",
+				opt:  godfish.WithTargetVersion(""),
+			},
+			{
+				name: "WithMigrationsTable empty string",
+				opt:  godfish.WithMigrationsTable(""),
+			},
+			{
+				name: "WithLockTimeout zero value",
+				opt:  godfish.WithLockTimeout(0),
+			},
+			{
+				name: "WithWriter nil",
+				opt:  godfish.WithWriter(nil),
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				driver := makeNoCallDriver(t)
+				err := godfish.ApplyMigrationWith(t.Context(), driver, dirFS, test.opt)
+				if err == nil {
+					t.Fatal("expected error but got nil")
+				}
+				if m := err.Error(); !strings.Contains(m, "zero value") {
+					t.Errorf("expected for error message (%q) to contain %q", m, "zero value")
+				}
+			})
+		}
+	})
+
+	t.Run("called with no options, does not blow up", func(t *testing.T) {
+		testBasicOperationWithoutOpts(t, []string{"1234"}, 1, godfish.ApplyRollbackWith)
+	})
+}
+
+func TestApplyRollbackWith(t *testing.T) {
+	t.Run("error - non-zero value required", func(t *testing.T) {
+		dirFS, err := fs.Sub(testdata.Migrations, "default")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name string
+			opt  godfish.Opter
+		}{
+			{
+				name: "WithTargetVersion empty string",
+				opt:  godfish.WithTargetVersion(""),
+			},
+			{
+				name: "WithMigrationsTable empty string",
+				opt:  godfish.WithMigrationsTable(""),
+			},
+			{
+				name: "WithLockTimeout zero value",
+				opt:  godfish.WithLockTimeout(0),
+			},
+			{
+				name: "WithWriter nil",
+				opt:  godfish.WithWriter(nil),
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				driver := makeNoCallDriver(t)
+				err := godfish.ApplyRollbackWith(t.Context(), driver, dirFS, test.opt)
+				if err == nil {
+					t.Fatal("expected error but got nil")
+				}
+				if m := err.Error(); !strings.Contains(m, "zero value") {
+					t.Errorf("expected for error message (%q) to contain %q", m, "zero value")
+				}
+			})
+		}
+	})
+
+	t.Run("called with no options, does not blow up", func(t *testing.T) {
+		testBasicOperationWithoutOpts(t, []string{"1234", "2345", "3456"}, 1, godfish.ApplyRollbackWith)
+	})
+}
+
+func TestInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		info compat.InfoFunc
+	}{
+		{
+			name: "Deprecated APIs",
+			info: func(
+				ctx context.Context,
+				driver driver.Driver,
+				dirFS fs.FS,
+				fwd bool,
+				version string,
+				w io.Writer,
+				format string,
+				table string,
+			) error {
+				return godfish.Info(ctx, driver, dirFS, fwd, version, w, format, table)
+			},
+		},
+		{
+			name: "Replacement APIs",
+			info: func(
+				ctx context.Context,
+				driver driver.Driver,
+				dirFS fs.FS,
+				fwd bool,
+				version string,
+				w io.Writer,
+				format string,
+				table string,
+			) error {
+				opts := compat.MakeMigrationOpts(compat.MigrationOptParams{
+					Format:          format,
+					MigrationsTable: table,
+					Writer:          w,
+				})
+				return godfish.InfoWith(ctx, driver, dirFS, opts...)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testInfo(t, test.info)
+		})
+	}
+}
+
+func testInfo(t *testing.T, info compat.InfoFunc) {
+	okFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("unknown format does not error out", func(t *testing.T) {
+		driver := stub.Double{
+			AppliedVersionsFn: makeScanApplied(t, "1234", "2345", "3456"),
+		}
+		err := info(t.Context(), &driver, okFS, false, "", t.Output(), "tea_ess_vee", "")
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+	})
+
+	t.Run("scanned migrations have empty label", func(t *testing.T) {
+		// Check that applied migrations inserted prior to a schema upgrade can have
+		// there Label fields inferred by inspecting matching filenames.
+
+		driver := &stub.Double{
+			AppliedVersionsFn: makeScanApplied(t, "1234", "2345", "3456"),
+		}
+
+		var buf bytes.Buffer
+		err := info(t.Context(), driver, okFS, true, "3456", &buf, "json", "")
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+
+		expLabels := []string{"alpha", "bravo", "charlie"}
+		lines := bytes.TrimSpace(buf.Bytes())
+		for i, line := range bytes.Split(lines, []byte{'\n'}) {
+			var item struct{ Label string }
+			if err = json.Unmarshal(line, &item); err != nil {
+				t.Fatal(err)
+			}
+			expLabel := expLabels[i]
+			if got := item.Label; got != expLabel {
+				t.Errorf("item %d, got %q, expected %q", i, got, expLabel)
+			}
+		}
+	})
+
+	t.Run("error scanning migraions", func(t *testing.T) {
+		oof := errors.New("OOF")
+		driver := stub.Double{
+			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
+				return nil, oof
+			},
+		}
+		err := info(t.Context(), &driver, okFS, true, "", t.Output(), "tsv", "")
+		if !errors.Is(err, oof) {
+			t.Errorf("expected error (%v) to be %v", err, oof)
+		}
+	})
+}
+
+func TestInfoWith(t *testing.T) {
+	t.Run("error - non-zero value required", func(t *testing.T) {
+		dirFS, err := fs.Sub(testdata.Migrations, "default")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name string
+			opt  godfish.Opter
+		}{
+			{
+				name: "WithWriter nil writer",
+				opt:  godfish.WithWriter(nil),
+			},
+			{
+				name: "WithFormat empty string",
+				opt:  godfish.WithFormat(""),
+			},
+			{
+				name: "WithMigrationsTable empty string",
+				opt:  godfish.WithMigrationsTable(""),
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				driver := makeNoCallDriver(t)
+				err := godfish.InfoWith(t.Context(), driver, dirFS, test.opt)
+				if err == nil {
+					t.Fatal("expected error but got nil")
+				}
+				if m := err.Error(); !strings.Contains(m, "zero value") {
+					t.Errorf("expected for error message (%q) to contain %q", m, "zero value")
+				}
+			})
+		}
+	})
+
+	t.Run("called with no options, does not blow up", func(t *testing.T) {
+		testBasicOperationWithoutOpts(t, []string{"1234", "2345", "3456"}, 0, godfish.InfoWith)
+	})
+}
+
+func TestStatus(t *testing.T) {
+	dirFS := fstest.MapFS{
+		"forward-1234-alpha.sql":   &fstest.MapFile{Data: []byte("CREATE TABLE alpha (id int);")},
+		"reverse-1234-alpha.sql":   &fstest.MapFile{Data: []byte("DROP TABLE alpha;")},
+		"forward-2345-bravo.sql":   &fstest.MapFile{Data: []byte("CREATE TABLE bravo (id int);")},
+		"forward-3456-charlie.sql": &fstest.MapFile{Data: []byte("-- godfish:up\nCREATE TABLE charlie (id int);\n-- godfish:down\nDROP TABLE charlie;\n")},
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		d := &stub.Double{AppliedVersionsFn: makeScanApplied(t, "1234", "2000")}
+		got, err := godfish.Status(t.Context(), d, dirFS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := []godfish.MigrationStatus{
+			{Version: "1234", Label: "alpha", Direction: "forward", Filename: "forward-1234-alpha.sql", Applied: true, Reversible: true},
+			{Version: "2000", Direction: "forward", Applied: true},
+			{Version: "2345", Label: "bravo", Direction: "forward", Filename: "forward-2345-bravo.sql"},
+			{Version: "3456", Label: "charlie", Direction: "forward", Filename: "forward-3456-charlie.sql", Reversible: true},
+		}
+		if !slices.Equal(got, exp) {
+			t.Errorf("wrong statuses\ngot:      %+v\nexpected: %+v", got, exp)
+		}
+	})
+
+	t.Run("migrations table does not exist", func(t *testing.T) {
+		d := &stub.Double{
+			AppliedVersionsFn: func(context.Context, string) (driver.AppliedVersions, error) {
+				return nil, driver.ErrSchemaMigrationsDoesNotExist
+			},
+		}
+		got, err := godfish.Status(t.Context(), d, dirFS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 3 || slices.ContainsFunc(got, func(s godfish.MigrationStatus) bool { return s.Applied }) {
+			t.Errorf("expected every migration to be pending, got %+v", got)
+		}
+	})
+
+	t.Run("error - WithMigrationsTable empty string", func(t *testing.T) {
+		_, err := godfish.Status(t.Context(), makeNoCallDriver(t), dirFS, godfish.WithMigrationsTable(""))
+		if err == nil {
+			t.Fatal("expected error but got nil")
+		}
+	})
+}
+
+func TestMigrator(t *testing.T) {
+	makeDriver := func(t *testing.T) *stub.Double {
+		var applied []internal.Migration
+		return &stub.Double{
+			AppliedVersionsFn: func(context.Context, string) (driver.AppliedVersions, error) {
+				return stub.NewAppliedVersions(applied...), nil
+			},
+			ExecuteFn:                makeExecuteFn(nil),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: func(_ context.Context, _ string, forward bool, version, label string) error {
+				if forward {
+					applied = append(applied, internal.Migration{Version: mustParseVersion(t, version), Label: label})
+				} else {
+					applied = slices.DeleteFunc(applied, func(mig internal.Migration) bool { return mig.Version.String() == version })
+				}
+				return nil
+			},
+		}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		dirFS := fstest.MapFS{
+			"forward-1234-alpha.sql": &fstest.MapFile{Data: []byte("CREATE TABLE alpha (id int);")},
+			"reverse-1234-alpha.sql": &fstest.MapFile{Data: []byte("DROP TABLE alpha;")},
+			"forward-2345-bravo.sql": &fstest.MapFile{Data: []byte("CREATE TABLE bravo (id int);")},
+			"reverse-2345-bravo.sql": &fstest.MapFile{Data: []byte("DROP TABLE bravo;")},
+		}
+		migrator, err := godfish.NewMigrator(makeDriver(t), dirFS, godfish.WithWriter(io.Discard))
+		if err != nil {
+			t.Fatal(err)
+		}
+		appliedVersions := func(t *testing.T) (out []string) {
+			t.Helper()
+			statuses, err := migrator.Status(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, status := range statuses {
+				if status.Applied {
+					out = append(out, status.Version)
+				}
+			}
+			return
+		}
+
+		if err = migrator.Migrate(t.Context(), godfish.WithSteps(1)); err != nil {
+			t.Fatal(err)
+		}
+		if got := appliedVersions(t); !slices.Equal(got, []string{"1234"}) {
+			t.Errorf("wrong applied versions after Migrate %q", got)
+		}
+
+		// The directory listing is cached, so a new file is not found yet.
+		dirFS["forward-3456-charlie.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE charlie (id int);")}
+		dirFS["reverse-3456-charlie.sql"] = &fstest.MapFile{Data: []byte("DROP TABLE charlie;")}
+		if err = migrator.Migrate(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		if got := appliedVersions(t); !slices.Equal(got, []string{"1234", "2345"}) {
+			t.Errorf("wrong applied versions after Migrate %q", got)
+		}
+
+		migrator.Refresh()
+		if err = migrator.ApplyMigration(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		if got := appliedVersions(t); !slices.Equal(got, []string{"1234", "2345", "3456"}) {
+			t.Errorf("wrong applied versions after Refresh, ApplyMigration %q", got)
+		}
+
+		if err = migrator.ApplyRollback(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		if err = migrator.Rollback(t.Context(), godfish.WithTargetLabel("alpha")); err != nil {
+			t.Fatal(err)
+		}
+		if got := appliedVersions(t); len(got) != 0 {
+			t.Errorf("wrong applied versions after Rollback %q", got)
+		}
+
+		if err = migrator.Info(t.Context(), godfish.WithFormat("json")); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("parsed migrations are cached", func(t *testing.T) {
+		dirFS := fstest.MapFS{
+			"forward-1234-alpha.sql": &fstest.MapFile{Data: []byte("CREATE TABLE alpha (id int);")},
+		}
+		migrator, err := godfish.NewMigrator(makeDriver(t), dirFS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		directives := func(t *testing.T) string {
+			t.Helper()
+			var buf bytes.Buffer
+			if err := migrator.Info(t.Context(), godfish.WithWriter(&buf), godfish.WithFormat("json")); err != nil {
+				t.Fatal(err)
+			}
+			var out struct {
+				Directives string `json:"directives"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+				t.Fatal(err)
+			}
+			return out.Directives
+		}
+
+		if got := directives(t); got != "" {
+			t.Errorf("wrong directives %q", got)
+		}
+		dirFS["forward-1234-alpha.sql"].Data = []byte("-- godfish:no-transaction\nCREATE TABLE alpha (id int);")
+		if got := directives(t); got != "" {
+			t.Errorf("wrong directives before Refresh %q", got)
+		}
+		migrator.Refresh()
+		if got := directives(t); got != "no-transaction" {
+			t.Errorf("wrong directives after Refresh %q", got)
+		}
+	})
+
+	t.Run("options that add to a list are replaced", func(t *testing.T) {
+		dirFS := fstest.MapFS{
+			"forward-1234-alpha.sql": &fstest.MapFile{Data: []byte("CREATE TABLE alpha (id int);")},
+		}
+		gm := godfish.GoMigration{Version: "2345", Label: "gopher", Forward: func(context.Context, any) error { return nil }}
+		obs := &recordingObserver{}
+		migrator, err := godfish.NewMigrator(makeDriver(t), dirFS, godfish.WithGoMigrations(gm), godfish.WithObserver(obs))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = migrator.Migrate(t.Context(), godfish.WithGoMigrations(gm), godfish.WithObserver(obs)); err != nil {
+			t.Fatal(err)
+		}
+		exp := []string{
+			"run started forward 2",
+			"migration started forward 1234 alpha forward-1234-alpha.sql",
+			"migration finished forward 1234 alpha forward-1234-alpha.sql",
+			"migration started forward 2345 gopher (go func)",
+			"migration finished forward 2345 gopher (go func)",
+			"run finished forward 2",
+		}
+		if !slices.Equal(obs.events, exp) {
+			t.Errorf("wrong events\ngot:      %q\nexpected: %q", obs.events, exp)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dirFS, err := fs.Sub(testdata.Migrations, "default")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			name   string
+			driver driver.Driver
+			dirFS  fs.FS
+			opts   []godfish.Opter
+		}{
+			{name: "nil driver", dirFS: dirFS},
+			{name: "nil dirFS", driver: makeNoCallDriver(t)},
+			{name: "invalid option", driver: makeNoCallDriver(t), dirFS: dirFS, opts: []godfish.Opter{godfish.WithMigrationsTable("")}},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				if _, err := godfish.NewMigrator(test.driver, test.dirFS, test.opts...); err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+			})
+		}
+	})
+}
+
+func TestObserver(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("migrate", func(t *testing.T) {
+		obs := &recordingObserver{}
+		d := &stub.Double{
+			AppliedVersionsFn:        makeScanApplied(t, "1234"),
+			ExecuteFn:                makeExecuteFn(nil),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: func(context.Context, string, bool, string, string) error { return nil },
+		}
+		if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithObserver(obs)); err != nil {
+			t.Fatal(err)
+		}
+		exp := []string{
+			"run started forward 2",
+			"migration started forward 2345 bravo forward-2345-bravo.sql",
+			"migration finished forward 2345 bravo forward-2345-bravo.sql",
+			"migration started forward 3456 charlie forward-3456-charlie.sql",
+			"migration finished forward 3456 charlie forward-3456-charlie.sql",
+			"run finished forward 2",
+		}
+		if !slices.Equal(obs.events, exp) {
+			t.Errorf("wrong events\ngot:      %q\nexpected: %q", obs.events, exp)
+		}
+		if obs.migrations[1].Batch != 1 || obs.migrations[1].Err != nil {
+			t.Errorf("wrong finished migration event %+v", obs.migrations[1])
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		obs := &recordingObserver{}
+		d := &stub.Double{
+			AppliedVersionsFn: makeScanApplied(t),
+			ExecuteFn:         makeExecuteFn(errors.New("test")),
+		}
+		err := godfish.ApplyMigrationWith(t.Context(), d, dirFS, godfish.WithObserver(obs), godfish.WithObserver(obs))
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+		exp := []string{
+			"run started forward 1",
+			"run started forward 1",
+			"migration started forward 1234 alpha forward-1234-alpha.sql",
+			"migration started forward 1234 alpha forward-1234-alpha.sql",
+			"migration finished forward 1234 alpha forward-1234-alpha.sql",
+			"migration finished forward 1234 alpha forward-1234-alpha.sql",
+			"run finished forward 1",
+			"run finished forward 1",
+		}
+		if !slices.Equal(obs.events, exp) {
+			t.Errorf("wrong events\ngot:      %q\nexpected: %q", obs.events, exp)
+		}
+		if got := obs.migrations[len(obs.migrations)-1].Err; !errors.Is(got, internal.ErrExecutingMigration) {
+			t.Errorf("expected error (%v) of migration to be %v", got, internal.ErrExecutingMigration)
+		}
+		if got := obs.runs[len(obs.runs)-1].Err; got == nil {
+			t.Error("expected error of run, got nil")
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		obs := &recordingObserver{}
+		d := &stub.Double{AppliedVersionsFn: makeScanApplied(t), NameFn: func() string { return "test" }}
+		if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithObserver(obs), godfish.WithDryRun(), godfish.WithWriter(io.Discard)); err != nil {
+			t.Fatal(err)
+		}
+		if len(obs.events) > 0 {
+			t.Errorf("expected no events, got %q", obs.events)
+		}
+	})
+
+	t.Run("error - nil observer", func(t *testing.T) {
+		if err := godfish.MigrateWith(t.Context(), makeNoCallDriver(t), dirFS, godfish.WithObserver(nil)); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+	})
+}
+
+func TestWithLogger(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("migrate", func(t *testing.T) {
+		var buf bytes.Buffer
+		lgr := slog.New(slog.NewTextHandler(&buf, nil))
+		var driverLoggers []*slog.Logger
+		d := &stub.Double{
+			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
+				driverLoggers = append(driverLoggers, driver.Logger(ctx))
+				return makeScanApplied(t, "1234")(ctx, migrationsTable)
+			},
+			ExecuteFn: func(ctx context.Context, _ string, _ ...any) error {
+				driverLoggers = append(driverLoggers, driver.Logger(ctx))
+				return nil
+			},
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: func(context.Context, string, bool, string, string) error { return nil },
+		}
+		if err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithLogger(lgr)); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); !strings.Contains(got, "migrating ...") || !strings.Contains(got, "version=3456") {
+			t.Errorf("expected output of logger to have migrations\n%s", got)
+		}
+		if len(driverLoggers) < 1 {
+			t.Fatal("expected driver to be called")
+		}
+		for i, got := range driverLoggers {
+			if got != lgr {
+				t.Errorf("item %d; expected the driver to get the injected logger", i)
+			}
+		}
+	})
+
+	t.Run("create migration files", func(t *testing.T) {
+		var buf bytes.Buffer
+		lgr := slog.New(slog.NewTextHandler(&buf, nil))
+		if err := godfish.CreateMigrationFilesWith("alpha", true, t.TempDir(), godfish.WithLogger(lgr)); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); !strings.Contains(got, "created forward file") || !strings.Contains(got, "created reverse file") {
+			t.Errorf("expected output of logger to have files\n%s", got)
+		}
+	})
+
+	t.Run("error - nil logger", func(t *testing.T) {
+		if err := godfish.MigrateWith(t.Context(), makeNoCallDriver(t), dirFS, godfish.WithLogger(nil)); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+	})
+}
+
+func TestMigrationError(t *testing.T) {
+	// The driver reports the error at the position of "SELEC".
+	makeDriver := func(t *testing.T, stmtErr *driver.StatementError) *stub.Double {
+		t.Helper()
+		return &stub.Double{
+			AppliedVersionsFn: makeScanApplied(t),
+			ExecuteFn: func(_ context.Context, query string, _ ...any) error {
+				if stmtErr == nil {
+					return errors.New("test")
+				}
+				out := *stmtErr
+				if out.Position == 0 {
+					out.Position = strings.Index(query, "SELEC ")
+				}
+				return &out
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		file         string
+		reverse      bool
+		stmtErr      *driver.StatementError
+		expLine      int
+		expColumn    int
+		expStatement int
+		expSnippet   string
+	}{
+		{
+			name:         "position",
+			file:         "CREATE TABLE foos (id int);\n\nINSERT INTO foos (id)\n\tSELEC 1;\n",
+			stmtErr:      &driver.StatementError{Statement: 1, Offset: 28},
+			expLine:      4,
+			expColumn:    2,
+			expStatement: 1,
+			expSnippet:   "4 | \tSELEC 1;\n  | \t^",
+		},
+		{
+			name:         "start of statement",
+			file:         "CREATE TABLE foos (id int);\n\nSELEC 1;\n",
+			stmtErr:      &driver.StatementError{Statement: 1, Offset: 27, Position: -1},
+			expLine:      3,
+			expColumn:    1,
+			expStatement: 1,
+			expSnippet:   "3 | SELEC 1;\n  | ^",
+		},
+		{
+			name:       "single file, down section",
+			file:       "-- godfish:up\nCREATE TABLE foos (id int);\n-- godfish:down\nDROP TABLE foos;\n  SELEC 1;\n",
+			reverse:    true,
+			stmtErr:    &driver.StatementError{},
+			expLine:    5,
+			expColumn:  3,
+			expSnippet: "5 |   SELEC 1;\n  |   ^",
+		},
+		{
+			name: "unknown location",
+			file: "SELEC 1;\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dirFS := fstest.MapFS{"forward-1234-alpha.sql": {Data: []byte(test.file)}}
+			d := makeDriver(t, test.stmtErr)
+			opts := []godfish.Opter{godfish.WithTargetVersion("1234")}
+			var err error
+			if test.reverse {
+				d.AppliedVersionsFn = makeScanApplied(t, "1234")
+				err = godfish.ApplyRollbackWith(t.Context(), d, dirFS, opts...)
+			} else {
+				err = godfish.ApplyMigrationWith(t.Context(), d, dirFS, opts...)
+			}
+
+			if !errors.Is(err, internal.ErrExecutingMigration) {
+				t.Fatalf("expected error (%v) to be %v", err, internal.ErrExecutingMigration)
+			}
+			var merr *godfish.MigrationError
+			if !errors.As(err, &merr) {
+				t.Fatalf("expected error (%v) to be a %T", err, merr)
+			}
+			if merr.Version != "1234" || merr.Label != "alpha" || merr.Filename != "forward-1234-alpha.sql" {
+				t.Errorf("wrong migration (%q, %q, %q)", merr.Version, merr.Label, merr.Filename)
+			}
+			if merr.Line != test.expLine || merr.Column != test.expColumn || merr.Statement != test.expStatement {
+				t.Errorf(
+					"wrong location; got line %d, column %d, statement %d; expected line %d, column %d, statement %d",
+					merr.Line, merr.Column, merr.Statement, test.expLine, test.expColumn, test.expStatement,
+				)
+			}
+			if got := merr.Snippet(); got != test.expSnippet {
+				t.Errorf("wrong snippet\ngot:\n%s\nexpected:\n%s", got, test.expSnippet)
+			}
+		})
+	}
+}
+
+func TestWithReport(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type item struct {
+		direction, version, filename string
+		outcome                      godfish.Outcome
+	}
+	checkReport := func(t *testing.T, report *godfish.Report, exp []item) {
+		t.Helper()
+		got := make([]item, len(report.Migrations))
+		for i, mig := range report.Migrations {
+			got[i] = item{mig.Direction, mig.Version, mig.Filename, mig.Outcome}
+		}
+		if !slices.Equal(got, exp) {
+			t.Errorf("wrong report\ngot:      %v\nexpected: %v", got, exp)
+		}
+	}
+
+	t.Run("failure", func(t *testing.T) {
+		var report godfish.Report
+		d := &stub.Double{
+			AppliedVersionsFn: makeScanApplied(t),
+			ExecuteFn: func(_ context.Context, query string, _ ...any) error {
+				if strings.Contains(query, "bars") {
+					return errors.New("test")
+				}
+				return nil
+			},
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
+		}
+		err := godfish.MigrateWith(t.Context(), d, dirFS, godfish.WithReport(&report))
+		if !errors.Is(err, internal.ErrExecutingMigration) {
+			t.Fatalf("expected error (%v) to be %v", err, internal.ErrExecutingMigration)
+		}
+		checkReport(t, &report, []item{
+			{"forward", "1234", "forward-1234-alpha.sql", godfish.OutcomeApplied},
+			{"forward", "2345", "forward-2345-bravo.sql", godfish.OutcomeFailed},
+			{"forward", "3456", "forward-3456-charlie.sql", godfish.OutcomeSkipped},
+		})
+		if report.Migrations[1].Err == nil {
+			t.Error("expected error of failed migration, got nil")
+		}
+		if report.Migrations[0].Err != nil || report.Migrations[2].Err != nil {
+			t.Errorf("expected no errors for other migrations, got %v, %v", report.Migrations[0].Err, report.Migrations[2].Err)
+		}
+	})
+
+	t.Run("more than one run", func(t *testing.T) {
+		var report godfish.Report
+		d := &stub.Double{
+			AppliedVersionsFn:        makeScanApplied(t, "1234", "2345"),
+			ExecuteFn:                makeExecuteFn(nil),
+			CreateSchemaMigrationsFn: makeCreateSchemaMigrationsFn(nil),
+			UpdateSchemaMigrationsFn: makeUpdatSchemaMigrationsFn(nil),
+		}
+		if err := godfish.ApplyRollbackWith(t.Context(), d, dirFS, godfish.WithReport(&report)); err != nil {
+			t.Fatal(err)
+		}
+		if err := godfish.ApplyMigrationWith(t.Context(), d, dirFS, godfish.WithReport(&report)); err != nil {
+			t.Fatal(err)
+		}
+		checkReport(t, &report, []item{
+			{"reverse", "2345", "reverse-2345-bravo.sql", godfish.OutcomeApplied},
+			{"forward", "3456", "forward-3456-charlie.sql", godfish.OutcomeApplied},
+		})
+	})
+
+	t.Run("print", func(t *testing.T) {
+		report := godfish.Report{Migrations: []godfish.MigrationReport{
+			{Direction: "forward", Version: "1234", Label: "alpha", Filename: "forward-1234-alpha.sql", Outcome: godfish.OutcomeApplied},
+		}}
+		var buf bytes.Buffer
+		if err := report.Print(&buf, "json"); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); !strings.Contains(got, `"outcome":"applied"`) {
+			t.Errorf("wrong output %s", got)
+		}
+		if err := report.Print(&buf, "xml"); !errors.Is(err, internal.ErrDataInvalid) {
+			t.Errorf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
+		}
+	})
+
+	t.Run("error - nil report", func(t *testing.T) {
+		if err := godfish.MigrateWith(t.Context(), makeNoCallDriver(t), dirFS, godfish.WithReport(nil)); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+	})
+}
+
+// recordingObserver is a godfish.Observer that keeps each event.
+type recordingObserver struct {
+	events     []string
+	runs       []godfish.RunEvent
+	migrations []godfish.MigrationEvent
+}
+
+func (r *recordingObserver) RunStarted(_ context.Context, run godfish.RunEvent) {
+	r.events = append(r.events, fmt.Sprintf("run started %s %d", run.Direction, run.Pending))
+	r.runs = append(r.runs, run)
+}
+
+func (r *recordingObserver) MigrationStarted(_ context.Context, mig godfish.MigrationEvent) {
+	r.events = append(r.events, fmt.Sprintf("migration started %s %s %s %s", mig.Direction, mig.Version, mig.Label, mig.Filename))
+	r.migrations = append(r.migrations, mig)
+}
+
+func (r *recordingObserver) MigrationFinished(_ context.Context, mig godfish.MigrationEvent) {
+	r.events = append(r.events, fmt.Sprintf("migration finished %s %s %s %s", mig.Direction, mig.Version, mig.Label, mig.Filename))
+	r.migrations = append(r.migrations, mig)
+}
+
+func (r *recordingObserver) RunFinished(_ context.Context, run godfish.RunEvent) {
+	r.events = append(r.events, fmt.Sprintf("run finished %s %d", run.Direction, run.Pending))
+	r.runs = append(r.runs, run)
+}
+
+func TestVerifyWith(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readChecksum := func(t *testing.T, filename string) string {
+		t.Helper()
+		data, err := fs.ReadFile(dirFS, filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return internal.Checksum(data)
+	}
+	makeApplied := func(t *testing.T, versionsAndChecksums ...string) func(context.Context, string) (driver.AppliedVersions, error) {
+		return func(context.Context, string) (driver.AppliedVersions, error) {
+			var migs []internal.Migration
+			for i := 0; i < len(versionsAndChecksums); i += 2 {
+				migs = append(migs, internal.Migration{
+					Version:  mustParseVersion(t, versionsAndChecksums[i]),
+					Checksum: versionsAndChecksums[i+1],
+				})
+			}
+			return stub.NewAppliedVersions(migs...), nil
+		}
+	}
+
+	type result struct {
+		Version string `json:"version"`
+		Status  string `json:"status"`
+	}
+
+	tests := []struct {
+		name    string
+		applied func(context.Context, string) (driver.AppliedVersions, error)
+		// notRecorder means the driver is not a driver.MetadataRecorder.
+		notRecorder bool
+		expOut      []result
+		expErr      error
+	}{
+		{
+			name:    "all ok",
+			applied: makeApplied(t, "1234", readChecksum(t, "forward-1234-alpha.sql"), "2345", readChecksum(t, "forward-2345-bravo.sql")),
+			expOut:  []result{{"1234", "ok"}, {"2345", "ok"}},
+		},
+		{
+			name:    "modified",
+			applied: makeApplied(t, "1234", readChecksum(t, "forward-1234-alpha.sql"), "2345", "abc123"),
+			expOut:  []result{{"1234", "ok"}, {"2345", "modified"}},
+			expErr:  internal.ErrChecksumMismatch,
+		},
+		{
+			name:    "missing",
+			applied: makeApplied(t, "1234", readChecksum(t, "forward-1234-alpha.sql"), "9999", "abc123"),
+			expOut:  []result{{"1234", "ok"}, {"9999", "missing"}},
+			expErr:  internal.ErrChecksumMismatch,
+		},
+		{
+			name:    "unrecorded",
+			applied: makeApplied(t, "1234", ""),
+			expOut:  []result{{"1234", "unrecorded"}},
+		},
+		{
+			name:        "driver does not record checksums",
+			applied:     makeApplied(t, "1234", readChecksum(t, "forward-1234-alpha.sql")),
+			notRecorder: true,
+			expOut:      []result{{"1234", "unrecorded"}},
+		},
+		{
+			name: "error reading applied versions",
+			applied: func(context.Context, string) (driver.AppliedVersions, error) {
+				return nil, driver.ErrSchemaMigrationsMissingColumns
+			},
+			expErr: driver.ErrSchemaMigrationsMissingColumns,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			double := makeNoCallDriver(t)
+			double.AppliedVersionsFn = test.applied
+			var d driver.Driver = &stub.Recorder{Double: *double}
+			if test.notRecorder {
+				d = double
+			}
+
+			var buf bytes.Buffer
+			err := godfish.VerifyWith(t.Context(), d, dirFS, godfish.WithWriter(&buf), godfish.WithFormat("json"))
+			if test.expErr == nil && err != nil {
+				t.Fatal(err)
+			} else if test.expErr != nil && !errors.Is(err, test.expErr) {
+				t.Fatalf("expected error (%v) to be %v", err, test.expErr)
+			}
+
+			var got []result
+			dec := json.NewDecoder(&buf)
+			for dec.More() {
+				var r result
+				if err := dec.Decode(&r); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, r)
+			}
+			if len(got) != len(test.expOut) {
+				t.Fatalf("wrong number of results; got %d, expected %d", len(got), len(test.expOut))
+			}
+			for i, exp := range test.expOut {
+				if got[i] != exp {
+					t.Errorf("index %d; got %+v, expected %+v", i, got[i], exp)
+				}
+			}
+		})
+	}
+
+	t.Run("error - non-zero value required", func(t *testing.T) {
+		driver := makeNoCallDriver(t)
+		err := godfish.VerifyWith(t.Context(), driver, dirFS, godfish.WithFormat(""))
+		if err == nil {
+			t.Fatal("expected error but got nil")
+		}
+		if m := err.Error(); !strings.Contains(m, "zero value") {
+			t.Errorf("expected for error message (%q) to contain %q", m, "zero value")
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	sql := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+
+	type result struct {
+		Severity string `json:"severity"`
+		Problem  string `json:"problem"`
+		Version  string `json:"version"`
+		Filename string `json:"filename"`
+	}
+
+	tests := []struct {
+		name   string
+		dirFS  fs.FS
+		opts   []godfish.Opter
+		expOut []result
+		expErr error
+	}{
+		{
+			name: "ok",
+			dirFS: fstest.MapFS{
+				"forward-1234-alpha.sql": sql("CREATE TABLE foos (id int);"),
+				"reverse-1234-alpha.sql": sql("DROP TABLE foos;"),
+				".gitkeep":               sql(""),
+				"subdir/notes.md":        sql("hello"),
+			},
+		},
+		{
+			name: "single-file migrations",
+			dirFS: fstest.MapFS{
+				"forward-1234-alpha.sql": sql("-- godfish:up\nCREATE TABLE foos (id int);\n-- godfish:down\nDROP TABLE foos;"),
+				"forward-2345-bravo.sql": sql("-- godfish:up\nCREATE TABLE bars (id int);"),
+				"forward-3456-charlie.sql": sql(
+					"-- godfish:up\nCREATE TABLE quxes (id int);\n-- godfish:down\n-- godfish:timeout never\nDROP TABLE quxes;",
+				),
+				"reverse-4567-delta.sql": sql("-- godfish:up\nDROP TABLE foos;"),
+				"forward-5678-echo.sql":  sql("-- godfish:up\nCREATE TABLE echos (id int);\n-- godfish:down\nDROP TABLE echos;"),
+				"reverse-5678-echo.sql":  sql("DROP TABLE echos;"),
+			},
+			expOut: []result{
+				{"warning", "no-reverse", "2345", "forward-2345-bravo.sql"},
+				{"error", "invalid-directives", "3456", "forward-3456-charlie.sql"},
+				{"error", "invalid-directives", "4567", "reverse-4567-delta.sql"},
+				{"error", "no-forward", "4567", "reverse-4567-delta.sql"},
+				{"error", "duplicate-version", "5678", "forward-5678-echo.sql"},
+				{"error", "duplicate-version", "5678", "reverse-5678-echo.sql"},
+			},
+			expErr: internal.ErrDataInvalid,
+		},
+		{
+			name: "repeatable migrations",
+			dirFS: fstest.MapFS{
+				"forward-1234-alpha.sql":    sql("CREATE TABLE foos (id int);"),
+				"reverse-1234-alpha.sql":    sql("DROP TABLE foos;"),
+				"repeatable-foos_view.sql":  sql("CREATE OR REPLACE VIEW foos_view AS SELECT id FROM foos;"),
+				"repeatable-foos_view.psql": sql("CREATE OR REPLACE VIEW foos_view AS SELECT id FROM foos;"),
+				"repeatable-bars_view.sql":  sql(" \n"),
+				"repeatable-quxes_view.sql": sql("-- godfish:transaction\nCREATE OR REPLACE VIEW quxes_view AS SELECT 1;"),
+			},
+			expOut: []result{
+				{"error", "empty-file", "", "repeatable-bars_view.sql"},
+				{"error", "duplicate-label", "", "repeatable-foos_view.sql"},
+				{"warning", "unknown-directive", "", "repeatable-quxes_view.sql"},
+			},
+			expErr: internal.ErrDataInvalid,
+		},
+		{
+			name: "no reverse is a warning",
+			dirFS: fstest.MapFS{
+				"forward-1234-alpha.sql": sql("CREATE TABLE foos (id int);"),
+			},
+			expOut: []result{{"warning", "no-reverse", "1234", "forward-1234-alpha.sql"}},
+		},
+		{
+			name: "all problems at once",
+			dirFS: fstest.MapFS{
+				"notes.txt":              sql("hello"),
+				"forward-1234-alpha.sql": sql("CREATE TABLE foos (id int);"),
+				"forward-1234-bravo.sql": sql("CREATE TABLE bars (id int);"),
+				"reverse-1234-alpha.sql": sql("DROP TABLE foos;"),
+				"forward-2345-charlie.sql": sql(
+					"-- godfish:no-transactions\nALTER TABLE foos ADD COLUMN a int;",
+				),
+				"reverse-2345-charlie.sql": sql(" \n\t"),
+				"reverse-3456-delta.sql":   sql("DROP TABLE bars;"),
+			},
+			expOut: []result{
+				{"error", "unparseable-name", "", "notes.txt"},
+				{"error", "duplicate-version", "1234", "forward-1234-alpha.sql"},
+				{"error", "duplicate-version", "1234", "forward-1234-bravo.sql"},
+				{"warning", "unknown-directive", "2345", "forward-2345-charlie.sql"},
+				{"error", "empty-file", "2345", "reverse-2345-charlie.sql"},
+				{"error", "no-forward", "3456", "reverse-3456-delta.sql"},
+			},
+			expErr: internal.ErrDataInvalid,
+		},
+		{
+			name: "Go migration with same version as file",
+			dirFS: fstest.MapFS{
+				"forward-1234-alpha.sql": sql("CREATE TABLE foos (id int);"),
+				"reverse-1234-alpha.sql": sql("DROP TABLE foos;"),
+			},
+			opts: []godfish.Opter{godfish.WithGoMigrations(godfish.GoMigration{
+				Version: "1234", Label: "gopher",
+				Forward: func(context.Context, any) error { return nil },
+				Reverse: func(context.Context, any) error { return nil },
+			})},
+			expOut: []result{
+				{"error", "duplicate-version", "1234", internal.GoFuncMarker},
+				{"error", "duplicate-version", "1234", internal.GoFuncMarker},
+				{"error", "duplicate-version", "1234", "forward-1234-alpha.sql"},
+				{"error", "duplicate-version", "1234", "reverse-1234-alpha.sql"},
+			},
+			expErr: internal.ErrDataInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := append([]godfish.Opter{godfish.WithWriter(&buf), godfish.WithFormat("json")}, test.opts...)
+			err := godfish.Validate(test.dirFS, opts...)
+			if test.expErr == nil && err != nil {
+				t.Fatal(err)
+			} else if test.expErr != nil && !errors.Is(err, test.expErr) {
+				t.Fatalf("expected error (%v) to be %v", err, test.expErr)
+			}
+
+			var got []result
+			dec := json.NewDecoder(&buf)
+			for dec.More() {
+				var r result
+				if err := dec.Decode(&r); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, r)
+			}
+			if len(got) != len(test.expOut) {
+				t.Fatalf("wrong number of results; got %d, expected %d\n%+v", len(got), len(test.expOut), got)
+			}
+			for i, exp := range test.expOut {
+				if got[i] != exp {
+					t.Errorf("index %d; got %+v, expected %+v", i, got[i], exp)
+				}
+			}
+		})
+	}
+
+	t.Run("error - non-zero value required", func(t *testing.T) {
+		err := godfish.Validate(fstest.MapFS{}, godfish.WithFormat(""))
+		if err == nil {
+			t.Fatal("expected error but got nil")
+		}
+		if m := err.Error(); !strings.Contains(m, "zero value") {
+			t.Errorf("expected for error message (%q) to contain %q", m, "zero value")
+		}
+	})
+}
+
+func TestBaselineWith(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readChecksum := func(t *testing.T, filename string) string {
+		t.Helper()
+		data, err := fs.ReadFile(dirFS, filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return internal.Checksum(data)
+	}
+
+	// makeDriver sets up a Driver that should not Execute anything. It records
+	// each call to RecordMigration.
+	makeDriver := func(t *testing.T, updates *[]string, applied func(context.Context, string) (driver.AppliedVersions, error)) *stub.Recorder {
+		t.Helper()
+		d := makeNoCallDriver(t)
+		d.AppliedVersionsFn = applied
+		d.CreateSchemaMigrationsFn = makeCreateSchemaMigrationsFn(nil)
+		return &stub.Recorder{
+			Double: *d,
+			RecordMigrationFn: func(ctx context.Context, migrationsTable, version, label string, meta driver.Metadata) error {
+				*updates = append(*updates, version+"-"+label+"-"+meta.Checksum)
+				return nil
+			},
+		}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var updates []string
+		d := makeDriver(t, &updates, makeScanApplied(t))
+		if err := godfish.BaselineWith(t.Context(), d, dirFS, godfish.WithTargetVersion("2345")); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{
+			"1234-alpha-" + readChecksum(t, "forward-1234-alpha.sql"),
+			"2345-bravo-" + readChecksum(t, "forward-2345-bravo.sql"),
+		}
+		if !slices.Equal(updates, expected) {
+			t.Errorf("wrong updates\ngot:      %q\nexpected: %q", updates, expected)
+		}
+	})
+
+	t.Run("ok when schema migrations table does not exist", func(t *testing.T) {
+		var updates []string
+		d := makeDriver(t, &updates, func(context.Context, string) (driver.AppliedVersions, error) {
+			return nil, driver.ErrSchemaMigrationsDoesNotExist
+		})
+		if err := godfish.BaselineWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1234")); err != nil {
+			t.Fatal(err)
+		}
+		if len(updates) != 1 {
+			t.Errorf("wrong number of updates; got %d, expected %d", len(updates), 1)
+		}
+	})
+
+	t.Run("within a transaction", func(t *testing.T) {
+		var updates []string
+		var txCalls int
+		d := &stub.Transactor{
+			Double: makeDriver(t, &updates, makeScanApplied(t)).Double,
+			WithinTransactionFn: func(ctx context.Context, fn func(context.Context, driver.Driver) error) error {
+				txCalls++
+				return fn(ctx, makeDriver(t, &updates, nil))
+			},
+		}
+		if err := godfish.BaselineWith(t.Context(), d, dirFS, godfish.WithTargetVersion("3456")); err != nil {
+			t.Fatal(err)
+		}
+		if txCalls != 1 {
+			t.Errorf("wrong number of transactions; got %d, expected %d", txCalls, 1)
+		}
+		if len(updates) != 3 {
+			t.Errorf("wrong number of updates; got %d, expected %d", len(updates), 3)
+		}
+	})
+
+	t.Run("error - already applied", func(t *testing.T) {
+		var updates []string
+		d := makeDriver(t, &updates, makeScanApplied(t, "1234"))
+		err := godfish.BaselineWith(t.Context(), d, dirFS, godfish.WithTargetVersion("2345"))
+		if !errors.Is(err, internal.ErrAlreadyApplied) {
+			t.Errorf("expected error (%v) to be %v", err, internal.ErrAlreadyApplied)
+		}
+		if len(updates) > 0 {
+			t.Errorf("expected no updates; got %q", updates)
+		}
+	})
 
-		tests := []struct {
-			name string
-			opt  godfish.Opter
-		}{
-			{
-				name: "WithTargetVersion empty string",
-				opt:  godfish.WithTargetVersion(""),
-			},
-			{
-				name: "WithMigrationsTable empty string",
-				opt:  godfish.WithMigrationsTable(""),
-			},
+	t.Run("error - nothing to record", func(t *testing.T) {
+		var updates []string
+		d := makeDriver(t, &updates, makeScanApplied(t))
+		err := godfish.BaselineWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1000"))
+		if !errors.Is(err, internal.ErrNotFound) {
+			t.Errorf("expected error (%v) to be %v", err, internal.ErrNotFound)
 		}
+	})
 
-		for _, test := range tests {
-			t.Run(test.name, func(t *testing.T) {
-				driver := makeNoCallDriver(t)
-				err := godfish.ApplyMigrationWith(t.Context(), driver, dirFS, test.opt)
-				if err == nil {
-					t.Fatal("expected error but got nil")
-				}
-				if m := err.Error(); !strings.Contains(m, "zero value") {
-					t.Errorf("expected for error message (%q) to contain %q", m, "zero value")
-				}
-			})
+	t.Run("error - target version required", func(t *testing.T) {
+		driver := makeNoCallDriver(t)
+		err := godfish.BaselineWith(t.Context(), driver, dirFS)
+		if !errors.Is(err, internal.ErrDataInvalid) {
+			t.Errorf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
 		}
 	})
 
-	t.Run("called with no options, does not blow up", func(t *testing.T) {
-		testBasicOperationWithoutOpts(t, []string{"1234"}, 1, godfish.ApplyRollbackWith)
+	t.Run("error - non-zero value required", func(t *testing.T) {
+		driver := makeNoCallDriver(t)
+		err := godfish.BaselineWith(t.Context(), driver, dirFS, godfish.WithTargetVersion("1234"), godfish.WithMigrationsTable(""))
+		if err == nil {
+			t.Fatal("expected error but got nil")
+		}
+		if m := err.Error(); !strings.Contains(m, "zero value") {
+			t.Errorf("expected for error message (%q) to contain %q", m, "zero value")
+		}
 	})
 }
 
-func TestApplyRollbackWith(t *testing.T) {
-	t.Run("error - non-zero value required", func(t *testing.T) {
-		dirFS, err := fs.Sub(testdata.Migrations, "default")
+func TestMarkAppliedWith(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		data, err := fs.ReadFile(dirFS, "forward-2345-bravo.sql")
 		if err != nil {
 			t.Fatal(err)
 		}
-
-		tests := []struct {
-			name string
-			opt  godfish.Opter
-		}{
-			{
-				name: "WithTargetVersion empty string",
-				opt:  godfish.WithTargetVersion(""),
-			},
-			{
-				name: "WithMigrationsTable empty string",
-				opt:  godfish.WithMigrationsTable(""),
+		var updates []string
+		d := &stub.Recorder{
+			Double: *makeNoCallDriver(t),
+			RecordMigrationFn: func(ctx context.Context, migrationsTable, version, label string, meta driver.Metadata) error {
+				updates = append(updates, fmt.Sprintf("%s-%s-%s", version, label, meta.Checksum))
+				return nil
 			},
 		}
-
-		for _, test := range tests {
-			t.Run(test.name, func(t *testing.T) {
-				driver := makeNoCallDriver(t)
-				err := godfish.ApplyRollbackWith(t.Context(), driver, dirFS, test.opt)
-				if err == nil {
-					t.Fatal("expected error but got nil")
-				}
-				if m := err.Error(); !strings.Contains(m, "zero value") {
-					t.Errorf("expected for error message (%q) to contain %q", m, "zero value")
-				}
-			})
+		d.AppliedVersionsFn = makeScanApplied(t, "1234")
+		d.CreateSchemaMigrationsFn = makeCreateSchemaMigrationsFn(nil)
+		if err := godfish.MarkAppliedWith(t.Context(), d, dirFS, godfish.WithTargetVersion("2345")); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"2345-bravo-" + internal.Checksum(data)}
+		if !slices.Equal(updates, expected) {
+			t.Errorf("wrong updates\ngot:      %q\nexpected: %q", updates, expected)
 		}
 	})
 
-	t.Run("called with no options, does not blow up", func(t *testing.T) {
-		testBasicOperationWithoutOpts(t, []string{"1234", "2345", "3456"}, 1, godfish.ApplyRollbackWith)
-	})
-}
-
-func TestInfo(t *testing.T) {
 	tests := []struct {
-		name string
-		info compat.InfoFunc
+		name    string
+		opts    []godfish.Opter
+		applied []string
+		expErr  error
 	}{
 		{
-			name: "Deprecated APIs",
-			info: func(
-				ctx context.Context,
-				driver driver.Driver,
-				dirFS fs.FS,
-				fwd bool,
-				version string,
-				w io.Writer,
-				format string,
-				table string,
-			) error {
-				return godfish.Info(ctx, driver, dirFS, fwd, version, w, format, table)
-			},
+			name:    "already applied",
+			opts:    []godfish.Opter{godfish.WithTargetVersion("1234")},
+			applied: []string{"1234"},
+			expErr:  internal.ErrAlreadyApplied,
 		},
 		{
-			name: "Replacement APIs",
-			info: func(
-				ctx context.Context,
-				driver driver.Driver,
-				dirFS fs.FS,
-				fwd bool,
-				version string,
-				w io.Writer,
-				format string,
-				table string,
-			) error {
-				opts := compat.MakeMigrationOpts(compat.MigrationOptParams{
-					Format:          format,
-					MigrationsTable: table,
-					Writer:          w,
-				})
-				return godfish.InfoWith(ctx, driver, dirFS, opts...)
-			},
+			name:   "no migration file",
+			opts:   []godfish.Opter{godfish.WithTargetVersion("9999")},
+			expErr: internal.ErrNotFound,
+		},
+		{
+			name:   "target version required",
+			expErr: internal.ErrDataInvalid,
 		},
 	}
 
 	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			testInfo(t, test.info)
+		t.Run("error - "+test.name, func(t *testing.T) {
+			d := makeNoCallDriver(t)
+			d.AppliedVersionsFn = makeScanApplied(t, test.applied...)
+			err := godfish.MarkAppliedWith(t.Context(), d, dirFS, test.opts...)
+			if !errors.Is(err, test.expErr) {
+				t.Errorf("expected error (%v) to be %v", err, test.expErr)
+			}
 		})
 	}
 }
 
-func testInfo(t *testing.T, info compat.InfoFunc) {
-	okFS, err := fs.Sub(testdata.Migrations, "default")
+func TestMarkUnappliedWith(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	t.Run("unknown format does not error out", func(t *testing.T) {
-		driver := stub.Double{
-			AppliedVersionsFn: makeScanApplied(t, "1234", "2345", "3456"),
+	t.Run("ok", func(t *testing.T) {
+		var updates []string
+		d := makeNoCallDriver(t)
+		d.AppliedVersionsFn = makeScanApplied(t, "1234", "2345")
+		d.CreateSchemaMigrationsFn = makeCreateSchemaMigrationsFn(nil)
+		d.UpdateSchemaMigrationsFn = func(ctx context.Context, migrationsTable string, forward bool, version, label string) error {
+			updates = append(updates, fmt.Sprintf("%t-%s-%s", forward, version, label))
+			return nil
 		}
-		err := info(t.Context(), &driver, okFS, false, "", t.Output(), "tea_ess_vee", "")
-		if err != nil {
-			t.Fatalf("unexpected error, %v", err)
+		if err := godfish.MarkUnappliedWith(t.Context(), d, dirFS, godfish.WithTargetVersion("1234")); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"false-1234-alpha"}
+		if !slices.Equal(updates, expected) {
+			t.Errorf("wrong updates\ngot:      %q\nexpected: %q", updates, expected)
 		}
 	})
 
-	t.Run("scanned migrations have empty label", func(t *testing.T) {
-		// Check that applied migrations inserted prior to a schema upgrade can have
-		// there Label fields inferred by inspecting matching filenames.
+	tests := []struct {
+		name    string
+		opts    []godfish.Opter
+		applied func(context.Context, string) (driver.AppliedVersions, error)
+		expErr  error
+	}{
+		{
+			name:    "not applied",
+			opts:    []godfish.Opter{godfish.WithTargetVersion("2345")},
+			applied: makeScanApplied(t, "1234"),
+			expErr:  internal.ErrNotFound,
+		},
+		{
+			name: "schema migrations table does not exist",
+			opts: []godfish.Opter{godfish.WithTargetVersion("1234")},
+			applied: func(context.Context, string) (driver.AppliedVersions, error) {
+				return nil, driver.ErrSchemaMigrationsDoesNotExist
+			},
+			expErr: internal.ErrNotFound,
+		},
+		{
+			name:   "target version required",
+			expErr: internal.ErrDataInvalid,
+		},
+	}
 
-		driver := &stub.Double{
-			AppliedVersionsFn: makeScanApplied(t, "1234", "2345", "3456"),
-		}
+	for _, test := range tests {
+		t.Run("error - "+test.name, func(t *testing.T) {
+			d := makeNoCallDriver(t)
+			if test.applied != nil {
+				d.AppliedVersionsFn = test.applied
+			}
+			err := godfish.MarkUnappliedWith(t.Context(), d, dirFS, test.opts...)
+			if !errors.Is(err, test.expErr) {
+				t.Errorf("expected error (%v) to be %v", err, test.expErr)
+			}
+		})
+	}
+}
 
-		var buf bytes.Buffer
-		err := info(t.Context(), driver, okFS, true, "3456", &buf, "json", "")
-		if err != nil {
-			t.Fatalf("unexpected error, %v", err)
-		}
+func TestImportStateWith(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
 
-		expLabels := []string{"alpha", "bravo", "charlie"}
-		lines := bytes.TrimSpace(buf.Bytes())
-		for i, line := range bytes.Split(lines, []byte{'\n'}) {
-			var item struct{ Label string }
-			if err = json.Unmarshal(line, &item); err != nil {
-				t.Fatal(err)
-			}
-			expLabel := expLabels[i]
-			if got := item.Label; got != expLabel {
-				t.Errorf("item %d, got %q, expected %q", i, got, expLabel)
+	tests := []struct {
+		name string
+		tool string
+		opts []godfish.Opter
+	}{
+		{name: "unknown tool", tool: "rails"},
+		{name: "migrations table is the table of the tool", tool: "golang-migrate"},
+		{name: "migrations table is the source table", tool: "goose", opts: []godfish.Opter{godfish.WithSourceTable("schema_migrations")}},
+		{name: "invalid source table", tool: "goose", opts: []godfish.Opter{godfish.WithSourceTable("foo; DROP TABLE bar")}},
+		{name: "driver is not a RawConnector", tool: "dbmate", opts: []godfish.Opter{godfish.WithMigrationsTable("godfish_migrations")}},
+	}
+	for _, test := range tests {
+		t.Run("error - "+test.name, func(t *testing.T) {
+			d := makeNoCallDriver(t)
+			d.NameFn = func() string { return "test" }
+			err := godfish.ImportStateWith(t.Context(), d, dirFS, test.tool, append(test.opts, godfish.WithWriter(io.Discard))...)
+			if !errors.Is(err, internal.ErrDataInvalid) {
+				t.Errorf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
 			}
-		}
-	})
+		})
+	}
 
-	t.Run("error scanning migraions", func(t *testing.T) {
-		oof := errors.New("OOF")
-		driver := stub.Double{
-			AppliedVersionsFn: func(ctx context.Context, migrationsTable string) (driver.AppliedVersions, error) {
-				return nil, oof
-			},
-		}
-		err := info(t.Context(), &driver, okFS, true, "", t.Output(), "tsv", "")
-		if !errors.Is(err, oof) {
-			t.Errorf("expected error (%v) to be %v", err, oof)
+	t.Run("error - zero value source table", func(t *testing.T) {
+		err := godfish.ImportStateWith(t.Context(), makeNoCallDriver(t), dirFS, "goose", godfish.WithSourceTable(""))
+		if err == nil {
+			t.Fatal("expected an error, got nil")
 		}
 	})
 }
 
-func TestInfoWith(t *testing.T) {
-	t.Run("error - non-zero value required", func(t *testing.T) {
-		dirFS, err := fs.Sub(testdata.Migrations, "default")
+func TestConvertWith(t *testing.T) {
+	dirFS, err := fs.Sub(testdata.Migrations, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		gooseDir, godfishDir := t.TempDir(), t.TempDir()
+		var buf bytes.Buffer
+		if err := godfish.ConvertWith(dirFS, gooseDir, "godfish", "goose", godfish.WithWriter(&buf), godfish.WithFormat("json")); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := os.ReadDir(gooseDir)
 		if err != nil {
 			t.Fatal(err)
 		}
-
-		tests := []struct {
-			name string
-			opt  godfish.Opter
-		}{
-			{
-				name: "WithWriter nil writer",
-				opt:  godfish.WithWriter(nil),
-			},
-			{
-				name: "WithFormat empty string",
-				opt:  godfish.WithFormat(""),
-			},
-			{
-				name: "WithMigrationsTable empty string",
-				opt:  godfish.WithMigrationsTable(""),
-			},
+		var gotNames []string
+		for _, entry := range entries {
+			gotNames = append(gotNames, entry.Name())
+		}
+		if exp := []string{"1234_alpha.sql", "2345_bravo.sql", "3456_charlie.sql"}; !slices.Equal(gotNames, exp) {
+			t.Errorf("wrong files\ngot:      %q\nexpected: %q", gotNames, exp)
+		}
+		if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 {
+			t.Errorf("wrong number of report lines %d\n%s", len(lines), buf.String())
 		}
 
-		for _, test := range tests {
-			t.Run(test.name, func(t *testing.T) {
-				driver := makeNoCallDriver(t)
-				err := godfish.InfoWith(t.Context(), driver, dirFS, test.opt)
-				if err == nil {
-					t.Fatal("expected error but got nil")
-				}
-				if m := err.Error(); !strings.Contains(m, "zero value") {
-					t.Errorf("expected for error message (%q) to contain %q", m, "zero value")
-				}
-			})
+		// And back again.
+		err = godfish.ConvertWith(os.DirFS(gooseDir), godfishDir, "goose", "godfish", godfish.WithWriter(io.Discard))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"forward-1234-alpha.sql", "reverse-1234-alpha.sql", "forward-3456-charlie.sql"} {
+			got, err := os.ReadFile(filepath.Join(godfishDir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			exp, err := fs.ReadFile(dirFS, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(string(got)) != strings.TrimSpace(string(exp)) {
+				t.Errorf("wrong contents of %q\ngot:      %q\nexpected: %q", name, got, exp)
+			}
 		}
 	})
 
-	t.Run("called with no options, does not blow up", func(t *testing.T) {
-		testBasicOperationWithoutOpts(t, []string{"1234", "2345", "3456"}, 0, godfish.InfoWith)
-	})
+	tests := []struct {
+		name     string
+		from, to string
+	}{
+		{name: "same formats", from: "goose", to: "goose"},
+		{name: "unknown format", from: "godfish", to: "rails"},
+	}
+	for _, test := range tests {
+		t.Run("error - "+test.name, func(t *testing.T) {
+			err := godfish.ConvertWith(dirFS, t.TempDir(), test.from, test.to, godfish.WithWriter(io.Discard))
+			if !errors.Is(err, internal.ErrDataInvalid) {
+				t.Errorf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
+			}
+		})
+	}
 }
 
 func TestInit(t *testing.T) {
@@ -1132,6 +4289,15 @@ func TestInit(t *testing.T) {
 			"foo", conf2.PathToFiles,
 		)
 	}
+
+	// test3: the file is still present, and it's logged with the logger
+	var buf bytes.Buffer
+	if err := godfish.InitWith(pathToFile, godfish.WithLogger(slog.New(slog.NewTextHandler(&buf, nil)))); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "config file already present") {
+		t.Errorf("expected log output to mention the file already present\n%s", buf.String())
+	}
 }
 
 func TestUpgradeSchemaMigrations(t *testing.T) {
@@ -0,0 +1,170 @@
+package godfish
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+// ImportStateWith records migrations as applied, without running them, based
+// on the bookkeeping table of another migration tool. It's meant for moving
+// a database, whose migrations were applied by that tool, over to this
+// library. The tool is one of "goose", "golang-migrate", "flyway", "dbmate".
+//
+// The table of the tool is read with the connection of the driver, which must
+// be a [driver.RawConnector] whose connection is a *database/sql.DB. Each
+// version in it is matched to a forward migration by the version scheme. It
+// writes a report with one entry per version. A version without a matching
+// migration is reported as unmatched, and otherwise left alone. Migrations
+// already recorded in the schema migrations table are left alone too, so it's
+// safe to run more than once.
+//
+// # Relevant opts
+//
+//   - [WithSourceTable]. If passed in with a non-zero value, then the table
+//     of the tool is read from that name. Otherwise, it's the default name
+//     for the tool.
+//     When passed in with a zero value, then an error is returned.
+//   - [WithMigrationsTable]. If passed in with a non-zero value, then this
+//     function will override the default value of "schema_migrations".
+//     When passed in with a zero value, then an error is returned.
+//     It's an error for the migrations table to be the table of the tool,
+//     which is "schema_migrations" for golang-migrate and dbmate.
+//   - [WithVersionScheme]. Versions of the tool are parsed with the scheme,
+//     such as "sequential" for goose migrations numbered 1, 2, 3.
+//   - [WithFormat]. Use "json" or "tsv" for the report. The default is "tsv".
+//   - [WithWriter]. If passed in with a non-zero value, then it will set the
+//     output writer for the report.
+//     When passed in with a zero value, then an error is returned.
+//     When this option is omitted, then it will write to standard output.
+//   - See also [WithGoMigrations] and [WithLockTimeout].
+func ImportStateWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, tool string, opts ...Opter) error {
+	o, err := setOptions(opts...)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", msgPrefix, "ImportStateWith", err)
+	}
+	src, err := internal.LookupImportSource(tool)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", msgPrefix, "ImportStateWith", err)
+	}
+
+	return importState(ctx, driver, dirFS, src, o)
+}
+
+// sqlQueryer is the part of a *database/sql.DB needed to read the table of
+// another migration tool.
+type sqlQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func importState(ctx context.Context, d driver.Driver, dirFS fs.FS, src internal.ImportSource, o *options) (err error) {
+	ctx = o.logContext(ctx)
+	w := cmp.Or[io.Writer](o.writer, os.Stdout)
+	migrationsTable := cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName)
+	if sourceTable := cmp.Or(o.sourceTable, src.Table); sourceTable == migrationsTable {
+		return fmt.Errorf(
+			"%w; the %s table %q is also the schema migrations table, pick another migrations table",
+			internal.ErrDataInvalid, src.Name, sourceTable,
+		)
+	}
+	queryer, ok := rawConn(d).(sqlQueryer)
+	if !ok {
+		return fmt.Errorf("%w; driver %q cannot read the table of another tool", internal.ErrDataInvalid, d.Name())
+	}
+	state, err := src.ReadState(ctx, func(ctx context.Context, query string) (internal.Rows, error) {
+		return queryer.QueryContext(ctx, query)
+	}, o.sourceTable)
+	if err != nil {
+		return
+	}
+
+	unlock, err := lockSchemaMigrations(ctx, d, migrationsTable, o.lockTimeout)
+	if err != nil {
+		return
+	}
+	defer func() { err = errors.Join(err, unlock()) }()
+
+	finder := migrationFinder{direction: internal.DirForward, dirFS: dirFS, goMigrations: o.goMigrations, scheme: o.scheme(), convention: o.convention(), recursive: o.recursive, files: o.parsedFiles, logger: o.log()}
+	availableByVersion, orderedVersions, err := finder.available()
+	if err != nil {
+		return fmt.Errorf("getting available migrations: %w", err)
+	}
+
+	applied, err := scanAppliedVersions(ctx, d, migrationsTable, o.scheme(), availableByVersion)
+	if errors.Is(err, driver.ErrSchemaMigrationsDoesNotExist) {
+		err = nil // The table is created before recording migrations.
+	} else if err != nil {
+		return
+	}
+	appliedVersions := make(map[int64]bool, len(applied))
+	for _, mig := range applied {
+		appliedVersions[mig.Version.Value()] = true
+	}
+
+	var results []internal.ImportResult
+	var migrations []*internal.Migration
+	seen := make(map[int64]bool)
+	collect := func(version string, mig *internal.Migration) {
+		if mig == nil {
+			results = append(results, internal.ImportResult{Version: version, Status: internal.ImportUnmatched})
+			return
+		}
+		if seen[mig.Version.Value()] {
+			return
+		}
+		seen[mig.Version.Value()] = true
+		if appliedVersions[mig.Version.Value()] {
+			results = append(results, internal.ImportResult{Version: version, Status: internal.ImportAlreadyApplied, Migration: mig})
+			return
+		}
+		results = append(results, internal.ImportResult{Version: version, Status: internal.ImportRecorded, Migration: mig})
+		migrations = append(migrations, mig)
+	}
+
+	if state.Through != "" {
+		through, perr := o.scheme().ParseVersion(state.Through)
+		if perr != nil {
+			collect(state.Through, nil)
+		} else {
+			for _, version := range orderedVersions {
+				if mig := availableByVersion[version]; !through.Before(mig.Version) {
+					collect(mig.Version.String(), mig)
+				}
+			}
+			if _, found := availableByVersion[through.Value()]; !found {
+				collect(state.Through, nil)
+			}
+		}
+	}
+	for _, version := range state.Versions {
+		parsed, perr := o.scheme().ParseVersion(version)
+		if perr != nil {
+			collect(version, nil)
+			continue
+		}
+		collect(version, availableByVersion[parsed.Value()])
+	}
+
+	if err = choosePrinter(o.format, w, o.log(), internal.NewImportJSON, internal.NewImportTSV).PrintImport(results); err != nil {
+		return
+	}
+	for _, res := range results {
+		if res.Status == internal.ImportUnmatched {
+			o.log().Warn("no migration matches version of other tool", slog.String("tool", src.Name), slog.String("version", res.Version))
+		}
+	}
+	if len(migrations) < 1 {
+		return nil
+	}
+
+	return recordMigrations(ctx, d, dirFS, migrations, migrationsTable, true, latestBatch(applied)+1)
+}
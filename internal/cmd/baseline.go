@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+	"github.com/rafaelespinoza/godfish/internal/compat"
+
+	"github.com/urfave/cli/v3"
+)
+
+func makeBaseline(name string) *cli.Command {
+	return &cli.Command{
+		Name:  name,
+		Usage: "Record migrations as applied without running them",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "version",
+				Value:    "",
+				Usage:    fmt.Sprintf("timestamp of last migration to record, format: %s", internal.TimeFormat),
+				Required: true,
+			},
+			&cli.DurationFlag{
+				Name:  timeoutFlagname,
+				Value: 0,
+				Usage: fmt.Sprintf("max duration to run, ignored if non-positive, example vals %q", exampleDurationVals),
+			},
+			&cli.DurationFlag{
+				Name:  lockTimeoutFlagname,
+				Value: 0,
+				Usage: fmt.Sprintf("max duration to wait for a migration lock, ignored if non-positive, example vals %q", exampleDurationVals),
+			},
+		},
+		Description: fmt.Sprintf(`Record available forward migrations as applied, without executing them.
+This could be useful when adopting godfish on a database whose schema already
+exists.
+
+The schema migrations table is created if needed, and then each forward
+migration up to and including the "version" is recorded. Specify a version in
+the form: %s. Nothing is recorded if any of those versions is already in the
+schema migrations table.
+
+The "files" flag can specify the path to a directory with migration files.`,
+			internal.TimeFormat,
+		),
+		Action: func(ctx context.Context, c *cli.Command) error {
+			driver, err := getDriver(ctx)
+			if err != nil {
+				return fmt.Errorf("getting driver from %s command: %w", name, err)
+			}
+			timeout := c.Duration(timeoutFlagname)
+			dirFS := migrationsFS(c)
+
+			return runBaseline(ctx, driver, timeout, dirFS, compat.MigrationOptParams{
+				TargetVersion:    c.String("version"),
+				MigrationsTable:  c.String(migrationsTableFlagname),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+				Recursive:        c.Bool(recursiveFlagname),
+				LockTimeout:      c.Duration(lockTimeoutFlagname),
+			})
+		},
+	}
+}
+
+func runBaseline(ctx context.Context, driverConn DriverConnector, timeout time.Duration, dirFS fs.FS, migOpts compat.MigrationOptParams) error {
+	if timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := withConnection(ctx, "", driverConn, func(ictx context.Context) error {
+		opts := compat.MakeMigrationOpts(migOpts)
+		return godfish.BaselineWith(ictx, driverConn, dirFS, opts...)
+	})
+
+	if errors.Is(err, driver.ErrSchemaMigrationsMissingColumns) {
+		err = fmt.Errorf("%w; run the %q command to fix this", err, upgradeCmdName)
+	}
+	return err
+}
@@ -6,12 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"text/template"
 
+	"github.com/rafaelespinoza/godfish"
 	"github.com/rafaelespinoza/godfish/driver"
 	"github.com/rafaelespinoza/godfish/internal"
 
@@ -60,12 +62,17 @@ Sample DSN:
 				Usage:       "path to godfish config file",
 				TakesFile:   true,
 			},
-			&cli.StringFlag{
+			&cli.StringSliceFlag{
 				Name:      pathToFilesFlagname,
-				Usage:     "path to migration files directory, can also set with config file",
+				Usage:     "path to migration files directory, can also set with config file. Pass in more than one to merge them",
 				TakesFile: true,
 				Sources:   newSourceConfigChain(&pathToConfig, "path_to_files"),
 			},
+			&cli.BoolFlag{
+				Name:    recursiveFlagname,
+				Usage:   "also look for migration files in subdirectories of the files directory",
+				Sources: newSourceConfigChain(&pathToConfig, "recursive"),
+			},
 			&cli.StringFlag{
 				Name:    "dsn",
 				Usage:   fmt.Sprintf("database DSN, if empty then fallback to environment variable %s", internal.DSNKey),
@@ -77,6 +84,23 @@ Sample DSN:
 				Usage:   "name of DB table for storing migration state",
 				Sources: newSourceConfigChain(&pathToConfig, "migrations_table"),
 			},
+			&cli.StringFlag{
+				Name:    versionSchemeFlagname,
+				Value:   internal.TimestampScheme.Name(),
+				Usage:   fmt.Sprintf("how migration versions are written and ordered, one of %q", internal.VersionSchemeNames()),
+				Sources: newSourceConfigChain(&pathToConfig, "version_scheme"),
+			},
+			&cli.StringFlag{
+				Name:    namingConventionFlagname,
+				Value:   internal.DefaultConvention.Name(),
+				Usage:   fmt.Sprintf("how migration filenames are laid out, one of %q", internal.NamingConventionNames()),
+				Sources: newSourceConfigChain(&pathToConfig, "naming_convention"),
+			},
+			&cli.StringFlag{
+				Name:    environmentFlagname,
+				Usage:   "name of the environment, compared against env directives in migration files",
+				Sources: newSourceConfigChain(&pathToConfig, "env"),
+			},
 			&cli.BoolFlag{
 				Name:  "q",
 				Usage: "if true, then all logging is effectively off",
@@ -93,13 +117,21 @@ Sample DSN:
 			},
 		},
 		Commands: []*cli.Command{
+			makeBaseline("baseline"),
+			makeConvert("convert"),
 			makeCreateMigration("create-migration", &pathToConfig),
+			makeExportSQL("export-sql"),
+			makeImportState("import-state"),
 			makeInfo("info"),
 			makeInit("init"),
+			makeLint("lint"),
+			makeMarkApplied("mark-applied"),
+			makeMarkUnapplied("mark-unapplied"),
 			makeMigrate("migrate"),
 			makeRemigrate("remigrate"),
 			makeRollback("rollback"),
 			makeUpgradeSchemaMigrations(upgradeCmdName, &pathToConfig),
+			makeVerify("verify"),
 			MakeVersion("version", d),
 		},
 		CommandNotFound: func(ctx context.Context, c *cli.Command, input string) {
@@ -127,9 +159,13 @@ Sample DSN:
 				slog.Int("num_flags_set", c.NumFlags()),
 				slog.GroupAttrs("flags",
 					slog.String("conf", c.String("conf")),
-					slog.String(pathToFilesFlagname, c.String(pathToFilesFlagname)),
+					slog.Any(pathToFilesFlagname, c.StringSlice(pathToFilesFlagname)),
+					slog.Bool(recursiveFlagname, c.Bool(recursiveFlagname)),
 					slog.String("dsn", c.String("dsn")),
 					slog.String(migrationsTableFlagname, c.String(migrationsTableFlagname)),
+					slog.String(versionSchemeFlagname, c.String(versionSchemeFlagname)),
+					slog.String(namingConventionFlagname, c.String(namingConventionFlagname)),
+					slog.String(environmentFlagname, c.String(environmentFlagname)),
 					slog.Bool("q", c.Bool("q")),
 					slog.String("loglevel", c.String("loglevel")),
 					slog.String("logformat", c.String("logformat")),
@@ -147,9 +183,20 @@ Sample DSN:
 
 // Keep references to names of flags consistent.
 const (
-	pathToFilesFlagname     = "files"
-	migrationsTableFlagname = "migrations-table"
-	timeoutFlagname         = "timeout"
+	pathToFilesFlagname      = "files"
+	recursiveFlagname        = "recursive"
+	migrationsTableFlagname  = "migrations-table"
+	timeoutFlagname          = "timeout"
+	lockTimeoutFlagname      = "lock-timeout"
+	dryRunFlagname           = "dry-run"
+	environmentFlagname      = "env"
+	stepsFlagname            = "steps"
+	toLabelFlagname          = "to-label"
+	sinceFlagname            = "since"
+	batchFlagname            = "batch"
+	versionSchemeFlagname    = "version-scheme"
+	namingConventionFlagname = "naming-convention"
+	outputFlagname           = "output"
 )
 
 // newSourceConfigChain is for use on flags that may have values set from a configuration file.
@@ -159,6 +206,24 @@ func newSourceConfigChain(pathToConfigFile *string, key string) cli.ValueSourceC
 	)
 }
 
+// migrationsFS opens the directories of migration files named by the files
+// flag. More than one directory is merged into one, see [godfish.MergeFS].
+func migrationsFS(c *cli.Command) fs.FS {
+	var dirs []fs.FS
+	for _, pathToFiles := range c.StringSlice(pathToFilesFlagname) {
+		if pathToFiles != "" {
+			dirs = append(dirs, os.DirFS(pathToFiles))
+		}
+	}
+	switch len(dirs) {
+	case 0:
+		return os.DirFS("")
+	case 1:
+		return dirs[0]
+	}
+	return godfish.MergeFS(dirs...)
+}
+
 var exampleDurationVals = []string{"30s", "5m", "1h2m3s"}
 
 // DriverConnector is a godfish Driver with connection management.
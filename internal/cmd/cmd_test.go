@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/rafaelespinoza/godfish"
 	"github.com/rafaelespinoza/godfish/driver"
 	"github.com/rafaelespinoza/godfish/internal"
 	"github.com/rafaelespinoza/godfish/internal/stub"
@@ -22,24 +24,68 @@ func TestRoot(t *testing.T) {
 
 	args := [][]string{
 		{"help"},
+		{"baseline"},
+		{"baseline", "-h"},
+		{"baseline", "-version", "1234"},
+		{"convert"},
+		{"convert", "-h"},
+		{"convert", "-from", "godfish", "-to", "goose", "-out", filepath.Join(testdir, "goose")},
+		{"convert", "-from", "golang-migrate", "-to", "godfish", "-in", testdir, "-out", filepath.Join(testdir, "godfish"), "-format", "json"},
 		{"create-migration"},
 		{"create-migration", "-h"},
 		{"create-migration", "-fwdlabel", "up"},
 		{"create-migration", "-revlabel", "down"},
+		{"create-migration", "-single-file"},
+		{"export-sql"},
+		{"export-sql", "-h"},
+		{"export-sql", "-steps", "2"},
+		{"export-sql", "-out", filepath.Join(testdir, "export.sql")},
+		{"import-state"},
+		{"import-state", "-h"},
+		{"import-state", "-from", "goose"},
+		{"import-state", "-from", "dbmate", "-table", "dbmate_migrations", "-format", "json"},
 		{"info"},
 		{"info", "-h"},
 		{"info", "-format", "json"},
 		{"info", "-direction", "reverse"},
 		{"init", "-conf", filepath.Join(testdir, "test.json")},
 		{"init", "-h"},
+		{"lint"},
+		{"lint", "-h"},
+		{"lint", "-format", "json"},
+		{"-naming-convention", "flyway", "lint"},
+		{"-recursive", "lint"},
+		{"-files", testdir, "-files", filepath.Join(testdir, "other"), "lint"},
+		{"mark-applied", "-h"},
+		{"mark-applied", "-version", "1234"},
+		{"mark-unapplied", "-h"},
+		{"mark-unapplied", "-version", "1234"},
 		{"migrate"},
 		{"migrate", "-h"},
+		{"migrate", "-dry-run"},
+		{"migrate", "-env", "staging"},
+		{"migrate", "-steps", "2"},
+		{"migrate", "-to-label", "alpha"},
+		{"migrate", "-output", "json"},
+		{"migrate", "-output", "xml"},
 		{"remigrate"},
 		{"remigrate", "-h"},
+		{"remigrate", "-dry-run"},
+		{"remigrate", "-output", "tsv"},
 		{"rollback"},
 		{"rollback", "-h"},
+		{"rollback", "-dry-run"},
+		{"rollback", "-steps", "2"},
+		{"rollback", "-to-label", "alpha"},
+		{"rollback", "-since", "2026-01-02"},
+		{"rollback", "-batch", "last"},
+		{"rollback", "-batch", "2"},
+		{"rollback", "-output", "json"},
 		{"upgrade"},
 		{"upgrade", "-h"},
+		{"verify"},
+		{"verify", "-h"},
+		{"verify", "-format", "json"},
 		{"version"},
 		{"version", "-json"},
 		{"version", "-h"},
@@ -270,6 +316,30 @@ func TestWithConnection(t *testing.T) {
 	}
 }
 
+func TestPrintMigrationError(t *testing.T) {
+	merr := &godfish.MigrationError{
+		Filename: "forward-1234-alpha.sql",
+		Line:     12,
+		Column:   3,
+		Source:   "  SELEC 1;",
+		Err:      errors.New("test"),
+	}
+
+	var buf bytes.Buffer
+	printMigrationError(&buf, fmt.Errorf("running migration: %w", merr))
+	exp := "forward-1234-alpha.sql:12:3\n12 |   SELEC 1;\n   |   ^\n"
+	if got := buf.String(); got != exp {
+		t.Errorf("wrong output\ngot:\n%s\nexpected:\n%s", got, exp)
+	}
+
+	buf.Reset()
+	printMigrationError(&buf, &godfish.MigrationError{Filename: "forward-1234-alpha.sql", Err: errors.New("test")})
+	printMigrationError(&buf, errors.New("test"))
+	if buf.Len() > 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
 func makeNoopConnector() *connector {
 	conn := connector{
 		Double: &stub.Double{
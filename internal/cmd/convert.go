@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/internal"
+	"github.com/rafaelespinoza/godfish/internal/compat"
+
+	"github.com/urfave/cli/v3"
+)
+
+func makeConvert(name string) *cli.Command {
+	return &cli.Command{
+		Name:  name,
+		Usage: "Rewrite migration files into the layout of another migration tool",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "from",
+				Usage:    fmt.Sprintf("layout of the files to read, one of %q", internal.FileFormatNames()),
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "to",
+				Usage:    fmt.Sprintf("layout of the files to write, one of %q", internal.FileFormatNames()),
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:      "in",
+				Value:     "",
+				Usage:     "path to a directory of files to read, if empty then use the first files directory",
+				TakesFile: true,
+			},
+			&cli.StringFlag{
+				Name:      "out",
+				Usage:     "path to a directory to write the files to, it's created if needed",
+				Required:  true,
+				TakesFile: true,
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "tsv",
+				Usage: "output format, one of [json|tsv]",
+			},
+		},
+		Description: `Rewrite a directory of migration files into the layout of another migration
+tool, or from the layout of another tool into godfish files. A database
+connection is not needed. The layouts are:
+	godfish:        forward and reverse files, or single-file migrations, named
+	                by the naming convention.
+	goose:          one file per migration, "${version}_${label}.sql", with
+	                "-- +goose Up" and "-- +goose Down" annotations.
+	golang-migrate: "${version}_${label}.up.sql" and
+	                "${version}_${label}.down.sql" files.
+
+The version and label of each migration are kept as is. Versions are parsed
+with the version scheme, so pick the "sequential" scheme for files numbered
+1, 2, 3. A godfish migration without a reverse file is written without a down
+part. Files already in the output directory are never overwritten.
+
+The output maps the files read to the files written for each migration.`,
+		Action: func(_ context.Context, c *cli.Command) error {
+			pathToFiles := c.String("in")
+			if dirs := c.StringSlice(pathToFilesFlagname); pathToFiles == "" && len(dirs) > 0 {
+				pathToFiles = dirs[0]
+			}
+			opts := compat.MakeMigrationOpts(compat.MigrationOptParams{
+				Format:           c.String("format"),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+				Writer:           os.Stdout,
+			})
+			return godfish.ConvertWith(os.DirFS(pathToFiles), c.String("out"), c.String("from"), c.String("to"), opts...)
+		},
+	}
+}
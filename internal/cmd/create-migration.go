@@ -13,7 +13,7 @@ import (
 )
 
 func makeCreateMigration(subcmdName string, pathToConfig *string) *cli.Command {
-	const fwdlabelFlagname, revlabelFlagname, filenameExtFlagname = "fwdlabel", "revlabel", "ext"
+	const fwdlabelFlagname, revlabelFlagname, filenameExtFlagname, singleFileFlagname = "fwdlabel", "revlabel", "ext", "single-file"
 
 	return &cli.Command{
 		Name:  subcmdName,
@@ -22,12 +22,26 @@ func makeCreateMigration(subcmdName string, pathToConfig *string) *cli.Command {
 another meant for "reverse". Optionally create a migration in the forward
 direction only by passing the flag "-reversible=false". The "name" flag has
 no effects other than on the generated filename. The output filename
-automatically has a "version". Timestamp layout: %s.
+automatically has a "version", which depends on the %q flag:
+	- timestamp: the current UTC time, layout %s.
+	- sequential: one more than the latest version, such as 0007.
+	- dotted: the last part of the latest version is incremented, such as V1.2.4.
+
+The layout of the filename depends on the %q flag:
+	- default: ${direction}-${version}-${name}, such as forward-0007-create_foos.sql.
+	- flyway: V${version}__${name} going forward and U${version}__${name} in
+	  reverse, such as V1.2.4__create_foos.sql. The direction flags are ignored.
+
+Pass the %q flag to create one file, in the forward direction, that holds
+both directions. The statements for each direction go after the marker
+comments "-- godfish:up" and "-- godfish:down".
 
 Acceptable values for the %q and %q flags are:
 	- %s
 	- %s`,
-			internal.TimeFormat,
+			versionSchemeFlagname, internal.TimeFormat,
+			namingConventionFlagname,
+			singleFileFlagname,
 			fwdlabelFlagname, revlabelFlagname,
 			strings.Join(internal.ForwardDirections, ", "),
 			strings.Join(internal.ReverseDirections, ", "),
@@ -59,15 +73,26 @@ Acceptable values for the %q and %q flags are:
 				Value: ".sql",
 				Usage: "customize filename extension",
 			},
+			&cli.BoolFlag{
+				Name:  singleFileFlagname,
+				Usage: "create one file with an up section, and a down section if reversible",
+			},
 		},
 		Action: func(_ context.Context, c *cli.Command) error {
 			migrationName := c.String("name")
 			reversible := c.Bool("reversible")
-			pathToFiles := c.String(pathToFilesFlagname)
+			var pathToFiles string
+			if dirs := c.StringSlice(pathToFilesFlagname); len(dirs) > 0 {
+				// New files go into the first of the directories.
+				pathToFiles = dirs[0]
+			}
 			opts := compat.MakeMigrationOpts(compat.MigrationOptParams{
-				ForwardLabel: c.String(fwdlabelFlagname),
-				ReverseLabel: c.String(revlabelFlagname),
-				FilenameExt:  c.String(filenameExtFlagname),
+				ForwardLabel:     c.String(fwdlabelFlagname),
+				ReverseLabel:     c.String(revlabelFlagname),
+				FilenameExt:      c.String(filenameExtFlagname),
+				SingleFile:       c.Bool(singleFileFlagname),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
 			})
 
 			return godfish.CreateMigrationFilesWith(migrationName, reversible, pathToFiles, opts...)
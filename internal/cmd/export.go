@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+	"github.com/rafaelespinoza/godfish/internal/compat"
+
+	"github.com/urfave/cli/v3"
+)
+
+func makeExportSQL(name string) *cli.Command {
+	return &cli.Command{
+		Name:  name,
+		Usage: "Output pending migrations as one SQL script to run offline",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "version",
+				Value: "",
+				Usage: fmt.Sprintf("timestamp of migration, format: %s", internal.TimeFormat),
+			},
+			&cli.IntFlag{
+				Name:  stepsFlagname,
+				Value: 0,
+				Usage: "max number of migrations to export, ignored if non-positive",
+			},
+			&cli.StringFlag{
+				Name:  toLabelFlagname,
+				Value: "",
+				Usage: "label of last migration to export, the name part of its filename",
+			},
+			&cli.StringFlag{
+				Name:      "out",
+				Value:     "",
+				Usage:     "path to a file to write the script to, if empty then write to standard output",
+				TakesFile: true,
+			},
+			&cli.DurationFlag{
+				Name:  timeoutFlagname,
+				Value: 0,
+				Usage: fmt.Sprintf("max duration to run, ignored if non-positive, example vals %q", exampleDurationVals),
+			},
+		},
+		Description: fmt.Sprintf(`Output every pending migration in the forward direction, in order, as one
+SQL script for the driver's dialect. Nothing is executed. The database is only
+read to find out which migrations are pending.
+
+The script creates the schema migrations table unless it already exists. After
+the statements of each migration, it records the migration in that table, so
+the info command shows it as applied once the script has run. It's meant for
+databases that may only be changed by running scripts through other tooling.
+
+The "version", "steps" and "to-label" flags limit which migrations to export,
+like the migrate command. Specify a version in the form: %s.
+
+A migration file with an env directive is left out unless the "env" flag names
+one of its environments. Go migrations cannot be exported.
+
+The "files" flag can specify the path to a directory with migration files.`,
+			internal.TimeFormat,
+		),
+		Action: func(ctx context.Context, c *cli.Command) (err error) {
+			driver, err := getDriver(ctx)
+			if err != nil {
+				return fmt.Errorf("getting driver from %s command: %w", name, err)
+			}
+			timeout := c.Duration(timeoutFlagname)
+			dirFS := migrationsFS(c)
+
+			var w io.Writer = os.Stdout
+			if pathToFile := c.String("out"); pathToFile != "" {
+				file, ferr := os.Create(pathToFile)
+				if ferr != nil {
+					return ferr
+				}
+				defer func() { err = errors.Join(err, file.Close()) }()
+				w = file
+			}
+
+			return runExportSQL(ctx, driver, timeout, dirFS, compat.MigrationOptParams{
+				TargetVersion:    c.String("version"),
+				Steps:            c.Int(stepsFlagname),
+				TargetLabel:      c.String(toLabelFlagname),
+				MigrationsTable:  c.String(migrationsTableFlagname),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+				Recursive:        c.Bool(recursiveFlagname),
+				Environment:      c.String(environmentFlagname),
+				Writer:           w,
+			})
+		},
+	}
+}
+
+func runExportSQL(ctx context.Context, driverConn DriverConnector, timeout time.Duration, dirFS fs.FS, migOpts compat.MigrationOptParams) error {
+	if timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := withConnection(ctx, "", driverConn, func(ictx context.Context) error {
+		opts := compat.MakeMigrationOpts(migOpts)
+		return godfish.ExportWith(ictx, driverConn, dirFS, opts...)
+	})
+
+	if errors.Is(err, driver.ErrSchemaMigrationsMissingColumns) {
+		err = fmt.Errorf("%w; run the %q command to fix this", err, upgradeCmdName)
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+	"github.com/rafaelespinoza/godfish/internal/compat"
+
+	"github.com/urfave/cli/v3"
+)
+
+func makeImportState(name string) *cli.Command {
+	return &cli.Command{
+		Name:  name,
+		Usage: "Record migrations as applied based on the table of another migration tool",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "from",
+				Usage:    fmt.Sprintf("the other migration tool, one of %q", internal.ImportSourceNames()),
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "table",
+				Usage: "name of the table of the other tool, if empty then use the default name for the tool",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "tsv",
+				Usage: "output format, one of [json|tsv]",
+			},
+			&cli.DurationFlag{
+				Name:  timeoutFlagname,
+				Value: 0,
+				Usage: fmt.Sprintf("max duration to run, ignored if non-positive, example vals %q", exampleDurationVals),
+			},
+			&cli.DurationFlag{
+				Name:  lockTimeoutFlagname,
+				Value: 0,
+				Usage: fmt.Sprintf("max duration to wait for a migration lock, ignored if non-positive, example vals %q", exampleDurationVals),
+			},
+		},
+		Description: `Record migrations as applied, without executing them, based on the
+bookkeeping table of another migration tool. This could be useful when moving
+a database from that tool over to godfish.
+
+The table is read with the same database connection. Its default name is:
+	goose:          goose_db_version
+	golang-migrate: schema_migrations
+	flyway:         flyway_schema_history
+	dbmate:         schema_migrations
+When the table of the tool has the same name as the godfish schema migrations
+table, then set another one with the "migrations-table" flag.
+
+Each version of the other tool is matched to a forward migration file in the
+"files" directory, by the version scheme. The output has one row per version
+with a status of:
+	recorded:        the migration is now recorded as applied.
+	already-applied: the migration was already recorded as applied.
+	unmatched:       no migration file has the version, so nothing is recorded.
+
+Migration files may need to be renamed to follow a godfish naming convention
+first, such as "forward-20240102030405-create_foos.sql".`,
+		Action: func(ctx context.Context, c *cli.Command) error {
+			driver, err := getDriver(ctx)
+			if err != nil {
+				return fmt.Errorf("getting driver from %s command: %w", name, err)
+			}
+			timeout := c.Duration(timeoutFlagname)
+			dirFS := migrationsFS(c)
+
+			return runImportState(ctx, driver, timeout, dirFS, c.String("from"), compat.MigrationOptParams{
+				SourceTable:      c.String("table"),
+				Format:           c.String("format"),
+				MigrationsTable:  c.String(migrationsTableFlagname),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+				Recursive:        c.Bool(recursiveFlagname),
+				LockTimeout:      c.Duration(lockTimeoutFlagname),
+				Writer:           os.Stdout,
+			})
+		},
+	}
+}
+
+func runImportState(ctx context.Context, driverConn DriverConnector, timeout time.Duration, dirFS fs.FS, tool string, migOpts compat.MigrationOptParams) error {
+	if timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := withConnection(ctx, "", driverConn, func(ictx context.Context) error {
+		opts := compat.MakeMigrationOpts(migOpts)
+		return godfish.ImportStateWith(ictx, driverConn, dirFS, tool, opts...)
+	})
+
+	if errors.Is(err, driver.ErrSchemaMigrationsMissingColumns) {
+		err = fmt.Errorf("%w; run the %q command to fix this", err, upgradeCmdName)
+	}
+	return err
+}
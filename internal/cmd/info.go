@@ -58,12 +58,15 @@ limit or extend the range of migrations to apply.`,
 				return fmt.Errorf("getting driver from %s command: %w", name, err)
 			}
 			timeout := c.Duration(timeoutFlagname)
-			dirFS := os.DirFS(c.String(pathToFilesFlagname))
+			dirFS := migrationsFS(c)
 
 			return runInfo(ctx, driver, timeout, dirFS, compat.MigrationOptParams{
-				MigrationsTable: c.String(migrationsTableFlagname),
-				Format:          c.String("format"),
-				Writer:          os.Stdout,
+				MigrationsTable:  c.String(migrationsTableFlagname),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+				Recursive:        c.Bool(recursiveFlagname),
+				Format:           c.String("format"),
+				Writer:           os.Stdout,
 			})
 		},
 	}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/internal/compat"
+
+	"github.com/urfave/cli/v3"
+)
+
+func makeLint(name string) *cli.Command {
+	return &cli.Command{
+		Name:  name,
+		Usage: "Check migration files for problems, without a DB connection",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "tsv",
+				Usage: "output format, one of [json|tsv]",
+			},
+		},
+		Description: `Check the migration files for problems, and report all of them at once.
+A database connection is not needed.
+
+The problem of each file is one of:
+	unparseable-name:   the filename has no direction or version.
+	duplicate-version:  another migration in the same direction has the same version.
+	no-reverse:         a forward migration has no reverse migration.
+	no-forward:         a reverse migration has no forward migration.
+	empty-file:         the file has nothing but whitespace.
+	invalid-directives: the header has a directive with an invalid value.
+	unknown-directive:  the header has a directive that is not recognized.
+
+A no-reverse problem is only a warning, since a migration may be deliberately
+irreversible. An unknown-directive problem is only a warning, since the
+directive is ignored. It exits with an error if there are any other problems.`,
+		Action: func(_ context.Context, c *cli.Command) error {
+			dirFS := migrationsFS(c)
+			opts := compat.MakeMigrationOpts(compat.MigrationOptParams{
+				Format:           c.String("format"),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+				Recursive:        c.Bool(recursiveFlagname),
+				Writer:           os.Stdout,
+			})
+			return godfish.Validate(dirFS, opts...)
+		},
+	}
+}
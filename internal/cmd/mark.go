@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+	"github.com/rafaelespinoza/godfish/internal/compat"
+
+	"github.com/urfave/cli/v3"
+)
+
+func makeMarkApplied(name string) *cli.Command {
+	return &cli.Command{
+		Name:  name,
+		Usage: "Record one migration as applied without running it",
+		Flags: makeMarkFlags(),
+		Description: fmt.Sprintf(`Insert a row for one forward migration into the schema migrations table,
+without executing the migration. This is a repair tool, for example after a
+migration only partially succeeded and the rest was finished by hand.
+
+The "version" flag is required. Specify a version in the form: %s. There must
+be a forward migration file with that version, and it must not already be
+recorded as applied.
+
+The "files" flag can specify the path to a directory with migration files.`,
+			internal.TimeFormat,
+		),
+		Action: func(ctx context.Context, c *cli.Command) error {
+			driver, err := getDriver(ctx)
+			if err != nil {
+				return fmt.Errorf("getting driver from %s command: %w", name, err)
+			}
+			timeout := c.Duration(timeoutFlagname)
+			dirFS := migrationsFS(c)
+
+			return runMark(ctx, driver, timeout, dirFS, godfish.MarkAppliedWith, compat.MigrationOptParams{
+				TargetVersion:    c.String("version"),
+				MigrationsTable:  c.String(migrationsTableFlagname),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+				Recursive:        c.Bool(recursiveFlagname),
+				LockTimeout:      c.Duration(lockTimeoutFlagname),
+			})
+		},
+	}
+}
+
+func makeMarkUnapplied(name string) *cli.Command {
+	return &cli.Command{
+		Name:  name,
+		Usage: "Remove the record of one applied migration without running it",
+		Flags: makeMarkFlags(),
+		Description: fmt.Sprintf(`Delete the row for one forward migration from the schema migrations table,
+without executing its reverse migration. This is a repair tool, for example
+after a migration was undone by hand.
+
+The "version" flag is required. Specify a version in the form: %s. There must
+be a forward migration file with that version, and it must be recorded as
+applied.
+
+The "files" flag can specify the path to a directory with migration files.`,
+			internal.TimeFormat,
+		),
+		Action: func(ctx context.Context, c *cli.Command) error {
+			driver, err := getDriver(ctx)
+			if err != nil {
+				return fmt.Errorf("getting driver from %s command: %w", name, err)
+			}
+			timeout := c.Duration(timeoutFlagname)
+			dirFS := migrationsFS(c)
+
+			return runMark(ctx, driver, timeout, dirFS, godfish.MarkUnappliedWith, compat.MigrationOptParams{
+				TargetVersion:    c.String("version"),
+				MigrationsTable:  c.String(migrationsTableFlagname),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+				Recursive:        c.Bool(recursiveFlagname),
+				LockTimeout:      c.Duration(lockTimeoutFlagname),
+			})
+		},
+	}
+}
+
+func makeMarkFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "version",
+			Value:    "",
+			Usage:    fmt.Sprintf("timestamp of migration, format: %s", internal.TimeFormat),
+			Required: true,
+		},
+		&cli.DurationFlag{
+			Name:  timeoutFlagname,
+			Value: 0,
+			Usage: fmt.Sprintf("max duration to run, ignored if non-positive, example vals %q", exampleDurationVals),
+		},
+		&cli.DurationFlag{
+			Name:  lockTimeoutFlagname,
+			Value: 0,
+			Usage: fmt.Sprintf("max duration to wait for a migration lock, ignored if non-positive, example vals %q", exampleDurationVals),
+		},
+	}
+}
+
+// runMark calls markFn, which is expected to be either
+// [godfish.MarkAppliedWith] or [godfish.MarkUnappliedWith].
+func runMark(ctx context.Context, driverConn DriverConnector, timeout time.Duration, dirFS fs.FS, markFn func(context.Context, driver.Driver, fs.FS, ...godfish.Opter) error, migOpts compat.MigrationOptParams) error {
+	if timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := withConnection(ctx, "", driverConn, func(ictx context.Context) error {
+		opts := compat.MakeMigrationOpts(migOpts)
+		return markFn(ictx, driverConn, dirFS, opts...)
+	})
+
+	if errors.Is(err, driver.ErrSchemaMigrationsMissingColumns) {
+		err = fmt.Errorf("%w; run the %q command to fix this", err, upgradeCmdName)
+	}
+	return err
+}
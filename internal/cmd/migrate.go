@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/rafaelespinoza/godfish"
@@ -26,17 +29,61 @@ func makeMigrate(name string) *cli.Command {
 				Value: "",
 				Usage: fmt.Sprintf("timestamp of migration, format: %s", internal.TimeFormat),
 			},
+			&cli.IntFlag{
+				Name:  stepsFlagname,
+				Value: 0,
+				Usage: "max number of migrations to execute, ignored if non-positive",
+			},
+			&cli.StringFlag{
+				Name:  toLabelFlagname,
+				Value: "",
+				Usage: "label of last migration to execute, the name part of its filename",
+			},
 			&cli.DurationFlag{
 				Name:  timeoutFlagname,
 				Value: 0,
 				Usage: fmt.Sprintf("max duration to run, ignored if non-positive, example vals %q", exampleDurationVals),
 			},
+			&cli.DurationFlag{
+				Name:  lockTimeoutFlagname,
+				Value: 0,
+				Usage: fmt.Sprintf("max duration to wait for a migration lock, ignored if non-positive, example vals %q", exampleDurationVals),
+			},
+			&cli.BoolFlag{
+				Name:  dryRunFlagname,
+				Value: false,
+				Usage: "output the SQL that would run, without running it",
+			},
+			&cli.StringFlag{
+				Name:  outputFlagname,
+				Value: "",
+				Usage: fmt.Sprintf("print a report of the migrations to standard output, one of %q", validOutputs),
+			},
 		},
 		Description: fmt.Sprintf(`Execute migration(s) in the forward direction. If the "version" is left
 unspecified, then all available migrations are executed. Otherwise,
 available migrations are executed up to and including the specified version.
 Specify a version in the form: %s.
 
+Instead of the "version", the "steps" flag limits how many migrations to
+execute. Or, the "to-label" flag executes migrations up to and including the
+first one with that label. Only one of these flags may be used.
+
+When none of these flags are used, then each repeatable migration, such as
+"repeatable-foos_view.sql", runs afterwards if its contents changed since its
+last run.
+
+With the "dry-run" flag, the statements that would run, including updates to
+the schema migrations table, are written to standard output as annotated SQL.
+Nothing is executed.
+
+With the "output" flag, each migration considered, and whether it was applied,
+skipped or failed, is written to standard output as JSON or as tab separated
+values. The report is written even if a migration fails.
+
+A migration file with an env directive is skipped unless the "env" flag names
+one of its environments.
+
 The "files" flag can specify the path to a directory with migration files.`,
 			internal.TimeFormat,
 		),
@@ -46,12 +93,26 @@ The "files" flag can specify the path to a directory with migration files.`,
 				return fmt.Errorf("getting driver from %s command: %w", name, err)
 			}
 			timeout := c.Duration(timeoutFlagname)
-			dirFS := os.DirFS(c.String(pathToFilesFlagname))
+			dirFS := migrationsFS(c)
+			report, err := newReport(c.String(outputFlagname))
+			if err != nil {
+				return err
+			}
 
-			return runMigrate(ctx, driver, timeout, dirFS, compat.MigrationOptParams{
-				TargetVersion:   c.String("version"),
-				MigrationsTable: c.String(migrationsTableFlagname),
+			err = runMigrate(ctx, driver, timeout, dirFS, compat.MigrationOptParams{
+				TargetVersion:    c.String("version"),
+				Steps:            c.Int(stepsFlagname),
+				TargetLabel:      c.String(toLabelFlagname),
+				MigrationsTable:  c.String(migrationsTableFlagname),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+				Recursive:        c.Bool(recursiveFlagname),
+				LockTimeout:      c.Duration(lockTimeoutFlagname),
+				DryRun:           c.Bool(dryRunFlagname),
+				Environment:      c.String(environmentFlagname),
+				Report:           report,
 			})
+			return printReport(report, c.String(outputFlagname), err)
 		},
 	}
 }
@@ -71,6 +132,7 @@ func runMigrate(ctx context.Context, driverConn DriverConnector, timeout time.Du
 	if errors.Is(err, driver.ErrSchemaMigrationsMissingColumns) {
 		err = fmt.Errorf("%w; run the %q command to fix this", err, upgradeCmdName)
 	}
+	printMigrationError(os.Stderr, err)
 	return err
 }
 
@@ -84,10 +146,36 @@ func makeRemigrate(name string) *cli.Command {
 				Value: 0,
 				Usage: fmt.Sprintf("max duration to run, ignored if non-positive, example vals %q", exampleDurationVals),
 			},
+			&cli.DurationFlag{
+				Name:  lockTimeoutFlagname,
+				Value: 0,
+				Usage: fmt.Sprintf("max duration to wait for a migration lock, ignored if non-positive, example vals %q", exampleDurationVals),
+			},
+			&cli.BoolFlag{
+				Name:  dryRunFlagname,
+				Value: false,
+				Usage: "output the SQL that would run, without running it",
+			},
+			&cli.StringFlag{
+				Name:  outputFlagname,
+				Value: "",
+				Usage: fmt.Sprintf("print a report of the migrations to standard output, one of %q", validOutputs),
+			},
 		},
 		Description: `Execute the last migration in reverse (rollback) and then execute the same
 one forward. This could be useful for development.
 
+With the "dry-run" flag, the statements that would run, including updates to
+the schema migrations table, are written to standard output as annotated SQL.
+Nothing is executed.
+
+With the "output" flag, each migration considered, and whether it was applied,
+skipped or failed, is written to standard output as JSON or as tab separated
+values. The report is written even if a migration fails.
+
+A migration file with an env directive is skipped unless the "env" flag names
+one of its environments.
+
 The "files" flag can specify the path to a directory with migration files.`,
 		Action: func(ctx context.Context, c *cli.Command) error {
 			driver, err := getDriver(ctx)
@@ -95,10 +183,24 @@ The "files" flag can specify the path to a directory with migration files.`,
 				return fmt.Errorf("getting driver from %s command: %w", name, err)
 			}
 			timeout := c.Duration(timeoutFlagname)
-			dirFS := os.DirFS(c.String(pathToFilesFlagname))
-			migOpts := compat.MigrationOptParams{MigrationsTable: c.String(migrationsTableFlagname)}
+			dirFS := migrationsFS(c)
+			report, err := newReport(c.String(outputFlagname))
+			if err != nil {
+				return err
+			}
+			migOpts := compat.MigrationOptParams{
+				MigrationsTable:  c.String(migrationsTableFlagname),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+				Recursive:        c.Bool(recursiveFlagname),
+				LockTimeout:      c.Duration(lockTimeoutFlagname),
+				DryRun:           c.Bool(dryRunFlagname),
+				Environment:      c.String(environmentFlagname),
+				Report:           report,
+			}
 
-			return runRemigrate(ctx, driver, timeout, dirFS, migOpts)
+			err = runRemigrate(ctx, driver, timeout, dirFS, migOpts)
+			return printReport(report, c.String(outputFlagname), err)
 		},
 	}
 }
@@ -112,15 +214,13 @@ func runRemigrate(ctx context.Context, driverConn DriverConnector, timeout time.
 
 	err := withConnection(ctx, "", driverConn, func(ictx context.Context) error {
 		opts := compat.MakeMigrationOpts(migOpts)
-		if ierr := godfish.ApplyRollbackWith(ctx, driverConn, dirFS, opts...); ierr != nil {
-			return ierr
-		}
-		return godfish.ApplyMigrationWith(ictx, driverConn, dirFS, opts...)
+		return godfish.RemigrateWith(ictx, driverConn, dirFS, opts...)
 	})
 
 	if errors.Is(err, driver.ErrSchemaMigrationsMissingColumns) {
 		err = fmt.Errorf("%w; run the %q command to fix this", err, upgradeCmdName)
 	}
+	printMigrationError(os.Stderr, err)
 	return err
 }
 
@@ -134,17 +234,70 @@ func makeRollback(name string) *cli.Command {
 				Value: "",
 				Usage: fmt.Sprintf("timestamp of migration, format: %s", internal.TimeFormat),
 			},
+			&cli.IntFlag{
+				Name:  stepsFlagname,
+				Value: 0,
+				Usage: "max number of migrations to execute, ignored if non-positive",
+			},
+			&cli.StringFlag{
+				Name:  toLabelFlagname,
+				Value: "",
+				Usage: "label of last migration to execute, the name part of its filename",
+			},
+			&cli.StringFlag{
+				Name:  sinceFlagname,
+				Value: "",
+				Usage: fmt.Sprintf("execute migrations applied after this time, one of the formats %q", sinceLayouts),
+			},
+			&cli.StringFlag{
+				Name:  batchFlagname,
+				Value: "",
+				Usage: fmt.Sprintf("execute migrations applied in this batch, a number or %q", lastBatch),
+			},
 			&cli.DurationFlag{
 				Name:  timeoutFlagname,
 				Value: 0,
 				Usage: fmt.Sprintf("max duration to run, ignored if non-positive, example vals %q", exampleDurationVals),
 			},
+			&cli.DurationFlag{
+				Name:  lockTimeoutFlagname,
+				Value: 0,
+				Usage: fmt.Sprintf("max duration to wait for a migration lock, ignored if non-positive, example vals %q", exampleDurationVals),
+			},
+			&cli.BoolFlag{
+				Name:  dryRunFlagname,
+				Value: false,
+				Usage: "output the SQL that would run, without running it",
+			},
+			&cli.StringFlag{
+				Name:  outputFlagname,
+				Value: "",
+				Usage: fmt.Sprintf("print a report of the migrations to standard output, one of %q", validOutputs),
+			},
 		},
 		Description: fmt.Sprintf(`Execute migration(s) in the reverse direction. If the "version" is left
 unspecified, then only the first available migration is executed. Otherwise,
 available migrations are executed down to and including the specified
 version. Specify a version in the form: %s.
 
+Instead of the "version", the "steps" flag sets how many migrations to
+execute. Or, the "to-label" flag executes migrations down to and including the
+most recent one with that label. Or, the "since" flag executes each migration
+that was applied after that time. Or, the "batch" flag executes each migration
+that was applied in the same run, see the batch column of the info command. Use
+"-batch last" for the most recent run. Only one of these flags may be used.
+
+With the "dry-run" flag, the statements that would run, including updates to
+the schema migrations table, are written to standard output as annotated SQL.
+Nothing is executed.
+
+With the "output" flag, each migration considered, and whether it was applied,
+skipped or failed, is written to standard output as JSON or as tab separated
+values. The report is written even if a migration fails.
+
+A migration file with an env directive is skipped unless the "env" flag names
+one of its environments.
+
 The "files" flag can specify the path to a directory with migration files.`,
 			internal.TimeFormat),
 		Action: func(ctx context.Context, c *cli.Command) error {
@@ -153,12 +306,37 @@ The "files" flag can specify the path to a directory with migration files.`,
 				return fmt.Errorf("getting driver from %s command: %w", name, err)
 			}
 			timeout := c.Duration(timeoutFlagname)
-			dirFS := os.DirFS(c.String(pathToFilesFlagname))
+			dirFS := migrationsFS(c)
+			since, err := parseSince(c.String(sinceFlagname))
+			if err != nil {
+				return err
+			}
+			batch, isLastBatch, err := parseBatch(c.String(batchFlagname))
+			if err != nil {
+				return err
+			}
+			report, err := newReport(c.String(outputFlagname))
+			if err != nil {
+				return err
+			}
 
-			return runRollback(ctx, driver, timeout, dirFS, compat.MigrationOptParams{
-				MigrationsTable: c.String(migrationsTableFlagname),
-				TargetVersion:   c.String("version"),
+			err = runRollback(ctx, driver, timeout, dirFS, compat.MigrationOptParams{
+				MigrationsTable:  c.String(migrationsTableFlagname),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+				Recursive:        c.Bool(recursiveFlagname),
+				TargetVersion:    c.String("version"),
+				Steps:            c.Int(stepsFlagname),
+				TargetLabel:      c.String(toLabelFlagname),
+				Since:            since,
+				Batch:            batch,
+				LastBatch:        isLastBatch,
+				LockTimeout:      c.Duration(lockTimeoutFlagname),
+				DryRun:           c.Bool(dryRunFlagname),
+				Environment:      c.String(environmentFlagname),
+				Report:           report,
 			})
+			return printReport(report, c.String(outputFlagname), err)
 		},
 	}
 }
@@ -170,8 +348,9 @@ func runRollback(ctx context.Context, driverConn DriverConnector, timeout time.D
 		defer cancel()
 	}
 
+	// Without a target, rollback only the last migration.
 	var rollbackFn func(context.Context, driver.Driver, fs.FS, ...godfish.Opter) error
-	if migOpts.TargetVersion == "" {
+	if migOpts.TargetVersion == "" && migOpts.Steps < 1 && migOpts.TargetLabel == "" && migOpts.Since.IsZero() && migOpts.Batch < 1 && !migOpts.LastBatch {
 		rollbackFn = godfish.ApplyRollbackWith
 	} else {
 		rollbackFn = godfish.RollbackWith
@@ -189,5 +368,73 @@ func runRollback(ctx context.Context, driverConn DriverConnector, timeout time.D
 	if errors.Is(err, driver.ErrSchemaMigrationsMissingColumns) {
 		err = fmt.Errorf("%w; run the %q command to fix this", err, upgradeCmdName)
 	}
+	printMigrationError(os.Stderr, err)
 	return err
 }
+
+// sinceLayouts are the accepted formats for the value of the since flag.
+// Values without a time zone are interpreted as UTC.
+var sinceLayouts = []string{time.RFC3339, time.DateTime, time.DateOnly}
+
+func parseSince(val string) (out time.Time, err error) {
+	if val == "" {
+		return
+	}
+	for _, layout := range sinceLayouts {
+		if out, err = time.Parse(layout, val); err == nil {
+			return
+		}
+	}
+	err = fmt.Errorf("invalid value %q for flag %s, use one of the formats %q", val, sinceFlagname, sinceLayouts)
+	return
+}
+
+// lastBatch is the value of the batch flag to target the most recent batch.
+const lastBatch = "last"
+
+func parseBatch(val string) (batch int64, isLast bool, err error) {
+	if val == "" {
+		return
+	}
+	if val == lastBatch {
+		isLast = true
+		return
+	}
+	if batch, err = strconv.ParseInt(val, 10, 64); err != nil || batch < 1 {
+		err = fmt.Errorf("invalid value %q for flag %s, use a positive number or %q", val, batchFlagname, lastBatch)
+	}
+	return
+}
+
+// validOutputs are the accepted values for the output flag.
+var validOutputs = []string{"json", "tsv"}
+
+// newReport makes a report for a run when the output flag is set. Otherwise,
+// the report is nil.
+func newReport(output string) (*godfish.Report, error) {
+	if output == "" {
+		return nil, nil
+	}
+	if !slices.Contains(validOutputs, output) {
+		return nil, fmt.Errorf("invalid value %q for flag %s, should be one of %q", output, outputFlagname, validOutputs)
+	}
+	return &godfish.Report{}, nil
+}
+
+// printReport writes out a non-nil report, even if the run failed with err.
+func printReport(report *godfish.Report, output string, err error) error {
+	if report == nil {
+		return err
+	}
+	return errors.Join(err, report.Print(os.Stdout, output))
+}
+
+// printMigrationError writes out the line of the migration file where err
+// happened, with a caret under the column, when that is known.
+func printMigrationError(w io.Writer, err error) {
+	var merr *godfish.MigrationError
+	if !errors.As(err, &merr) || merr.Line < 1 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%s:%d:%d\n%s\n", merr.Filename, merr.Line, merr.Column, merr.Snippet())
+}
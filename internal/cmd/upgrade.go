@@ -44,6 +44,11 @@ will vary based on the DB driver, but it's roughly:
 
 	label VARCHAR DEFAULT ''
 	executed_at INT DEFAULT 0
+	checksum VARCHAR DEFAULT ''
+
+Only the columns that are missing are added. A table created with a
+version that already had the label and executed_at columns also needs
+this subcommand, to add the checksum column.
 
 The flag, -migrations-table, specifies which table to work on.
 If that table does not exist yet, then there should be no need to use
@@ -55,7 +60,11 @@ returns early without doing anything else.`,
 				return fmt.Errorf("getting driver from %s command: %w", name, err)
 			}
 			timeout := c.Duration(timeoutFlagname)
-			migOpts := compat.MigrationOptParams{MigrationsTable: c.String(migrationsTableFlagname)}
+			migOpts := compat.MigrationOptParams{
+				MigrationsTable:  c.String(migrationsTableFlagname),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+			}
 
 			return runUpgrade(ctx, driver, timeout, migOpts)
 		},
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/rafaelespinoza/godfish"
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal/compat"
+
+	"github.com/urfave/cli/v3"
+)
+
+func makeVerify(name string) *cli.Command {
+	return &cli.Command{
+		Name:  name,
+		Usage: "Check applied migrations for changes to their files",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "tsv",
+				Usage: "output format, one of [json|tsv]",
+			},
+			&cli.DurationFlag{
+				Name:  timeoutFlagname,
+				Value: 0,
+				Usage: fmt.Sprintf("max duration to run, ignored if non-positive, example vals %q", exampleDurationVals),
+			},
+		},
+		Description: `Compare the checksum recorded for each applied migration against the
+current contents of its forward migration file.
+
+The status of each applied migration is one of:
+	ok:         the file is unchanged since it was applied.
+	modified:   the file has changed since it was applied.
+	missing:    there is no file for the applied version.
+	unrecorded: it was applied before checksums were recorded.
+
+It exits with an error if any applied migration is modified or missing.`,
+		Action: func(ctx context.Context, c *cli.Command) error {
+			driver, err := getDriver(ctx)
+			if err != nil {
+				return fmt.Errorf("getting driver from %s command: %w", name, err)
+			}
+			timeout := c.Duration(timeoutFlagname)
+			dirFS := migrationsFS(c)
+
+			return runVerify(ctx, driver, timeout, dirFS, compat.MigrationOptParams{
+				MigrationsTable:  c.String(migrationsTableFlagname),
+				VersionScheme:    c.String(versionSchemeFlagname),
+				NamingConvention: c.String(namingConventionFlagname),
+				Recursive:        c.Bool(recursiveFlagname),
+				Format:           c.String("format"),
+				Writer:           os.Stdout,
+			})
+		},
+	}
+}
+
+func runVerify(ctx context.Context, driverConn DriverConnector, timeout time.Duration, dirFS fs.FS, migOpts compat.MigrationOptParams) error {
+	if timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := withConnection(ctx, "", driverConn, func(ictx context.Context) error {
+		opts := compat.MakeMigrationOpts(migOpts)
+		return godfish.VerifyWith(ictx, driverConn, dirFS, opts...)
+	})
+	if errors.Is(err, driver.ErrSchemaMigrationsMissingColumns) {
+		err = fmt.Errorf("%w; run the %q command to fix this", err, upgradeCmdName)
+	}
+	return err
+}
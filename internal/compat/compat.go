@@ -7,34 +7,63 @@ import (
 	"io"
 	"io/fs"
 	"log/slog"
+	"time"
 
 	"github.com/rafaelespinoza/godfish"
 	"github.com/rafaelespinoza/godfish/driver"
 )
 
 type MigrationOptParams struct {
-	Format          string
-	MigrationsTable string
-	TargetVersion   string
-	Writer          io.Writer
+	Batch            int64
+	DryRun           bool
+	Environment      string
+	Format           string
+	LastBatch        bool
+	LockTimeout      time.Duration
+	MigrationsTable  string
+	NamingConvention string
+	Recursive        bool
+	Report           *godfish.Report
+	Since            time.Time
+	SourceTable      string
+	Steps            int
+	TargetLabel      string
+	TargetVersion    string
+	VersionScheme    string
+	Writer           io.Writer
 
 	// for creating migration files
 
 	ForwardLabel string
 	ReverseLabel string
 	FilenameExt  string
+	SingleFile   bool
 }
 
 // LogValue lets this type implement the [slog.LogValuer] interface.
 func (m MigrationOptParams) LogValue() slog.Value {
 	return slog.GroupValue(
+		slog.Int64("batch", m.Batch),
+		slog.Bool("dry_run", m.DryRun),
+		slog.String("environment", m.Environment),
 		slog.String("format", m.Format),
+		slog.Bool("last_batch", m.LastBatch),
+		slog.Duration("lock_timeout", m.LockTimeout),
 		slog.String("migrations_table", m.MigrationsTable),
+		slog.String("naming_convention", m.NamingConvention),
+		slog.Bool("recursive", m.Recursive),
+		slog.Bool("report_nil?", m.Report == nil),
+		slog.Time("since", m.Since),
+		slog.String("source_table", m.SourceTable),
+		slog.Int("steps", m.Steps),
+		slog.String("target_label", m.TargetLabel),
 		slog.String("target_version", m.TargetVersion),
+		slog.String("version_scheme", m.VersionScheme),
 		slog.Bool("writer_nil?", m.Writer == nil),
 		slog.String("forward_label", m.ForwardLabel),
 		slog.String("reverse_label", m.ReverseLabel),
 		slog.String("filename_ext", m.FilenameExt),
+		slog.Bool("single_file", m.SingleFile),
 	)
 }
 
@@ -43,15 +72,54 @@ func (m MigrationOptParams) LogValue() slog.Value {
 // value.
 func MakeMigrationOpts(m MigrationOptParams) []godfish.Opter {
 	out := []godfish.Opter{}
+	if m.Batch > 0 {
+		out = append(out, godfish.WithBatch(m.Batch))
+	}
+	if m.DryRun {
+		out = append(out, godfish.WithDryRun())
+	}
+	if m.Environment != "" {
+		out = append(out, godfish.WithEnvironment(m.Environment))
+	}
 	if m.Format != "" {
 		out = append(out, godfish.WithFormat(m.Format))
 	}
+	if m.LastBatch {
+		out = append(out, godfish.WithLastBatch())
+	}
+	if m.LockTimeout > 0 {
+		out = append(out, godfish.WithLockTimeout(m.LockTimeout))
+	}
 	if m.MigrationsTable != "" {
 		out = append(out, godfish.WithMigrationsTable(m.MigrationsTable))
 	}
+	if m.NamingConvention != "" {
+		out = append(out, godfish.WithNamingConvention(m.NamingConvention))
+	}
+	if m.Recursive {
+		out = append(out, godfish.WithRecursive())
+	}
+	if m.Report != nil {
+		out = append(out, godfish.WithReport(m.Report))
+	}
+	if !m.Since.IsZero() {
+		out = append(out, godfish.WithSince(m.Since))
+	}
+	if m.SourceTable != "" {
+		out = append(out, godfish.WithSourceTable(m.SourceTable))
+	}
+	if m.Steps > 0 {
+		out = append(out, godfish.WithSteps(m.Steps))
+	}
+	if m.TargetLabel != "" {
+		out = append(out, godfish.WithTargetLabel(m.TargetLabel))
+	}
 	if m.TargetVersion != "" {
 		out = append(out, godfish.WithTargetVersion(m.TargetVersion))
 	}
+	if m.VersionScheme != "" {
+		out = append(out, godfish.WithVersionScheme(m.VersionScheme))
+	}
 	if m.Writer != nil {
 		out = append(out, godfish.WithWriter(m.Writer))
 	}
@@ -66,6 +134,9 @@ func MakeMigrationOpts(m MigrationOptParams) []godfish.Opter {
 	if m.FilenameExt != "" {
 		out = append(out, godfish.WithFilenameExtension(m.FilenameExt))
 	}
+	if m.SingleFile {
+		out = append(out, godfish.WithSingleFile())
+	}
 
 	return out
 }
@@ -3,7 +3,9 @@ package compat_test
 import (
 	"io"
 	"testing"
+	"time"
 
+	"github.com/rafaelespinoza/godfish"
 	"github.com/rafaelespinoza/godfish/internal/compat"
 )
 
@@ -18,21 +20,86 @@ func TestMakeMigrationOpts(t *testing.T) {
 			params:    compat.MigrationOptParams{},
 			expLength: 0,
 		},
+		{
+			name:      "only Batch set",
+			params:    compat.MigrationOptParams{Batch: 3},
+			expLength: 1,
+		},
+		{
+			name:      "only LastBatch set",
+			params:    compat.MigrationOptParams{LastBatch: true},
+			expLength: 1,
+		},
+		{
+			name:      "only DryRun set",
+			params:    compat.MigrationOptParams{DryRun: true},
+			expLength: 1,
+		},
+		{
+			name:      "only Since set",
+			params:    compat.MigrationOptParams{Since: time.Now()},
+			expLength: 1,
+		},
+		{
+			name:      "only Steps set",
+			params:    compat.MigrationOptParams{Steps: 2},
+			expLength: 1,
+		},
+		{
+			name:      "only TargetLabel set",
+			params:    compat.MigrationOptParams{TargetLabel: "alpha"},
+			expLength: 1,
+		},
+		{
+			name:      "only Environment set",
+			params:    compat.MigrationOptParams{Environment: "prod"},
+			expLength: 1,
+		},
 		{
 			name:      "only Format set",
 			params:    compat.MigrationOptParams{Format: "json"},
 			expLength: 1,
 		},
+		{
+			name:      "only LockTimeout set",
+			params:    compat.MigrationOptParams{LockTimeout: time.Minute},
+			expLength: 1,
+		},
 		{
 			name:      "only MigrationsTable set",
 			params:    compat.MigrationOptParams{MigrationsTable: "schema_migrations"},
 			expLength: 1,
 		},
+		{
+			name:      "only NamingConvention set",
+			params:    compat.MigrationOptParams{NamingConvention: "flyway"},
+			expLength: 1,
+		},
+		{
+			name:      "only Recursive set",
+			params:    compat.MigrationOptParams{Recursive: true},
+			expLength: 1,
+		},
+		{
+			name:      "only SourceTable set",
+			params:    compat.MigrationOptParams{SourceTable: "goose_db_version"},
+			expLength: 1,
+		},
 		{
 			name:      "only TargetVersion set",
 			params:    compat.MigrationOptParams{TargetVersion: "20260101"},
 			expLength: 1,
 		},
+		{
+			name:      "only Report set",
+			params:    compat.MigrationOptParams{Report: &godfish.Report{}},
+			expLength: 1,
+		},
+		{
+			name:      "only VersionScheme set",
+			params:    compat.MigrationOptParams{VersionScheme: "sequential"},
+			expLength: 1,
+		},
 		{
 			name:      "only Writer set",
 			params:    compat.MigrationOptParams{Writer: io.Discard},
@@ -53,6 +120,11 @@ func TestMakeMigrationOpts(t *testing.T) {
 			params:    compat.MigrationOptParams{FilenameExt: ".abc"},
 			expLength: 1,
 		},
+		{
+			name:      "only SingleFile set",
+			params:    compat.MigrationOptParams{SingleFile: true},
+			expLength: 1,
+		},
 		{
 			name: "partial options set",
 			params: compat.MigrationOptParams{
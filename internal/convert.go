@@ -0,0 +1,462 @@
+package internal
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// A PortableMigration is a migration apart from the layout of the files of any
+// one migration tool. It's read from files with a FileFormat, and then
+// written in another one.
+type PortableMigration struct {
+	Version Version
+	Label   string
+	Forward []byte
+	Reverse []byte // nil when the migration is irreversible.
+	// NoTransaction means the migration should run outside of a transaction.
+	NoTransaction bool
+	// Files are the basenames of the files that the migration was read from.
+	Files []string
+}
+
+// A FileFormat is the layout of the migration files of a migration tool.
+type FileFormat interface {
+	// Name identifies the format, such as in a command line flag.
+	Name() string
+	// Read parses the migration files at the top level of fsys, ordered by
+	// version. Other files are skipped, and logged with lgr.
+	Read(fsys fs.FS, scheme VersionScheme, lgr *slog.Logger) ([]*PortableMigration, error)
+	// Write creates the files for mig in the directory at dirpath, and returns
+	// their basenames. It's an error if a file already exists.
+	Write(dirpath string, mig *PortableMigration) ([]string, error)
+}
+
+// FileFormatNames lists the names of the available file formats.
+func FileFormatNames() []string {
+	return []string{godfishFormatName, gooseFormat{}.Name(), golangMigrateFormat{}.Name()}
+}
+
+// LookupFileFormat finds a FileFormat by its name. The migration files of this
+// library are named by the convention. An unknown name is an [ErrDataInvalid].
+func LookupFileFormat(name string, convention NamingConvention, scheme VersionScheme) (FileFormat, error) {
+	switch name {
+	case godfishFormatName:
+		return godfishFormat{convention: convention, scheme: scheme}, nil
+	case gooseFormat{}.Name():
+		return gooseFormat{}, nil
+	case golangMigrateFormat{}.Name():
+		return golangMigrateFormat{}, nil
+	}
+	return nil, fmt.Errorf(
+		"%w; unknown file format %q, should be one of %q",
+		ErrDataInvalid, name, FileFormatNames(),
+	)
+}
+
+const godfishFormatName = "godfish"
+
+// godfishFormat is the layout of the migration files of this library, in
+// either the forward and reverse files of a naming convention, or single
+// files with sections.
+type godfishFormat struct {
+	convention NamingConvention
+	scheme     VersionScheme
+}
+
+func (godfishFormat) Name() string { return godfishFormatName }
+
+func (f godfishFormat) Read(fsys fs.FS, scheme VersionScheme, lgr *slog.Logger) ([]*PortableMigration, error) {
+	names, err := ListFiles(fsys, false, lgr)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*PortableMigration)
+	for _, name := range names {
+		mig, perr := f.convention.ParseMigration(Filename(name), scheme)
+		if perr != nil {
+			lgr.Warn("parsing migration filename, skipping over this one", slog.String("filename", name), slog.String("error", perr.Error()))
+			continue
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		out, found := byVersion[mig.Version.Value()]
+		if !found {
+			out = &PortableMigration{Version: mig.Version, Label: mig.Label}
+			byVersion[mig.Version.Value()] = out
+		}
+		out.Files = append(out.Files, name)
+
+		forward, reverse := data, []byte(nil)
+		if mig.Indirection.Value == DirReverse {
+			forward, reverse = nil, data
+		} else if section, ok := Section(data, DirForward); ok {
+			forward = section
+			reverse, _ = Section(data, DirReverse)
+		}
+		if forward != nil {
+			if out.Forward != nil {
+				return nil, fmt.Errorf("%w; more than one forward migration with version %q", ErrDataInvalid, mig.Version.String())
+			}
+			out.Forward, out.Label = forward, mig.Label
+			directives, derr := ParseDirectives(forward)
+			if derr != nil {
+				return nil, fmt.Errorf("parsing directives of file %q: %w", name, derr)
+			}
+			out.NoTransaction = directives.NoTransaction
+		}
+		if reverse != nil {
+			if out.Reverse != nil {
+				return nil, fmt.Errorf("%w; more than one reverse migration with version %q", ErrDataInvalid, mig.Version.String())
+			}
+			out.Reverse = reverse
+		}
+	}
+
+	return sortPortable(byVersion)
+}
+
+func (f godfishFormat) Write(dirpath string, mig *PortableMigration) ([]string, error) {
+	files := []struct {
+		indirection Indirection
+		data        []byte
+	}{
+		{indirection: Indirection{Value: DirForward, Label: ForwardDirections[0]}, data: mig.Forward},
+		{indirection: Indirection{Value: DirReverse, Label: ReverseDirections[0]}, data: mig.Reverse},
+	}
+
+	var out []string
+	for _, file := range files {
+		if file.data == nil {
+			continue
+		}
+		name := string(f.convention.MakeFilename(mig.Version.String(), file.indirection, mig.Label)) + ".sql"
+
+		// Check that the version and label would be read back as is.
+		parsed, err := f.convention.ParseMigration(Filename(name), f.scheme)
+		if err == nil && (parsed.Version.String() != mig.Version.String() || parsed.Label != mig.Label) {
+			err = fmt.Errorf("would be read as version %q, label %q", parsed.Version.String(), parsed.Label)
+		}
+		if err != nil {
+			return out, fmt.Errorf(
+				"%w; version %q, label %q are not preserved by filename %q: %v",
+				ErrDataInvalid, mig.Version.String(), mig.Label, name, err,
+			)
+		}
+
+		data := file.data
+		if directives, _ := ParseDirectives(data); mig.NoTransaction && !directives.NoTransaction {
+			data = append([]byte("-- "+directivePrefix+"no-transaction\n"), data...)
+		}
+		if err = writeNewFile(dirpath, name, data); err != nil {
+			return out, err
+		}
+		out = append(out, name)
+	}
+	return out, nil
+}
+
+// Annotations of goose migration files.
+const (
+	gooseUp            = "+goose Up"
+	gooseDown          = "+goose Down"
+	gooseNoTransaction = "+goose NO TRANSACTION"
+)
+
+// gooseFormat is the layout of SQL migration files of goose,
+// https://github.com/pressly/goose. Each one is a single file named
+// "${version}_${label}.sql", with both directions separated by annotations.
+type gooseFormat struct{}
+
+func (gooseFormat) Name() string { return "goose" }
+
+var gooseFilenameMatcher = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
+
+func (gooseFormat) Read(fsys fs.FS, scheme VersionScheme, lgr *slog.Logger) ([]*PortableMigration, error) {
+	names, err := ListFiles(fsys, false, lgr)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*PortableMigration)
+	for _, name := range names {
+		matches := gooseFilenameMatcher.FindStringSubmatch(name)
+		if matches == nil {
+			lgr.Warn("file is not a goose SQL migration, skipping over this one", slog.String("filename", name))
+			continue
+		}
+		mig, err := newPortable(matches[1], matches[2], scheme, byVersion)
+		if err != nil {
+			return nil, fmt.Errorf("reading file %q: %w", name, err)
+		}
+		mig.Files = append(mig.Files, name)
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		var foundUp, foundDown bool
+		for line := range bytes.Lines(data) {
+			switch {
+			case isGooseAnnotation(line, gooseNoTransaction):
+				mig.NoTransaction = true
+			case !foundUp && isGooseAnnotation(line, gooseUp):
+				foundUp = true
+				mig.Forward = []byte{}
+			case foundUp && !foundDown && isGooseAnnotation(line, gooseDown):
+				foundDown = true
+			case foundDown:
+				mig.Reverse = append(mig.Reverse, line...)
+			case foundUp:
+				mig.Forward = append(mig.Forward, line...)
+			}
+		}
+		if !foundUp {
+			return nil, fmt.Errorf("%w; file %q has no %q annotation", ErrDataInvalid, name, "-- "+gooseUp)
+		}
+		if len(bytes.TrimSpace(mig.Reverse)) < 1 {
+			mig.Reverse = nil
+		}
+	}
+
+	return sortPortable(byVersion)
+}
+
+func isGooseAnnotation(line []byte, annotation string) bool {
+	comment, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("--"))
+	return ok && strings.EqualFold(string(bytes.TrimSpace(comment)), annotation)
+}
+
+func (gooseFormat) Write(dirpath string, mig *PortableMigration) ([]string, error) {
+	if err := checkIntegerVersion(mig.Version); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if mig.NoTransaction {
+		data = append(data, "-- "+gooseNoTransaction+"\n"...)
+	}
+	data = append(data, "-- "+gooseUp+"\n"...)
+	data = appendLine(data, mig.Forward)
+	if mig.Reverse != nil {
+		data = append(data, "\n-- "+gooseDown+"\n"...)
+		data = appendLine(data, mig.Reverse)
+	}
+
+	name := mig.Version.String() + "_" + mig.Label + ".sql"
+	if err := writeNewFile(dirpath, name, data); err != nil {
+		return nil, err
+	}
+	return []string{name}, nil
+}
+
+// golangMigrateFormat is the layout of migration files of golang-migrate,
+// https://github.com/golang-migrate/migrate. Each direction is in its own
+// file, named "${version}_${label}.up.sql" or "${version}_${label}.down.sql".
+type golangMigrateFormat struct{}
+
+func (golangMigrateFormat) Name() string { return "golang-migrate" }
+
+var golangMigrateFilenameMatcher = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+func (golangMigrateFormat) Read(fsys fs.FS, scheme VersionScheme, lgr *slog.Logger) ([]*PortableMigration, error) {
+	names, err := ListFiles(fsys, false, lgr)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*PortableMigration)
+	for _, name := range names {
+		matches := golangMigrateFilenameMatcher.FindStringSubmatch(name)
+		if matches == nil {
+			lgr.Warn("file is not a golang-migrate migration, skipping over this one", slog.String("filename", name))
+			continue
+		}
+		mig, err := newPortable(matches[1], matches[2], scheme, byVersion)
+		if err != nil {
+			return nil, fmt.Errorf("reading file %q: %w", name, err)
+		}
+		mig.Files = append(mig.Files, name)
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if matches[3] == "up" {
+			mig.Forward = data
+		} else {
+			mig.Reverse = data
+		}
+	}
+
+	return sortPortable(byVersion)
+}
+
+func (golangMigrateFormat) Write(dirpath string, mig *PortableMigration) ([]string, error) {
+	if err := checkIntegerVersion(mig.Version); err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, file := range []struct {
+		suffix string
+		data   []byte
+	}{{suffix: ".up.sql", data: mig.Forward}, {suffix: ".down.sql", data: mig.Reverse}} {
+		if file.data == nil {
+			continue
+		}
+		name := mig.Version.String() + "_" + mig.Label + file.suffix
+		if err := writeNewFile(dirpath, name, file.data); err != nil {
+			return out, err
+		}
+		out = append(out, name)
+	}
+	return out, nil
+}
+
+// newPortable finds the PortableMigration for the written version in
+// byVersion, or adds one. It's an error if the label differs from the one of
+// another file with the same version.
+func newPortable(written, label string, scheme VersionScheme, byVersion map[int64]*PortableMigration) (*PortableMigration, error) {
+	version, err := scheme.ParseVersion(written)
+	if err == nil && version.String() != written {
+		err = fmt.Errorf("unexpected characters after version %q", version.String())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w, could not parse version %q; %v", ErrDataInvalid, written, err)
+	}
+
+	mig, found := byVersion[version.Value()]
+	if !found {
+		mig = &PortableMigration{Version: version, Label: label}
+		byVersion[version.Value()] = mig
+	} else if mig.Label != label {
+		return nil, fmt.Errorf("%w; version %q has labels %q and %q", ErrDataInvalid, written, mig.Label, label)
+	}
+	return mig, nil
+}
+
+func sortPortable(byVersion map[int64]*PortableMigration) ([]*PortableMigration, error) {
+	out := make([]*PortableMigration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Forward == nil {
+			return nil, fmt.Errorf("%w; version %q has no forward migration, files %q", ErrDataInvalid, mig.Version.String(), mig.Files)
+		}
+		out = append(out, mig)
+	}
+	slices.SortFunc(out, func(a, b *PortableMigration) int { return cmp.Compare(a.Version.Value(), b.Version.Value()) })
+	return out, nil
+}
+
+// checkIntegerVersion is for tools whose versions are integers.
+func checkIntegerVersion(version Version) error {
+	if _, err := strconv.ParseUint(version.String(), 10, 64); err != nil {
+		return fmt.Errorf("%w; version %q is not an integer", ErrDataInvalid, version.String())
+	}
+	return nil
+}
+
+func appendLine(data, line []byte) []byte {
+	data = append(data, line...)
+	if len(line) > 0 && !bytes.HasSuffix(line, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	return data
+}
+
+func writeNewFile(dirpath, name string, data []byte) error {
+	file, err := os.OpenFile(filepath.Join(dirpath, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	return errors.Join(err, file.Close())
+}
+
+// ConvertResult maps the files of one migration, that were read in one
+// format, to the files written in another.
+type ConvertResult struct {
+	Version string
+	Label   string
+	From    []string
+	To      []string
+}
+
+// ConvertPrinter outputs the results of converting migration files.
+type ConvertPrinter interface {
+	PrintConvert([]ConvertResult) error
+}
+
+// NewConvertTSV constructs a ConvertPrinter to write out tab separated values.
+func NewConvertTSV(w io.Writer, lgr *slog.Logger) ConvertPrinter {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	return &tsvPrinter{tw, lgr}
+}
+
+// NewConvertJSON constructs a ConvertPrinter to write out JSON.
+func NewConvertJSON(w io.Writer, lgr *slog.Logger) ConvertPrinter {
+	enc := json.NewEncoder(w)
+	return &jsonPrinter{enc, lgr}
+}
+
+func (p *tsvPrinter) PrintConvert(in []ConvertResult) error {
+	const format = "%s\t%s\t%s\t%s\t%s"
+
+	// headers
+	_, err := fmt.Fprintf(p.tw, format+"\n", "i", "version", "label", "from", "to")
+	if err != nil {
+		p.lgr.Error("internal: printing TSV headers", slog.Any("error", err))
+	}
+
+	// body
+	for i, res := range in {
+		_, err = fmt.Fprintf(
+			p.tw,
+			format+"\n",
+			strconv.Itoa(i), res.Version, cmp.Or(res.Label, "-"),
+			cmp.Or(strings.Join(res.From, ","), "-"), cmp.Or(strings.Join(res.To, ","), "-"),
+		)
+		if err != nil {
+			p.lgr.Error("internal: printing TSV body", slog.Any("error", err), slog.String("version", res.Version))
+		}
+	}
+	if err = p.tw.Flush(); err != nil {
+		p.lgr.Error("internal: flushing TSV", slog.Any("error", err))
+	}
+	return nil
+}
+
+func (p *jsonPrinter) PrintConvert(in []ConvertResult) error {
+	type result struct {
+		I       int      `json:"i"`
+		Version string   `json:"version"`
+		Label   string   `json:"label"`
+		From    []string `json:"from"`
+		To      []string `json:"to"`
+	}
+
+	for i, res := range in {
+		err := p.enc.Encode(result{I: i, Version: res.Version, Label: res.Label, From: res.From, To: res.To})
+		if err != nil {
+			p.lgr.Error("internal: printing JSON item", slog.Any("error", err), slog.String("version", res.Version))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func TestFileFormat(t *testing.T) {
+	godfishFiles := fstest.MapFS{
+		"forward-1-create_foos.sql": &fstest.MapFile{Data: []byte("CREATE TABLE foos (id int);\n")},
+		"reverse-1-create_foos.sql": &fstest.MapFile{Data: []byte("DROP TABLE foos;\n")},
+		"forward-2-index_foos.sql": &fstest.MapFile{
+			Data: []byte("-- godfish:no-transaction\nCREATE INDEX CONCURRENTLY foos_id ON foos (id);\n"),
+		},
+		"forward-3-single.sql": &fstest.MapFile{
+			Data: []byte("-- godfish:up\nCREATE TABLE bars (id int);\n-- godfish:down\nDROP TABLE bars;\n"),
+		},
+		"README.md": &fstest.MapFile{Data: []byte("hello")},
+	}
+	scheme := internal.SequentialScheme
+
+	lookup := func(t *testing.T, name string) internal.FileFormat {
+		t.Helper()
+		format, err := internal.LookupFileFormat(name, internal.DefaultConvention, scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return format
+	}
+
+	read := func(t *testing.T, format internal.FileFormat, dirpath string) []*internal.PortableMigration {
+		t.Helper()
+		migrations, err := format.Read(os.DirFS(dirpath), scheme, slog.Default())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return migrations
+	}
+
+	for _, name := range []string{"goose", "golang-migrate"} {
+		t.Run("round trip "+name, func(t *testing.T) {
+			migrations, err := lookup(t, "godfish").Read(godfishFiles, scheme, slog.Default())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrations) != 3 {
+				t.Fatalf("wrong number of migrations %d", len(migrations))
+			}
+
+			other, back := t.TempDir(), t.TempDir()
+			for _, mig := range migrations {
+				if _, err = lookup(t, name).Write(other, mig); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, mig := range read(t, lookup(t, name), other) {
+				if _, err = lookup(t, "godfish").Write(back, mig); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := read(t, lookup(t, "godfish"), back)
+			if len(got) != len(migrations) {
+				t.Fatalf("wrong number of migrations, got %d, expected %d", len(got), len(migrations))
+			}
+			for i, mig := range got {
+				exp := migrations[i]
+				if mig.Version.String() != exp.Version.String() || mig.Label != exp.Label {
+					t.Errorf("item %d; got version %q, label %q; expected version %q, label %q", i, mig.Version.String(), mig.Label, exp.Version.String(), exp.Label)
+				}
+				if strings.TrimSpace(string(mig.Forward)) != strings.TrimSpace(string(exp.Forward)) {
+					t.Errorf("item %d; wrong forward\ngot:      %q\nexpected: %q", i, mig.Forward, exp.Forward)
+				}
+				if strings.TrimSpace(string(mig.Reverse)) != strings.TrimSpace(string(exp.Reverse)) {
+					t.Errorf("item %d; wrong reverse\ngot:      %q\nexpected: %q", i, mig.Reverse, exp.Reverse)
+				}
+				if mig.NoTransaction != exp.NoTransaction {
+					t.Errorf("item %d; wrong NoTransaction %t", i, mig.NoTransaction)
+				}
+			}
+		})
+	}
+
+	t.Run("goose", func(t *testing.T) {
+		dir := t.TempDir()
+		mig := &internal.PortableMigration{
+			Label:         "create_foos",
+			Forward:       []byte("CREATE TABLE foos (id int);"),
+			Reverse:       []byte("DROP TABLE foos;"),
+			NoTransaction: true,
+		}
+		var err error
+		if mig.Version, err = scheme.ParseVersion("12"); err != nil {
+			t.Fatal(err)
+		}
+		files, err := lookup(t, "goose").Write(dir, mig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := []string{"12_create_foos.sql"}; !slices.Equal(files, exp) {
+			t.Fatalf("wrong files %q", files)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, files[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		const exp = "-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE foos (id int);\n\n-- +goose Down\nDROP TABLE foos;\n"
+		if string(data) != exp {
+			t.Errorf("wrong contents\ngot:      %q\nexpected: %q", data, exp)
+		}
+
+		if _, err = lookup(t, "goose").Write(dir, mig); err == nil {
+			t.Error("expected an error for a file that already exists")
+		}
+	})
+
+	t.Run("golang-migrate", func(t *testing.T) {
+		migrations, err := lookup(t, "golang-migrate").Read(fstest.MapFS{
+			"000001_create_foos.up.sql":   &fstest.MapFile{Data: []byte("CREATE TABLE foos (id int);")},
+			"000001_create_foos.down.sql": &fstest.MapFile{Data: []byte("DROP TABLE foos;")},
+			"000002_add_bar.up.sql":       &fstest.MapFile{Data: []byte("ALTER TABLE foos ADD bar int;")},
+		}, scheme, slog.Default())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(migrations) != 2 {
+			t.Fatalf("wrong number of migrations %d", len(migrations))
+		}
+		if migrations[1].Label != "add_bar" || migrations[1].Reverse != nil {
+			t.Errorf("wrong migration %+v", migrations[1])
+		}
+		if migrations[0].Version.String() != "000001" {
+			t.Errorf("wrong version %q, the zero padding should be kept", migrations[0].Version.String())
+		}
+		if exp := []string{"000001_create_foos.down.sql", "000001_create_foos.up.sql"}; !slices.Equal(migrations[0].Files, exp) {
+			t.Errorf("wrong files %q", migrations[0].Files)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			format string
+			fsys   fstest.MapFS
+		}{
+			{
+				name:   "goose file without up annotation",
+				format: "goose",
+				fsys:   fstest.MapFS{"1_a.sql": &fstest.MapFile{Data: []byte("CREATE TABLE a (id int);")}},
+			},
+			{
+				name:   "down file without up file",
+				format: "golang-migrate",
+				fsys:   fstest.MapFS{"1_a.down.sql": &fstest.MapFile{Data: []byte("DROP TABLE a;")}},
+			},
+			{
+				name:   "labels differ",
+				format: "golang-migrate",
+				fsys: fstest.MapFS{
+					"1_a.up.sql":   &fstest.MapFile{Data: []byte("CREATE TABLE a (id int);")},
+					"1_b.down.sql": &fstest.MapFile{Data: []byte("DROP TABLE a;")},
+				},
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				_, err := lookup(t, test.format).Read(test.fsys, scheme, slog.Default())
+				if !internal.IsInvalidDataError(err) {
+					t.Errorf("expected an %v, got %v", internal.ErrDataInvalid, err)
+				}
+			})
+		}
+
+		t.Run("version is not an integer", func(t *testing.T) {
+			version, err := internal.DottedScheme.ParseVersion("1.2.3")
+			if err != nil {
+				t.Fatal(err)
+			}
+			mig := &internal.PortableMigration{Version: version, Label: "a", Forward: []byte("SELECT 1;")}
+			if _, err = lookup(t, "goose").Write(t.TempDir(), mig); !internal.IsInvalidDataError(err) {
+				t.Errorf("expected an %v, got %v", internal.ErrDataInvalid, err)
+			}
+		})
+
+		t.Run("unknown format", func(t *testing.T) {
+			if _, err := internal.LookupFileFormat("rails", internal.DefaultConvention, scheme); !internal.IsInvalidDataError(err) {
+				t.Errorf("expected an %v, got %v", internal.ErrDataInvalid, err)
+			}
+		})
+	})
+}
+
+func TestConvertPrinter(t *testing.T) {
+	results := []internal.ConvertResult{
+		{Version: "1", Label: "create_foos", From: []string{"forward-1-create_foos.sql", "reverse-1-create_foos.sql"}, To: []string{"1_create_foos.sql"}},
+	}
+
+	t.Run("tsv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := internal.NewConvertTSV(&buf, slog.Default()).PrintConvert(results); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("wrong number of lines, %d\n%s", len(lines), buf.String())
+		}
+		exp := []string{"0", "1", "create_foos", "forward-1-create_foos.sql,reverse-1-create_foos.sql", "1_create_foos.sql"}
+		if fields := strings.Fields(lines[1]); !slices.Equal(fields, exp) {
+			t.Errorf("wrong fields %q", fields)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := internal.NewConvertJSON(&buf, slog.Default()).PrintConvert(results); err != nil {
+			t.Fatal(err)
+		}
+		var got struct {
+			Version string   `json:"version"`
+			From    []string `json:"from"`
+			To      []string `json:"to"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Version != "1" || len(got.From) != 2 || !slices.Equal(got.To, results[0].To) {
+			t.Errorf("wrong item %+v", got)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Directives are per-migration settings, read from line comments at the top of
+// a migration file. Each one is in the form:
+//
+//	-- godfish:<name> [value]
+//
+// The header ends at the first line that is not blank and not a comment, or at
+// the up marker of a single-file migration, see [Section].
+type Directives struct {
+	// NoTransaction is set with "-- godfish:no-transaction". The migration
+	// runs outside of a transaction, even if the driver supports them.
+	NoTransaction bool
+	// Timeout is set with a duration, such as "-- godfish:timeout 10m". It
+	// limits how long the migration may run.
+	Timeout time.Duration
+	// Envs is set with a comma-separated list, such as
+	// "-- godfish:env staging,prod". The migration only runs in one of these
+	// environments.
+	Envs []string
+	// Unknown holds the names of unrecognized directives, such as a
+	// misspelled one, or one from a newer version. They are ignored.
+	Unknown []string
+}
+
+const directivePrefix = "godfish:"
+
+// Names of the markers between the sections of a single-file migration.
+const (
+	upMarker   = "up"
+	downMarker = "down"
+)
+
+// ParseDirectives reads the Directives from the header of a migration file's
+// contents. An unknown directive is ignored, but its name is added to Unknown.
+// An invalid value of a known directive is an [ErrDataInvalid].
+func ParseDirectives(data []byte) (out Directives, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+header:
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		comment, ok := strings.CutPrefix(line, "--")
+		if !ok {
+			break
+		}
+		directive, ok := strings.CutPrefix(strings.TrimSpace(comment), directivePrefix)
+		if !ok {
+			continue
+		}
+		name, value, _ := strings.Cut(directive, " ")
+		value = strings.TrimSpace(value)
+
+		switch name {
+		case upMarker:
+			break header
+		case downMarker:
+			return out, fmt.Errorf("%w; directive %q must come after %q", ErrDataInvalid, directivePrefix+name, directivePrefix+upMarker)
+		case "no-transaction":
+			if value != "" {
+				return out, fmt.Errorf("%w; directive %q does not take a value, got %q", ErrDataInvalid, name, value)
+			}
+			out.NoTransaction = true
+		case "timeout":
+			timeout, perr := time.ParseDuration(value)
+			if perr != nil {
+				return out, fmt.Errorf("%w; directive %q: %w", ErrDataInvalid, name, perr)
+			}
+			if timeout <= 0 {
+				return out, fmt.Errorf("%w; directive %q must be positive, got %q", ErrDataInvalid, name, value)
+			}
+			out.Timeout = timeout
+		case "env":
+			for env := range strings.SplitSeq(value, ",") {
+				if env = strings.TrimSpace(env); env != "" {
+					out.Envs = append(out.Envs, env)
+				}
+			}
+			if len(out.Envs) < 1 {
+				return out, fmt.Errorf("%w; directive %q requires at least one environment", ErrDataInvalid, name)
+			}
+		default:
+			out.Unknown = append(out.Unknown, directivePrefix+name)
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// Section returns the part of a single-file migration for the direction. A
+// single-file migration holds both directions, separated by marker comments:
+//
+//	-- godfish:up
+//	CREATE TABLE foos (id int);
+//
+//	-- godfish:down
+//	DROP TABLE foos;
+//
+// The lines before the up marker begin each section, so directives there
+// apply to both directions. Directives at the top of a section only apply to
+// that direction. The marker lines are not part of a section.
+//
+// The ok value is false when data has no up marker, meaning it's an ordinary
+// migration file, or when there is no section for the direction.
+func Section(data []byte, direction Direction) (section []byte, ok bool) {
+	var header, up, down []byte
+	var foundUp, foundDown bool
+	for line := range bytes.Lines(data) {
+		switch {
+		case !foundUp && isMarker(line, upMarker):
+			foundUp = true
+		case foundUp && !foundDown && isMarker(line, downMarker):
+			foundDown = true
+		case foundDown:
+			down = append(down, line...)
+		case foundUp:
+			up = append(up, line...)
+		default:
+			header = append(header, line...)
+		}
+	}
+
+	switch direction {
+	case DirForward:
+		if foundUp {
+			return append(header, up...), true
+		}
+	case DirReverse:
+		if foundDown {
+			return append(header, down...), true
+		}
+	}
+	return nil, false
+}
+
+// SectionLine converts line n of the section of data for the direction, see
+// [Section], into its line in data. Lines start at 1.
+func SectionLine(data []byte, direction Direction, n int) int {
+	var numHeader, numUp int
+	var foundUp, foundDown bool
+	for line := range bytes.Lines(data) {
+		switch {
+		case !foundUp && isMarker(line, upMarker):
+			foundUp = true
+		case foundUp && !foundDown && isMarker(line, downMarker):
+			foundDown = true
+		case foundDown:
+		case foundUp:
+			numUp++
+		default:
+			numHeader++
+		}
+	}
+
+	switch {
+	case n <= numHeader:
+		return n
+	case direction == DirReverse:
+		// Skip past both markers and the up section.
+		return n + numUp + 2
+	default:
+		return n + 1
+	}
+}
+
+// IsSingleFile reports whether or not data, the contents of a migration file,
+// holds both directions. See [Section].
+func IsSingleFile(data []byte) bool {
+	_, ok := Section(data, DirForward)
+	return ok
+}
+
+func isMarker(line []byte, name string) bool {
+	comment, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("--"))
+	return ok && string(bytes.TrimSpace(comment)) == directivePrefix+name
+}
+
+// SingleFileTemplate is the contents of a new, empty single-file migration.
+// Without reversible, there is no down section.
+func SingleFileTemplate(reversible bool) string {
+	out := "-- " + directivePrefix + upMarker + "\n\n"
+	if reversible {
+		out += "-- " + directivePrefix + downMarker + "\n\n"
+	}
+	return out
+}
+
+// MatchesEnv reports whether or not a migration should run in the environment
+// env. It's always true when there is no env directive, or when env is empty,
+// which means that no environment is configured.
+func (d Directives) MatchesEnv(env string) bool {
+	return env == "" || len(d.Envs) < 1 || slices.Contains(d.Envs, env)
+}
+
+// String formats the Directives in the same way they are written in the file,
+// minus the prefix. It's empty when there are no directives.
+func (d Directives) String() string {
+	var parts []string
+	if d.NoTransaction {
+		parts = append(parts, "no-transaction")
+	}
+	if d.Timeout > 0 {
+		parts = append(parts, "timeout "+d.Timeout.String())
+	}
+	if len(d.Envs) > 0 {
+		parts = append(parts, "env "+strings.Join(d.Envs, ","))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,258 @@
+package internal_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func TestParseDirectives(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		exp    internal.Directives
+		expErr error
+	}{
+		{
+			name: "none",
+			data: "CREATE TABLE foos (id int);",
+		},
+		{
+			name: "all",
+			data: `-- godfish:no-transaction
+-- godfish:timeout 10m
+--godfish:env staging, prod
+CREATE TABLE foos (id int);`,
+			exp: internal.Directives{NoTransaction: true, Timeout: 10 * time.Minute, Envs: []string{"staging", "prod"}},
+		},
+		{
+			name: "other comments and blank lines in header",
+			data: `
+-- add the foos table.
+
+-- godfish:timeout 30s
+CREATE TABLE foos (id int);`,
+			exp: internal.Directives{Timeout: 30 * time.Second},
+		},
+		{
+			name: "stops at first statement",
+			data: `CREATE TABLE foos (id int);
+-- godfish:no-transaction`,
+		},
+		{
+			name: "stops at up marker",
+			data: `-- godfish:timeout 30s
+-- godfish:up
+-- godfish:no-transaction
+CREATE TABLE foos (id int);`,
+			exp: internal.Directives{Timeout: 30 * time.Second},
+		},
+		{
+			name:   "down marker before up marker",
+			data:   "-- godfish:down\n-- godfish:up",
+			expErr: internal.ErrDataInvalid,
+		},
+		{
+			name: "unknown directive is ignored",
+			data: "-- godfish:no-transactions\n-- godfish:timeout 30s",
+			exp:  internal.Directives{Timeout: 30 * time.Second, Unknown: []string{"godfish:no-transactions"}},
+		},
+		{
+			name:   "no-transaction with a value",
+			data:   "-- godfish:no-transaction true",
+			expErr: internal.ErrDataInvalid,
+		},
+		{
+			name:   "timeout not a duration",
+			data:   "-- godfish:timeout ten",
+			expErr: internal.ErrDataInvalid,
+		},
+		{
+			name:   "timeout not positive",
+			data:   "-- godfish:timeout 0s",
+			expErr: internal.ErrDataInvalid,
+		},
+		{
+			name:   "env without values",
+			data:   "-- godfish:env ,",
+			expErr: internal.ErrDataInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := internal.ParseDirectives([]byte(test.data))
+			if test.expErr != nil {
+				if !errors.Is(err, test.expErr) {
+					t.Fatalf("expected error (%v) to be %v", err, test.expErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.NoTransaction != test.exp.NoTransaction {
+				t.Errorf("wrong NoTransaction; got %t, expected %t", got.NoTransaction, test.exp.NoTransaction)
+			}
+			if got.Timeout != test.exp.Timeout {
+				t.Errorf("wrong Timeout; got %v, expected %v", got.Timeout, test.exp.Timeout)
+			}
+			if !slices.Equal(got.Envs, test.exp.Envs) {
+				t.Errorf("wrong Envs; got %q, expected %q", got.Envs, test.exp.Envs)
+			}
+			if !slices.Equal(got.Unknown, test.exp.Unknown) {
+				t.Errorf("wrong Unknown; got %q, expected %q", got.Unknown, test.exp.Unknown)
+			}
+		})
+	}
+}
+
+func TestSection(t *testing.T) {
+	const singleFile = `-- godfish:timeout 1m
+-- godfish:up
+CREATE TABLE foos (id int);
+
+--godfish:down
+-- godfish:no-transaction
+DROP TABLE foos;
+`
+	tests := []struct {
+		name      string
+		data      string
+		direction internal.Direction
+		exp       string
+		expOk     bool
+	}{
+		{
+			name:      "up",
+			data:      singleFile,
+			direction: internal.DirForward,
+			exp:       "-- godfish:timeout 1m\nCREATE TABLE foos (id int);\n\n",
+			expOk:     true,
+		},
+		{
+			name:      "down",
+			data:      singleFile,
+			direction: internal.DirReverse,
+			exp:       "-- godfish:timeout 1m\n-- godfish:no-transaction\nDROP TABLE foos;\n",
+			expOk:     true,
+		},
+		{
+			name:      "no down section",
+			data:      "-- godfish:up\nCREATE TABLE foos (id int);",
+			direction: internal.DirReverse,
+		},
+		{
+			name:      "not a single file",
+			data:      "CREATE TABLE foos (id int);\n-- godfish:down\nDROP TABLE foos;",
+			direction: internal.DirForward,
+		},
+		{
+			name:      "unknown direction",
+			data:      singleFile,
+			direction: internal.DirUnknown,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := internal.Section([]byte(test.data), test.direction)
+			if ok != test.expOk {
+				t.Fatalf("wrong ok; got %t, expected %t", ok, test.expOk)
+			}
+			if string(got) != test.exp {
+				t.Errorf("wrong section\ngot:\n%q\nexpected:\n%q", got, test.exp)
+			}
+		})
+	}
+
+	t.Run("line in file", func(t *testing.T) {
+		tests := []struct {
+			direction internal.Direction
+			n, exp    int
+		}{
+			{direction: internal.DirForward, n: 1, exp: 1},
+			{direction: internal.DirForward, n: 2, exp: 3},
+			{direction: internal.DirReverse, n: 1, exp: 1},
+			{direction: internal.DirReverse, n: 3, exp: 7},
+		}
+		for _, test := range tests {
+			if got := internal.SectionLine([]byte(singleFile), test.direction, test.n); got != test.exp {
+				t.Errorf("%s line %d; got %d, expected %d", test.direction, test.n, got, test.exp)
+			}
+		}
+	})
+
+	t.Run("directives of each section", func(t *testing.T) {
+		up, _ := internal.Section([]byte(singleFile), internal.DirForward)
+		down, _ := internal.Section([]byte(singleFile), internal.DirReverse)
+		upDirectives, err := internal.ParseDirectives(up)
+		if err != nil {
+			t.Fatal(err)
+		}
+		downDirectives, err := internal.ParseDirectives(down)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := upDirectives.String(); got != "timeout 1m0s" {
+			t.Errorf("wrong up directives %q", got)
+		}
+		if got := downDirectives.String(); got != "no-transaction; timeout 1m0s" {
+			t.Errorf("wrong down directives %q", got)
+		}
+	})
+
+	t.Run("SingleFileTemplate", func(t *testing.T) {
+		for _, reversible := range []bool{true, false} {
+			data := []byte(internal.SingleFileTemplate(reversible))
+			if !internal.IsSingleFile(data) {
+				t.Errorf("reversible=%t; expected template to be a single file", reversible)
+			}
+			if _, ok := internal.Section(data, internal.DirReverse); ok != reversible {
+				t.Errorf("reversible=%t; wrong ok for down section %t", reversible, ok)
+			}
+		}
+	})
+}
+
+func TestDirectives(t *testing.T) {
+	t.Run("MatchesEnv", func(t *testing.T) {
+		var none internal.Directives
+		if !none.MatchesEnv("") || !none.MatchesEnv("prod") {
+			t.Error("expected no env directive to match any environment")
+		}
+
+		some := internal.Directives{Envs: []string{"staging", "prod"}}
+		if !some.MatchesEnv("prod") {
+			t.Error("expected a match")
+		}
+		if some.MatchesEnv("dev") {
+			t.Error("expected no match")
+		}
+		if !some.MatchesEnv("") {
+			t.Error("expected a match when no environment is configured")
+		}
+	})
+
+	t.Run("String", func(t *testing.T) {
+		tests := []struct {
+			in  internal.Directives
+			exp string
+		}{
+			{in: internal.Directives{}, exp: ""},
+			{in: internal.Directives{NoTransaction: true}, exp: "no-transaction"},
+			{
+				in:  internal.Directives{NoTransaction: true, Timeout: time.Minute, Envs: []string{"staging", "prod"}},
+				exp: "no-transaction; timeout 1m0s; env staging,prod",
+			},
+		}
+		for _, test := range tests {
+			if got := test.in.String(); got != test.exp {
+				t.Errorf("got %q, expected %q", got, test.exp)
+			}
+		}
+	})
+}
@@ -1,22 +1,229 @@
 package internal
 
-import "strings"
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
 
 const filenameDelimeter = "-"
 
 // Filename is just a string with a specific format to migration files. One part
-// has a generated timestamp, one part has a direction, another has a label.
+// has a generated version, one part has a direction, another has a label.
 type Filename string
 
-// MakeFilename creates a filename based on the independent parts.
+// MakeFilename creates a filename based on the independent parts, in the
+// DefaultConvention.
 //
 // Format:
 //
 //	"${direction}-${version}-${label}"
 func MakeFilename(version string, indirection Indirection, label string) Filename {
+	return DefaultConvention.MakeFilename(version, indirection, label)
+}
+
+// NamingConvention is a layout of migration filenames. The version part of a
+// filename is parsed with a VersionScheme.
+type NamingConvention interface {
+	// Name identifies the convention, such as in a configuration file.
+	Name() string
+	// ParseMigration constructs a Migration from the basename of name. It's an
+	// [ErrDataInvalid] if name does not follow the convention.
+	ParseMigration(name Filename, scheme VersionScheme) (*Migration, error)
+	// MakeFilename creates a filename, without an extension, from its parts.
+	MakeFilename(version string, indirection Indirection, label string) Filename
+	// ParseRepeatable returns the label of a repeatable migration from the
+	// basename of name. It's an [ErrDataInvalid] if name is not one.
+	ParseRepeatable(name Filename) (label string, err error)
+}
+
+// Available naming conventions.
+var (
+	// DefaultConvention has filenames in the form
+	// "${direction}-${version}-${label}", such as
+	// "forward-20200128070010-add_users.sql". The direction is one of the
+	// ForwardDirections or ReverseDirections. A repeatable migration is in the
+	// form "repeatable-${label}".
+	DefaultConvention NamingConvention = defaultConvention{}
+	// FlywayConvention has filenames in the form "V${version}__${label}" for
+	// forward migrations, and "U${version}__${label}" for reverse migrations,
+	// such as "V1.2__add_users.sql". An underscore in the version is read as a
+	// dot, so "V1_2__add_users.sql" has the same version. A repeatable
+	// migration is in the form "R__${label}".
+	FlywayConvention NamingConvention = flywayConvention{}
+
+	namingConventions = []NamingConvention{DefaultConvention, FlywayConvention}
+)
+
+// NamingConventionNames lists the names of the available naming conventions.
+func NamingConventionNames() []string {
+	out := make([]string, len(namingConventions))
+	for i, convention := range namingConventions {
+		out[i] = convention.Name()
+	}
+	return out
+}
+
+// LookupNamingConvention finds a NamingConvention by its name. An unknown name
+// is an [ErrDataInvalid].
+func LookupNamingConvention(name string) (NamingConvention, error) {
+	for _, convention := range namingConventions {
+		if convention.Name() == name {
+			return convention, nil
+		}
+	}
+	return nil, fmt.Errorf(
+		"%w; unknown naming convention %q, should be one of %q",
+		ErrDataInvalid, name, NamingConventionNames(),
+	)
+}
+
+type defaultConvention struct{}
+
+func (defaultConvention) Name() string { return "default" }
+
+func (defaultConvention) ParseMigration(name Filename, scheme VersionScheme) (mig *Migration, err error) {
+	basename := filepath.Base(string(name))
+	indirection := parseIndirection(basename)
+	if indirection.Value == DirUnknown {
+		err = fmt.Errorf(
+			"%w; could not parse Direction for filename %q",
+			ErrDataInvalid, name,
+		)
+		return
+	}
+
+	// index of the start of version
+	i := min(len(indirection.Label)+len(filenameDelimeter), len(basename))
+	version, err := scheme.ParseVersion(basename[i:])
+	if err != nil {
+		err = fmt.Errorf(
+			"%w, could not parse version for filename %q; %v",
+			ErrDataInvalid, name, err,
+		)
+		return
+	}
+
+	var label string
+	// index of the start of migration label
+	j := i + len(version.String()) + len(filenameDelimeter)
+	if j < len(basename) {
+		tail := string(basename[j:])
+		ext := filepath.Ext(tail)
+		label = strings.TrimSuffix(tail, ext)
+	}
+
+	mig = &Migration{
+		Indirection: indirection,
+		Label:       label,
+		Version:     version,
+	}
+	return
+}
+
+func (defaultConvention) MakeFilename(version string, indirection Indirection, label string) Filename {
 	dir := strings.ToLower(indirection.Label) + filenameDelimeter
 
-	// the length will top out at the high quantifier for this regexp.
-	ver := timeformatMatcher.FindString(version) + filenameDelimeter
-	return Filename(dir + ver + label)
+	// A timestamp version tops out at the length of the TimeFormat. Versions of
+	// other schemes are written as is.
+	ver := version
+	if strings.Trim(ver, "0123456789") == "" && len(ver) > len(TimeFormat) {
+		ver = ver[:len(TimeFormat)]
+	}
+	return Filename(dir + ver + filenameDelimeter + label)
+}
+
+func (defaultConvention) ParseRepeatable(name Filename) (string, error) {
+	return parseRepeatable(name, repeatablePrefix+filenameDelimeter)
+}
+
+// repeatablePrefix starts the filename of a repeatable migration in the
+// DefaultConvention.
+const repeatablePrefix = "repeatable"
+
+// parseRepeatable returns the part of the basename of name after the prefix,
+// without an extension.
+func parseRepeatable(name Filename, prefix string) (string, error) {
+	basename := filepath.Base(string(name))
+	tail, ok := strings.CutPrefix(basename, prefix)
+	if !ok {
+		return "", fmt.Errorf("%w; filename %q is not a repeatable migration", ErrDataInvalid, name)
+	}
+	label := strings.TrimSuffix(tail, filepath.Ext(tail))
+	if label == "" {
+		return "", fmt.Errorf("%w; repeatable migration %q needs a label", ErrDataInvalid, name)
+	}
+	return label, nil
+}
+
+// Prefixes, separator of filename parts in the FlywayConvention.
+const (
+	flywayForward    = "V"
+	flywayReverse    = "U"
+	flywayRepeatable = "R"
+	flywaySeparator  = "__"
+)
+
+type flywayConvention struct{}
+
+func (flywayConvention) Name() string { return "flyway" }
+
+func (flywayConvention) ParseMigration(name Filename, scheme VersionScheme) (mig *Migration, err error) {
+	basename := filepath.Base(string(name))
+
+	var indirection Indirection
+	var rest string
+	if after, ok := strings.CutPrefix(basename, flywayForward); ok {
+		indirection, rest = Indirection{Value: DirForward, Label: flywayForward}, after
+	} else if after, ok = strings.CutPrefix(basename, flywayReverse); ok {
+		indirection, rest = Indirection{Value: DirReverse, Label: flywayReverse}, after
+	} else {
+		err = fmt.Errorf(
+			"%w; could not parse Direction for filename %q",
+			ErrDataInvalid, name,
+		)
+		return
+	}
+
+	written, tail, found := strings.Cut(rest, flywaySeparator)
+	if !found {
+		err = fmt.Errorf(
+			"%w; filename %q is missing the separator %q after the version",
+			ErrDataInvalid, name, flywaySeparator,
+		)
+		return
+	}
+	written = strings.ReplaceAll(written, "_", ".")
+	version, err := scheme.ParseVersion(written)
+	if err == nil && version.String() != written {
+		err = fmt.Errorf("unexpected characters after version %q", version.String())
+	}
+	if err != nil {
+		err = fmt.Errorf(
+			"%w, could not parse version for filename %q; %v",
+			ErrDataInvalid, name, err,
+		)
+		return
+	}
+
+	mig = &Migration{
+		Indirection: indirection,
+		Label:       strings.TrimSuffix(tail, filepath.Ext(tail)),
+		Version:     version,
+	}
+	return
+}
+
+func (flywayConvention) MakeFilename(version string, indirection Indirection, label string) Filename {
+	prefix := flywayForward
+	if indirection.Value == DirReverse {
+		prefix = flywayReverse
+	}
+	// The prefix already says that it's a version.
+	version = strings.TrimLeft(version, "vV")
+	return Filename(prefix + version + flywaySeparator + label)
+}
+
+func (flywayConvention) ParseRepeatable(name Filename) (string, error) {
+	return parseRepeatable(name, flywayRepeatable+flywaySeparator)
 }
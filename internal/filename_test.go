@@ -39,6 +39,13 @@ func TestFilename(t *testing.T) {
 			label:     "test",
 			expOut:    internal.Filename("forward-1234-test"),
 		},
+		// version of another scheme
+		{
+			version:   "V1.2.3",
+			direction: internal.Indirection{Value: internal.DirForward, Label: "forward"},
+			label:     "test",
+			expOut:    internal.Filename("forward-V1.2.3-test"),
+		},
 		// label has dashes
 		{
 			version:   "20191118121314",
@@ -82,3 +89,93 @@ func TestFilename(t *testing.T) {
 		}
 	}
 }
+
+func TestNamingConventions(t *testing.T) {
+	t.Run("Lookup", func(t *testing.T) {
+		for _, name := range internal.NamingConventionNames() {
+			got, err := internal.LookupNamingConvention(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name() != name {
+				t.Errorf("wrong Name; got %q, expected %q", got.Name(), name)
+			}
+		}
+		if _, err := internal.LookupNamingConvention("rails"); !internal.IsInvalidDataError(err) {
+			t.Errorf("expected error %v to be an invalid data error", err)
+		}
+	})
+
+	t.Run("flyway ParseMigration", func(t *testing.T) {
+		tests := []struct {
+			filename     internal.Filename
+			scheme       internal.VersionScheme
+			expDirection internal.Direction
+			expVersion   string
+			expLabel     string
+			expErr       bool
+		}{
+			{filename: "V1__create_foos.sql", scheme: internal.SequentialScheme, expDirection: internal.DirForward, expVersion: "1", expLabel: "create_foos"},
+			{filename: "U0007__create_foos.sql", scheme: internal.SequentialScheme, expDirection: internal.DirReverse, expVersion: "0007", expLabel: "create_foos"},
+			{filename: "V1.2.3__create_foos.sql", scheme: internal.DottedScheme, expDirection: internal.DirForward, expVersion: "1.2.3", expLabel: "create_foos"},
+			{filename: "V1_2__create_foos.sql", scheme: internal.DottedScheme, expDirection: internal.DirForward, expVersion: "1.2", expLabel: "create_foos"},
+			{filename: "V20191118121314__test.sql", scheme: internal.TimestampScheme, expDirection: internal.DirForward, expVersion: "20191118121314", expLabel: "test"},
+			{filename: "R__create_foos.sql", scheme: internal.SequentialScheme, expErr: true},
+			{filename: "V1_create_foos.sql", scheme: internal.SequentialScheme, expErr: true},
+			{filename: "V1a__create_foos.sql", scheme: internal.SequentialScheme, expErr: true},
+			{filename: "forward-0001-create_foos.sql", scheme: internal.SequentialScheme, expErr: true},
+		}
+
+		for _, test := range tests {
+			t.Run(string(test.filename), func(t *testing.T) {
+				got, err := internal.FlywayConvention.ParseMigration(test.filename, test.scheme)
+				if test.expErr {
+					if !internal.IsInvalidDataError(err) {
+						t.Fatalf("expected error %v to be an invalid data error", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.Indirection.Value != test.expDirection {
+					t.Errorf("wrong Direction; got %s, expected %s", got.Indirection.Value, test.expDirection)
+				}
+				if got.Version.String() != test.expVersion {
+					t.Errorf("wrong Version; got %q, expected %q", got.Version.String(), test.expVersion)
+				}
+				if got.Label != test.expLabel {
+					t.Errorf("wrong Label; got %q, expected %q", got.Label, test.expLabel)
+				}
+			})
+		}
+	})
+
+	t.Run("flyway MakeFilename", func(t *testing.T) {
+		tests := []struct {
+			version   string
+			direction internal.Indirection
+			label     string
+			expOut    internal.Filename
+		}{
+			{
+				version:   "0007",
+				direction: internal.Indirection{Value: internal.DirForward, Label: "forward"},
+				label:     "create_foos",
+				expOut:    "V0007__create_foos",
+			},
+			{
+				version:   "V1.2",
+				direction: internal.Indirection{Value: internal.DirReverse, Label: "reverse"},
+				label:     "create_foos",
+				expOut:    "U1.2__create_foos",
+			},
+		}
+		for i, test := range tests {
+			out := internal.FlywayConvention.MakeFilename(test.version, test.direction, test.label)
+			if out != test.expOut {
+				t.Errorf("test %d; wrong filename; got %q, expected %q", i, out, test.expOut)
+			}
+		}
+	})
+}
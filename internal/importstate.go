@@ -0,0 +1,291 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// An ImportSource is another migration tool, whose record of applied
+// migrations may be imported. Its bookkeeping table is read with plain SQL, so
+// it works for any database the tool supports.
+type ImportSource struct {
+	Name  string
+	Table string // default name of the bookkeeping table.
+	// query selects rows from the table, which is named by the %s verb, in
+	// the order that the tool recorded them.
+	query string
+	// scan reads one row of the query into the state.
+	scan func(rows Rows, state *ImportedState) error
+}
+
+// Rows is the result of a query. It's implemented by a *database/sql.Rows.
+type Rows interface {
+	Close() error
+	Err() error
+	Next() bool
+	Scan(dest ...any) error
+}
+
+// QueryFunc runs a query, which has no placeholders, and returns its result.
+type QueryFunc func(ctx context.Context, query string) (Rows, error)
+
+// ImportedState is what another tool recorded about applied migrations.
+type ImportedState struct {
+	// Versions were applied, in the order they were recorded.
+	Versions []string
+	// Through, when non-empty, means that every version up to and including
+	// it was applied, such as the current version of golang-migrate.
+	Through string
+}
+
+func (s *ImportedState) add(version string) {
+	s.remove(version)
+	s.Versions = append(s.Versions, version)
+}
+
+func (s *ImportedState) remove(version string) {
+	s.Versions = slices.DeleteFunc(s.Versions, func(v string) bool { return v == version })
+}
+
+var importSources = []ImportSource{
+	{
+		Name:  "goose",
+		Table: "goose_db_version",
+		query: "SELECT version_id, is_applied FROM %s ORDER BY id",
+		scan: func(rows Rows, state *ImportedState) error {
+			var version int64
+			var applied bool
+			if err := rows.Scan(&version, &applied); err != nil {
+				return err
+			}
+			if version == 0 {
+				// The first row is written by goose when creating the table.
+				return nil
+			}
+			if applied {
+				state.add(strconv.FormatInt(version, 10))
+			} else {
+				state.remove(strconv.FormatInt(version, 10))
+			}
+			return nil
+		},
+	},
+	{
+		Name:  "golang-migrate",
+		Table: "schema_migrations",
+		query: "SELECT version, dirty FROM %s",
+		scan: func(rows Rows, state *ImportedState) error {
+			var version int64
+			var dirty bool
+			if err := rows.Scan(&version, &dirty); err != nil {
+				return err
+			}
+			if dirty {
+				return fmt.Errorf("%w; version %d is dirty, resolve it with golang-migrate first", ErrDataInvalid, version)
+			}
+			state.Through = strconv.FormatInt(version, 10)
+			return nil
+		},
+	},
+	{
+		Name:  "flyway",
+		Table: "flyway_schema_history",
+		query: "SELECT version, type, success FROM %s ORDER BY installed_rank",
+		scan: func(rows Rows, state *ImportedState) error {
+			var version *string
+			var kind string
+			var success bool
+			if err := rows.Scan(&version, &kind, &success); err != nil {
+				return err
+			}
+			// A repeatable migration has no version.
+			if version == nil || !success {
+				return nil
+			}
+			switch {
+			case kind == "SCHEMA":
+				// Flyway created the schema, it's not a migration.
+			case strings.HasPrefix(kind, "UNDO") || kind == "DELETE":
+				state.remove(*version)
+			case strings.HasSuffix(kind, "BASELINE"):
+				state.Through = *version
+				state.add(*version)
+			default:
+				state.add(*version)
+			}
+			return nil
+		},
+	},
+	{
+		Name:  "dbmate",
+		Table: "schema_migrations",
+		query: "SELECT version FROM %s ORDER BY version",
+		scan: func(rows Rows, state *ImportedState) error {
+			var version string
+			if err := rows.Scan(&version); err != nil {
+				return err
+			}
+			state.add(version)
+			return nil
+		},
+	},
+}
+
+// ImportSourceNames lists the name of each ImportSource.
+func ImportSourceNames() []string {
+	out := make([]string, len(importSources))
+	for i, src := range importSources {
+		out[i] = src.Name
+	}
+	return out
+}
+
+// LookupImportSource finds an ImportSource by its name. An unknown name is an
+// [ErrDataInvalid].
+func LookupImportSource(name string) (ImportSource, error) {
+	for _, src := range importSources {
+		if src.Name == name {
+			return src, nil
+		}
+	}
+	return ImportSource{}, fmt.Errorf(
+		"%w; unknown import source %q, should be one of %q",
+		ErrDataInvalid, name, ImportSourceNames(),
+	)
+}
+
+// tableMatcher is a conservative pattern for the name of a bookkeeping table,
+// since it's put into a query as is. It may have a schema, such as
+// "public.goose_db_version".
+var tableMatcher = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}(\.[A-Za-z_][A-Za-z0-9_]{0,62})?$`)
+
+// ReadState reads the bookkeeping table of the tool with query. If table is
+// empty, then it's the default table of the tool.
+func (s ImportSource) ReadState(ctx context.Context, query QueryFunc, table string) (state *ImportedState, err error) {
+	table = cmp.Or(table, s.Table)
+	if !tableMatcher.MatchString(table) {
+		return nil, fmt.Errorf("%w; table name %q must match pattern %s", ErrDataInvalid, table, tableMatcher.String())
+	}
+
+	rows, err := query(ctx, fmt.Sprintf(s.query, table))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s table %q: %w", s.Name, table, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	state = &ImportedState{}
+	for rows.Next() {
+		if err = s.scan(rows, state); err != nil {
+			return nil, fmt.Errorf("reading %s table %q: %w", s.Name, table, err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s table %q: %w", s.Name, table, err)
+	}
+	return state, nil
+}
+
+// ImportStatus describes what happened to one version when importing the
+// state of another tool.
+type ImportStatus string
+
+const (
+	// ImportRecorded means the migration is now recorded as applied.
+	ImportRecorded ImportStatus = "recorded"
+	// ImportAlreadyApplied means the migration was already recorded as applied.
+	ImportAlreadyApplied ImportStatus = "already-applied"
+	// ImportUnmatched means no migration has the version.
+	ImportUnmatched ImportStatus = "unmatched"
+)
+
+// ImportResult is the outcome of importing one version. The Migration is nil
+// when the Status is ImportUnmatched.
+type ImportResult struct {
+	Version   string
+	Status    ImportStatus
+	Migration *Migration
+}
+
+// ImportPrinter outputs the results of importing the state of another tool.
+type ImportPrinter interface {
+	PrintImport([]ImportResult) error
+}
+
+// NewImportTSV constructs an ImportPrinter to write out tab separated values.
+func NewImportTSV(w io.Writer, lgr *slog.Logger) ImportPrinter {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	return &tsvPrinter{tw, lgr}
+}
+
+// NewImportJSON constructs an ImportPrinter to write out JSON.
+func NewImportJSON(w io.Writer, lgr *slog.Logger) ImportPrinter {
+	enc := json.NewEncoder(w)
+	return &jsonPrinter{enc, lgr}
+}
+
+func (p *tsvPrinter) PrintImport(in []ImportResult) error {
+	const format = "%s\t%s\t%s\t%s\t%s"
+
+	// headers
+	_, err := fmt.Fprintf(p.tw, format+"\n", "i", "version", "status", "label", "filename")
+	if err != nil {
+		p.lgr.Error("internal: printing TSV headers", slog.Any("error", err))
+	}
+
+	// body
+	for i, res := range in {
+		label, filename := "-", "-"
+		if mig := res.Migration; mig != nil {
+			label, filename = cmp.Or(mig.Label, "-"), cmp.Or(mig.DisplayName(), "-")
+		}
+		_, err = fmt.Fprintf(p.tw, format+"\n", strconv.Itoa(i), res.Version, string(res.Status), label, filename)
+		if err != nil {
+			p.lgr.Error(
+				"internal: printing TSV body",
+				slog.Any("error", err), slog.String("version", res.Version), slog.String("status", string(res.Status)),
+			)
+		}
+	}
+	if err = p.tw.Flush(); err != nil {
+		p.lgr.Error("internal: flushing TSV", slog.Any("error", err))
+	}
+	return nil
+}
+
+func (p *jsonPrinter) PrintImport(in []ImportResult) error {
+	type result struct {
+		I        int    `json:"i"`
+		Version  string `json:"version"`
+		Status   string `json:"status"`
+		Label    string `json:"label"`
+		Filename string `json:"filename"`
+	}
+
+	for i, res := range in {
+		item := result{I: i, Version: res.Version, Status: string(res.Status)}
+		if mig := res.Migration; mig != nil {
+			item.Label, item.Filename = mig.Label, mig.DisplayName()
+		}
+		if err := p.enc.Encode(item); err != nil {
+			p.lgr.Error(
+				"internal: printing JSON item",
+				slog.Any("error", err), slog.String("version", res.Version), slog.String("status", string(res.Status)),
+			)
+		}
+	}
+
+	return nil
+}
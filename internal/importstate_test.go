@@ -0,0 +1,222 @@
+package internal_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func TestImportSource(t *testing.T) {
+	strptr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name       string
+		rows       [][]any
+		expQuery   string
+		expState   internal.ImportedState
+		expErr     bool
+		sourceName string
+	}{
+		{
+			sourceName: "goose",
+			name:       "applied, then one is rolled back",
+			rows:       [][]any{{int64(0), true}, {int64(1), true}, {int64(2), true}, {int64(3), true}, {int64(2), false}},
+			expQuery:   "SELECT version_id, is_applied FROM goose_db_version ORDER BY id",
+			expState:   internal.ImportedState{Versions: []string{"1", "3"}},
+		},
+		{
+			sourceName: "golang-migrate",
+			name:       "current version",
+			rows:       [][]any{{int64(20240102030405), false}},
+			expQuery:   "SELECT version, dirty FROM schema_migrations",
+			expState:   internal.ImportedState{Through: "20240102030405"},
+		},
+		{
+			sourceName: "golang-migrate",
+			name:       "dirty",
+			rows:       [][]any{{int64(20240102030405), true}},
+			expErr:     true,
+		},
+		{
+			sourceName: "flyway",
+			name:       "baseline, undo, repeatable and failure",
+			rows: [][]any{
+				{strptr("1"), "BASELINE", true},
+				{strptr("1.1"), "SQL", true},
+				{strptr("1.2"), "SQL", true},
+				{(*string)(nil), "SQL", true},
+				{strptr("1.3"), "SQL", false},
+				{strptr("1.2"), "UNDO_SQL", true},
+			},
+			expQuery: "SELECT version, type, success FROM flyway_schema_history ORDER BY installed_rank",
+			expState: internal.ImportedState{Versions: []string{"1", "1.1"}, Through: "1"},
+		},
+		{
+			sourceName: "dbmate",
+			name:       "applied",
+			rows:       [][]any{{"20240101000000"}, {"20240102000000"}},
+			expQuery:   "SELECT version FROM schema_migrations ORDER BY version",
+			expState:   internal.ImportedState{Versions: []string{"20240101000000", "20240102000000"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sourceName+" "+test.name, func(t *testing.T) {
+			src, err := internal.LookupImportSource(test.sourceName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotQuery string
+			query := func(_ context.Context, query string) (internal.Rows, error) {
+				gotQuery = query
+				return &fakeRows{rows: test.rows}, nil
+			}
+
+			got, err := src.ReadState(t.Context(), query, "")
+			if test.expErr {
+				if !errors.Is(err, internal.ErrDataInvalid) {
+					t.Fatalf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if gotQuery != test.expQuery {
+				t.Errorf("wrong query\ngot:      %q\nexpected: %q", gotQuery, test.expQuery)
+			}
+			if !slices.Equal(got.Versions, test.expState.Versions) {
+				t.Errorf("wrong versions\ngot:      %q\nexpected: %q", got.Versions, test.expState.Versions)
+			}
+			if got.Through != test.expState.Through {
+				t.Errorf("wrong through; got %q, expected %q", got.Through, test.expState.Through)
+			}
+		})
+	}
+
+	t.Run("other table", func(t *testing.T) {
+		src, err := internal.LookupImportSource("dbmate")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var gotQuery string
+		query := func(_ context.Context, query string) (internal.Rows, error) {
+			gotQuery = query
+			return &fakeRows{}, nil
+		}
+		if _, err = src.ReadState(t.Context(), query, "public.dbmate_migrations"); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(gotQuery, "FROM public.dbmate_migrations") {
+			t.Errorf("wrong query %q", gotQuery)
+		}
+	})
+
+	t.Run("error - invalid table", func(t *testing.T) {
+		src, err := internal.LookupImportSource("dbmate")
+		if err != nil {
+			t.Fatal(err)
+		}
+		query := func(context.Context, string) (internal.Rows, error) {
+			t.Fatal("should not query")
+			return nil, nil
+		}
+		for _, table := range []string{"foo; DROP TABLE bar", "a.b.c", `"quoted"`} {
+			if _, err = src.ReadState(t.Context(), query, table); !errors.Is(err, internal.ErrDataInvalid) {
+				t.Errorf("table %q; expected error (%v) to be %v", table, err, internal.ErrDataInvalid)
+			}
+		}
+	})
+
+	t.Run("error - unknown source", func(t *testing.T) {
+		if _, err := internal.LookupImportSource("rails"); !errors.Is(err, internal.ErrDataInvalid) {
+			t.Errorf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
+		}
+	})
+}
+
+func TestImportPrinter(t *testing.T) {
+	mig := mustMakeMigrations(t, "alpha")[0]
+	results := []internal.ImportResult{
+		{Version: "1234", Status: internal.ImportRecorded, Migration: mig},
+		{Version: "9999", Status: internal.ImportUnmatched},
+	}
+
+	t.Run("tsv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := internal.NewImportTSV(&buf, slog.Default()).PrintImport(results); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("wrong number of lines, %d\n%s", len(lines), buf.String())
+		}
+		if fields := strings.Fields(lines[2]); !slices.Equal(fields, []string{"1", "9999", "unmatched", "-", "-"}) {
+			t.Errorf("wrong fields %q", fields)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := internal.NewImportJSON(&buf, slog.Default()).PrintImport(results); err != nil {
+			t.Fatal(err)
+		}
+		dec := json.NewDecoder(&buf)
+		var got []map[string]any
+		for dec.More() {
+			var item map[string]any
+			if err := dec.Decode(&item); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, item)
+		}
+		if len(got) != 2 {
+			t.Fatalf("wrong number of items, %d", len(got))
+		}
+		if got[0]["status"] != "recorded" || got[0]["label"] != "alpha" || got[1]["filename"] != "" {
+			t.Errorf("wrong item %v", got[0])
+		}
+	})
+}
+
+// fakeRows is an internal.Rows with the values of each row in rows.
+type fakeRows struct {
+	rows [][]any
+	i    int
+}
+
+func (r *fakeRows) Close() error { return nil }
+func (r *fakeRows) Err() error   { return nil }
+func (r *fakeRows) Next() bool {
+	r.i++
+	return r.i <= len(r.rows)
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	row := r.rows[r.i-1]
+	if len(dest) != len(row) {
+		return fmt.Errorf("wrong number of dest values, got %d, expected %d", len(dest), len(row))
+	}
+	for i, val := range row {
+		switch d := dest[i].(type) {
+		case *int64:
+			*d = val.(int64)
+		case *bool:
+			*d = val.(bool)
+		case *string:
+			*d = val.(string)
+		case **string:
+			*d = val.(*string)
+		default:
+			return fmt.Errorf("unsupported dest type %T", d)
+		}
+	}
+	return nil
+}
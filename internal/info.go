@@ -17,31 +17,38 @@ type InfoPrinter interface {
 }
 
 // NewTSV constructs an InfoPrinter to write out tab separated values.
-func NewTSV(w io.Writer) InfoPrinter {
+func NewTSV(w io.Writer, lgr *slog.Logger) InfoPrinter {
 	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
-	return &tsvPrinter{tw}
+	return &tsvPrinter{tw, lgr}
 }
 
 // NewJSON constructs an InfoPrinter to write out JSON.
-func NewJSON(w io.Writer) InfoPrinter {
+func NewJSON(w io.Writer, lgr *slog.Logger) InfoPrinter {
 	enc := json.NewEncoder(w)
-	return &jsonPrinter{enc}
+	return &jsonPrinter{enc, lgr}
 }
 
-type tsvPrinter struct{ tw *tabwriter.Writer }
-type jsonPrinter struct{ enc *json.Encoder }
+type tsvPrinter struct {
+	tw  *tabwriter.Writer
+	lgr *slog.Logger
+}
+
+type jsonPrinter struct {
+	enc *json.Encoder
+	lgr *slog.Logger
+}
 
 func (p *tsvPrinter) PrintInfo(in []*Migration) error {
-	const format = "%s\t%s\t%s\t%s\t%s\t%s"
+	const format = "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s"
 
 	// headers
-	_, err := fmt.Fprintf(p.tw, format+"\n", "i", "version", "applied", "executed_at", "label", "filename")
+	_, err := fmt.Fprintf(p.tw, format+"\n", "i", "version", "applied", "executed_at", "batch", "label", "filename", "directives")
 	if err != nil {
-		slog.Error("internal: printing TSV headers", slog.Any("error", err))
+		p.lgr.Error("internal: printing TSV headers", slog.Any("error", err))
 	}
 
 	// body
-	var executedAt, label string
+	var executedAt, batch, label, directives string
 	for i, mig := range in {
 		// These fields could be empty values. For display purposes in this format,
 		// show a "-" rather than "" to show that data is confirmed to be empty.
@@ -50,22 +57,27 @@ func (p *tsvPrinter) PrintInfo(in []*Migration) error {
 		// instead, which is fine for human visual purposes, but breaks unit tests
 		// that rely on parsing TSV. For that reason, put something here.
 		executedAt = cmp.Or(formatTime(mig.ExecutedAt), "-")
+		batch = "-"
+		if mig.Batch > 0 {
+			batch = strconv.FormatInt(mig.Batch, 10)
+		}
 		label = cmp.Or(mig.Label, "-")
+		directives = cmp.Or(mig.Directives.String(), "-")
 
 		_, err = fmt.Fprintf(
 			p.tw,
 			format+"\n",
-			strconv.Itoa(i), mig.Version.String(), strconv.FormatBool(mig.Applied), executedAt, label, mig.Filename,
+			strconv.Itoa(i), mig.Version.String(), strconv.FormatBool(mig.Applied), executedAt, batch, label, mig.DisplayName(), directives,
 		)
 		if err != nil {
-			slog.Error(
+			p.lgr.Error(
 				"internal: printing TSV body",
 				slog.Any("error", err), slog.String("version", mig.Version.String()), slog.String("label", label), slog.String("filename", mig.Filename),
 			)
 		}
 	}
 	if err = p.tw.Flush(); err != nil {
-		slog.Error("internal: flushing TSV", slog.Any("error", err))
+		p.lgr.Error("internal: flushing TSV", slog.Any("error", err))
 	}
 	return nil
 }
@@ -76,8 +88,10 @@ func (p *jsonPrinter) PrintInfo(in []*Migration) error {
 		Version    string `json:"version"`
 		Applied    bool   `json:"applied"`
 		ExecutedAt string `json:"executed_at"`
+		Batch      int64  `json:"batch"`
 		Label      string `json:"label"`
 		Filename   string `json:"filename"`
+		Directives string `json:"directives"`
 	}
 	encodeJSON := p.enc.Encode
 	var err error
@@ -88,11 +102,13 @@ func (p *jsonPrinter) PrintInfo(in []*Migration) error {
 			Version:    mig.Version.String(),
 			Applied:    mig.Applied,
 			ExecutedAt: formatTime(mig.ExecutedAt),
+			Batch:      mig.Batch,
 			Label:      mig.Label,
-			Filename:   mig.Filename,
+			Filename:   mig.DisplayName(),
+			Directives: mig.Directives.String(),
 		})
 		if err != nil {
-			slog.Error(
+			p.lgr.Error(
 				"internal: printing JSON item",
 				slog.Any("error", err), slog.String("version", mig.Version.String()), slog.String("label", mig.Label), slog.String("filename", mig.Filename),
 			)
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"strconv"
 	"testing"
 	"time"
@@ -20,20 +21,23 @@ func TestTSV(t *testing.T) {
 	// Set up migrations to print. The first half is considered in the past,
 	// applied. The latter half is considered in the future, not yet applied.
 	migrations := mustMakeMigrations(t, names...)
+	migrations[1].Batch = 2
+	migrations[2].Directives = internal.Directives{NoTransaction: true, Timeout: 10 * time.Minute}
+	migrations[3].Directives = internal.Directives{Envs: []string{"staging", "prod"}}
 
 	t.Run("ok", func(t *testing.T) {
 		var buf bytes.Buffer
-		if err := printMigrations(internal.NewTSV(&buf), migrations[:2], migrations[2:]); err != nil {
+		if err := printMigrations(internal.NewTSV(&buf, slog.Default()), migrations[:2], migrations[2:]); err != nil {
 			t.Fatal(err)
 		}
 
-		const numExpectedFields = 6
+		const numExpectedFields = 8
 		expected := [][numExpectedFields]string{
-			{"i", "version", "applied", "executed_at", "label", "filename"},
-			{"0", "1000", "true", "1000-01-02 15:04:05", "alfa", "forward-1000-alfa.sql"},
-			{"1", "2000", "true", "2000-01-02 15:04:05", "bravo", "forward-2000-bravo.sql"},
-			{"2", "3000", "false", "-", "charlie", "forward-3000-charlie.sql"},
-			{"3", "4000", "false", "-", "delta", "forward-4000-delta.sql"},
+			{"i", "version", "applied", "executed_at", "batch", "label", "filename", "directives"},
+			{"0", "1000", "true", "1000-01-02 15:04:05", "-", "alfa", "forward-1000-alfa.sql", "-"},
+			{"1", "2000", "true", "2000-01-02 15:04:05", "2", "bravo", "forward-2000-bravo.sql", "-"},
+			{"2", "3000", "false", "-", "-", "charlie", "forward-3000-charlie.sql", "no-transaction; timeout 10m0s"},
+			{"3", "4000", "false", "-", "-", "delta", "forward-4000-delta.sql", "env staging,prod"},
 		}
 
 		tsvReader := csv.NewReader(&buf)
@@ -63,7 +67,7 @@ func TestTSV(t *testing.T) {
 
 	t.Run("error", func(t *testing.T) {
 		w := errWriter{writeFn: func(p []byte) (int, error) { return len(p), errors.New("test") }}
-		if err := printMigrations(internal.NewTSV(&w), migrations[:2], migrations[2:]); err != nil {
+		if err := printMigrations(internal.NewTSV(&w, slog.Default()), migrations[:2], migrations[2:]); err != nil {
 			t.Fatal("function should try to print as much as it can without erroring out")
 		}
 	})
@@ -76,7 +80,9 @@ func TestJSON(t *testing.T) {
 		Version    string `json:"version"`
 		Applied    bool   `json:"applied"`
 		ExecutedAt string `json:"executed_at"`
+		Batch      int64  `json:"batch"`
 		Label      string `json:"label"`
+		Directives string `json:"directives"`
 	}
 
 	names := []string{"alfa", "bravo", "charlie", "delta"}
@@ -84,17 +90,19 @@ func TestJSON(t *testing.T) {
 	// Set up migrations to print. The first half is considered in the past,
 	// applied. The latter half is considered in the future, not yet applied.
 	migrations := mustMakeMigrations(t, names...)
+	migrations[1].Batch = 2
+	migrations[3].Directives = internal.Directives{NoTransaction: true}
 
 	t.Run("ok", func(t *testing.T) {
 		expected := []migration{
 			{I: 0, Version: "1000", Applied: true, ExecutedAt: "1000-01-02 15:04:05", Label: "alfa"},
-			{I: 1, Version: "2000", Applied: true, ExecutedAt: "2000-01-02 15:04:05", Label: "bravo"},
+			{I: 1, Version: "2000", Applied: true, ExecutedAt: "2000-01-02 15:04:05", Batch: 2, Label: "bravo"},
 			{I: 2, Version: "3000", Applied: false, ExecutedAt: "", Label: "charlie"},
-			{I: 3, Version: "4000", Applied: false, ExecutedAt: "", Label: "delta"},
+			{I: 3, Version: "4000", Applied: false, ExecutedAt: "", Label: "delta", Directives: "no-transaction"},
 		}
 
 		var buf bytes.Buffer
-		if err := printMigrations(internal.NewJSON(&buf), migrations[:2], migrations[2:]); err != nil {
+		if err := printMigrations(internal.NewJSON(&buf, slog.Default()), migrations[:2], migrations[2:]); err != nil {
 			t.Fatal(err)
 		}
 
@@ -122,7 +130,7 @@ func TestJSON(t *testing.T) {
 
 	t.Run("error", func(t *testing.T) {
 		w := errWriter{writeFn: func(p []byte) (int, error) { return len(p), errors.New("test") }}
-		if err := printMigrations(internal.NewJSON(&w), migrations[:2], migrations[2:]); err != nil {
+		if err := printMigrations(internal.NewJSON(&w, slog.Default()), migrations[:2], migrations[2:]); err != nil {
 			t.Fatal("function should try to print as much as it can without erroring out")
 		}
 	})
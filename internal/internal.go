@@ -8,10 +8,13 @@ import (
 
 // Config is for various runtime settings.
 type Config struct {
-	PathToFiles     string `json:"path_to_files"`
-	ForwardLabel    string `json:"forward_label"`
-	ReverseLabel    string `json:"reverse_label"`
-	MigrationsTable string `json:"migrations_table"`
+	PathToFiles      string `json:"path_to_files"`
+	ForwardLabel     string `json:"forward_label"`
+	ReverseLabel     string `json:"reverse_label"`
+	MigrationsTable  string `json:"migrations_table"`
+	VersionScheme    string `json:"version_scheme"`
+	NamingConvention string `json:"naming_convention"`
+	Recursive        bool   `json:"recursive"`
 }
 
 // LogValue lets this type implement the [slog.LogValuer] interface.
@@ -21,6 +24,9 @@ func (c Config) LogValue() slog.Value {
 		slog.String("forward_label", c.ForwardLabel),
 		slog.String("reverse_label", c.ReverseLabel),
 		slog.String("migrations_table", c.MigrationsTable),
+		slog.String("version_scheme", c.VersionScheme),
+		slog.String("naming_convention", c.NamingConvention),
+		slog.Bool("recursive", c.Recursive),
 	)
 }
 
@@ -29,6 +35,8 @@ var (
 	ErrNotFound           = errors.New("not found")
 	ErrDataInvalid        = errors.New("data invalid")
 	ErrExecutingMigration = errors.New("executing migration")
+	ErrChecksumMismatch   = errors.New("checksum mismatch")
+	ErrAlreadyApplied     = errors.New("already applied")
 )
 
 // IsInvalidDataError checks if err is an [ErrDataInvalid], and if not then it
@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"text/tabwriter"
+)
+
+// ProblemKind describes what is wrong with a migration file.
+type ProblemKind string
+
+const (
+	// ProblemUnparseableName means the filename could not be parsed into a
+	// direction, version and label.
+	ProblemUnparseableName ProblemKind = "unparseable-name"
+	// ProblemDuplicateVersion means another migration in the same direction
+	// has the same version.
+	ProblemDuplicateVersion ProblemKind = "duplicate-version"
+	// ProblemDuplicateLabel means another repeatable migration has the same
+	// label.
+	ProblemDuplicateLabel ProblemKind = "duplicate-label"
+	// ProblemNoReverse means a forward migration has no reverse migration
+	// with the same version. This is a warning, since a migration may be
+	// deliberately irreversible.
+	ProblemNoReverse ProblemKind = "no-reverse"
+	// ProblemNoForward means a reverse migration has no forward migration
+	// with the same version.
+	ProblemNoForward ProblemKind = "no-forward"
+	// ProblemEmptyFile means the file has nothing but whitespace.
+	ProblemEmptyFile ProblemKind = "empty-file"
+	// ProblemInvalidDirectives means the header of the file has a directive
+	// with an invalid value.
+	ProblemInvalidDirectives ProblemKind = "invalid-directives"
+	// ProblemUnknownDirective means the header of the file has a directive
+	// that is not recognized, so it's ignored. This is a warning, since it may
+	// be meant for a newer version.
+	ProblemUnknownDirective ProblemKind = "unknown-directive"
+)
+
+// IsWarning reports whether or not the problem is allowed, but still worth
+// pointing out.
+func (k ProblemKind) IsWarning() bool {
+	return k == ProblemNoReverse || k == ProblemUnknownDirective
+}
+
+// Severity is "warning" or "error", see IsWarning.
+func (k ProblemKind) Severity() string {
+	if k.IsWarning() {
+		return "warning"
+	}
+	return "error"
+}
+
+// Problem is the result of validating one migration file. The Version is
+// empty when the filename could not be parsed.
+type Problem struct {
+	Kind     ProblemKind
+	Filename string
+	Version  string
+	Detail   string
+}
+
+// ProblemPrinter outputs the results of validating migration files.
+type ProblemPrinter interface {
+	PrintProblems([]Problem) error
+}
+
+// NewProblemTSV constructs a ProblemPrinter to write out tab separated values.
+func NewProblemTSV(w io.Writer, lgr *slog.Logger) ProblemPrinter {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	return &tsvPrinter{tw, lgr}
+}
+
+// NewProblemJSON constructs a ProblemPrinter to write out JSON.
+func NewProblemJSON(w io.Writer, lgr *slog.Logger) ProblemPrinter {
+	enc := json.NewEncoder(w)
+	return &jsonPrinter{enc, lgr}
+}
+
+func (p *tsvPrinter) PrintProblems(in []Problem) error {
+	const format = "%s\t%s\t%s\t%s\t%s\t%s"
+
+	// headers
+	_, err := fmt.Fprintf(p.tw, format+"\n", "i", "severity", "problem", "version", "filename", "detail")
+	if err != nil {
+		p.lgr.Error("internal: printing TSV headers", slog.Any("error", err))
+	}
+
+	// body
+	for i, prob := range in {
+		_, err = fmt.Fprintf(
+			p.tw,
+			format+"\n",
+			strconv.Itoa(i), prob.Kind.Severity(), string(prob.Kind),
+			cmp.Or(prob.Version, "-"), cmp.Or(prob.Filename, "-"), cmp.Or(prob.Detail, "-"),
+		)
+		if err != nil {
+			p.lgr.Error(
+				"internal: printing TSV body",
+				slog.Any("error", err), slog.String("filename", prob.Filename), slog.String("problem", string(prob.Kind)),
+			)
+		}
+	}
+	if err = p.tw.Flush(); err != nil {
+		p.lgr.Error("internal: flushing TSV", slog.Any("error", err))
+	}
+	return nil
+}
+
+func (p *jsonPrinter) PrintProblems(in []Problem) error {
+	type problem struct {
+		I        int    `json:"i"`
+		Severity string `json:"severity"`
+		Problem  string `json:"problem"`
+		Version  string `json:"version"`
+		Filename string `json:"filename"`
+		Detail   string `json:"detail"`
+	}
+
+	for i, prob := range in {
+		err := p.enc.Encode(problem{
+			I:        i,
+			Severity: prob.Kind.Severity(),
+			Problem:  string(prob.Kind),
+			Version:  prob.Version,
+			Filename: prob.Filename,
+			Detail:   prob.Detail,
+		})
+		if err != nil {
+			p.lgr.Error(
+				"internal: printing JSON item",
+				slog.Any("error", err), slog.String("filename", prob.Filename), slog.String("problem", string(prob.Kind)),
+			)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func TestProblemTSV(t *testing.T) {
+	problems := makeProblems()
+
+	t.Run("ok", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := internal.NewProblemTSV(&buf, slog.Default()).PrintProblems(problems); err != nil {
+			t.Fatal(err)
+		}
+
+		const numExpectedFields = 6
+		expected := [][numExpectedFields]string{
+			{"i", "severity", "problem", "version", "filename", "detail"},
+			{"0", "error", "unparseable-name", "-", "notes.txt", "bad name"},
+			{"1", "warning", "no-reverse", "1000", "forward-1000-alfa.sql", "-"},
+			{"2", "error", "empty-file", "2000", "reverse-2000-bravo.sql", "-"},
+		}
+
+		tsvReader := csv.NewReader(&buf)
+		tsvReader.Comma = '\t'
+		tsvReader.FieldsPerRecord = numExpectedFields
+		tsvReader.TrimLeadingSpace = true
+		lines, err := tsvReader.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lines) != len(expected) {
+			t.Fatalf("wrong number of lines; got %d, expected %d", len(lines), len(expected))
+		}
+		for i, line := range lines {
+			for j := range numExpectedFields {
+				got := line[j]
+				exp := expected[i][j]
+				if got != exp {
+					t.Errorf("got %q, expected %q", got, exp)
+				}
+			}
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := errWriter{writeFn: func(p []byte) (int, error) { return len(p), errors.New("test") }}
+		if err := internal.NewProblemTSV(&w, slog.Default()).PrintProblems(problems); err != nil {
+			t.Fatal("function should try to print as much as it can without erroring out")
+		}
+	})
+}
+
+func TestProblemJSON(t *testing.T) {
+	// problem is copied from the function under test.
+	type problem struct {
+		I        int    `json:"i"`
+		Severity string `json:"severity"`
+		Problem  string `json:"problem"`
+		Version  string `json:"version"`
+		Filename string `json:"filename"`
+		Detail   string `json:"detail"`
+	}
+
+	problems := makeProblems()
+
+	t.Run("ok", func(t *testing.T) {
+		expected := []problem{
+			{I: 0, Severity: "error", Problem: "unparseable-name", Filename: "notes.txt", Detail: "bad name"},
+			{I: 1, Severity: "warning", Problem: "no-reverse", Version: "1000", Filename: "forward-1000-alfa.sql"},
+			{I: 2, Severity: "error", Problem: "empty-file", Version: "2000", Filename: "reverse-2000-bravo.sql"},
+		}
+
+		var buf bytes.Buffer
+		if err := internal.NewProblemJSON(&buf, slog.Default()).PrintProblems(problems); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range expected {
+			line, ierr := buf.ReadBytes('\n')
+			if ierr != nil {
+				t.Fatal(ierr)
+			}
+
+			var got problem
+			if err := json.Unmarshal(line, &got); err != nil {
+				t.Fatal(err)
+			}
+			exp := expected[i]
+			if got != exp {
+				t.Errorf("item[%d] incorrect\ngot:      %#v\nexpected: %#v", i, got, exp)
+			}
+		}
+
+		// should be no more data remaining.
+		if _, err := buf.ReadBytes('\n'); err != io.EOF {
+			t.Errorf("wrong error; got %v, expected %v", err, io.EOF)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := errWriter{writeFn: func(p []byte) (int, error) { return len(p), errors.New("test") }}
+		if err := internal.NewProblemJSON(&w, slog.Default()).PrintProblems(problems); err != nil {
+			t.Fatal("function should try to print as much as it can without erroring out")
+		}
+	})
+}
+
+func makeProblems() []internal.Problem {
+	return []internal.Problem{
+		{Kind: internal.ProblemUnparseableName, Filename: "notes.txt", Detail: "bad name"},
+		{Kind: internal.ProblemNoReverse, Filename: "forward-1000-alfa.sql", Version: "1000"},
+		{Kind: internal.ProblemEmptyFile, Filename: "reverse-2000-bravo.sql", Version: "2000"},
+	}
+}
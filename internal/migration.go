@@ -2,7 +2,9 @@ package internal
 
 import (
 	"cmp"
-	"fmt"
+	"context"
+	"errors"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -21,48 +23,38 @@ type Migration struct {
 	Applied     bool
 	ExecutedAt  time.Time
 	Filename    string // the file basename with an extension.
+	Checksum    string // hash of the file contents, see [Checksum].
+	Batch       int64  // identifies the run that applied the migration.
+	Directives  Directives
+	// Func is set when the migration is a Go function rather than a file.
+	Func func(ctx context.Context, conn any) error
 }
 
-// ParseMigration constructs a Migration from a Filename.
+// ParseMigration constructs a Migration from a Filename, with a version in
+// the TimestampScheme.
 func ParseMigration(name Filename) (mig *Migration, err error) {
-	basename := filepath.Base(string(name))
-	indirection := parseIndirection(basename)
-	if indirection.Value == DirUnknown {
-		err = fmt.Errorf(
-			"%w; could not parse Direction for filename %q",
-			ErrDataInvalid, name,
-		)
-		return
-	}
-
-	// index of the start of timestamp
-	i := len(indirection.Label) + len(filenameDelimeter)
-	version, err := ParseVersion(basename)
-	if err != nil {
-		err = fmt.Errorf(
-			"%w, could not parse version for filename %q; %v",
-			ErrDataInvalid, version, err,
-		)
-		return
-	}
+	return ParseMigrationWith(name, TimestampScheme)
+}
 
-	var label string
-	// index of the start of migration label
-	j := i + len(version.String()) + len(filenameDelimeter)
-	if j < len(basename) {
-		tail := string(basename[j:])
-		ext := filepath.Ext(tail)
-		label = strings.TrimSuffix(tail, ext)
-	}
+// ParseMigrationWith constructs a Migration from a Filename in the
+// DefaultConvention, with a version in the scheme.
+func ParseMigrationWith(name Filename, scheme VersionScheme) (mig *Migration, err error) {
+	return DefaultConvention.ParseMigration(name, scheme)
+}
 
-	mig = &Migration{
-		Indirection: indirection,
-		Label:       label,
-		Version:     version,
+// DisplayName is the Filename field, or a marker in its place when the
+// migration is a Go function.
+func (m *Migration) DisplayName() string {
+	if m.Func != nil {
+		return GoFuncMarker
 	}
-	return
+	return m.Filename
 }
 
+// GoFuncMarker is displayed in place of a filename for a migration that is a
+// Go function.
+const GoFuncMarker = "(go func)"
+
 // ToFilename converts a Migration to a Filename.
 func (m *Migration) ToFilename() Filename {
 	return MakeFilename(
@@ -88,6 +80,9 @@ func (m *Migration) LogValue() slog.Value {
 		slog.Bool("applied", m.Applied),
 		slog.String("executed_at", executedAt),
 		slog.String("filename", m.Filename),
+		slog.String("checksum", m.Checksum),
+		slog.Bool("go_func", m.Func != nil),
+		slog.String("directives", m.Directives.String()),
 	)
 }
 
@@ -106,17 +101,25 @@ func (m Migrations) LogValue() slog.Value {
 // MigrationParams collects inputs needed to generate migration files. Setting
 // Reversible to true will generate a migration file for each direction.
 // Otherwise, it only generates a file in the forward direction. The Directory
-// field refers to the path to the directory with the migration files.
+// field refers to the path to the directory with the migration files. Setting
+// SingleFile to true will generate one forward file, with a section for each
+// direction.
 type MigrationParams struct {
 	Forward           Migration
 	Reverse           Migration
 	Reversible        bool
+	SingleFile        bool
 	Dirpath           string
 	FilenameExtension string
+	Convention        NamingConvention
+	Logger            *slog.Logger // nil is the default logger.
 }
 
-// NewMigrationParams constructs a MigrationParams that's ready to use.
-func NewMigrationParams(name string, reversible bool, dirpath, fwdLabel, revLabel, filenameExt string) (out *MigrationParams, err error) {
+// NewMigrationParams constructs a MigrationParams that's ready to use. The
+// version is the next one in the scheme, after the latest version of the
+// migration files at dirpath. A nil scheme is the TimestampScheme, and a nil
+// convention is the DefaultConvention.
+func NewMigrationParams(name string, reversible bool, dirpath, fwdLabel, revLabel, filenameExt string, scheme VersionScheme, convention NamingConvention) (out *MigrationParams, err error) {
 	fwdLabel = cmp.Or(fwdLabel, ForwardDirections[0])
 	if err = ValidateForwardDirectionLabel(fwdLabel); err != nil {
 		return
@@ -127,8 +130,16 @@ func NewMigrationParams(name string, reversible bool, dirpath, fwdLabel, revLabe
 		return
 	}
 
-	now := time.Now().UTC()
-	version := timestamp{value: now.Unix(), label: now.Format(TimeFormat)}
+	scheme = cmp.Or(scheme, TimestampScheme)
+	convention = cmp.Or(convention, DefaultConvention)
+	latest, err := latestVersion(dirpath, scheme, convention)
+	if err != nil {
+		return
+	}
+	version, err := scheme.NextVersion(latest, time.Now())
+	if err != nil {
+		return
+	}
 
 	out = &MigrationParams{
 		Reversible: reversible,
@@ -136,49 +147,84 @@ func NewMigrationParams(name string, reversible bool, dirpath, fwdLabel, revLabe
 		Forward: Migration{
 			Indirection: Indirection{Value: DirForward, Label: fwdLabel},
 			Label:       name,
-			Version:     &version,
+			Version:     version,
 		},
 		Reverse: Migration{
 			Indirection: Indirection{Value: DirReverse, Label: revLabel},
 			Label:       name,
-			Version:     &version,
+			Version:     version,
 		},
 		FilenameExtension: filenameExt,
+		Convention:        convention,
+	}
+	return
+}
+
+// latestVersion finds the greatest version of the migration files at dirpath.
+// It's nil if there are none, or if dirpath does not exist.
+func latestVersion(dirpath string, scheme VersionScheme, convention NamingConvention) (latest Version, err error) {
+	entries, err := os.ReadDir(dirpath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		mig, perr := convention.ParseMigration(Filename(entry.Name()), scheme)
+		if perr != nil {
+			continue
+		}
+		if latest == nil || latest.Before(mig.Version) {
+			latest = mig.Version
+		}
 	}
 	return
 }
 
 // GenerateFiles creates the migration files. If the migration is reversible it
 // generates files in forward and reverse directions; otherwise it generates
-// just one migration file in the forward direction. It closes each file handle
-// when it's done.
+// just one migration file in the forward direction. A single-file migration
+// is always one file in the forward direction, with a down section if it's
+// reversible. It closes each file handle when it's done.
 func (m *MigrationParams) GenerateFiles() (err error) {
 	var forwardFile, reverseFile *os.File
 
-	if forwardFile, err = newMigrationFile(m.Forward, m.Dirpath, m.FilenameExtension); err != nil {
+	convention := cmp.Or(m.Convention, DefaultConvention)
+	lgr := cmp.Or(m.Logger, slog.Default())
+	if forwardFile, err = newMigrationFile(m.Forward, convention, m.Dirpath, m.FilenameExtension); err != nil {
 		return
 	}
 
-	slog.Info("created forward file", slog.String("filename", forwardFile.Name()))
+	lgr.Info("created forward file", slog.String("filename", forwardFile.Name()))
 	defer func() { _ = forwardFile.Close() }()
 
+	if m.SingleFile {
+		_, err = forwardFile.WriteString(SingleFileTemplate(m.Reversible))
+		lgr.Info("migration is a single file, did not create reverse file")
+		return
+	}
+
 	if !m.Reversible {
-		slog.Info("migration marked irreversible, did not create reverse file")
+		lgr.Info("migration marked irreversible, did not create reverse file")
 		return
 	}
 
-	if reverseFile, err = newMigrationFile(m.Reverse, m.Dirpath, m.FilenameExtension); err != nil {
+	if reverseFile, err = newMigrationFile(m.Reverse, convention, m.Dirpath, m.FilenameExtension); err != nil {
 		return
 	}
-	slog.Info("created reverse file", slog.String("filename", reverseFile.Name()))
+	lgr.Info("created reverse file", slog.String("filename", reverseFile.Name()))
 	defer func() { _ = reverseFile.Close() }()
 	return
 }
 
-func newMigrationFile(m Migration, baseDir string, ext string) (*os.File, error) {
+func newMigrationFile(m Migration, convention NamingConvention, baseDir string, ext string) (*os.File, error) {
 	if len(ext) > 0 && !strings.HasPrefix(ext, ".") {
 		ext = "." + ext
 	}
-	name := filepath.Join(baseDir, string(m.ToFilename())+ext)
+	filename := convention.MakeFilename(m.Version.String(), m.Indirection, m.Label)
+	name := filepath.Join(baseDir, string(filename)+ext)
 	return os.Create(filepath.Clean(name))
 }
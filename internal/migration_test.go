@@ -208,6 +208,45 @@ func TestParseMigration(t *testing.T) {
 	})
 }
 
+func TestParseMigrationWith(t *testing.T) {
+	tests := []struct {
+		filename   internal.Filename
+		scheme     internal.VersionScheme
+		expVersion string
+		expLabel   string
+		expErr     bool
+	}{
+		{filename: "forward-0007-add_users.sql", scheme: internal.SequentialScheme, expVersion: "0007", expLabel: "add_users"},
+		{filename: "down-12-add-users.sql", scheme: internal.SequentialScheme, expVersion: "12", expLabel: "add-users"},
+		{filename: "forward-V1.2.3-add_users.sql", scheme: internal.DottedScheme, expVersion: "V1.2.3", expLabel: "add_users"},
+		{filename: "reverse-1.2-add_users.sql", scheme: internal.DottedScheme, expVersion: "1.2", expLabel: "add_users"},
+		{filename: "forward-20191118121314-test.sql", scheme: internal.TimestampScheme, expVersion: "20191118121314", expLabel: "test"},
+		{filename: "forward-add_users.sql", scheme: internal.SequentialScheme, expErr: true},
+		{filename: "forward-add_users.sql", scheme: internal.DottedScheme, expErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.filename), func(t *testing.T) {
+			got, err := internal.ParseMigrationWith(test.filename, test.scheme)
+			if test.expErr {
+				if !internal.IsInvalidDataError(err) {
+					t.Fatalf("expected error %v to be an invalid data error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Version.String() != test.expVersion {
+				t.Errorf("wrong Version; got %q, expected %q", got.Version.String(), test.expVersion)
+			}
+			if got.Label != test.expLabel {
+				t.Errorf("wrong Label; got %q, expected %q", got.Label, test.expLabel)
+			}
+		})
+	}
+}
+
 func TestMigrationParams(t *testing.T) {
 	type testCase struct {
 		name               string
@@ -227,7 +266,7 @@ func TestMigrationParams(t *testing.T) {
 		)
 
 		// construct params and test the fields.
-		migParams, err = internal.NewMigrationParams(test.name, test.reversible, test.dirpath, test.fwdLabel, test.revLabel, test.filenameExt)
+		migParams, err = internal.NewMigrationParams(test.name, test.reversible, test.dirpath, test.fwdLabel, test.revLabel, test.filenameExt, nil, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// LineColumn locates the byte offset in data. The line and the column start
+// at 1, and the column is in characters. The text is the whole line, without
+// the line ending.
+func LineColumn(data []byte, offset int) (line, column int, text string) {
+	offset = min(max(offset, 0), len(data))
+	start := bytes.LastIndexByte(data[:offset], '\n') + 1
+	end := len(data)
+	if ind := bytes.IndexByte(data[offset:], '\n'); ind >= 0 {
+		end = offset + ind
+	}
+
+	line = bytes.Count(data[:start], []byte("\n")) + 1
+	column = utf8.RuneCount(data[start:offset]) + 1
+	text = string(bytes.TrimRight(data[start:end], "\r"))
+	return
+}
@@ -0,0 +1,33 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func TestLineColumn(t *testing.T) {
+	const data = "CREATE TABLE foos (id int);\r\n\n  SELEC 'é', 1;"
+	tests := []struct {
+		offset    int
+		expLine   int
+		expColumn int
+		expText   string
+	}{
+		{offset: 0, expLine: 1, expColumn: 1, expText: "CREATE TABLE foos (id int);"},
+		{offset: 13, expLine: 1, expColumn: 14, expText: "CREATE TABLE foos (id int);"},
+		{offset: 29, expLine: 2, expColumn: 1, expText: ""},
+		{offset: 32, expLine: 3, expColumn: 3, expText: "  SELEC 'é', 1;"},
+		{offset: 42, expLine: 3, expColumn: 12, expText: "  SELEC 'é', 1;"},
+		{offset: 100, expLine: 3, expColumn: 16, expText: "  SELEC 'é', 1;"},
+	}
+	for _, test := range tests {
+		line, column, text := internal.LineColumn([]byte(data), test.offset)
+		if line != test.expLine || column != test.expColumn || text != test.expText {
+			t.Errorf(
+				"offset %d; got (%d, %d, %q), expected (%d, %d, %q)",
+				test.offset, line, column, text, test.expLine, test.expColumn, test.expText,
+			)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"log/slog"
+	"time"
+)
+
+// A Repeatable is a migration without a version, such as the definition of a
+// view or a function. It runs after the versioned forward migrations, whenever
+// its checksum differs from the one of its last recorded run.
+type Repeatable struct {
+	Label      string
+	Filename   string // the file basename with an extension.
+	Checksum   string // hash of the file contents, see [Checksum].
+	Directives Directives
+	Batch      int64 // identifies the run that applied the migration.
+	// Applied is true when a run of the migration has been recorded.
+	Applied bool
+	// AppliedChecksum is the checksum recorded along with the last run.
+	AppliedChecksum string
+	ExecutedAt      time.Time
+}
+
+// Pending reports whether or not the repeatable migration should run, because
+// it has never run, or its contents changed since its last run.
+func (r *Repeatable) Pending() bool {
+	return !r.Applied || r.Checksum != r.AppliedChecksum
+}
+
+// LogValue lets *Repeatable implement the [log/slog.Valuer] interface.
+func (r *Repeatable) LogValue() slog.Value {
+	var executedAt string
+	if x := r.ExecutedAt; !x.IsZero() {
+		executedAt = x.UTC().Format(time.RFC3339)
+	}
+	return slog.GroupValue(
+		slog.String("label", r.Label),
+		slog.String("filename", r.Filename),
+		slog.String("checksum", r.Checksum),
+		slog.Bool("applied", r.Applied),
+		slog.String("applied_checksum", r.AppliedChecksum),
+		slog.String("executed_at", executedAt),
+		slog.String("directives", r.Directives.String()),
+	)
+}
+
+// repeatableTableSuffix is appended to the name of the schema migrations table
+// to name the table for repeatable migrations.
+const repeatableTableSuffix = "_repeatable"
+
+// RepeatableTable names the table that records the runs of repeatable
+// migrations. It's separate from migrationsTable, so that repeatable
+// migrations stay out of the order of versions. Its layout is the same as the
+// schema migrations table, where the migration_id is the label.
+func RepeatableTable(migrationsTable string) string {
+	return migrationsTable + repeatableTableSuffix
+}
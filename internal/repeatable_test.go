@@ -0,0 +1,65 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func TestRepeatable(t *testing.T) {
+	t.Run("Pending", func(t *testing.T) {
+		tests := []struct {
+			name string
+			in   internal.Repeatable
+			exp  bool
+		}{
+			{name: "never run", in: internal.Repeatable{Checksum: "abc"}, exp: true},
+			{name: "changed", in: internal.Repeatable{Checksum: "abc", Applied: true, AppliedChecksum: "def"}, exp: true},
+			{name: "unchanged", in: internal.Repeatable{Checksum: "abc", Applied: true, AppliedChecksum: "abc"}, exp: false},
+		}
+		for _, test := range tests {
+			if got := test.in.Pending(); got != test.exp {
+				t.Errorf("%s; got %t, expected %t", test.name, got, test.exp)
+			}
+		}
+	})
+
+	t.Run("RepeatableTable", func(t *testing.T) {
+		if got := internal.RepeatableTable("public.schema_migrations"); got != "public.schema_migrations_repeatable" {
+			t.Errorf("wrong table name %q", got)
+		}
+	})
+
+	t.Run("ParseRepeatable", func(t *testing.T) {
+		tests := []struct {
+			convention internal.NamingConvention
+			filename   internal.Filename
+			expLabel   string
+			expErr     bool
+		}{
+			{convention: internal.DefaultConvention, filename: "repeatable-foos_view.sql", expLabel: "foos_view"},
+			{convention: internal.DefaultConvention, filename: "repeatable-.sql", expErr: true},
+			{convention: internal.DefaultConvention, filename: "forward-1234-foos.sql", expErr: true},
+			{convention: internal.FlywayConvention, filename: "R__foos_view.sql", expLabel: "foos_view"},
+			{convention: internal.FlywayConvention, filename: "repeatable-foos_view.sql", expErr: true},
+			{convention: internal.FlywayConvention, filename: "V1__foos.sql", expErr: true},
+		}
+		for _, test := range tests {
+			t.Run(test.convention.Name()+" "+string(test.filename), func(t *testing.T) {
+				got, err := test.convention.ParseRepeatable(test.filename)
+				if test.expErr {
+					if !internal.IsInvalidDataError(err) {
+						t.Fatalf("expected error %v to be an invalid data error", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != test.expLabel {
+					t.Errorf("wrong label; got %q, expected %q", got, test.expLabel)
+				}
+			})
+		}
+	})
+}
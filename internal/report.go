@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// ReportItem is the outcome of one migration in a run.
+type ReportItem struct {
+	Direction  string
+	Version    string
+	Label      string
+	Filename   string
+	Repeatable bool
+	Outcome    string
+	Duration   time.Duration
+	Err        error
+}
+
+// ReportPrinter outputs the outcome of each migration in a run.
+type ReportPrinter interface {
+	PrintReport([]ReportItem) error
+}
+
+// NewReportTSV constructs a ReportPrinter to write out tab separated values.
+func NewReportTSV(w io.Writer, lgr *slog.Logger) ReportPrinter {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	return &tsvPrinter{tw, lgr}
+}
+
+// NewReportJSON constructs a ReportPrinter to write out JSON.
+func NewReportJSON(w io.Writer, lgr *slog.Logger) ReportPrinter {
+	enc := json.NewEncoder(w)
+	return &jsonPrinter{enc, lgr}
+}
+
+func (p *tsvPrinter) PrintReport(in []ReportItem) error {
+	const format = "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s"
+
+	// headers
+	_, err := fmt.Fprintf(p.tw, format+"\n", "i", "direction", "version", "label", "outcome", "duration_ms", "filename", "error")
+	if err != nil {
+		p.lgr.Error("internal: printing TSV headers", slog.Any("error", err))
+	}
+
+	// body
+	for i, item := range in {
+		errMsg := "-"
+		if item.Err != nil {
+			errMsg = strconv.Quote(item.Err.Error())
+		}
+		_, err = fmt.Fprintf(
+			p.tw,
+			format+"\n",
+			strconv.Itoa(i), item.Direction, cmp.Or(item.Version, "-"), cmp.Or(item.Label, "-"), item.Outcome,
+			strconv.FormatInt(item.Duration.Milliseconds(), 10), cmp.Or(item.Filename, "-"), errMsg,
+		)
+		if err != nil {
+			p.lgr.Error(
+				"internal: printing TSV body",
+				slog.Any("error", err), slog.String("version", item.Version), slog.String("outcome", item.Outcome),
+			)
+		}
+	}
+	if err = p.tw.Flush(); err != nil {
+		p.lgr.Error("internal: flushing TSV", slog.Any("error", err))
+	}
+	return nil
+}
+
+func (p *jsonPrinter) PrintReport(in []ReportItem) error {
+	type item struct {
+		I          int    `json:"i"`
+		Direction  string `json:"direction"`
+		Version    string `json:"version"`
+		Label      string `json:"label"`
+		Filename   string `json:"filename"`
+		Repeatable bool   `json:"repeatable"`
+		Outcome    string `json:"outcome"`
+		DurationMS int64  `json:"duration_ms"`
+		Error      string `json:"error,omitempty"`
+	}
+
+	for i, it := range in {
+		out := item{
+			I:          i,
+			Direction:  it.Direction,
+			Version:    it.Version,
+			Label:      it.Label,
+			Filename:   it.Filename,
+			Repeatable: it.Repeatable,
+			Outcome:    it.Outcome,
+			DurationMS: it.Duration.Milliseconds(),
+		}
+		if it.Err != nil {
+			out.Error = it.Err.Error()
+		}
+		if err := p.enc.Encode(out); err != nil {
+			p.lgr.Error(
+				"internal: printing JSON item",
+				slog.Any("error", err), slog.String("version", it.Version), slog.String("outcome", it.Outcome),
+			)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func TestReportPrinter(t *testing.T) {
+	items := []internal.ReportItem{
+		{Direction: "forward", Version: "1234", Label: "alpha", Filename: "forward-1234-alpha.sql", Outcome: "applied", Duration: 1500 * time.Millisecond},
+		{Direction: "forward", Version: "2345", Label: "bravo", Filename: "forward-2345-bravo.sql", Outcome: "failed", Err: errors.New("test")},
+		{Direction: "forward", Label: "foos_view", Filename: "repeatable-foos_view.sql", Repeatable: true, Outcome: "skipped"},
+	}
+
+	t.Run("tsv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := internal.NewReportTSV(&buf, slog.Default()).PrintReport(items); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("wrong number of lines, %d\n%s", len(lines), buf.String())
+		}
+		if fields := strings.Fields(lines[1]); !slices.Equal(fields, []string{"0", "forward", "1234", "alpha", "applied", "1500", "forward-1234-alpha.sql", "-"}) {
+			t.Errorf("wrong fields %q", fields)
+		}
+		if fields := strings.Fields(lines[3]); !slices.Equal(fields, []string{"2", "forward", "-", "foos_view", "skipped", "0", "repeatable-foos_view.sql", "-"}) {
+			t.Errorf("wrong fields %q", fields)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := internal.NewReportJSON(&buf, slog.Default()).PrintReport(items); err != nil {
+			t.Fatal(err)
+		}
+		dec := json.NewDecoder(&buf)
+		var got []map[string]any
+		for dec.More() {
+			var item map[string]any
+			if err := dec.Decode(&item); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, item)
+		}
+		if len(got) != 3 {
+			t.Fatalf("wrong number of items, %d", len(got))
+		}
+		if got[0]["outcome"] != "applied" || got[0]["duration_ms"] != float64(1500) || got[0]["error"] != nil {
+			t.Errorf("wrong item %v", got[0])
+		}
+		if got[1]["outcome"] != "failed" || got[1]["error"] != "test" {
+			t.Errorf("wrong item %v", got[1])
+		}
+		if got[2]["repeatable"] != true || got[2]["version"] != "" {
+			t.Errorf("wrong item %v", got[2])
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ScriptWriter outputs SQL comments and statements as one script. After the
+// first write error, subsequent writes are skipped and Err returns the error.
+type ScriptWriter struct {
+	w   io.Writer
+	err error
+}
+
+// NewScriptWriter constructs a ScriptWriter to write to w.
+func NewScriptWriter(w io.Writer) *ScriptWriter { return &ScriptWriter{w: w} }
+
+// Comment writes each line as a SQL line comment.
+func (s *ScriptWriter) Comment(lines ...string) {
+	for _, line := range lines {
+		s.printf("-- %s\n", line)
+	}
+}
+
+// Newline writes an empty line.
+func (s *ScriptWriter) Newline() { s.printf("\n") }
+
+// Statement writes stmt followed by a blank line. A terminating semicolon is
+// added unless stmt already ends with one. A blank stmt is skipped.
+func (s *ScriptWriter) Statement(stmt string) {
+	stmt = strings.TrimSpace(stmt)
+	if stmt == "" {
+		return
+	}
+	if !strings.HasSuffix(stmt, ";") {
+		// Avoid appending the terminator to a trailing line comment.
+		if lastLine := stmt[strings.LastIndex(stmt, "\n")+1:]; strings.Contains(lastLine, "--") {
+			stmt += "\n"
+		}
+		stmt += ";"
+	}
+	s.printf("%s\n\n", stmt)
+}
+
+// Err returns the first error encountered while writing, if any.
+func (s *ScriptWriter) Err() error { return s.err }
+
+func (s *ScriptWriter) printf(format string, args ...any) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = fmt.Fprintf(s.w, format, args...)
+}
@@ -0,0 +1,59 @@
+package internal_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func TestScriptWriter(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		var buf bytes.Buffer
+		script := internal.NewScriptWriter(&buf)
+		script.Comment("alfa", "bravo")
+		script.Newline()
+		script.Statement("CREATE TABLE foos (id int);\n")
+		script.Statement("  INSERT INTO foos (id) VALUES (1)  ")
+		script.Statement("DELETE FROM foos\n-- trailing comment")
+		script.Statement(" \n\t")
+		if err := script.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		const expected = `-- alfa
+-- bravo
+
+CREATE TABLE foos (id int);
+
+INSERT INTO foos (id) VALUES (1);
+
+DELETE FROM foos
+-- trailing comment
+;
+
+`
+		if got := buf.String(); got != expected {
+			t.Errorf("wrong output\ngot:\n%s\nexpected:\n%s", got, expected)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var calls int
+		oof := errors.New("oof")
+		w := errWriter{writeFn: func(p []byte) (int, error) {
+			calls++
+			return 0, oof
+		}}
+		script := internal.NewScriptWriter(&w)
+		script.Comment("alfa", "bravo")
+		script.Statement("SELECT 1")
+		if err := script.Err(); !errors.Is(err, oof) {
+			t.Errorf("expected error (%v) to be %v", err, oof)
+		}
+		if calls != 1 {
+			t.Errorf("expected writes to stop after first error; got %d calls", calls)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ListFiles returns the paths, relative to the root of fsys, of the files that
+// may be migrations. Hidden files and directories, whose names begin with a
+// dot, are left out. When recursive is true, then the files in every
+// subdirectory are included. Otherwise, subdirectories are skipped, and each
+// one is logged with lgr.
+func ListFiles(fsys fs.FS, recursive bool, lgr *slog.Logger) (out []string, err error) {
+	err = fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			if recursive {
+				return nil
+			}
+			lgr.Info("searching for available migrations and found directory, skipping", slog.String("path", name))
+			return fs.SkipDir
+		}
+		out = append(out, name)
+		return nil
+	})
+	return
+}
+
+// CachedFS is a file system whose directory entries are read once, and then
+// reused until the cache is cleared. The contents of files are not cached.
+// It's safe for concurrent use.
+type CachedFS struct {
+	fsys fs.FS
+	mu   sync.Mutex
+	dirs map[string][]fs.DirEntry
+}
+
+// NewCachedFS wraps fsys in a CachedFS.
+func NewCachedFS(fsys fs.FS) *CachedFS {
+	return &CachedFS{fsys: fsys, dirs: make(map[string][]fs.DirEntry)}
+}
+
+func (c *CachedFS) Open(name string) (fs.File, error) { return c.fsys.Open(name) }
+
+// ReadDir reads the named directory from the cache, or from the wrapped file
+// system if it's not there yet. Errors are not cached.
+func (c *CachedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, found := c.dirs[name]
+	if !found {
+		var err error
+		if entries, err = fs.ReadDir(c.fsys, name); err != nil {
+			return nil, err
+		}
+		c.dirs[name] = entries
+	}
+	return slices.Clone(entries), nil
+}
+
+// Clear drops the cached directory entries, so that they are read again.
+func (c *CachedFS) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.dirs)
+}
+
+// MergeFS combines several file systems into one. A directory in the merged
+// file system has the entries of the directories at the same path in each
+// one. It's an [ErrDataInvalid] to read a directory where the same file is
+// in more than one of them.
+func MergeFS(fsyss ...fs.FS) fs.FS { return mergedFS(fsyss) }
+
+type mergedFS []fs.FS
+
+func (m mergedFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, fsys := range m {
+		file, err := fs.Stat(fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if file.IsDir() {
+			return &mergedDir{fsys: m, name: name, info: file}, nil
+		}
+		return fsys.Open(name)
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m mergedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var out []fs.DirEntry
+	var found bool
+	seen := make(map[string]bool)
+	for _, fsys := range m {
+		entries, err := fs.ReadDir(fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		found = true
+
+		for _, entry := range entries {
+			isDir, ok := seen[entry.Name()]
+			if !ok {
+				seen[entry.Name()] = entry.IsDir()
+				out = append(out, entry)
+				continue
+			}
+			if !isDir || !entry.IsDir() {
+				return nil, fmt.Errorf(
+					"%w; file %q is in more than one directory",
+					ErrDataInvalid, path.Join(name, entry.Name()),
+				)
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	slices.SortFunc(out, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return out, nil
+}
+
+// mergedDir is a directory opened from a mergedFS.
+type mergedDir struct {
+	fsys    mergedFS
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+	read    bool
+}
+
+func (d *mergedDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *mergedDir) Close() error               { return nil }
+
+func (d *mergedDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *mergedDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fsys.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.read = entries, true
+	}
+
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) < 1 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return rest[:n], nil
+}
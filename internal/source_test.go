@@ -0,0 +1,120 @@
+package internal_test
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func TestListFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"forward-1-a.sql":      &fstest.MapFile{},
+		".hidden.sql":          &fstest.MapFile{},
+		"2024/forward-2-b.sql": &fstest.MapFile{},
+		"2024/deep/c.sql":      &fstest.MapFile{},
+		".git/forward-3-c.sql": &fstest.MapFile{},
+	}
+
+	tests := []struct {
+		recursive bool
+		exp       []string
+	}{
+		{recursive: false, exp: []string{"forward-1-a.sql"}},
+		{recursive: true, exp: []string{"2024/deep/c.sql", "2024/forward-2-b.sql", "forward-1-a.sql"}},
+	}
+	for _, test := range tests {
+		got, err := internal.ListFiles(fsys, test.recursive, slog.Default())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, test.exp) {
+			t.Errorf("recursive %t; wrong files\ngot:      %q\nexpected: %q", test.recursive, got, test.exp)
+		}
+	}
+}
+
+func TestMergeFS(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		fsys := internal.MergeFS(
+			fstest.MapFS{
+				"b.sql":     &fstest.MapFile{Data: []byte("b")},
+				"sub/c.sql": &fstest.MapFile{Data: []byte("c")},
+			},
+			fstest.MapFS{
+				"a.sql":     &fstest.MapFile{Data: []byte("a")},
+				"sub/d.sql": &fstest.MapFile{Data: []byte("d")},
+			},
+		)
+		if err := fstest.TestFS(fsys, "a.sql", "b.sql", "sub/c.sql", "sub/d.sql"); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := internal.ListFiles(fsys, true, slog.Default())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := []string{"a.sql", "b.sql", "sub/c.sql", "sub/d.sql"}; !slices.Equal(got, exp) {
+			t.Errorf("wrong files\ngot:      %q\nexpected: %q", got, exp)
+		}
+		if data, err := fs.ReadFile(fsys, "sub/d.sql"); err != nil {
+			t.Fatal(err)
+		} else if string(data) != "d" {
+			t.Errorf("wrong file contents %q", data)
+		}
+	})
+
+	t.Run("error - same file in more than one", func(t *testing.T) {
+		fsys := internal.MergeFS(
+			fstest.MapFS{"a.sql": &fstest.MapFile{}},
+			fstest.MapFS{"a.sql": &fstest.MapFile{}},
+		)
+		_, err := fs.ReadDir(fsys, ".")
+		if !internal.IsInvalidDataError(err) {
+			t.Errorf("expected an %v, got %v", internal.ErrDataInvalid, err)
+		}
+	})
+
+	t.Run("error - not found", func(t *testing.T) {
+		fsys := internal.MergeFS(fstest.MapFS{"a.sql": &fstest.MapFile{}})
+		if _, err := fsys.Open("b.sql"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected %v, got %v", fs.ErrNotExist, err)
+		}
+	})
+}
+
+func TestCachedFS(t *testing.T) {
+	fsys := fstest.MapFS{"a.sql": &fstest.MapFile{Data: []byte("a")}}
+	cached := internal.NewCachedFS(fsys)
+
+	list := func(t *testing.T) []string {
+		t.Helper()
+		got, err := internal.ListFiles(cached, false, slog.Default())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	if got := list(t); !slices.Equal(got, []string{"a.sql"}) {
+		t.Fatalf("wrong files %q", got)
+	}
+
+	fsys["b.sql"] = &fstest.MapFile{Data: []byte("b")}
+	if got := list(t); !slices.Equal(got, []string{"a.sql"}) {
+		t.Errorf("expected the cached listing, got %q", got)
+	}
+	if data, err := fs.ReadFile(cached, "b.sql"); err != nil {
+		t.Fatal(err)
+	} else if string(data) != "b" {
+		t.Errorf("wrong file contents %q", data)
+	}
+
+	cached.Clear()
+	if got := list(t); !slices.Equal(got, []string{"a.sql", "b.sql"}) {
+		t.Errorf("expected a new listing after Clear, got %q", got)
+	}
+}
@@ -28,9 +28,12 @@ func (r *appliedVersions) Close() error {
 
 func (r *appliedVersions) Next() bool { return r.counter < len(r.versions) }
 
+// Scan expects the fields of a row in the order described by
+// [driver.AppliedVersions]. There are 3 of them, or 5 when scanning for a
+// [driver.MetadataRecorder].
 func (r *appliedVersions) Scan(dest ...any) (err error) {
-	if len(dest) != 3 {
-		err = fmt.Errorf("expected 3 args, got %d", len(dest))
+	if len(dest) != 3 && len(dest) != 5 {
+		err = fmt.Errorf("expected 3 or 5 args, got %d", len(dest))
 		return
 	}
 	if !r.Next() {
@@ -66,5 +69,27 @@ func (r *appliedVersions) Scan(dest ...any) (err error) {
 		return fmt.Errorf("unexpected type (got %T) for %q field", val, "executed_at")
 	}
 
+	if len(dest) < 5 {
+		return nil
+	}
+
+	switch val := dest[3].(type) {
+	case *string:
+		if val != nil {
+			*val = curr.Checksum
+		}
+	default:
+		return fmt.Errorf("unexpected type (got %T) for %q field", val, "checksum")
+	}
+
+	switch val := dest[4].(type) {
+	case *int64:
+		if val != nil {
+			*val = curr.Batch
+		}
+	default:
+		return fmt.Errorf("unexpected type (got %T) for %q field", val, "batch")
+	}
+
 	return nil
 }
@@ -64,3 +64,79 @@ func (d *Double) UpgradeSchemaMigrations(ctx context.Context, migrationsTable st
 	}
 	return d.UpgradeSchemaMigrationsFn(ctx, migrationsTable)
 }
+
+// Locker is a test double for a [driver.Driver] that is also a
+// [driver.Locker]. Like [Double], its lock methods panic when the
+// corresponding function field is unset.
+type Locker struct {
+	Double
+	LockFn   func(ctx context.Context, migrationsTable string) error
+	UnlockFn func(ctx context.Context, migrationsTable string) error
+}
+
+func (d *Locker) Lock(ctx context.Context, migrationsTable string) error {
+	if d.LockFn == nil {
+		panic("define LockFn")
+	}
+	return d.LockFn(ctx, migrationsTable)
+}
+
+func (d *Locker) Unlock(ctx context.Context, migrationsTable string) error {
+	if d.UnlockFn == nil {
+		panic("define UnlockFn")
+	}
+	return d.UnlockFn(ctx, migrationsTable)
+}
+
+// Recorder is a test double for a [driver.Driver] that is also a
+// [driver.MetadataRecorder]. Like [Double], its record method panics when the
+// corresponding function field is unset.
+type Recorder struct {
+	Double
+	RecordMigrationFn func(ctx context.Context, migrationsTable, version, label string, meta driver.Metadata) error
+}
+
+func (d *Recorder) RecordMigration(ctx context.Context, migrationsTable, version, label string, meta driver.Metadata) error {
+	if d.RecordMigrationFn == nil {
+		panic("define RecordMigrationFn")
+	}
+	return d.RecordMigrationFn(ctx, migrationsTable, version, label, meta)
+}
+
+// Transactor is a test double for a [driver.Driver] that is also a
+// [driver.Transactor]. Like [Double], its transaction method panics when the
+// corresponding function field is unset.
+type Transactor struct {
+	Double
+	WithinTransactionFn func(ctx context.Context, fn func(ctx context.Context, tx driver.Driver) error) error
+}
+
+func (d *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context, tx driver.Driver) error) error {
+	if d.WithinTransactionFn == nil {
+		panic("define WithinTransactionFn")
+	}
+	return d.WithinTransactionFn(ctx, fn)
+}
+
+// Scripter is a test double for a [driver.Driver] that is also a
+// [driver.Scripter]. Like [Double], its script methods panic when the
+// corresponding function field is unset.
+type Scripter struct {
+	Double
+	CreateSchemaMigrationsTableScriptFn func(migrationsTable string) (string, error)
+	UpdateSchemaMigrationsScriptFn      func(migrationsTable string, forward bool, version, label, checksum string, batch int64) (string, error)
+}
+
+func (d *Scripter) CreateSchemaMigrationsTableScript(migrationsTable string) (string, error) {
+	if d.CreateSchemaMigrationsTableScriptFn == nil {
+		panic("define CreateSchemaMigrationsTableScriptFn")
+	}
+	return d.CreateSchemaMigrationsTableScriptFn(migrationsTable)
+}
+
+func (d *Scripter) UpdateSchemaMigrationsScript(migrationsTable string, forward bool, version, label, checksum string, batch int64) (string, error) {
+	if d.UpdateSchemaMigrationsScriptFn == nil {
+		panic("define UpdateSchemaMigrationsScriptFn")
+	}
+	return d.UpdateSchemaMigrationsScriptFn(migrationsTable, forward, version, label, checksum, batch)
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Checksum calculates a hash of the contents of a migration file. The output
+// is a hex-encoded SHA-256 digest.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ChecksumStatus describes how the recorded checksum of an applied migration
+// compares to the current contents of its file.
+type ChecksumStatus string
+
+const (
+	// ChecksumOK means the file is unchanged since it was applied.
+	ChecksumOK ChecksumStatus = "ok"
+	// ChecksumModified means the file has changed since it was applied.
+	ChecksumModified ChecksumStatus = "modified"
+	// ChecksumMissing means no file could be found for the applied migration.
+	ChecksumMissing ChecksumStatus = "missing"
+	// ChecksumUnrecorded means the migration was applied before checksums
+	// were recorded, so there is nothing to compare.
+	ChecksumUnrecorded ChecksumStatus = "unrecorded"
+	// ChecksumSkipped means the migration is a Go function, so there is no
+	// file to compare.
+	ChecksumSkipped ChecksumStatus = "skipped"
+)
+
+// Drift is the result of verifying one applied migration. The recorded
+// checksum is on the Migration. The Actual field is the checksum of the
+// current file contents, and is empty when the file is missing.
+type Drift struct {
+	Migration *Migration
+	Status    ChecksumStatus
+	Actual    string
+}
+
+// DriftPrinter outputs the results of verifying applied migrations.
+type DriftPrinter interface {
+	PrintDrift([]Drift) error
+}
+
+// NewDriftTSV constructs a DriftPrinter to write out tab separated values.
+func NewDriftTSV(w io.Writer, lgr *slog.Logger) DriftPrinter {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	return &tsvPrinter{tw, lgr}
+}
+
+// NewDriftJSON constructs a DriftPrinter to write out JSON.
+func NewDriftJSON(w io.Writer, lgr *slog.Logger) DriftPrinter {
+	enc := json.NewEncoder(w)
+	return &jsonPrinter{enc, lgr}
+}
+
+func (p *tsvPrinter) PrintDrift(in []Drift) error {
+	const format = "%s\t%s\t%s\t%s\t%s\t%s\t%s"
+
+	// headers
+	_, err := fmt.Fprintf(p.tw, format+"\n", "i", "version", "status", "label", "filename", "recorded", "actual")
+	if err != nil {
+		p.lgr.Error("internal: printing TSV headers", slog.Any("error", err))
+	}
+
+	// body
+	for i, d := range in {
+		mig := d.Migration
+		_, err = fmt.Fprintf(
+			p.tw,
+			format+"\n",
+			strconv.Itoa(i), mig.Version.String(), string(d.Status),
+			cmp.Or(mig.Label, "-"), cmp.Or(mig.DisplayName(), "-"), cmp.Or(mig.Checksum, "-"), cmp.Or(d.Actual, "-"),
+		)
+		if err != nil {
+			p.lgr.Error(
+				"internal: printing TSV body",
+				slog.Any("error", err), slog.String("version", mig.Version.String()), slog.String("status", string(d.Status)),
+			)
+		}
+	}
+	if err = p.tw.Flush(); err != nil {
+		p.lgr.Error("internal: flushing TSV", slog.Any("error", err))
+	}
+	return nil
+}
+
+func (p *jsonPrinter) PrintDrift(in []Drift) error {
+	type drift struct {
+		I        int    `json:"i"`
+		Version  string `json:"version"`
+		Status   string `json:"status"`
+		Label    string `json:"label"`
+		Filename string `json:"filename"`
+		Recorded string `json:"recorded"`
+		Actual   string `json:"actual"`
+	}
+
+	for i, d := range in {
+		mig := d.Migration
+		err := p.enc.Encode(drift{
+			I:        i,
+			Version:  mig.Version.String(),
+			Status:   string(d.Status),
+			Label:    mig.Label,
+			Filename: mig.DisplayName(),
+			Recorded: mig.Checksum,
+			Actual:   d.Actual,
+		})
+		if err != nil {
+			p.lgr.Error(
+				"internal: printing JSON item",
+				slog.Any("error", err), slog.String("version", mig.Version.String()), slog.String("status", string(d.Status)),
+			)
+		}
+	}
+
+	return nil
+}
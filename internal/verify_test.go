@@ -0,0 +1,152 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+func TestChecksum(t *testing.T) {
+	a := internal.Checksum([]byte("CREATE TABLE foos (id INT);"))
+	b := internal.Checksum([]byte("CREATE TABLE foos (id INT);"))
+	c := internal.Checksum([]byte("CREATE TABLE foos (id BIGINT);"))
+
+	if a != b {
+		t.Errorf("expected same input to have same checksum; got %q, %q", a, b)
+	}
+	if a == c {
+		t.Errorf("expected different input to have different checksum; got %q", a)
+	}
+	if len(a) != 64 {
+		t.Errorf("wrong length; got %d, expected %d", len(a), 64)
+	}
+}
+
+func TestDriftTSV(t *testing.T) {
+	drifts := mustMakeDrifts(t)
+
+	t.Run("ok", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := internal.NewDriftTSV(&buf, slog.Default()).PrintDrift(drifts); err != nil {
+			t.Fatal(err)
+		}
+
+		const numExpectedFields = 7
+		expected := [][numExpectedFields]string{
+			{"i", "version", "status", "label", "filename", "recorded", "actual"},
+			{"0", "1000", "ok", "alfa", "forward-1000-alfa.sql", "aaa", "aaa"},
+			{"1", "2000", "modified", "bravo", "forward-2000-bravo.sql", "bbb", "xxx"},
+			{"2", "3000", "missing", "-", "-", "ccc", "-"},
+			{"3", "4000", "unrecorded", "delta", "forward-4000-delta.sql", "-", "ddd"},
+		}
+
+		tsvReader := csv.NewReader(&buf)
+		tsvReader.Comma = '\t'
+		tsvReader.FieldsPerRecord = numExpectedFields
+		tsvReader.TrimLeadingSpace = true
+		lines, err := tsvReader.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lines) != len(expected) {
+			t.Fatalf("wrong number of lines; got %d, expected %d", len(lines), len(expected))
+		}
+		for i, line := range lines {
+			for j := range numExpectedFields {
+				got := line[j]
+				exp := expected[i][j]
+				if got != exp {
+					t.Errorf("got %q, expected %q", got, exp)
+				}
+			}
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := errWriter{writeFn: func(p []byte) (int, error) { return len(p), errors.New("test") }}
+		if err := internal.NewDriftTSV(&w, slog.Default()).PrintDrift(drifts); err != nil {
+			t.Fatal("function should try to print as much as it can without erroring out")
+		}
+	})
+}
+
+func TestDriftJSON(t *testing.T) {
+	// drift is copied from the function under test.
+	type drift struct {
+		I        int    `json:"i"`
+		Version  string `json:"version"`
+		Status   string `json:"status"`
+		Label    string `json:"label"`
+		Filename string `json:"filename"`
+		Recorded string `json:"recorded"`
+		Actual   string `json:"actual"`
+	}
+
+	drifts := mustMakeDrifts(t)
+
+	t.Run("ok", func(t *testing.T) {
+		expected := []drift{
+			{I: 0, Version: "1000", Status: "ok", Label: "alfa", Filename: "forward-1000-alfa.sql", Recorded: "aaa", Actual: "aaa"},
+			{I: 1, Version: "2000", Status: "modified", Label: "bravo", Filename: "forward-2000-bravo.sql", Recorded: "bbb", Actual: "xxx"},
+			{I: 2, Version: "3000", Status: "missing", Recorded: "ccc"},
+			{I: 3, Version: "4000", Status: "unrecorded", Label: "delta", Filename: "forward-4000-delta.sql", Actual: "ddd"},
+		}
+
+		var buf bytes.Buffer
+		if err := internal.NewDriftJSON(&buf, slog.Default()).PrintDrift(drifts); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range expected {
+			line, ierr := buf.ReadBytes('\n')
+			if ierr != nil {
+				t.Fatal(ierr)
+			}
+
+			var got drift
+			if err := json.Unmarshal(line, &got); err != nil {
+				t.Fatal(err)
+			}
+			exp := expected[i]
+			if got != exp {
+				t.Errorf("item[%d] incorrect\ngot:      %#v\nexpected: %#v", i, got, exp)
+			}
+		}
+
+		// should be no more data remaining.
+		if _, err := buf.ReadBytes('\n'); err != io.EOF {
+			t.Errorf("wrong error; got %v, expected %v", err, io.EOF)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := errWriter{writeFn: func(p []byte) (int, error) { return len(p), errors.New("test") }}
+		if err := internal.NewDriftJSON(&w, slog.Default()).PrintDrift(drifts); err != nil {
+			t.Fatal("function should try to print as much as it can without erroring out")
+		}
+	})
+}
+
+// mustMakeDrifts creates one Drift for each ChecksumStatus.
+func mustMakeDrifts(t *testing.T) []internal.Drift {
+	t.Helper()
+
+	migrations := mustMakeMigrations(t, "alfa", "bravo", "charlie", "delta")
+	migrations[0].Checksum = "aaa"
+	migrations[1].Checksum = "bbb"
+	migrations[2].Checksum = "ccc"
+	migrations[2].Label, migrations[2].Filename = "", ""
+
+	return []internal.Drift{
+		{Migration: migrations[0], Status: internal.ChecksumOK, Actual: "aaa"},
+		{Migration: migrations[1], Status: internal.ChecksumModified, Actual: "xxx"},
+		{Migration: migrations[2], Status: internal.ChecksumMissing},
+		{Migration: migrations[3], Status: internal.ChecksumUnrecorded, Actual: "ddd"},
+	}
+}
@@ -2,8 +2,11 @@ package internal
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,18 +17,86 @@ type Version interface {
 	Value() int64
 }
 
+// VersionScheme parses and generates migration versions. Versions of the same
+// scheme are ordered by their Value.
+type VersionScheme interface {
+	// Name identifies the scheme, such as in a configuration file.
+	Name() string
+	// ParseVersion extracts a Version from s, which is either a version as it's
+	// written, or the part of a file's basename after the direction.
+	ParseVersion(s string) (Version, error)
+	// NextVersion makes the version for a new migration. The latest version
+	// is the greatest existing one, and is nil if there are none. It's an
+	// error if there is no version after latest.
+	NextVersion(latest Version, now time.Time) (Version, error)
+}
+
+// Available version schemes.
+var (
+	// TimestampScheme is the default. Each version is the UTC time that the
+	// migration was created, in the TimeFormat layout. A unix timestamp is
+	// also accepted.
+	TimestampScheme VersionScheme = timestampScheme{}
+	// SequentialScheme has plain integers as versions, such as "0007". A new
+	// version is one more than the latest.
+	SequentialScheme VersionScheme = sequentialScheme{}
+	// DottedScheme has versions in the form "V1.2.3". The "V" is optional, and
+	// there may be up to 4 parts. A new version increments the last part of
+	// the latest.
+	DottedScheme VersionScheme = dottedScheme{}
+
+	versionSchemes = []VersionScheme{TimestampScheme, SequentialScheme, DottedScheme}
+)
+
+// VersionSchemeNames lists the names of the available version schemes.
+func VersionSchemeNames() []string {
+	out := make([]string, len(versionSchemes))
+	for i, scheme := range versionSchemes {
+		out[i] = scheme.Name()
+	}
+	return out
+}
+
+// LookupVersionScheme finds a VersionScheme by its name. An unknown name is
+// an [ErrDataInvalid].
+func LookupVersionScheme(name string) (VersionScheme, error) {
+	for _, scheme := range versionSchemes {
+		if scheme.Name() == name {
+			return scheme, nil
+		}
+	}
+	return nil, fmt.Errorf(
+		"%w; unknown version scheme %q, should be one of %q",
+		ErrDataInvalid, name, VersionSchemeNames(),
+	)
+}
+
+// Bounds of versions for any scheme. FirstVersion is before every other
+// Version, and every other Version is before LastVersion.
+var (
+	FirstVersion Version = bound(math.MinInt64)
+	LastVersion  Version = bound(math.MaxInt64)
+)
+
+type bound int64
+
+func (v bound) Before(u Version) bool { return int64(v) < u.Value() }
+
+func (v bound) String() string {
+	if v == FirstVersion {
+		return MinVersion
+	}
+	return MaxVersion
+}
+
+func (v bound) Value() int64 { return int64(v) }
+
 type timestamp struct {
 	value int64
 	label string
 }
 
-func (v *timestamp) Before(u Version) bool {
-	// Until there's more than 1 interface implementation, this is fine. So,
-	// panic here?  Yeah, maybe. Fail loudly, not silently.
-	w := u.(*timestamp)
-	return v.value < w.value
-}
-
+func (v *timestamp) Before(u Version) bool { return v.value < u.Value() }
 func (v *timestamp) String() string {
 	if v.label == "" {
 		return strconv.FormatInt(int64(v.value), 10)
@@ -50,8 +121,17 @@ var (
 
 var timeformatMatcher = regexp.MustCompile(`\d{4,14}`)
 
-// ParseVersion extracts Version info from a file's basename.
+// ParseVersion extracts Version info from a file's basename, according to
+// the TimestampScheme.
 func ParseVersion(basename string) (version Version, err error) {
+	return TimestampScheme.ParseVersion(basename)
+}
+
+type timestampScheme struct{}
+
+func (timestampScheme) Name() string { return "timestamp" }
+
+func (timestampScheme) ParseVersion(basename string) (version Version, err error) {
 	written := timeformatMatcher.FindString(basename)
 	if len(written) < 1 {
 		err = errors.New("could not parse version from filename")
@@ -83,3 +163,124 @@ func ParseVersion(basename string) (version Version, err error) {
 	version = &timestamp{value: num, label: written}
 	return
 }
+
+func (timestampScheme) NextVersion(_ Version, now time.Time) (Version, error) {
+	now = now.UTC()
+	return &timestamp{value: now.Unix(), label: now.Format(TimeFormat)}, nil
+}
+
+type sequence struct {
+	value int64
+	label string
+}
+
+func (v *sequence) Before(u Version) bool { return v.value < u.Value() }
+func (v *sequence) String() string        { return v.label }
+func (v *sequence) Value() int64          { return v.value }
+
+// minSequenceWidth is how many digits, with zero padding, to use for the
+// first sequential version.
+const minSequenceWidth = 4
+
+var sequenceMatcher = regexp.MustCompile(`^\d+`)
+
+type sequentialScheme struct{}
+
+func (sequentialScheme) Name() string { return "sequential" }
+
+func (sequentialScheme) ParseVersion(s string) (Version, error) {
+	written := sequenceMatcher.FindString(s)
+	if len(written) < 1 {
+		return nil, errors.New("could not parse sequential version")
+	}
+	num, err := strconv.ParseInt(written, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &sequence{value: num, label: written}, nil
+}
+
+func (sequentialScheme) NextVersion(latest Version, _ time.Time) (Version, error) {
+	var num int64 = 1
+	width := minSequenceWidth
+	if latest != nil {
+		num = latest.Value() + 1
+		width = max(width, len(latest.String()))
+	}
+	return &sequence{value: num, label: fmt.Sprintf("%0*d", width, num)}, nil
+}
+
+type dotted struct {
+	value int64
+	label string
+}
+
+func (v *dotted) Before(u Version) bool { return v.value < u.Value() }
+func (v *dotted) String() string        { return v.label }
+func (v *dotted) Value() int64          { return v.value }
+
+const (
+	// maxDottedParts is how many parts of a dotted version fit into the
+	// Value, with dottedPartBits for each part.
+	maxDottedParts = 4
+	dottedPartBits = 16
+)
+
+var dottedMatcher = regexp.MustCompile(`^[vV]?\d+(\.\d+)*`)
+
+type dottedScheme struct{}
+
+func (dottedScheme) Name() string { return "dotted" }
+
+func (dottedScheme) ParseVersion(s string) (Version, error) {
+	written := dottedMatcher.FindString(s)
+	if len(written) < 1 {
+		return nil, errors.New("could not parse dotted version")
+	}
+	parts := strings.Split(strings.TrimLeft(written, "vV"), ".")
+	if len(parts) > maxDottedParts {
+		return nil, fmt.Errorf("dotted version %q has more than %d parts", written, maxDottedParts)
+	}
+
+	// Pack each part into the Value, so that ordering by Value is the same
+	// as ordering part by part. The first part is limited so that the Value
+	// stays positive.
+	var value int64
+	for i := range maxDottedParts {
+		var part int64
+		if i < len(parts) {
+			num, err := strconv.ParseInt(parts[i], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			limit := int64(1)<<dottedPartBits - 1
+			if i == 0 {
+				limit >>= 1
+			}
+			if num > limit {
+				return nil, fmt.Errorf("part %q of dotted version %q is greater than %d", parts[i], written, limit)
+			}
+			part = num
+		}
+		value = value<<dottedPartBits | part
+	}
+	return &dotted{value: value, label: written}, nil
+}
+
+func (dottedScheme) NextVersion(latest Version, _ time.Time) (Version, error) {
+	if latest == nil {
+		return &dotted{value: 1 << (dottedPartBits * (maxDottedParts - 1)), label: "V1"}, nil
+	}
+	written := latest.String()
+	i := strings.LastIndexAny(written, ".vV") + 1
+	last, _ := strconv.ParseInt(written[i:], 10, 64)
+	next, err := DottedScheme.ParseVersion(written[:i] + strconv.FormatInt(last+1, 10))
+	if err == nil {
+		return next, nil
+	}
+	// The last part is at its limit, so start a new part instead.
+	if next, err = DottedScheme.ParseVersion(written + ".1"); err != nil {
+		return nil, fmt.Errorf("%w; no next dotted version after %q", ErrDataInvalid, written)
+	}
+	return next, nil
+}
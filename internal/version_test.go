@@ -1,6 +1,7 @@
 package internal_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -63,3 +64,152 @@ func TestParseVersion(t *testing.T) {
 		})
 	})
 }
+
+func TestVersionSchemes(t *testing.T) {
+	t.Run("LookupVersionScheme", func(t *testing.T) {
+		for _, name := range internal.VersionSchemeNames() {
+			scheme, err := internal.LookupVersionScheme(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if scheme.Name() != name {
+				t.Errorf("wrong Name; got %q, expected %q", scheme.Name(), name)
+			}
+		}
+		if _, err := internal.LookupVersionScheme("semver"); !internal.IsInvalidDataError(err) {
+			t.Errorf("expected error (%v) to be an invalid data error", err)
+		}
+	})
+
+	t.Run("ParseVersion", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			scheme internal.VersionScheme
+			input  string
+			expStr string
+			expErr bool
+		}{
+			{name: "sequential", scheme: internal.SequentialScheme, input: "0007", expStr: "0007"},
+			{name: "sequential, rest of filename", scheme: internal.SequentialScheme, input: "12-add_users.sql", expStr: "12"},
+			{name: "sequential, not a number", scheme: internal.SequentialScheme, input: "V1", expErr: true},
+			{name: "dotted", scheme: internal.DottedScheme, input: "V1.2.3", expStr: "V1.2.3"},
+			{name: "dotted, no prefix", scheme: internal.DottedScheme, input: "1.2", expStr: "1.2"},
+			{name: "dotted, rest of filename", scheme: internal.DottedScheme, input: "v1.2-add_users.sql", expStr: "v1.2"},
+			{name: "dotted, too many parts", scheme: internal.DottedScheme, input: "V1.2.3.4.5", expErr: true},
+			{name: "dotted, part too large", scheme: internal.DottedScheme, input: "V1.65536", expErr: true},
+			{name: "dotted, first part too large", scheme: internal.DottedScheme, input: "V32768", expErr: true},
+			{name: "dotted, not a number", scheme: internal.DottedScheme, input: "add_users", expErr: true},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				got, err := test.scheme.ParseVersion(test.input)
+				if !test.expErr && err != nil {
+					t.Fatal(err)
+				} else if test.expErr && err == nil {
+					t.Fatal("expected error but did not get one")
+				} else if test.expErr && err != nil {
+					return // ok, nothing more to test.
+				}
+				if got.String() != test.expStr {
+					t.Errorf("wrong String; got %q, expected %q", got.String(), test.expStr)
+				}
+			})
+		}
+	})
+
+	t.Run("ordering", func(t *testing.T) {
+		tests := []struct {
+			scheme  internal.VersionScheme
+			ordered []string
+		}{
+			{scheme: internal.SequentialScheme, ordered: []string{"1", "0002", "9", "10", "100"}},
+			{scheme: internal.DottedScheme, ordered: []string{"V0.9", "V1", "V1.0.1", "V1.2", "V1.2.3", "V1.10", "V2", "V32767.65535"}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.scheme.Name(), func(t *testing.T) {
+				for i := 1; i < len(test.ordered); i++ {
+					prev, err := test.scheme.ParseVersion(test.ordered[i-1])
+					if err != nil {
+						t.Fatal(err)
+					}
+					curr, err := test.scheme.ParseVersion(test.ordered[i])
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !prev.Before(curr) || curr.Before(prev) {
+						t.Errorf("expected %q to be before %q", prev, curr)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("NextVersion", func(t *testing.T) {
+		now := time.Date(2026, time.January, 2, 3, 4, 5, 0, time.UTC)
+		tests := []struct {
+			name   string
+			scheme internal.VersionScheme
+			latest string
+			exp    string
+		}{
+			{name: "timestamp", scheme: internal.TimestampScheme, latest: "20250101000000", exp: "20260102030405"},
+			{name: "sequential, first", scheme: internal.SequentialScheme, exp: "0001"},
+			{name: "sequential", scheme: internal.SequentialScheme, latest: "0007", exp: "0008"},
+			{name: "sequential, keeps width", scheme: internal.SequentialScheme, latest: "000099", exp: "000100"},
+			{name: "dotted, first", scheme: internal.DottedScheme, exp: "V1"},
+			{name: "dotted", scheme: internal.DottedScheme, latest: "V1.2.3", exp: "V1.2.4"},
+			{name: "dotted, one part", scheme: internal.DottedScheme, latest: "2", exp: "3"},
+			{name: "dotted, last part at limit", scheme: internal.DottedScheme, latest: "V1.65535", exp: "V1.65535.1"},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				var latest internal.Version
+				if test.latest != "" {
+					var err error
+					if latest, err = test.scheme.ParseVersion(test.latest); err != nil {
+						t.Fatal(err)
+					}
+				}
+				got, err := test.scheme.NextVersion(latest, now)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.String() != test.exp {
+					t.Errorf("wrong version; got %q, expected %q", got.String(), test.exp)
+				}
+				if latest != nil && !latest.Before(got) {
+					t.Errorf("expected %q to be before %q", latest, got)
+				}
+			})
+		}
+	})
+
+	t.Run("NextVersion, no next dotted version", func(t *testing.T) {
+		latest, err := internal.DottedScheme.ParseVersion("V1.2.3.65535")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := internal.DottedScheme.NextVersion(latest, time.Now())
+		if !errors.Is(err, internal.ErrDataInvalid) {
+			t.Errorf("expected error (%v) to be %v", err, internal.ErrDataInvalid)
+		}
+		if got != nil {
+			t.Errorf("expected nil version, got %q", got)
+		}
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		for _, scheme := range []internal.VersionScheme{internal.TimestampScheme, internal.SequentialScheme, internal.DottedScheme} {
+			version, err := scheme.NextVersion(nil, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !internal.FirstVersion.Before(version) || !version.Before(internal.LastVersion) {
+				t.Errorf("expected %s version %q to be within bounds", scheme.Name(), version)
+			}
+		}
+	})
+}
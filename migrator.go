@@ -0,0 +1,176 @@
+package godfish
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sync"
+
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+// A Migrator is configured once with a driver, a source of migration files,
+// and options, and then reused for any number of operations. Each method is
+// like the function of the same name, such as [MigrateWith] for
+// [Migrator.Migrate]. Options passed to a method are applied after the ones
+// of the Migrator, so they take precedence for that call only.
+//
+// Options that add to a list, [WithGoMigrations] and [WithObserver], are the
+// exception: when passed to a method, they replace the ones of the Migrator
+// for that call, rather than add to them.
+//
+// The directory listing of the source, and the migrations parsed from it, are
+// read on first use and then reused by later calls, which could help
+// long-running applications and test suites. Call [Migrator.Refresh] after
+// adding, removing or changing the directives of migration files. The
+// contents of the files are read again to run them.
+type Migrator struct {
+	driver driver.Driver
+	dirFS  *internal.CachedFS
+	files  *parsedFiles
+	opts   []Opter
+}
+
+// NewMigrator constructs a Migrator. It's an error if any of opts is invalid,
+// as documented on the function that makes each one, such as [WithSteps].
+func NewMigrator(driver driver.Driver, dirFS fs.FS, opts ...Opter) (*Migrator, error) {
+	if driver == nil {
+		return nil, fmt.Errorf("%s.%s: %w; driver is required", msgPrefix, "NewMigrator", internal.ErrDataInvalid)
+	}
+	if dirFS == nil {
+		return nil, fmt.Errorf("%s.%s: %w; dirFS is required", msgPrefix, "NewMigrator", internal.ErrDataInvalid)
+	}
+	if _, err := setOptions(opts...); err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", msgPrefix, "NewMigrator", err)
+	}
+
+	return &Migrator{
+		driver: driver,
+		dirFS:  internal.NewCachedFS(dirFS),
+		files:  &parsedFiles{byKey: make(map[parsedFilesKey][]*internal.Migration)},
+		opts:   slices.Clone(opts),
+	}, nil
+}
+
+// Refresh drops the cached directory listing and migrations, so that the next
+// call reads them again.
+func (m *Migrator) Refresh() {
+	m.dirFS.Clear()
+	m.files.clear()
+}
+
+// Migrate applies one or more migrations in the forward direction, see
+// [MigrateWith].
+func (m *Migrator) Migrate(ctx context.Context, opts ...Opter) error {
+	return MigrateWith(ctx, m.driver, m.dirFS, m.withOpts(opts)...)
+}
+
+// Rollback applies one or more migrations in the reverse direction, see
+// [RollbackWith].
+func (m *Migrator) Rollback(ctx context.Context, opts ...Opter) error {
+	return RollbackWith(ctx, m.driver, m.dirFS, m.withOpts(opts)...)
+}
+
+// ApplyMigration applies one migration in the forward direction, see
+// [ApplyMigrationWith].
+func (m *Migrator) ApplyMigration(ctx context.Context, opts ...Opter) error {
+	return ApplyMigrationWith(ctx, m.driver, m.dirFS, m.withOpts(opts)...)
+}
+
+// ApplyRollback applies one migration in the reverse direction, see
+// [ApplyRollbackWith].
+func (m *Migrator) ApplyRollback(ctx context.Context, opts ...Opter) error {
+	return ApplyRollbackWith(ctx, m.driver, m.dirFS, m.withOpts(opts)...)
+}
+
+// Info outputs the status of migrations, see [InfoWith].
+func (m *Migrator) Info(ctx context.Context, opts ...Opter) error {
+	return InfoWith(ctx, m.driver, m.dirFS, m.withOpts(opts)...)
+}
+
+// Status returns the state of each migration, see [Status].
+func (m *Migrator) Status(ctx context.Context, opts ...Opter) ([]MigrationStatus, error) {
+	return Status(ctx, m.driver, m.dirFS, m.withOpts(opts)...)
+}
+
+// UpgradeSchemaMigrations may alter the schema migrations table to have newer
+// metadata columns, see [UpgradeSchemaMigrationsWith].
+func (m *Migrator) UpgradeSchemaMigrations(ctx context.Context, opts ...Opter) error {
+	return UpgradeSchemaMigrationsWith(ctx, m.driver, m.withOpts(opts)...)
+}
+
+// withOpts puts opts after the options of m, so that they take precedence. An
+// option of m that adds to a list is left out when opts add to the same list.
+func (m *Migrator) withOpts(opts []Opter) []Opter {
+	var called options
+	for _, opt := range opts {
+		_ = opt.setOption(&called) // an invalid one is reported by the call.
+	}
+
+	out := make([]Opter, 0, len(m.opts)+len(opts)+1)
+	out = append(out, &opter{set: func(opt *options) error {
+		opt.parsedFiles = m.files
+		return nil
+	}})
+	for _, opt := range m.opts {
+		var own options
+		_ = opt.setOption(&own)
+		if len(own.goMigrationDefs) > 0 && len(called.goMigrationDefs) > 0 ||
+			len(own.observers) > 0 && len(called.observers) > 0 {
+			continue
+		}
+		out = append(out, opt)
+	}
+	return append(out, opts...)
+}
+
+// parsedFiles caches the migration files found by a migrationFinder, so that a
+// Migrator parses them once. It's safe for concurrent use, and a nil
+// *parsedFiles caches nothing.
+type parsedFiles struct {
+	mu    sync.Mutex
+	byKey map[parsedFilesKey][]*internal.Migration
+}
+
+// parsedFilesKey is what the parsed files depend on, besides the directory.
+type parsedFilesKey struct {
+	direction      internal.Direction
+	scheme         internal.VersionScheme
+	convention     internal.NamingConvention
+	recursive      bool
+	readDirectives bool
+}
+
+// get returns copies of the migrations at key, calling parse to find them if
+// they aren't cached yet. The copies may be changed by the caller.
+func (p *parsedFiles) get(key parsedFilesKey, parse func() ([]*internal.Migration, error)) ([]*internal.Migration, error) {
+	if p == nil {
+		return parse()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	migrations, ok := p.byKey[key]
+	if !ok {
+		var err error
+		if migrations, err = parse(); err != nil {
+			return nil, err
+		}
+		p.byKey[key] = migrations
+	}
+
+	out := make([]*internal.Migration, len(migrations))
+	for i, mig := range migrations {
+		dup := *mig
+		out[i] = &dup
+	}
+	return out, nil
+}
+
+func (p *parsedFiles) clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	clear(p.byKey)
+}
@@ -0,0 +1,191 @@
+package godfish
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+// An Observer is notified of the events of running migrations, such as to
+// emit metrics or to write to an audit log. Register one with [WithObserver].
+// The events happen in this order:
+//
+//   - RunStarted, once the migrations to run are known.
+//   - MigrationStarted and MigrationFinished, for each migration. A migration
+//     that is skipped, because its env directive does not match the
+//     environment, only has MigrationFinished, with Skipped set.
+//   - RunFinished, after the last migration, or after the first one to fail.
+//
+// A run is one call to a function such as [MigrateWith], [RollbackWith] or
+// [ApplyMigrationWith]. There are no events on a dry run or an export, since
+// nothing is run. The methods are called synchronously, so they should return
+// quickly. The built-in logging is an Observer too, it's always notified
+// first.
+type Observer interface {
+	RunStarted(ctx context.Context, run RunEvent)
+	MigrationStarted(ctx context.Context, mig MigrationEvent)
+	MigrationFinished(ctx context.Context, mig MigrationEvent)
+	RunFinished(ctx context.Context, run RunEvent)
+}
+
+// RunEvent describes one run of migrations.
+type RunEvent struct {
+	// Direction is "forward" or "reverse".
+	Direction string
+	// Pending is the number of migrations to run, including repeatable ones.
+	Pending int
+	// Duration is only set when the run is finished.
+	Duration time.Duration
+	// Err is only set when the run is finished, if it failed.
+	Err error
+}
+
+// MigrationEvent describes one migration within a run.
+type MigrationEvent struct {
+	// Direction is "forward" or "reverse".
+	Direction string
+	// Version is empty for a repeatable migration.
+	Version  string
+	Label    string
+	Filename string
+	// Batch identifies the run that applies the migration. It's 0 for a
+	// migration in the reverse direction.
+	Batch      int64
+	Repeatable bool
+	// Skipped is set when the migration did not run, because its env directive
+	// does not match the environment, see [WithEnvironment].
+	Skipped bool
+	// Duration is only set when the migration is finished.
+	Duration time.Duration
+	// Err is only set when the migration is finished, if it failed.
+	Err error
+}
+
+// observers notifies each Observer in order.
+type observers []Observer
+
+// observe lists the Observers of a run, starting with the built-in logging.
+func (o *options) observe() observers {
+	out := append(observers{logObserver{lgr: o.log()}}, o.observers...)
+	if o.report != nil {
+		out = append(out, reportObserver{report: o.report})
+	}
+	return out
+}
+
+func (obs observers) RunStarted(ctx context.Context, run RunEvent) {
+	for _, ob := range obs {
+		ob.RunStarted(ctx, run)
+	}
+}
+
+func (obs observers) MigrationStarted(ctx context.Context, mig MigrationEvent) {
+	for _, ob := range obs {
+		ob.MigrationStarted(ctx, mig)
+	}
+}
+
+func (obs observers) MigrationFinished(ctx context.Context, mig MigrationEvent) {
+	for _, ob := range obs {
+		ob.MigrationFinished(ctx, mig)
+	}
+}
+
+func (obs observers) RunFinished(ctx context.Context, run RunEvent) {
+	for _, ob := range obs {
+		ob.RunFinished(ctx, run)
+	}
+}
+
+// observeRun notifies the observers of o around fn, which runs the pending
+// migrations in the direction.
+func observeRun(ctx context.Context, o *options, direction internal.Direction, pending []MigrationEvent, fn func() error) error {
+	if o.report != nil {
+		o.report.plan(pending, o.log())
+	}
+	obs := o.observe()
+	run := RunEvent{Direction: direction.String(), Pending: len(pending)}
+	obs.RunStarted(ctx, run)
+	startTime := time.Now()
+
+	err := fn()
+	run.Duration, run.Err = time.Since(startTime), err
+	obs.RunFinished(ctx, run)
+	return err
+}
+
+// migrationEvent describes mig, before it runs.
+func migrationEvent(mig *internal.Migration) MigrationEvent {
+	return MigrationEvent{
+		Direction: mig.Indirection.Value.String(),
+		Version:   mig.Version.String(),
+		Label:     mig.Label,
+		Filename:  mig.DisplayName(),
+		Batch:     mig.Batch,
+	}
+}
+
+// repeatableEvent describes rep, before it runs.
+func repeatableEvent(rep *internal.Repeatable) MigrationEvent {
+	return MigrationEvent{
+		Direction:  internal.DirForward.String(),
+		Label:      rep.Label,
+		Filename:   rep.Filename,
+		Batch:      rep.Batch,
+		Repeatable: true,
+	}
+}
+
+// logObserver is the built-in logging.
+type logObserver struct{ lgr *slog.Logger }
+
+func (l logObserver) RunStarted(ctx context.Context, run RunEvent) {
+	l.lgr.DebugContext(ctx, "starting run", slog.String("direction", run.Direction), slog.Int("pending", run.Pending))
+}
+
+func (l logObserver) MigrationStarted(ctx context.Context, mig MigrationEvent) {
+	lgr := l.with(mig)
+	switch {
+	case mig.Repeatable:
+		lgr.InfoContext(ctx, "running repeatable ...")
+	case mig.Direction == internal.DirReverse.String():
+		lgr.InfoContext(ctx, "rolling back ...")
+	default:
+		lgr.InfoContext(ctx, "migrating ...")
+	}
+}
+
+func (l logObserver) MigrationFinished(ctx context.Context, mig MigrationEvent) {
+	if mig.Skipped {
+		return // logged where the environment is known
+	}
+	lgr := l.with(mig)
+	if mig.Err == nil {
+		lgr.InfoContext(ctx, "ok", slog.Int64("duration_ms", mig.Duration.Milliseconds()))
+		return
+	}
+
+	msg := "running migration"
+	if mig.Repeatable {
+		msg = "running repeatable migration"
+	}
+	lgr.ErrorContext(ctx, msg, slog.Any("error", mig.Err), slog.Int64("duration_ms", mig.Duration.Milliseconds()))
+}
+
+func (l logObserver) RunFinished(ctx context.Context, run RunEvent) {
+	l.lgr.DebugContext(ctx, "finished run",
+		slog.String("direction", run.Direction),
+		slog.Int("pending", run.Pending),
+		slog.Int64("duration_ms", run.Duration.Milliseconds()),
+		slog.Bool("ok", run.Err == nil),
+	)
+}
+
+func (l logObserver) with(mig MigrationEvent) *slog.Logger {
+	if mig.Repeatable {
+		return l.lgr.With(slog.String("path_to_file", mig.Filename), slog.String("label", mig.Label))
+	}
+	return l.lgr.With(slog.String("path_to_file", mig.Filename), slog.String("version", mig.Version))
+}
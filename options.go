@@ -1,25 +1,50 @@
 package godfish
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"time"
 
+	"github.com/rafaelespinoza/godfish/driver"
 	"github.com/rafaelespinoza/godfish/internal"
 )
 
 // options are configuration parameters set through an [opter].
 type options struct {
-	format          string
-	migrationsTable string
-	targetVersion   string
-	writer          io.Writer
+	batch            int64
+	dryRun           bool
+	environment      string
+	export           bool // set by ExportWith, there is no Opter for it.
+	format           string
+	goMigrations     []*internal.Migration
+	goMigrationDefs  []GoMigration
+	lastBatch        bool
+	lockTimeout      time.Duration
+	logger           *slog.Logger
+	migrationsTable  string
+	namingConvention internal.NamingConvention
+	observers        []Observer
+	parsedFiles      *parsedFiles // set by a Migrator, there is no Opter for it.
+	recursive        bool
+	report           *Report
+	since            time.Time
+	sourceTable      string
+	steps            int
+	targetLabel      string
+	targetVersion    string
+	versionScheme    internal.VersionScheme
+	writer           io.Writer
 
 	// relevant for create migration
 
 	filenameExt  string
 	forwardLabel string
 	reverseLabel string
+	singleFile   bool
 }
 
 // An Opter configures a migration/rollback and returns a validation error,
@@ -32,7 +57,9 @@ func (o *opter) setOption(opt *options) error { return o.set(opt) }
 
 var errNonZeroValueRequired = errors.New("non-zero value is required")
 
-// WithMigrationsTable sets the DB table name for storing migration state.
+// WithMigrationsTable sets the DB table name for storing migration state. When
+// this option is omitted, the table is "schema_migrations". Functions that
+// apply migrations create the table unless it already exists.
 // A zero value t is invalid and will lead to an error.
 func WithMigrationsTable(t string) Opter {
 	return &opter{set: func(opt *options) error {
@@ -44,6 +71,19 @@ func WithMigrationsTable(t string) Opter {
 	}}
 }
 
+// WithSourceTable sets the name of the bookkeeping table of another migration
+// tool, when importing its state. It's only needed when the table is not at
+// the tool's default name. A zero value t is invalid and will lead to an error.
+func WithSourceTable(t string) Opter {
+	return &opter{set: func(opt *options) error {
+		if t == "" {
+			return fmt.Errorf("%s: %w", "WithSourceTable", errNonZeroValueRequired)
+		}
+		opt.sourceTable = t
+		return nil
+	}}
+}
+
 // WithTargetVersion sets the migration version to target.
 // A zero value v is invalid and will lead to an error.
 func WithTargetVersion(v string) Opter {
@@ -56,6 +96,226 @@ func WithTargetVersion(v string) Opter {
 	}}
 }
 
+// WithSteps limits how many migrations to apply. It's an alternative to
+// [WithTargetVersion].
+// A non-positive value n is invalid and will lead to an error.
+func WithSteps(n int) Opter {
+	return &opter{set: func(opt *options) error {
+		if n <= 0 {
+			return fmt.Errorf("%s: %w", "WithSteps", errNonZeroValueRequired)
+		}
+		opt.steps = n
+		return nil
+	}}
+}
+
+// WithTargetLabel sets the label of the migration to target, which is the
+// name part of its filename. It's an alternative to [WithTargetVersion].
+// A zero value l is invalid and will lead to an error.
+func WithTargetLabel(l string) Opter {
+	return &opter{set: func(opt *options) error {
+		if l == "" {
+			return fmt.Errorf("%s: %w", "WithTargetLabel", errNonZeroValueRequired)
+		}
+		opt.targetLabel = l
+		return nil
+	}}
+}
+
+// WithSince targets applied migrations that were executed after t. It only
+// applies to a rollback and is an alternative to [WithTargetVersion].
+// A zero value t is invalid and will lead to an error.
+func WithSince(t time.Time) Opter {
+	return &opter{set: func(opt *options) error {
+		if t.IsZero() {
+			return fmt.Errorf("%s: %w", "WithSince", errNonZeroValueRequired)
+		}
+		opt.since = t
+		return nil
+	}}
+}
+
+// WithBatch targets the applied migrations of one run, identified by id. Each
+// migration applied in the same run shares a batch, see the "batch" column of
+// [InfoWith]. It only applies to a rollback and is an alternative to
+// [WithTargetVersion]. A migration applied before batches were recorded has
+// no batch, so it's never targeted.
+// A non-positive value id is invalid and will lead to an error.
+func WithBatch(id int64) Opter {
+	return &opter{set: func(opt *options) error {
+		if id <= 0 {
+			return fmt.Errorf("%s: %w", "WithBatch", errNonZeroValueRequired)
+		}
+		opt.batch = id
+		return nil
+	}}
+}
+
+// WithLastBatch targets the applied migrations of the most recent run. It's
+// like [WithBatch], without having to know the id.
+func WithLastBatch() Opter {
+	return &opter{set: func(opt *options) error {
+		opt.lastBatch = true
+		return nil
+	}}
+}
+
+// WithLockTimeout limits how long to wait for a lock on the schema migrations
+// table. It only applies to a [driver.Driver] that implements [driver.Locker].
+// When this option is omitted, the wait lasts until the lock is acquired or
+// the context is done.
+// A non-positive value d is invalid and will lead to an error.
+func WithLockTimeout(d time.Duration) Opter {
+	return &opter{set: func(opt *options) error {
+		if d <= 0 {
+			return fmt.Errorf("%s: %w", "WithLockTimeout", errNonZeroValueRequired)
+		}
+		opt.lockTimeout = d
+		return nil
+	}}
+}
+
+// WithDryRun outputs the plan of a migration run as annotated SQL, rather
+// than running it, to the writer of [WithWriter]. Nothing is executed against
+// the database, aside from reading the schema migrations table, and the lock
+// on the table is not acquired.
+func WithDryRun() Opter {
+	return &opter{set: func(opt *options) error {
+		opt.dryRun = true
+		return nil
+	}}
+}
+
+// WithEnvironment names the environment that migrations run in. A migration
+// file with an env directive, such as "-- godfish:env staging,prod", is
+// skipped unless one of its environments is env. A skipped migration is not
+// recorded, so it remains available to apply. Without this option, every
+// migration runs, regardless of its env directive.
+// A zero value env is invalid and will lead to an error.
+func WithEnvironment(env string) Opter {
+	return &opter{set: func(opt *options) error {
+		if env == "" {
+			return fmt.Errorf("%s: %w", "WithEnvironment", errNonZeroValueRequired)
+		}
+		opt.environment = env
+		return nil
+	}}
+}
+
+// WithGoMigrations registers migrations written as Go functions. They are
+// ordered by version along with the migration files. A zero-length input is
+// invalid and will lead to an error. So is a GoMigration with an unparseable
+// version, an empty Label, a nil Forward func, or a version used by another
+// GoMigration. Versions are parsed with the scheme of [WithVersionScheme].
+func WithGoMigrations(migrations ...GoMigration) Opter {
+	return &opter{set: func(opt *options) error {
+		if len(migrations) < 1 {
+			return fmt.Errorf("%s: %w", "WithGoMigrations", errNonZeroValueRequired)
+		}
+		// These are converted after all options are set, when the version
+		// scheme is known.
+		opt.goMigrationDefs = append(opt.goMigrationDefs, migrations...)
+		return nil
+	}}
+}
+
+// WithVersionScheme sets how migration versions are written and ordered. The
+// name is one of:
+//
+//   - "timestamp": the default, a UTC timestamp such as "20060102150405".
+//   - "sequential": plain integers, such as "0007".
+//   - "dotted": dot-separated integers, such as "V1.2.3".
+//
+// Migrations are ordered by version under the scheme, so the same scheme
+// should be used for the lifetime of a schema migrations table. When creating
+// a migration file, the version is the next one in the scheme.
+// Every other name is invalid and will lead to an error.
+func WithVersionScheme(name string) Opter {
+	return &opter{set: func(opt *options) error {
+		scheme, err := internal.LookupVersionScheme(name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", "WithVersionScheme", err)
+		}
+		opt.versionScheme = scheme
+		return nil
+	}}
+}
+
+// WithNamingConvention sets how migration filenames are laid out. The name is
+// one of:
+//
+//   - "default": "${direction}-${version}-${label}", such as
+//     "forward-20060102150405-create_foos.sql".
+//   - "flyway": "V${version}__${label}" going forward and
+//     "U${version}__${label}" in reverse, such as "V1.2__create_foos.sql".
+//
+// Files that don't follow the convention are not considered migrations. When
+// creating a migration file, the filename follows the convention.
+// Every other name is invalid and will lead to an error.
+func WithNamingConvention(name string) Opter {
+	return &opter{set: func(opt *options) error {
+		convention, err := internal.LookupNamingConvention(name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", "WithNamingConvention", err)
+		}
+		opt.namingConvention = convention
+		return nil
+	}}
+}
+
+// WithLogger sets the logger for the library, and for the driver through the
+// context, see [driver.Logger]. When this option is omitted, then it logs with
+// the default logger of the log/slog package. A nil lgr is invalid and will
+// lead to an error.
+func WithLogger(lgr *slog.Logger) Opter {
+	return &opter{set: func(opt *options) error {
+		if lgr == nil {
+			return fmt.Errorf("%s: %w", "WithLogger", errNonZeroValueRequired)
+		}
+		opt.logger = lgr
+		return nil
+	}}
+}
+
+// WithReport sets a Report, to which each migration considered by a run is
+// added, along with its outcome. The report is filled in even if the run
+// fails, so it shows where the failure happened. There is nothing to report
+// on a dry run. A nil report is invalid and will lead to an error.
+func WithReport(report *Report) Opter {
+	return &opter{set: func(opt *options) error {
+		if report == nil {
+			return fmt.Errorf("%s: %w", "WithReport", errNonZeroValueRequired)
+		}
+		opt.report = report
+		return nil
+	}}
+}
+
+// WithObserver registers an Observer, which is notified of each run of
+// migrations and of each migration in it. It may be passed in more than once,
+// then each one is notified in order. A nil obs is invalid and will lead to an
+// error.
+func WithObserver(obs Observer) Opter {
+	return &opter{set: func(opt *options) error {
+		if obs == nil {
+			return fmt.Errorf("%s: %w", "WithObserver", errNonZeroValueRequired)
+		}
+		opt.observers = append(opt.observers, obs)
+		return nil
+	}}
+}
+
+// WithRecursive searches for migration files in every subdirectory of the
+// directory, not just the top level of it. The migrations in all of them are
+// ordered by version as one set, so a version should only be used once.
+// Directories, whose names begin with a dot, are skipped.
+func WithRecursive() Opter {
+	return &opter{set: func(opt *options) error {
+		opt.recursive = true
+		return nil
+	}}
+}
+
 // WithFormat sets an output format.
 // A zero value f is invalid and will lead to an error.
 func WithFormat(f string) Opter {
@@ -68,7 +328,8 @@ func WithFormat(f string) Opter {
 	}}
 }
 
-// WithWriter sets the output writer.
+// WithWriter sets the output writer, such as for [WithDryRun]. When this option
+// is omitted, then output goes to standard output.
 // A zero value w is invalid and will lead to an error.
 func WithWriter(w io.Writer) Opter {
 	return &opter{set: func(opt *options) error {
@@ -116,6 +377,16 @@ func WithFilenameExtension(e string) Opter {
 	}}
 }
 
+// WithSingleFile sets up a single-file migration for when creating a migration
+// file. Both directions are in one file, separated by "-- godfish:up" and
+// "-- godfish:down" marker comments.
+func WithSingleFile() Opter {
+	return &opter{set: func(opt *options) error {
+		opt.singleFile = true
+		return nil
+	}}
+}
+
 func setOptions(opts ...Opter) (*options, error) {
 	options := &options{}
 	var err error
@@ -124,5 +395,49 @@ func setOptions(opts ...Opter) (*options, error) {
 			return nil, err
 		}
 	}
+	if err = options.setGoMigrations(); err != nil {
+		return nil, err
+	}
 	return options, nil
 }
+
+// setGoMigrations converts the Go migrations, with versions in the scheme.
+func (o *options) setGoMigrations() error {
+	seen := make(map[int64]bool, len(o.goMigrationDefs))
+	for i, gm := range o.goMigrationDefs {
+		forward, reverse, err := gm.toMigrations(o.scheme())
+		if err != nil {
+			return fmt.Errorf("%s: migrations[%d]: %w", "WithGoMigrations", i, err)
+		}
+		version := forward.Version.Value()
+		if seen[version] {
+			return fmt.Errorf("%s: migrations[%d]: %w; duplicate version %q", "WithGoMigrations", i, internal.ErrDataInvalid, gm.Version)
+		}
+		seen[version] = true
+		o.goMigrations = append(o.goMigrations, forward)
+		if reverse != nil {
+			o.goMigrations = append(o.goMigrations, reverse)
+		}
+	}
+	return nil
+}
+
+// scheme is the VersionScheme to use, the timestamp scheme by default.
+func (o *options) scheme() internal.VersionScheme {
+	return cmp.Or(o.versionScheme, internal.TimestampScheme)
+}
+
+// convention is the NamingConvention to use, the default one by default.
+func (o *options) convention() internal.NamingConvention {
+	return cmp.Or(o.namingConvention, internal.DefaultConvention)
+}
+
+// log is the logger to use, the default logger of log/slog by default.
+func (o *options) log() *slog.Logger {
+	return cmp.Or(o.logger, slog.Default())
+}
+
+// logContext puts the logger on ctx, so that the driver logs with it too.
+func (o *options) logContext(ctx context.Context) context.Context {
+	return driver.ContextWithLogger(ctx, o.log())
+}
@@ -0,0 +1,127 @@
+package godfish
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+// Outcome is what happened to a migration in a run.
+type Outcome string
+
+const (
+	// OutcomeApplied means the migration ran.
+	OutcomeApplied Outcome = "applied"
+	// OutcomeSkipped means the migration did not run, either because an
+	// earlier one in the run failed, or because its env directive did not
+	// match the environment.
+	OutcomeSkipped Outcome = "skipped"
+	// OutcomeFailed means the migration ran, but failed.
+	OutcomeFailed Outcome = "failed"
+)
+
+// A Report lists every migration considered by a run, see [WithReport]. When
+// it's passed to more than one run, such as with [RemigrateWith], then the
+// migrations of each run are added in the order they were considered.
+type Report struct {
+	Migrations []MigrationReport
+
+	lgr *slog.Logger
+}
+
+// MigrationReport is the outcome of one migration in a [Report].
+type MigrationReport struct {
+	// Direction is "forward" or "reverse".
+	Direction string
+	// Version is empty for a repeatable migration.
+	Version    string
+	Label      string
+	Filename   string
+	Repeatable bool
+	Outcome    Outcome
+	// Duration and Err are only set when the migration ran.
+	Duration time.Duration
+	Err      error
+
+	finished bool
+}
+
+// Print writes out each migration of the report to w. The format is "json",
+// for one JSON object per migration, or "tsv", for tab separated values. An
+// empty format is "tsv". Errors writing to w are logged with the logger of
+// the latest run, see [WithLogger].
+func (r *Report) Print(w io.Writer, format string) error {
+	lgr := cmp.Or(r.lgr, slog.Default())
+	var printer internal.ReportPrinter
+	switch format {
+	case "json":
+		printer = internal.NewReportJSON(w, lgr)
+	case "tsv", "":
+		printer = internal.NewReportTSV(w, lgr)
+	default:
+		return fmt.Errorf("%s: %w; unknown format %q, should be one of %q", msgPrefix, internal.ErrDataInvalid, format, []string{"json", "tsv"})
+	}
+
+	items := make([]internal.ReportItem, len(r.Migrations))
+	for i, mig := range r.Migrations {
+		items[i] = internal.ReportItem{
+			Direction:  mig.Direction,
+			Version:    mig.Version,
+			Label:      mig.Label,
+			Filename:   mig.Filename,
+			Repeatable: mig.Repeatable,
+			Outcome:    string(mig.Outcome),
+			Duration:   mig.Duration,
+			Err:        mig.Err,
+		}
+	}
+	return printer.PrintReport(items)
+}
+
+// plan adds the migrations of a run, which logs with lgr, to the report. Each
+// one is skipped until it's finished.
+func (r *Report) plan(pending []MigrationEvent, lgr *slog.Logger) {
+	r.lgr = lgr
+	for _, mig := range pending {
+		r.Migrations = append(r.Migrations, MigrationReport{
+			Direction:  mig.Direction,
+			Version:    mig.Version,
+			Label:      mig.Label,
+			Filename:   mig.Filename,
+			Repeatable: mig.Repeatable,
+			Outcome:    OutcomeSkipped,
+		})
+	}
+}
+
+// reportObserver updates the outcome of each migration in a Report.
+type reportObserver struct{ report *Report }
+
+func (reportObserver) RunStarted(context.Context, RunEvent)             {}
+func (reportObserver) MigrationStarted(context.Context, MigrationEvent) {}
+func (reportObserver) RunFinished(context.Context, RunEvent)            {}
+
+func (r reportObserver) MigrationFinished(_ context.Context, mig MigrationEvent) {
+	// The migration was planned in the latest run, so look from the end.
+	for i := len(r.report.Migrations) - 1; i >= 0; i-- {
+		item := &r.report.Migrations[i]
+		if item.finished || item.Direction != mig.Direction || item.Version != mig.Version ||
+			item.Filename != mig.Filename || item.Repeatable != mig.Repeatable {
+			continue
+		}
+		item.finished = true
+		if mig.Skipped {
+			return
+		}
+		item.Outcome, item.Duration, item.Err = OutcomeApplied, mig.Duration, mig.Err
+		if mig.Err != nil {
+			item.Outcome = OutcomeFailed
+		}
+		return
+	}
+}
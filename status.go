@@ -0,0 +1,111 @@
+package godfish
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"time"
+
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+// MigrationStatus is the state of one migration, as returned by [Status].
+type MigrationStatus struct {
+	Version string
+	Label   string
+	// Direction is the direction of the migration, which is "forward".
+	Direction string
+	// Filename is the basename of the migration file. It's empty for an
+	// applied migration without a file, and it's a marker for a Go migration.
+	Filename   string
+	Applied    bool
+	ExecutedAt time.Time // zero value unless applied, or if it's not recorded.
+	Batch      int64     // identifies the run that applied the migration.
+	// Reversible means there is a reverse migration for the version.
+	Reversible bool
+}
+
+// Status returns the state of each migration, like [InfoWith], but as values
+// instead of a report. The applied migrations come first, in the order of the
+// schema migrations table, followed by the pending ones in the order that
+// they would be applied. The schema migrations table is not created, so every
+// migration is pending when it doesn't exist yet.
+//
+// # Relevant opts
+//
+//   - [WithRecursive]. If passed in, then the files in subdirectories are
+//     considered as well.
+//   - See also [WithMigrationsTable], [WithGoMigrations], [WithVersionScheme]
+//     and [WithNamingConvention].
+func Status(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts ...Opter) ([]MigrationStatus, error) {
+	o, err := setOptions(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", msgPrefix, "Status", err)
+	}
+	out, err := status(ctx, driver, dirFS, o)
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", msgPrefix, "Status", err)
+	}
+	return out, nil
+}
+
+func status(ctx context.Context, d driver.Driver, dirFS fs.FS, o *options) ([]MigrationStatus, error) {
+	ctx = o.logContext(ctx)
+	migrationsTable := cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName)
+	finder := migrationFinder{
+		direction:    internal.DirForward,
+		dirFS:        dirFS,
+		goMigrations: o.goMigrations,
+		scheme:       o.scheme(),
+		convention:   o.convention(),
+		recursive:    o.recursive,
+		files:        o.parsedFiles,
+		logger:       o.log(),
+	}
+	availableByVersion, orderedVersions, err := finder.available()
+	if err != nil {
+		return nil, fmt.Errorf("getting available migrations: %w", err)
+	}
+	available := make([]*internal.Migration, len(orderedVersions))
+	for i, version := range orderedVersions {
+		available[i] = availableByVersion[version]
+	}
+
+	reverseFinder := finder
+	reverseFinder.direction = internal.DirReverse
+	reversesByVersion, _, err := reverseFinder.available()
+	if err != nil {
+		return nil, fmt.Errorf("getting available reverse migrations: %w", err)
+	}
+
+	applied, err := scanAppliedVersions(ctx, d, migrationsTable, o.scheme(), availableByVersion)
+	if errors.Is(err, driver.ErrSchemaMigrationsDoesNotExist) {
+		err = nil
+	} else if err != nil {
+		return nil, err
+	}
+	pending, err := finder.filter(applied, available)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]MigrationStatus, 0, len(applied)+len(pending))
+	for _, mig := range slices.Concat(applied, pending) {
+		_, reversible := reversesByVersion[mig.Version.Value()]
+		out = append(out, MigrationStatus{
+			Version:    mig.Version.String(),
+			Label:      mig.Label,
+			Direction:  internal.DirForward.String(),
+			Filename:   mig.DisplayName(),
+			Applied:    mig.Applied,
+			ExecutedAt: mig.ExecutedAt,
+			Batch:      mig.Batch,
+			Reversible: reversible,
+		})
+	}
+	return out, nil
+}
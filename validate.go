@@ -0,0 +1,219 @@
+package godfish
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+// Validate checks the migration files at dirFS for problems, without a
+// database connection. It writes a report with one entry per problem. The
+// kind of each problem is one of:
+//
+//   - unparseable-name: the filename has no direction or version.
+//   - duplicate-version: another migration in the same direction has the
+//     same version.
+//   - no-reverse: a forward migration has no reverse migration. This is only
+//     a warning, since a migration may be deliberately irreversible.
+//   - no-forward: a reverse migration has no forward migration.
+//   - empty-file: the file has nothing but whitespace.
+//   - invalid-directives: the header has a directive with an invalid value.
+//   - unknown-directive: the header has a directive that is not recognized.
+//     This is only a warning, since the directive is ignored.
+//
+// Directories and hidden files, such as ".gitkeep", are ignored. If there is
+// any problem other than a warning, then it returns an error.
+//
+// # Relevant opts
+//
+//   - [WithWriter]. If passed in with a non-zero value, then it will set the
+//     output writer.
+//     When passed in with a zero value, then an error is returned.
+//     When this option is omitted, then it will write to standard output.
+//   - [WithFormat]. If passed in with a non-zero value, then it will set the
+//     output format. Supported formats at this time are JSON, TSV.
+//     When passed in with a zero value, then an error is returned.
+//     When this option is omitted then it will write in TSV format.
+//   - [WithGoMigrations]. If passed in with a non-zero value, then the Go
+//     migrations are checked along with the migration files.
+//     When passed in with a zero value, then an error is returned.
+//   - [WithRecursive]. If passed in, then the files in subdirectories are
+//     checked as well.
+//   - See also [WithVersionScheme] and [WithNamingConvention].
+func Validate(dirFS fs.FS, opts ...Opter) error {
+	o, err := setOptions(opts...)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", msgPrefix, "Validate", err)
+	}
+	return validate(dirFS, o)
+}
+
+func validate(dirFS fs.FS, o *options) (err error) {
+	w := cmp.Or[io.Writer](o.writer, os.Stdout)
+
+	names, err := internal.ListFiles(dirFS, o.recursive, o.log())
+	if err != nil {
+		return fmt.Errorf("%s: reading directory entries: %w", msgPrefix, err)
+	}
+
+	var problems []internal.Problem
+	repeatableLabels := make(map[string]string)
+	byVersion := [2]map[int64][]*internal.Migration{
+		make(map[int64][]*internal.Migration),
+		make(map[int64][]*internal.Migration),
+	}
+	directionIndex := func(dir internal.Direction) int {
+		if dir == internal.DirForward {
+			return 0
+		}
+		return 1
+	}
+
+	for _, name := range names {
+		mig, perr := o.convention().ParseMigration(internal.Filename(name), o.scheme())
+		if label, rerr := o.convention().ParseRepeatable(internal.Filename(name)); perr != nil && rerr == nil {
+			prob, found, verr := validateRepeatable(dirFS, name, label, repeatableLabels)
+			if verr != nil {
+				return verr
+			} else if found {
+				problems = append(problems, prob)
+			}
+			continue
+		} else if internal.IsInvalidDataError(perr) {
+			problems = append(problems, internal.Problem{Kind: internal.ProblemUnparseableName, Filename: name, Detail: perr.Error()})
+			continue
+		} else if perr != nil {
+			return perr
+		}
+		mig.Filename = name
+
+		data, rerr := fs.ReadFile(dirFS, name)
+		if rerr != nil {
+			return fmt.Errorf("%s: reading file to validate: %w", msgPrefix, rerr)
+		}
+		if len(bytes.TrimSpace(data)) < 1 {
+			problems = append(problems, internal.Problem{Kind: internal.ProblemEmptyFile, Filename: name, Version: mig.Version.String()})
+		} else if !internal.IsSingleFile(data) {
+			if prob, found := directiveProblem(data, name, mig.Version.String()); found {
+				problems = append(problems, prob)
+			}
+		} else if mig.Indirection.Value != internal.DirForward {
+			problems = append(problems, internal.Problem{Kind: internal.ProblemInvalidDirectives, Filename: name, Version: mig.Version.String(), Detail: "a single-file migration should be named in the forward direction"})
+		} else {
+			var reported bool
+			for _, dir := range []internal.Direction{internal.DirForward, internal.DirReverse} {
+				section, ok := internal.Section(data, dir)
+				if !ok {
+					continue
+				}
+				// The header is in both sections, only report its problem once.
+				if prob, found := directiveProblem(section, name, mig.Version.String()); found && !reported {
+					reported = true
+					problems = append(problems, prob)
+				}
+				i := directionIndex(dir)
+				byVersion[i][mig.Version.Value()] = append(byVersion[i][mig.Version.Value()], mig)
+			}
+			continue
+		}
+
+		i := directionIndex(mig.Indirection.Value)
+		byVersion[i][mig.Version.Value()] = append(byVersion[i][mig.Version.Value()], mig)
+	}
+	for _, mig := range o.goMigrations {
+		i := directionIndex(mig.Indirection.Value)
+		byVersion[i][mig.Version.Value()] = append(byVersion[i][mig.Version.Value()], mig)
+	}
+
+	for i, migrations := range byVersion {
+		for _, version := range slices.Sorted(maps.Keys(migrations)) {
+			sameVersion := migrations[version]
+			if len(sameVersion) > 1 {
+				names := make([]string, len(sameVersion))
+				for j, mig := range sameVersion {
+					names[j] = mig.DisplayName()
+				}
+				for _, mig := range sameVersion {
+					problems = append(problems, internal.Problem{
+						Kind:     internal.ProblemDuplicateVersion,
+						Filename: mig.DisplayName(),
+						Version:  mig.Version.String(),
+						Detail:   "version used by " + strings.Join(names, ", "),
+					})
+				}
+			}
+
+			// Look for the counterpart in the other direction.
+			if _, found := byVersion[1-i][version]; found {
+				continue
+			}
+			kind := internal.ProblemNoReverse
+			if i != directionIndex(internal.DirForward) {
+				kind = internal.ProblemNoForward
+			}
+			for _, mig := range sameVersion {
+				problems = append(problems, internal.Problem{Kind: kind, Filename: mig.DisplayName(), Version: mig.Version.String()})
+			}
+		}
+	}
+
+	slices.SortStableFunc(problems, func(a, b internal.Problem) int {
+		return cmp.Or(cmp.Compare(a.Version, b.Version), cmp.Compare(a.Filename, b.Filename))
+	})
+
+	if err = choosePrinter(o.format, w, o.log(), internal.NewProblemJSON, internal.NewProblemTSV).PrintProblems(problems); err != nil {
+		return
+	}
+
+	var numErrors int
+	for _, prob := range problems {
+		if !prob.Kind.IsWarning() {
+			numErrors++
+		}
+	}
+	if numErrors > 0 {
+		err = fmt.Errorf("%w; found %d problem(s) with migration files", internal.ErrDataInvalid, numErrors)
+	}
+	return
+}
+
+// validateRepeatable checks the repeatable migration file, name. The labels
+// map each label seen so far to its filename.
+func validateRepeatable(dirFS fs.FS, name, label string, labels map[string]string) (prob internal.Problem, found bool, err error) {
+	if existing, ok := labels[label]; ok {
+		return internal.Problem{Kind: internal.ProblemDuplicateLabel, Filename: name, Detail: "label used by " + existing}, true, nil
+	}
+	labels[label] = name
+
+	data, err := fs.ReadFile(dirFS, name)
+	if err != nil {
+		return prob, false, fmt.Errorf("%s: reading file to validate: %w", msgPrefix, err)
+	}
+	if len(bytes.TrimSpace(data)) < 1 {
+		return internal.Problem{Kind: internal.ProblemEmptyFile, Filename: name}, true, nil
+	}
+	prob, found = directiveProblem(data, name, "")
+	return
+}
+
+// directiveProblem checks the directives in the header of data, the contents
+// of the migration file, name. A directive with an invalid value is an error,
+// and an unknown directive is a warning.
+func directiveProblem(data []byte, name, version string) (internal.Problem, bool) {
+	directives, err := internal.ParseDirectives(data)
+	if err != nil {
+		return internal.Problem{Kind: internal.ProblemInvalidDirectives, Filename: name, Version: version, Detail: err.Error()}, true
+	}
+	if len(directives.Unknown) > 0 {
+		return internal.Problem{Kind: internal.ProblemUnknownDirective, Filename: name, Version: version, Detail: fmt.Sprintf("ignored %q", directives.Unknown)}, true
+	}
+	return internal.Problem{}, false
+}
@@ -0,0 +1,102 @@
+package godfish
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/rafaelespinoza/godfish/driver"
+	"github.com/rafaelespinoza/godfish/internal"
+)
+
+// VerifyWith compares the checksums recorded for applied migrations against
+// the current contents of the migration files at dirFS. It writes a report
+// with one entry per applied migration. The status of each entry is one of:
+//
+//   - ok: the file is unchanged since it was applied.
+//   - modified: the file has changed since it was applied.
+//   - missing: there is no forward migration file for the applied version.
+//   - unrecorded: it was applied before checksums were recorded, or the driver
+//     is not a [driver.MetadataRecorder].
+//
+// If any applied migration is modified or missing, then it returns an error.
+//
+// # Relevant opts
+//
+//   - [WithWriter]. If passed in with a non-zero value, then it will set the
+//     output writer.
+//     When passed in with a zero value, then an error is returned.
+//     When this option is omitted, then it will write to standard output.
+//   - [WithFormat]. If passed in with a non-zero value, then it will set the
+//     output format. Supported formats at this time are JSON, TSV.
+//     When passed in with a zero value, then an error is returned.
+//     When this option is omitted then it will write in TSV format.
+//   - See also [WithMigrationsTable] and [WithGoMigrations].
+func VerifyWith(ctx context.Context, driver driver.Driver, dirFS fs.FS, opts ...Opter) error {
+	o, err := setOptions(opts...)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", msgPrefix, "VerifyWith", err)
+	}
+	return verify(ctx, driver, dirFS, o)
+}
+
+func verify(ctx context.Context, d driver.Driver, dirFS fs.FS, o *options) (err error) {
+	ctx = o.logContext(ctx)
+	w := cmp.Or[io.Writer](o.writer, os.Stdout)
+	migrationsTable := cmp.Or(o.migrationsTable, internal.DefaultMigrationsTableName)
+
+	finder := migrationFinder{direction: internal.DirForward, dirFS: dirFS, goMigrations: o.goMigrations, scheme: o.scheme(), convention: o.convention(), recursive: o.recursive, files: o.parsedFiles, logger: o.log()}
+	availableByVersion, _, err := finder.available()
+	if err != nil {
+		return fmt.Errorf("getting available migrations: %w", err)
+	}
+
+	applied, err := scanAppliedVersions(ctx, d, migrationsTable, o.scheme(), availableByVersion)
+	if err != nil {
+		return
+	}
+
+	drifts := make([]internal.Drift, 0, len(applied))
+	var numDrifted int
+	for _, mig := range applied {
+		drift := internal.Drift{Migration: mig, Status: internal.ChecksumMissing}
+		if mig.Func != nil {
+			drift.Status = internal.ChecksumSkipped
+		} else if mig.Filename != "" {
+			data, rerr := fs.ReadFile(dirFS, filepath.Clean(mig.Filename))
+			if rerr != nil {
+				return fmt.Errorf("%s: reading file to verify checksum: %w", msgPrefix, rerr)
+			}
+			drift.Actual = internal.Checksum(data)
+
+			switch mig.Checksum {
+			case "":
+				drift.Status = internal.ChecksumUnrecorded
+			case drift.Actual:
+				drift.Status = internal.ChecksumOK
+			default:
+				drift.Status = internal.ChecksumModified
+			}
+		}
+		if drift.Status == internal.ChecksumModified || drift.Status == internal.ChecksumMissing {
+			numDrifted++
+		}
+		drifts = append(drifts, drift)
+	}
+
+	if err = choosePrinter(o.format, w, o.log(), internal.NewDriftJSON, internal.NewDriftTSV).PrintDrift(drifts); err != nil {
+		return
+	}
+
+	if numDrifted > 0 {
+		err = fmt.Errorf(
+			"%w; %d of %d applied migration(s) are modified or missing",
+			internal.ErrChecksumMismatch, numDrifted, len(applied),
+		)
+	}
+	return
+}